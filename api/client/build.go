@@ -19,6 +19,7 @@ import (
 
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/graph/tags"
+	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/jsonmessage"
@@ -30,6 +31,7 @@ import (
 	"github.com/docker/docker/pkg/units"
 	"github.com/docker/docker/pkg/urlutil"
 	"github.com/docker/docker/registry"
+	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 )
 
@@ -47,6 +49,9 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	tag := cmd.String([]string{"t", "-tag"}, "", "Repository name (and optionally a tag) for the image")
 	suppressOutput := cmd.Bool([]string{"q", "-quiet"}, false, "Suppress the verbose output generated by the containers")
 	noCache := cmd.Bool([]string{"#no-cache", "-no-cache"}, false, "Do not use cache when building the image")
+	noCacheFromStep := cmd.Int([]string{"-no-cache-from-step"}, 0, "Do not use cache from this build step on")
+	flCacheFrom := opts.NewListOpts(nil)
+	cmd.Var(&flCacheFrom, []string{"-cache-from"}, "Images to consider as cache sources")
 	rm := cmd.Bool([]string{"#rm", "-rm"}, true, "Remove intermediate containers after a successful build")
 	forceRm := cmd.Bool([]string{"-force-rm"}, false, "Always remove intermediate containers")
 	pull := cmd.Bool([]string{"-pull"}, false, "Always attempt to pull a newer version of the image")
@@ -59,6 +64,8 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	flCPUSetCpus := cmd.String([]string{"-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
 	flCPUSetMems := cmd.String([]string{"-cpuset-mems"}, "", "MEMs in which to allow execution (0-3, 0,1)")
 	flCgroupParent := cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
+	flBuildArg := opts.NewListOpts(opts.ValidateEnv)
+	cmd.Var(&flBuildArg, []string{"-build-arg"}, "Set build-time variables")
 
 	cmd.Require(flag.Exact, 1)
 	cmd.ParseFlags(args, true)
@@ -257,6 +264,16 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	if *noCache {
 		v.Set("nocache", "1")
 	}
+	if *noCacheFromStep > 0 {
+		v.Set("nocachefromstep", strconv.Itoa(*noCacheFromStep))
+	}
+	if flCacheFrom.Len() > 0 {
+		cacheFromJSON, err := json.Marshal(flCacheFrom.GetAll())
+		if err != nil {
+			return err
+		}
+		v.Set("cachefrom", string(cacheFromJSON))
+	}
 	if *rm {
 		v.Set("rm", "1")
 	} else {
@@ -280,6 +297,14 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	v.Set("memswap", strconv.FormatInt(memorySwap, 10))
 	v.Set("cgroupparent", *flCgroupParent)
 
+	if flBuildArg.Len() > 0 {
+		buildArgsJSON, err := json.Marshal(runconfig.ConvertKVStringsToMap(flBuildArg.GetAll()))
+		if err != nil {
+			return err
+		}
+		v.Set("buildargs", string(buildArgsJSON))
+	}
+
 	v.Set("dockerfile", *dockerfileName)
 
 	headers := http.Header(make(map[string][]string))
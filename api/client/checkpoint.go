@@ -0,0 +1,35 @@
+package client
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/runconfig"
+)
+
+// CmdCheckpoint dumps the processes of one or more running containers.
+//
+// Usage: docker checkpoint [OPTIONS] CONTAINER [CONTAINER...]
+func (cli *DockerCli) CmdCheckpoint(args ...string) error {
+	cmd := cli.Subcmd("checkpoint", "CONTAINER [CONTAINER...]", "Checkpoint one or more running containers, which must not have a TTY\nand must use --net=host or --net=none", true)
+
+	config, err := runconfig.ParseCheckpoint(cmd, args)
+	if err != nil {
+		cmd.ReportError(err.Error(), true)
+		os.Exit(1)
+	}
+
+	var errNames []string
+	for _, name := range cmd.Args() {
+		if _, _, err := readBody(cli.call("POST", fmt.Sprintf("/containers/%s/checkpoint", name), config, nil)); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			errNames = append(errNames, name)
+		} else {
+			fmt.Fprintf(cli.out, "%s\n", name)
+		}
+	}
+	if len(errNames) > 0 {
+		return fmt.Errorf("Error: failed to checkpoint containers: %v", errNames)
+	}
+	return nil
+}
@@ -1,8 +1,16 @@
 package client
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/docker/docker/api/types"
@@ -10,48 +18,283 @@ import (
 	flag "github.com/docker/docker/pkg/mflag"
 )
 
-// CmdCp copies files/folders from a path on the container to a directory on the host running the command.
+type copyDirection int
+
+const (
+	fromContainer copyDirection = (1 << iota)
+	toContainer
+	acrossContainers = fromContainer | toContainer
+)
+
+var errCopyDirToFile = errors.New("cannot copy a directory to a file")
+
+// CmdCp copies files/folders to or from a path in a container.
+//
+// When copying from a container, if LOCALPATH is '-' the data is written as a
+// tar archive to STDOUT.
 //
-// If HOSTDIR is '-', the data is written as a tar file to STDOUT.
+// When copying to a container, if LOCALPATH is '-' the data is read as a tar
+// archive from STDIN and the destination CONTAINER:PATH, must specify a
+// directory.
 //
-// Usage: docker cp CONTAINER:PATH HOSTDIR
+// Usage: docker cp CONTAINER:PATH LOCALPATH|- or docker cp LOCALPATH|- CONTAINER:PATH
 func (cli *DockerCli) CmdCp(args ...string) error {
-	cmd := cli.Subcmd("cp", "CONTAINER:PATH HOSTDIR|-", "Copy files/folders from a PATH on the container to a HOSTDIR on the host\nrunning the command. Use '-' to write the data as a tar file to STDOUT.", true)
+	cmd := cli.Subcmd(
+		"cp",
+		"CONTAINER:PATH LOCALPATH|- | LOCALPATH|- CONTAINER:PATH",
+		"Copy files/folders between a container and your host.\n"+
+			"Use '-' as the source to read a tar archive from stdin\n"+
+			"and extract it to a directory destination in a container.\n"+
+			"Use '-' as the destination to stream a tar archive of a\n"+
+			"container source to stdout.",
+		true,
+	)
 	cmd.Require(flag.Exact, 2)
 
 	cmd.ParseFlags(args, true)
 
-	// deal with path name with `:`
-	info := strings.SplitN(cmd.Arg(0), ":", 2)
+	if cmd.Arg(0) == "" {
+		return fmt.Errorf("source can not be empty")
+	}
+	if cmd.Arg(1) == "" {
+		return fmt.Errorf("destination can not be empty")
+	}
+
+	srcContainer, srcPath := splitCpArg(cmd.Arg(0))
+	dstContainer, dstPath := splitCpArg(cmd.Arg(1))
 
-	if len(info) != 2 {
-		return fmt.Errorf("Error: Path not specified")
+	var direction copyDirection
+	if srcContainer != "" {
+		direction |= fromContainer
+	}
+	if dstContainer != "" {
+		direction |= toContainer
 	}
 
-	cfg := &types.CopyConfig{
-		Resource: info[1],
+	switch direction {
+	case fromContainer:
+		return cli.copyFromContainer(srcContainer, srcPath, dstPath)
+	case toContainer:
+		return cli.copyToContainer(srcPath, dstContainer, dstPath)
+	case acrossContainers:
+		// Copying between containers isn't supported.
+		return fmt.Errorf("copying between containers is not supported")
+	default:
+		// User didn't specify any container.
+		return fmt.Errorf("must specify at least one container source")
 	}
-	stream, statusCode, err := cli.call("POST", "/containers/"+info[0]+"/copy", cfg, nil)
-	if stream != nil {
-		defer stream.Close()
+}
+
+// splitCpArg splits the given argument in a container name and a path in this
+// container. If the argument is a local path, the container name is empty.
+// Absolute paths and paths starting with a dot are always local, so that a
+// local path containing a colon can be given as `./file:name`.
+func splitCpArg(arg string) (container, path string) {
+	if filepath.IsAbs(arg) {
+		// Explicit local absolute path, e.g., `C:\foo` or `/foo`.
+		return "", arg
 	}
-	if statusCode == 404 {
-		return fmt.Errorf("No such container: %v", info[0])
+
+	parts := strings.SplitN(arg, ":", 2)
+
+	if len(parts) == 1 || strings.HasPrefix(parts[0], ".") {
+		// Either there's no `:` in the arg
+		// OR it's an explicit local relative path like `./file:name.txt`.
+		return "", arg
 	}
+
+	return parts[0], parts[1]
+}
+
+func archiveURL(containerName, path string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("path", path)
+	return fmt.Sprintf("/containers/%s/archive?%s", containerName, query.Encode())
+}
+
+func getContainerPathStatFromHeader(header http.Header) (types.ContainerPathStat, error) {
+	var stat types.ContainerPathStat
+
+	encodedStat := header.Get("X-Docker-Container-Path-Stat")
+	statDecoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(encodedStat))
+
+	err := json.NewDecoder(statDecoder).Decode(&stat)
 	if err != nil {
+		err = fmt.Errorf("unable to decode container path stat header: %s", err)
+	}
+
+	return stat, err
+}
+
+// statContainerPath returns stat info about the given path in the container.
+// A missing path is reported with os.ErrNotExist.
+func (cli *DockerCli) statContainerPath(containerName, path string) (types.ContainerPathStat, error) {
+	body, header, statusCode, err := cli.clientRequestWithHeader("HEAD", archiveURL(containerName, path, nil), nil, nil)
+	if body != nil {
+		body.Close()
+	}
+	if statusCode == http.StatusNotFound {
+		return types.ContainerPathStat{}, os.ErrNotExist
+	}
+	if err != nil {
+		return types.ContainerPathStat{}, err
+	}
+
+	return getContainerPathStatFromHeader(header)
+}
+
+func (cli *DockerCli) copyFromContainer(srcContainer, srcPath, dstPath string) error {
+	body, header, _, err := cli.clientRequestWithHeader("GET", archiveURL(srcContainer, srcPath, nil), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	stat, err := getContainerPathStatFromHeader(header)
+	if err != nil {
+		body.Close()
 		return err
 	}
 
-	hostPath := cmd.Arg(1)
-	if statusCode == 200 {
-		if hostPath == "-" {
-			_, err = io.Copy(cli.out, stream)
-		} else {
-			err = archive.Untar(stream, hostPath, &archive.TarOptions{NoLchown: true})
+	// A symlink is followed, in the scope of the container's rootfs, and
+	// its target is copied instead.
+	if stat.LinkTarget != "" {
+		body.Close()
+
+		srcPath = stat.LinkTarget
+		body, header, _, err = cli.clientRequestWithHeader("GET", archiveURL(srcContainer, srcPath, nil), nil, nil)
+		if err != nil {
+			return err
+		}
+		if stat, err = getContainerPathStatFromHeader(header); err != nil {
+			body.Close()
+			return err
 		}
+	}
+	defer body.Close()
+
+	if dstPath == "-" {
+		// Send the response to STDOUT.
+		_, err = io.Copy(cli.out, body)
+		return err
+	}
+
+	srcBase := path.Base(stat.Name)
+	dstStat, err := os.Stat(dstPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		// The destination doesn't exist: the resource is copied with the
+		// destination name, in the parent directory of the destination.
+		if _, err := os.Stat(filepath.Dir(dstPath)); err != nil {
+			return err
+		}
+		return untarRebased(body, srcBase, dstPath)
+	}
+
+	if dstStat.IsDir() {
+		return archive.Untar(body, dstPath, &archive.TarOptions{NoLchown: true})
+	}
+
+	// The destination is an existing file, which is replaced.
+	if stat.Mode.IsDir() {
+		return errCopyDirToFile
+	}
+	return untarRebased(body, srcBase, dstPath)
+}
+
+// untarRebased extracts the given archive of the resource srcBase in the
+// parent directory of dstPath, naming the resource with the base name of
+// dstPath.
+func untarRebased(content io.Reader, srcBase, dstPath string) error {
+	dstDir, dstBase := filepath.Split(dstPath)
+	if dstDir == "" {
+		dstDir = "."
+	}
+
+	rebased := archive.RebaseArchiveEntries(content, srcBase, dstBase)
+	defer rebased.Close()
+
+	return archive.Untar(rebased, dstDir, &archive.TarOptions{NoLchown: true})
+}
+
+func (cli *DockerCli) copyToContainer(srcPath, dstContainer, dstPath string) error {
+	// Prepare the destination: it is fully resolved, following a symlink
+	// in the scope of the container's rootfs.
+	dstExists := true
+	dstStat, err := cli.statContainerPath(dstContainer, dstPath)
+	if err == nil && dstStat.LinkTarget != "" {
+		dstPath = dstStat.LinkTarget
+		dstStat, err = cli.statContainerPath(dstContainer, dstPath)
+	}
+	if err != nil {
+		if err != os.ErrNotExist {
+			return err
+		}
+		dstExists = false
+	}
+
+	var (
+		content io.Reader
+		dstDir  string
+	)
+
+	if srcPath == "-" {
+		// Read the tar archive from STDIN, which can only be extracted to
+		// an existing directory.
+		if !dstExists || !dstStat.Mode.IsDir() {
+			return fmt.Errorf("destination %q must be a directory", dstContainer+":"+dstPath)
+		}
+		content = cli.in
+		dstDir = dstPath
+	} else {
+		srcStat, err := os.Stat(srcPath)
 		if err != nil {
 			return err
 		}
+
+		srcDir, srcBase := filepath.Split(filepath.Clean(srcPath))
+		if srcDir == "" {
+			srcDir = "."
+		}
+
+		srcArchive, err := archive.TarWithOptions(srcDir, &archive.TarOptions{
+			Compression:  archive.Uncompressed,
+			IncludeFiles: []string{srcBase},
+		})
+		if err != nil {
+			return err
+		}
+		defer srcArchive.Close()
+
+		switch {
+		case dstExists && dstStat.Mode.IsDir():
+			// The resource is copied into the destination directory.
+			content = srcArchive
+			dstDir = dstPath
+		case dstExists && srcStat.IsDir():
+			return errCopyDirToFile
+		default:
+			// The resource is copied with the destination name, in the
+			// parent directory of the destination.
+			rebased := archive.RebaseArchiveEntries(srcArchive, srcBase, path.Base(dstPath))
+			defer rebased.Close()
+			content = rebased
+			dstDir = path.Dir(dstPath)
+		}
+	}
+
+	query := url.Values{}
+	// Do not allow for an existing directory to be overwritten by a
+	// non-directory and vice versa.
+	query.Set("noOverwriteDirNonDir", "true")
+
+	headers := map[string][]string{"Content-Type": {"application/x-tar"}}
+	stream, _, _, err := cli.clientRequest("PUT", archiveURL(dstContainer, dstDir, query), content, headers)
+	if stream != nil {
+		stream.Close()
 	}
-	return nil
+	return err
 }
@@ -1,9 +1,13 @@
 package client
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/url"
 	"time"
 
+	eventtypes "github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers/filters"
@@ -51,12 +55,51 @@ func (cli *DockerCli) CmdEvents(args ...string) error {
 		}
 		v.Set("filters", filterJSON)
 	}
-	sopts := &streamOpts{
-		rawTerminal: true,
-		out:         cli.out,
-	}
-	if err := cli.stream("GET", "/events?"+v.Encode(), sopts); err != nil {
+	stream, _, err := cli.call("GET", "/events?"+v.Encode(), nil, nil)
+	if err != nil {
 		return err
 	}
-	return nil
+	defer stream.Close()
+
+	dec := json.NewDecoder(stream)
+	for {
+		var event eventtypes.Message
+		if err := dec.Decode(&event); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		printEvent(cli.out, event)
+	}
+}
+
+// printEvent prints an event with the format of the deprecated JSONMessage
+// events, the ID of its actor followed by the image a container comes from,
+// or the type of the actor when it isn't a container or an image, and the
+// action.
+func printEvent(out io.Writer, event eventtypes.Message) {
+	if event.TimeNano != 0 {
+		fmt.Fprintf(out, "%s ", time.Unix(0, event.TimeNano).Format(timeutils.RFC3339NanoFixed))
+	} else if event.Time != 0 {
+		fmt.Fprintf(out, "%s ", time.Unix(event.Time, 0).Format(timeutils.RFC3339NanoFixed))
+	}
+
+	id, action := event.Actor.ID, event.Action
+	if event.Type == "" {
+		// Events from daemons which don't send typed events.
+		id, action = event.ID, event.Status
+	}
+	fmt.Fprintf(out, "%s: ", id)
+
+	switch event.Type {
+	case "", eventtypes.ContainerEventType:
+		if event.From != "" {
+			fmt.Fprintf(out, "(from %s) ", event.From)
+		}
+	case eventtypes.ImageEventType:
+	default:
+		fmt.Fprintf(out, "(%s) ", event.Type)
+	}
+	fmt.Fprintf(out, "%s\n", action)
 }
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/runconfig"
+)
+
+// CmdNetwork is the parent subcommand for all network commands
+//
+// Usage: docker network <COMMAND> <OPTS>
+func (cli *DockerCli) CmdNetwork(args ...string) error {
+	description := "Manage Docker networks\n\nCommands:\n"
+	commands := [][]string{
+		{"create", "Create a network"},
+		{"connect", "Connect a container to a network"},
+		{"disconnect", "Disconnect a container from a network"},
+		{"inspect", "Return low-level information on a network"},
+		{"ls", "List networks"},
+		{"rm", "Remove a network"},
+	}
+
+	for _, cmd := range commands {
+		description += fmt.Sprintf("  %-25.25s%s\n", cmd[0], cmd[1])
+	}
+
+	description += "\nRun 'docker network COMMAND --help' for more information on a command."
+	cmd := cli.Subcmd("network", "[COMMAND]", description, true)
+	cmd.Require(flag.Exact, 0)
+	cmd.ParseFlags(args, true)
+
+	cmd.Usage()
+	return nil
+}
+
+// CmdNetworkCreate creates a new network with a given name.
+//
+// Usage: docker network create [OPTIONS] NETWORK-NAME
+func (cli *DockerCli) CmdNetworkCreate(args ...string) error {
+	cmd := cli.Subcmd("network create", "NETWORK-NAME", "Create a network", true)
+	flDriver := cmd.String([]string{"d", "-driver"}, "", "Specify network driver name")
+
+	flDriverOpts := opts.NewListOpts(nil)
+	cmd.Var(&flDriverOpts, []string{"o", "-opt"}, "Set driver specific options")
+
+	cmd.Require(flag.Exact, 1)
+	cmd.ParseFlags(args, true)
+
+	nc := &types.NetworkCreate{
+		Name:    cmd.Arg(0),
+		Driver:  *flDriver,
+		Options: runconfig.ConvertKVStringsToMap(flDriverOpts.GetAll()),
+	}
+
+	resp, _, err := cli.call("POST", "/networks/create", nc, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	var ncr types.NetworkCreateResponse
+	if err := json.NewDecoder(resp).Decode(&ncr); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", ncr.ID)
+	return nil
+}
+
+// CmdNetworkRm deletes one or more networks.
+//
+// Usage: docker network rm NETWORK [NETWORK...]
+func (cli *DockerCli) CmdNetworkRm(args ...string) error {
+	cmd := cli.Subcmd("network rm", "NETWORK [NETWORK...]", "Remove a network", true)
+	cmd.Require(flag.Min, 1)
+	cmd.ParseFlags(args, true)
+
+	var status = 0
+	for _, name := range cmd.Args() {
+		_, _, err := readBody(cli.call("DELETE", "/networks/"+name, nil, nil))
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+		fmt.Fprintf(cli.out, "%s\n", name)
+	}
+
+	if status != 0 {
+		return StatusError{StatusCode: status}
+	}
+	return nil
+}
+
+// CmdNetworkConnect connects a container to a network.
+//
+// Usage: docker network connect NETWORK CONTAINER
+func (cli *DockerCli) CmdNetworkConnect(args ...string) error {
+	cmd := cli.Subcmd("network connect", "NETWORK CONTAINER", "Connect a container to a network", true)
+	cmd.Require(flag.Exact, 2)
+	cmd.ParseFlags(args, true)
+
+	nc := &types.NetworkConnect{Container: cmd.Arg(1)}
+	_, _, err := readBody(cli.call("POST", "/networks/"+cmd.Arg(0)+"/connect", nc, nil))
+	return err
+}
+
+// CmdNetworkDisconnect disconnects a container from a network.
+//
+// Usage: docker network disconnect NETWORK CONTAINER
+func (cli *DockerCli) CmdNetworkDisconnect(args ...string) error {
+	cmd := cli.Subcmd("network disconnect", "NETWORK CONTAINER", "Disconnect a container from a network", true)
+	cmd.Require(flag.Exact, 2)
+	cmd.ParseFlags(args, true)
+
+	nc := &types.NetworkDisconnect{Container: cmd.Arg(1)}
+	_, _, err := readBody(cli.call("POST", "/networks/"+cmd.Arg(0)+"/disconnect", nc, nil))
+	return err
+}
+
+// CmdNetworkLs lists all the networks managed by the docker daemon.
+//
+// Usage: docker network ls [OPTIONS]
+func (cli *DockerCli) CmdNetworkLs(args ...string) error {
+	cmd := cli.Subcmd("network ls", "", "List networks", true)
+	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Only display numeric IDs")
+	noTrunc := cmd.Bool([]string{"-no-trunc"}, false, "Do not truncate the output")
+
+	cmd.Require(flag.Exact, 0)
+	cmd.ParseFlags(args, true)
+
+	rdr, _, err := cli.call("GET", "/networks", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	var networks []*types.NetworkResource
+	if err := json.NewDecoder(rdr).Decode(&networks); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	if !*quiet {
+		fmt.Fprintln(w, "NETWORK ID\tNAME\tDRIVER")
+	}
+
+	for _, n := range networks {
+		id := n.ID
+		if !*noTrunc {
+			id = stringid.TruncateID(id)
+		}
+		if *quiet {
+			fmt.Fprintln(w, id)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", id, n.Name, n.Driver)
+	}
+	w.Flush()
+	return nil
+}
+
+// CmdNetworkInspect displays low-level information on one or more networks.
+//
+// Usage: docker network inspect [OPTIONS] NETWORK [NETWORK...]
+func (cli *DockerCli) CmdNetworkInspect(args ...string) error {
+	cmd := cli.Subcmd("network inspect", "NETWORK [NETWORK...]", "Return low-level information on a network", true)
+	tmplStr := cmd.String([]string{"f", "-format"}, "", "Format the output using the given go template")
+	cmd.Require(flag.Min, 1)
+
+	cmd.ParseFlags(args, true)
+
+	var tmpl *template.Template
+	if *tmplStr != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*tmplStr); err != nil {
+			return StatusError{StatusCode: 64,
+				Status: "Template parsing error: " + err.Error()}
+		}
+	}
+
+	var status = 0
+	var networks []*types.NetworkResource
+	for _, name := range cmd.Args() {
+		rdr, _, err := cli.call("GET", "/networks/"+name, nil, nil)
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+
+		var network types.NetworkResource
+		err = json.NewDecoder(rdr).Decode(&network)
+		rdr.Close()
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+
+		if tmpl == nil {
+			networks = append(networks, &network)
+			continue
+		}
+
+		if err := tmpl.Execute(cli.out, &network); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+		io.WriteString(cli.out, "\n")
+	}
+
+	if tmpl == nil {
+		// Always write a JSON array, like docker inspect does.
+		if networks == nil {
+			networks = []*types.NetworkResource{}
+		}
+		b, err := json.MarshalIndent(networks, "", "    ")
+		if err != nil {
+			return err
+		}
+		cli.out.Write(append(b, '\n'))
+	}
+
+	if status != 0 {
+		return StatusError{StatusCode: status}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/runconfig"
+)
+
+// CmdUpdate updates the resource limits of one or more containers.
+//
+// Usage: docker update [OPTIONS] CONTAINER [CONTAINER...]
+func (cli *DockerCli) CmdUpdate(args ...string) error {
+	cmd := cli.Subcmd("update", "CONTAINER [CONTAINER...]", "Update the resource limits of one or more containers", true)
+
+	updateConfig, err := runconfig.ParseUpdate(cmd, args)
+	if err != nil {
+		cmd.ReportError(err.Error(), true)
+		os.Exit(1)
+	}
+
+	if *updateConfig == (runconfig.UpdateConfig{}) {
+		cmd.Usage()
+		return fmt.Errorf("You must provide one or more flags when using this command.")
+	}
+
+	var errNames []string
+	for _, name := range cmd.Args() {
+		stream, _, err := cli.call("POST", fmt.Sprintf("/containers/%s/update", name), updateConfig, nil)
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			errNames = append(errNames, name)
+			continue
+		}
+
+		var response types.ContainerUpdateResponse
+		err = json.NewDecoder(stream).Decode(&response)
+		stream.Close()
+		if err != nil {
+			return err
+		}
+		for _, warning := range response.Warnings {
+			fmt.Fprintf(cli.err, "WARNING: %s\n", warning)
+		}
+		fmt.Fprintf(cli.out, "%s\n", name)
+	}
+	if len(errNames) > 0 {
+		return fmt.Errorf("Error: failed to update containers: %v", errNames)
+	}
+	return nil
+}
@@ -49,13 +49,23 @@ func (cli *DockerCli) encodeData(data interface{}) (*bytes.Buffer, error) {
 }
 
 func (cli *DockerCli) clientRequest(method, path string, in io.Reader, headers map[string][]string) (io.ReadCloser, string, int, error) {
+	body, header, statusCode, err := cli.clientRequestWithHeader(method, path, in, headers)
+	if err != nil {
+		return body, "", statusCode, err
+	}
+	return body, header.Get("Content-Type"), statusCode, nil
+}
+
+// clientRequestWithHeader is like clientRequest but returns all the headers
+// of the response, for the API routes which return information in them.
+func (cli *DockerCli) clientRequestWithHeader(method, path string, in io.Reader, headers map[string][]string) (io.ReadCloser, http.Header, int, error) {
 	expectedPayload := (method == "POST" || method == "PUT")
 	if expectedPayload && in == nil {
 		in = bytes.NewReader([]byte{})
 	}
 	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", api.APIVERSION, path), in)
 	if err != nil {
-		return nil, "", -1, err
+		return nil, nil, -1, err
 	}
 
 	// Add CLI Config's HTTP Headers BEFORE we set the Docker headers
@@ -85,27 +95,27 @@ func (cli *DockerCli) clientRequest(method, path string, in io.Reader, headers m
 	}
 	if err != nil {
 		if strings.Contains(err.Error(), "connection refused") {
-			return nil, "", statusCode, errConnectionRefused
+			return nil, nil, statusCode, errConnectionRefused
 		}
 
 		if cli.tlsConfig == nil {
-			return nil, "", statusCode, fmt.Errorf("%v. Are you trying to connect to a TLS-enabled daemon without TLS?", err)
+			return nil, nil, statusCode, fmt.Errorf("%v. Are you trying to connect to a TLS-enabled daemon without TLS?", err)
 		}
-		return nil, "", statusCode, fmt.Errorf("An error occurred trying to connect: %v", err)
+		return nil, nil, statusCode, fmt.Errorf("An error occurred trying to connect: %v", err)
 	}
 
 	if statusCode < 200 || statusCode >= 400 {
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return nil, "", statusCode, err
+			return nil, nil, statusCode, err
 		}
 		if len(body) == 0 {
-			return nil, "", statusCode, fmt.Errorf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(statusCode), req.URL)
+			return nil, nil, statusCode, fmt.Errorf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(statusCode), req.URL)
 		}
-		return nil, "", statusCode, fmt.Errorf("Error response from daemon: %s", bytes.TrimSpace(body))
+		return nil, nil, statusCode, fmt.Errorf("Error response from daemon: %s", bytes.TrimSpace(body))
 	}
 
-	return resp.Body, resp.Header.Get("Content-Type"), statusCode, nil
+	return resp.Body, resp.Header, statusCode, nil
 }
 
 func (cli *DockerCli) clientRequestAttemptLogin(method, path string, in io.Reader, out io.Writer, index *registry.IndexInfo, cmdName string) (io.ReadCloser, int, error) {
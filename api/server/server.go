@@ -20,13 +20,14 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/api/types"
+	eventtypes "github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/builder"
 	"github.com/docker/docker/cliconfig"
 	"github.com/docker/docker/daemon"
+	"github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/pkg/ioutils"
-	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/parsers/filters"
 	"github.com/docker/docker/pkg/parsers/kernel"
@@ -34,6 +35,7 @@ import (
 	"github.com/docker/docker/pkg/sockets"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/docker/docker/pkg/timeutils"
 	"github.com/docker/docker/pkg/version"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
@@ -337,6 +339,48 @@ func (s *Server) postContainersUnpause(version version.Version, w http.ResponseW
 	return nil
 }
 
+func (s *Server) postContainersCheckpoint(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	var config runconfig.CheckpointConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		return err
+	}
+
+	if err := s.daemon.ContainerCheckpoint(vars["name"], &config); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (s *Server) postContainersRestore(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := parseForm(r); err != nil {
+		return err
+	}
+
+	if err := s.daemon.ContainerRestore(vars["name"]); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
 func (s *Server) getContainersExport(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if vars == nil {
 		return fmt.Errorf("Missing parameter")
@@ -378,28 +422,25 @@ func (s *Server) getEvents(version version.Version, w http.ResponseWriter, r *ht
 	if err := parseForm(r); err != nil {
 		return err
 	}
-	var since int64 = -1
-	if r.Form.Get("since") != "" {
-		s, err := strconv.ParseInt(r.Form.Get("since"), 10, 64)
-		if err != nil {
-			return err
-		}
-		since = s
+	since, sinceNano, err := timeutils.ParseTimestamps(r.Form.Get("since"), -1)
+	if err != nil {
+		return err
+	}
+	until, untilNano, err := timeutils.ParseTimestamps(r.Form.Get("until"), -1)
+	if err != nil {
+		return err
 	}
 
-	var until int64 = -1
-	if r.Form.Get("until") != "" {
-		u, err := strconv.ParseInt(r.Form.Get("until"), 10, 64)
-		if err != nil {
-			return err
-		}
-		until = u
+	var sinceTime, untilTime time.Time
+	if since >= 0 {
+		sinceTime = time.Unix(since, sinceNano)
 	}
 
 	timer := time.NewTimer(0)
 	timer.Stop()
 	if until > 0 {
-		dur := time.Unix(until, 0).Sub(time.Now())
+		untilTime = time.Unix(until, untilNano)
+		dur := untilTime.Sub(time.Now())
 		timer = time.NewTimer(dur)
 	}
 
@@ -408,60 +449,34 @@ func (s *Server) getEvents(version version.Version, w http.ResponseWriter, r *ht
 		return err
 	}
 
-	isFiltered := func(field string, filter []string) bool {
-		if len(filter) == 0 {
-			return false
-		}
-		for _, v := range filter {
-			if v == field {
-				return false
-			}
-			if strings.Contains(field, ":") {
-				image := strings.Split(field, ":")
-				if image[0] == v {
-					return false
-				}
-			}
-		}
-		return true
-	}
-
 	d := s.daemon
 	es := d.EventsService
 	w.Header().Set("Content-Type", "application/json")
 	enc := json.NewEncoder(ioutils.NewWriteFlusher(w))
 
-	getContainerId := func(cn string) string {
-		c, err := d.Get(cn)
-		if err != nil {
-			return ""
+	// incoming container filter can be name, id or partial id, the full
+	// container id is added to match the events of the container
+	for _, cn := range ef["container"] {
+		if c, err := d.Get(cn); err == nil && c.ID != cn {
+			ef["container"] = append(ef["container"], c.ID)
 		}
-		return c.ID
 	}
+	eventFilter := events.NewFilter(ef)
 
-	sendEvent := func(ev *jsonmessage.JSONMessage) error {
-		//incoming container filter can be name,id or partial id, convert and replace as a full container id
-		for i, cn := range ef["container"] {
-			ef["container"][i] = getContainerId(cn)
-		}
-
-		if isFiltered(ev.Status, ef["event"]) || isFiltered(ev.From, ef["image"]) ||
-			isFiltered(ev.ID, ef["container"]) {
+	sendEvent := func(ev eventtypes.Message) error {
+		if !eventFilter.Include(ev) {
 			return nil
 		}
-
 		return enc.Encode(ev)
 	}
 
-	current, l := es.Subscribe()
-	if since == -1 {
+	// the stored events are only replayed when since is given
+	current, l := es.SubscribeTimeRange(sinceTime, untilTime)
+	if since < 0 {
 		current = nil
 	}
 	defer es.Evict(l)
 	for _, ev := range current {
-		if ev.Time < since {
-			continue
-		}
 		if err := sendEvent(ev); err != nil {
 			return err
 		}
@@ -475,7 +490,7 @@ func (s *Server) getEvents(version version.Version, w http.ResponseWriter, r *ht
 	for {
 		select {
 		case ev := <-l:
-			jev, ok := ev.(*jsonmessage.JSONMessage)
+			jev, ok := ev.(eventtypes.Message)
 			if !ok {
 				continue
 			}
@@ -564,6 +579,50 @@ func (s *Server) getContainersJSON(version version.Version, w http.ResponseWrite
 	return writeJSON(w, http.StatusOK, containers)
 }
 
+func (s *Server) getVolumesList(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+
+	volumes, err := s.daemon.Volumes(r.Form.Get("filters"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, &types.VolumesListResponse{Volumes: volumes})
+}
+
+func (s *Server) getVolumeByName(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	volume, err := s.daemon.VolumeInspect(vars["name"])
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, volume)
+}
+
+func (s *Server) getNetworksList(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	networks, err := s.daemon.Networks()
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, networks)
+}
+
+func (s *Server) getNetwork(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	network, err := s.daemon.NetworkInspect(vars["id"])
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, network)
+}
+
 func (s *Server) getContainersStats(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -603,6 +662,7 @@ func (s *Server) getContainersLogs(version version.Version, w http.ResponseWrite
 		closeNotifier = notifier.CloseNotify()
 	}
 
+	output := ioutils.NewWriteFlusher(w)
 	logsConfig := &daemon.ContainerLogsConfig{
 		Follow:     boolValue(r, "follow"),
 		Timestamps: boolValue(r, "timestamps"),
@@ -610,11 +670,16 @@ func (s *Server) getContainersLogs(version version.Version, w http.ResponseWrite
 		Tail:       r.Form.Get("tail"),
 		UseStdout:  stdout,
 		UseStderr:  stderr,
-		OutStream:  ioutils.NewWriteFlusher(w),
+		OutStream:  output,
 		Stop:       closeNotifier,
 	}
 
 	if err := s.daemon.ContainerLogs(vars["name"], logsConfig); err != nil {
+		// Report the error with its status code while nothing was sent,
+		// e.g. when the logging driver can't read the logs back.
+		if !output.Flushed() {
+			return err
+		}
 		fmt.Fprintf(w, "Error running logs job: %s\n", err)
 	}
 
@@ -636,7 +701,8 @@ func (s *Server) postImagesTag(version version.Version, w http.ResponseWriter, r
 	if err := s.daemon.Repositories().Tag(repo, tag, name, force); err != nil {
 		return err
 	}
-	s.daemon.EventsService.Log("tag", utils.ImageReference(repo, tag), "")
+	ref := utils.ImageReference(repo, tag)
+	s.daemon.Repositories().LogImageEvent("tag", ref, ref)
 	w.WriteHeader(http.StatusCreated)
 	return nil
 }
@@ -909,6 +975,83 @@ func (s *Server) postContainersCreate(version version.Version, w http.ResponseWr
 	})
 }
 
+func (s *Server) postVolumesCreate(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+
+	var req types.VolumeCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	volume, err := s.daemon.VolumeCreate(req.Name, req.Driver, req.DriverOpts, req.Labels)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, volume)
+}
+
+func (s *Server) postNetworksCreate(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+
+	var req types.NetworkCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	id, err := s.daemon.NetworkCreate(req.Name, req.Driver, req.Options)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, &types.NetworkCreateResponse{ID: id})
+}
+
+func (s *Server) postNetworkConnect(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+
+	var req types.NetworkConnect
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	if err := s.daemon.ConnectContainerToNetwork(req.Container, vars["id"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) postNetworkDisconnect(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+
+	var req types.NetworkDisconnect
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	if err := s.daemon.DisconnectContainerFromNetwork(req.Container, vars["id"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
 func (s *Server) postContainersRestart(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -945,6 +1088,32 @@ func (s *Server) postContainerRename(version version.Version, w http.ResponseWri
 	return nil
 }
 
+func (s *Server) postContainerUpdate(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	var updateConfig runconfig.UpdateConfig
+	if err := json.NewDecoder(r.Body).Decode(&updateConfig); err != nil {
+		return err
+	}
+
+	warnings, err := s.daemon.ContainerUpdate(vars["name"], &updateConfig)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, &types.ContainerUpdateResponse{
+		Warnings: warnings,
+	})
+}
+
 func (s *Server) deleteContainers(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -973,6 +1142,30 @@ func (s *Server) deleteContainers(version version.Version, w http.ResponseWriter
 	return nil
 }
 
+func (s *Server) deleteVolumes(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	if err := s.daemon.VolumeRm(vars["name"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) deleteNetwork(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	if err := s.daemon.NetworkRm(vars["id"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 func (s *Server) deleteImages(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -1237,6 +1430,7 @@ func (s *Server) postBuild(version version.Version, w http.ResponseWriter, r *ht
 	buildConfig.RepoName = r.FormValue("t")
 	buildConfig.SuppressOutput = boolValue(r, "q")
 	buildConfig.NoCache = boolValue(r, "nocache")
+	buildConfig.NoCacheFromStep = int(int64ValueOrZero(r, "nocachefromstep"))
 	buildConfig.ForceRemove = boolValue(r, "forcerm")
 	buildConfig.AuthConfig = authConfig
 	buildConfig.ConfigFile = configFile
@@ -1249,6 +1443,17 @@ func (s *Server) postBuild(version version.Version, w http.ResponseWriter, r *ht
 	buildConfig.CpuSetMems = r.FormValue("cpusetmems")
 	buildConfig.CgroupParent = r.FormValue("cgroupparent")
 
+	if buildArgsJSON := r.FormValue("buildargs"); buildArgsJSON != "" {
+		if err := json.Unmarshal([]byte(buildArgsJSON), &buildConfig.BuildArgs); err != nil {
+			return fmt.Errorf("Bad parameter: invalid buildargs: %v", err)
+		}
+	}
+	if cacheFromJSON := r.FormValue("cachefrom"); cacheFromJSON != "" {
+		if err := json.Unmarshal([]byte(cacheFromJSON), &buildConfig.CacheFrom); err != nil {
+			return fmt.Errorf("Bad parameter: invalid cachefrom: %v", err)
+		}
+	}
+
 	// Job cancellation. Note: not all job types support this.
 	if closeNotifier, ok := w.(http.CloseNotifier); ok {
 		finished := make(chan struct{})
@@ -1314,6 +1519,89 @@ func (s *Server) postContainersCopy(version version.Version, w http.ResponseWrit
 	return nil
 }
 
+// Encode the stat to JSON, base64 encode, and place in a header.
+func setContainerPathStatHeader(stat *types.ContainerPathStat, header http.Header) error {
+	statJSON, err := json.Marshal(stat)
+	if err != nil {
+		return err
+	}
+
+	header.Set(
+		"X-Docker-Container-Path-Stat",
+		base64.StdEncoding.EncodeToString(statJSON),
+	)
+
+	return nil
+}
+
+func (s *Server) headContainersArchive(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	v, err := archiveFormValues(r, vars)
+	if err != nil {
+		return err
+	}
+
+	stat, err := s.daemon.ContainerStatPath(v.name, v.path)
+	if err != nil {
+		return err
+	}
+
+	return setContainerPathStatHeader(stat, w.Header())
+}
+
+func (s *Server) getContainersArchive(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	v, err := archiveFormValues(r, vars)
+	if err != nil {
+		return err
+	}
+
+	tarArchive, stat, err := s.daemon.ContainerArchivePath(v.name, v.path)
+	if err != nil {
+		return err
+	}
+	defer tarArchive.Close()
+
+	if err := setContainerPathStatHeader(stat, w.Header()); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	_, err = io.Copy(w, tarArchive)
+
+	return err
+}
+
+func (s *Server) putContainersArchive(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	v, err := archiveFormValues(r, vars)
+	if err != nil {
+		return err
+	}
+
+	noOverwriteDirNonDir := boolValue(r, "noOverwriteDirNonDir")
+	return s.daemon.ContainerExtractToDir(v.name, v.path, noOverwriteDirNonDir, r.Body)
+}
+
+type archiveOptions struct {
+	name string
+	path string
+}
+
+// archiveFormValues parses form values and turns them into archiveOptions.
+func archiveFormValues(r *http.Request, vars map[string]string) (archiveOptions, error) {
+	if vars == nil {
+		return archiveOptions{}, fmt.Errorf("Missing parameter")
+	}
+	if err := parseForm(r); err != nil {
+		return archiveOptions{}, err
+	}
+
+	path := r.Form.Get("path")
+	if path == "" {
+		return archiveOptions{}, fmt.Errorf("Bad parameter: path cannot be empty")
+	}
+
+	return archiveOptions{vars["name"], path}, nil
+}
+
 func (s *Server) postContainerExecCreate(version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -1506,35 +1794,55 @@ func createRouter(s *Server) *mux.Router {
 			"/containers/{name:.*}/logs":      s.getContainersLogs,
 			"/containers/{name:.*}/stats":     s.getContainersStats,
 			"/containers/{name:.*}/attach/ws": s.wsContainersAttach,
+			"/containers/{name:.*}/archive":   s.getContainersArchive,
 			"/exec/{id:.*}/json":              s.getExecByID,
+			"/volumes":                        s.getVolumesList,
+			"/volumes/{name:.*}":              s.getVolumeByName,
+			"/networks":                       s.getNetworksList,
+			"/networks/{id:.*}":               s.getNetwork,
 		},
 		"POST": {
-			"/auth":                         s.postAuth,
-			"/commit":                       s.postCommit,
-			"/build":                        s.postBuild,
-			"/images/create":                s.postImagesCreate,
-			"/images/load":                  s.postImagesLoad,
-			"/images/{name:.*}/push":        s.postImagesPush,
-			"/images/{name:.*}/tag":         s.postImagesTag,
-			"/containers/create":            s.postContainersCreate,
-			"/containers/{name:.*}/kill":    s.postContainersKill,
-			"/containers/{name:.*}/pause":   s.postContainersPause,
-			"/containers/{name:.*}/unpause": s.postContainersUnpause,
-			"/containers/{name:.*}/restart": s.postContainersRestart,
-			"/containers/{name:.*}/start":   s.postContainersStart,
-			"/containers/{name:.*}/stop":    s.postContainersStop,
-			"/containers/{name:.*}/wait":    s.postContainersWait,
-			"/containers/{name:.*}/resize":  s.postContainersResize,
-			"/containers/{name:.*}/attach":  s.postContainersAttach,
-			"/containers/{name:.*}/copy":    s.postContainersCopy,
-			"/containers/{name:.*}/exec":    s.postContainerExecCreate,
-			"/exec/{name:.*}/start":         s.postContainerExecStart,
-			"/exec/{name:.*}/resize":        s.postContainerExecResize,
-			"/containers/{name:.*}/rename":  s.postContainerRename,
+			"/auth":                            s.postAuth,
+			"/commit":                          s.postCommit,
+			"/build":                           s.postBuild,
+			"/images/create":                   s.postImagesCreate,
+			"/images/load":                     s.postImagesLoad,
+			"/images/{name:.*}/push":           s.postImagesPush,
+			"/images/{name:.*}/tag":            s.postImagesTag,
+			"/containers/create":               s.postContainersCreate,
+			"/containers/{name:.*}/kill":       s.postContainersKill,
+			"/containers/{name:.*}/pause":      s.postContainersPause,
+			"/containers/{name:.*}/unpause":    s.postContainersUnpause,
+			"/containers/{name:.*}/checkpoint": s.postContainersCheckpoint,
+			"/containers/{name:.*}/restore":    s.postContainersRestore,
+			"/containers/{name:.*}/restart":    s.postContainersRestart,
+			"/containers/{name:.*}/start":      s.postContainersStart,
+			"/containers/{name:.*}/stop":       s.postContainersStop,
+			"/containers/{name:.*}/wait":       s.postContainersWait,
+			"/containers/{name:.*}/resize":     s.postContainersResize,
+			"/containers/{name:.*}/attach":     s.postContainersAttach,
+			"/containers/{name:.*}/copy":       s.postContainersCopy,
+			"/containers/{name:.*}/exec":       s.postContainerExecCreate,
+			"/exec/{name:.*}/start":            s.postContainerExecStart,
+			"/exec/{name:.*}/resize":           s.postContainerExecResize,
+			"/containers/{name:.*}/rename":     s.postContainerRename,
+			"/containers/{name:.*}/update":     s.postContainerUpdate,
+			"/volumes/create":                  s.postVolumesCreate,
+			"/networks/create":                 s.postNetworksCreate,
+			"/networks/{id:.*}/connect":        s.postNetworkConnect,
+			"/networks/{id:.*}/disconnect":     s.postNetworkDisconnect,
+		},
+		"HEAD": {
+			"/containers/{name:.*}/archive": s.headContainersArchive,
+		},
+		"PUT": {
+			"/containers/{name:.*}/archive": s.putContainersArchive,
 		},
 		"DELETE": {
 			"/containers/{name:.*}": s.deleteContainers,
 			"/images/{name:.*}":     s.deleteImages,
+			"/volumes/{name:.*}":    s.deleteVolumes,
+			"/networks/{id:.*}":     s.deleteNetwork,
 		},
 		"OPTIONS": {
 			"": s.optionsHandler,
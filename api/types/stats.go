@@ -69,6 +69,13 @@ type BlkioStats struct {
 	SectorsRecursive        []BlkioStatEntry `json:"sectors_recursive"`
 }
 
+type PidsStats struct {
+	// number of tasks of the container
+	Current uint64 `json:"current"`
+	// maximum number of tasks of the container, 0 for no limit
+	Limit uint64 `json:"limit"`
+}
+
 // TODO Windows: This will require refactoring
 type Network struct {
 	RxBytes   uint64 `json:"rx_bytes"`
@@ -88,4 +95,5 @@ type Stats struct {
 	CpuStats    CpuStats    `json:"cpu_stats,omitempty"`
 	MemoryStats MemoryStats `json:"memory_stats,omitempty"`
 	BlkioStats  BlkioStats  `json:"blkio_stats,omitempty"`
+	PidsStats   PidsStats   `json:"pids_stats,omitempty"`
 }
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"os"
 	"time"
 
 	"github.com/docker/docker/daemon/network"
@@ -18,6 +19,12 @@ type ContainerCreateResponse struct {
 	Warnings []string `json:"Warnings"`
 }
 
+// POST /containers/{name:.*}/update
+type ContainerUpdateResponse struct {
+	// Warnings are any warnings encountered during the update of the container.
+	Warnings []string `json:"Warnings"`
+}
+
 // POST /containers/{name:.*}/exec
 type ContainerExecCreateResponse struct {
 	// ID is the exec ID.
@@ -118,6 +125,18 @@ type CopyConfig struct {
 	Resource string
 }
 
+// ContainerPathStat is used to encode the header from
+// GET /containers/{name:.*}/archive
+// "name" is the file or directory name.
+// "linkTarget" is the path of the resource a symlink points to, if any.
+type ContainerPathStat struct {
+	Name       string      `json:"name"`
+	Size       int64       `json:"size"`
+	Mode       os.FileMode `json:"mode"`
+	Mtime      time.Time   `json:"mtime"`
+	LinkTarget string      `json:"linkTarget"`
+}
+
 // GET "/containers/{name:.*}/top"
 type ContainerProcessList struct {
 	Processes [][]string
@@ -192,6 +211,22 @@ type ContainerState struct {
 	Error      string
 	StartedAt  time.Time
 	FinishedAt time.Time
+	Health     *Health `json:",omitempty"`
+}
+
+// Health stores the health check state of a container
+type Health struct {
+	Status        string               // starting, healthy or unhealthy
+	FailingStreak int                  // number of consecutive failed probes
+	Log           []*HealthcheckResult // the last few probe results
+}
+
+// HealthcheckResult stores the outcome of a single health check probe
+type HealthcheckResult struct {
+	Start    time.Time // Start is the time this check started
+	End      time.Time // End is the time this check ended
+	ExitCode int       // ExitCode: 0=healthy, 1=unhealthy, other values are errors
+	Output   string    // Output from the last few lines of the probe
 }
 
 // GET "/containers/{name:.*}/json"
@@ -240,3 +275,66 @@ type ContainerConfig struct {
 	CpuShares  int64
 	Cpuset     string
 }
+
+// Volume represents the configuration of a volume for the remote API
+type Volume struct {
+	Name       string            // Name is the name of the volume
+	Driver     string            // Driver is the Driver name used to create the volume
+	Mountpoint string            // Mountpoint is the location on disk of the volume
+	Labels     map[string]string // Labels is the metadata set on the volume at creation
+	Options    map[string]string // Options are the driver specific options the volume was created with
+}
+
+// VolumesListResponse contains the response for the remote API:
+// GET "/volumes"
+type VolumesListResponse struct {
+	Volumes []*Volume // Volumes is the list of volumes being returned
+}
+
+// VolumeCreateRequest contains the request for the remote API:
+// POST "/volumes/create"
+type VolumeCreateRequest struct {
+	Name       string            // Name is the requested name of the volume
+	Driver     string            // Driver is the name of the driver that should be used to create the volume
+	DriverOpts map[string]string // DriverOpts holds the driver specific options to use
+	Labels     map[string]string // Labels holds metadata specific to the volume being created
+}
+
+// NetworkResource is the body of the "get network" http response message
+type NetworkResource struct {
+	Name       string                      // Name is the name of the network
+	ID         string                      `json:"Id"` // ID uniquely identifies the network
+	Driver     string                      // Driver is the name of the driver managing the network
+	Containers map[string]EndpointResource // Containers maps the IDs of the connected containers to their endpoint
+}
+
+// EndpointResource contains the network resources allocated to a container
+// on a network
+type EndpointResource struct {
+	EndpointID  string
+	MacAddress  string
+	IPv4Address string
+	IPv6Address string
+}
+
+// NetworkCreate is the expected body of the "create network" http request message
+type NetworkCreate struct {
+	Name    string            // Name is the requested name of the network
+	Driver  string            // Driver is the name of the driver that should manage the network
+	Options map[string]string // Options holds the driver specific options to use
+}
+
+// NetworkCreateResponse is the response message sent by the server for network create call
+type NetworkCreateResponse struct {
+	ID string `json:"Id"`
+}
+
+// NetworkConnect represents the data to be used to connect a container to the network
+type NetworkConnect struct {
+	Container string
+}
+
+// NetworkDisconnect represents the data to be used to disconnect a container from the network
+type NetworkDisconnect struct {
+	Container string
+}
@@ -16,6 +16,8 @@ const (
 	Expose     = "expose"
 	Volume     = "volume"
 	User       = "user"
+	Arg        = "arg"
+	StopSignal = "stopsignal"
 )
 
 // Commands is list of all Dockerfile commands
@@ -34,4 +36,6 @@ var Commands = map[string]struct{}{
 	Expose:     {},
 	Volume:     {},
 	User:       {},
+	Arg:        {},
+	StopSignal: {},
 }
@@ -8,6 +8,7 @@ package builder
 // package.
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
@@ -19,6 +20,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/nat"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/runconfig"
 )
 
@@ -162,38 +164,65 @@ func add(b *Builder, args []string, attributes map[string]bool, original string)
 		return err
 	}
 
-	return b.runContextCommand(args, true, true, "ADD")
+	return b.runContextCommand(args, true, true, "ADD", "")
 }
 
-// COPY foo /path
+// COPY [--from=stage] foo /path
 //
-// Same as 'ADD' but without the tar and remote url handling.
+// Same as 'ADD' but without the tar and remote url handling. With --from, the
+// files are copied from the image built by an earlier stage, given by name or
+// index, instead of the context.
 //
 func dispatchCopy(b *Builder, args []string, attributes map[string]bool, original string) error {
 	if len(args) < 2 {
 		return fmt.Errorf("COPY requires at least two arguments")
 	}
 
+	flFrom := b.BuilderFlags.AddString("from", "")
+
 	if err := b.BuilderFlags.Parse(); err != nil {
 		return err
 	}
 
-	return b.runContextCommand(args, false, false, "COPY")
+	var srcImage string
+	if flFrom.Value != "" {
+		stage, err := b.findStage(flFrom.Value)
+		if err != nil {
+			return err
+		}
+		if stage == nil {
+			return fmt.Errorf("Unknown build stage: %s", flFrom.Value)
+		}
+		srcImage = stage.image
+	}
+
+	return b.runContextCommand(args, false, false, "COPY", srcImage)
 }
 
-// FROM imagename
+// FROM imagename [AS stagename]
 //
-// This sets the image the dockerfile will build on top of.
+// This sets the image the dockerfile will build on top of. Each FROM starts a
+// new build stage, named so that later stages can copy its files with
+// COPY --from. Only the last stage makes the image of the build.
 //
 func from(b *Builder, args []string, attributes map[string]bool, original string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("FROM requires one argument")
+	if len(args) != 1 && (len(args) != 3 || !strings.EqualFold(args[1], "AS")) {
+		return fmt.Errorf("FROM requires either one argument, or three: FROM <image> AS <stage name>")
 	}
 
 	if err := b.BuilderFlags.Parse(); err != nil {
 		return err
 	}
 
+	var stageName string
+	if len(args) == 3 {
+		stageName = strings.ToLower(args[2])
+		if err := b.checkStageName(stageName); err != nil {
+			return err
+		}
+	}
+	b.startStage(stageName)
+
 	name := args[0]
 
 	if name == NoBaseImageSpecifier {
@@ -202,6 +231,19 @@ func from(b *Builder, args []string, attributes map[string]bool, original string
 		return nil
 	}
 
+	// An earlier stage can be the base image of the new one.
+	stage, err := b.findStage(name)
+	if err != nil {
+		return err
+	}
+	if stage != nil {
+		image, err := b.Daemon.Repositories().LookupImage(stage.image)
+		if err != nil {
+			return err
+		}
+		return b.processImageFrom(image)
+	}
+
 	image, err := b.Daemon.Repositories().LookupImage(name)
 	if b.Pull {
 		image, err = b.pullImage(name)
@@ -325,9 +367,32 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 
 	defer func(cmd *runconfig.Command) { b.Config.Cmd = cmd }(cmd)
 
+	// The build-time variables are given to the command, unless ENV defined
+	// them already. They are not persisted in the environment of the image,
+	// but prepended to the command committed with the container, as
+	// "|<count> KEY=sha256:<digest of value>...", so that the cache only
+	// matches a build with the same values without recording them. Commands
+	// cannot start with "|".
+	env := b.Config.Env
+	configEnv := runconfig.ConvertKVStringsToMap(env)
+	var buildEnv, hashedEnv []string
+	for _, kv := range b.buildArgsEnv() {
+		parts := strings.SplitN(kv, "=", 2)
+		if _, ok := configEnv[parts[0]]; !ok {
+			buildEnv = append(buildEnv, kv)
+			hashedEnv = append(hashedEnv, fmt.Sprintf("%s=sha256:%x", parts[0], sha256.Sum256([]byte(parts[1]))))
+		}
+	}
+	saveCmd := b.Config.Cmd
+	if len(buildEnv) > 0 {
+		saveCmd = runconfig.NewCommand(append(append([]string{fmt.Sprintf("|%d", len(hashedEnv))}, hashedEnv...), b.Config.Cmd.Slice()...)...)
+	}
+
 	logrus.Debugf("[BUILDER] Command to be executed: %v", b.Config.Cmd)
 
+	b.Config.Cmd = saveCmd
 	hit, err := b.probeCache()
+	b.Config.Cmd = config.Cmd
 	if err != nil {
 		return err
 	}
@@ -335,8 +400,10 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 		return nil
 	}
 
+	b.Config.Env = append(append([]string{}, env...), buildEnv...)
 	c, err := b.create()
 	if err != nil {
+		b.Config.Env = env
 		return err
 	}
 
@@ -346,6 +413,10 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 	defer c.Unmount()
 
 	err = b.run(c)
+	// The container shares its config with the builder, this also sets the
+	// config it is committed with.
+	b.Config.Env = env
+	b.Config.Cmd = saveCmd
 	if err != nil {
 		return err
 	}
@@ -527,3 +598,57 @@ func volume(b *Builder, args []string, attributes map[string]bool, original stri
 	}
 	return nil
 }
+
+// STOPSIGNAL signal
+//
+// Set the signal that will be used to kill the container.
+//
+func stopSignal(b *Builder, args []string, attributes map[string]bool, original string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("STOPSIGNAL requires exactly one argument")
+	}
+
+	if err := b.BuilderFlags.Parse(); err != nil {
+		return err
+	}
+
+	sig := args[0]
+	if _, err := signal.ParseSignal(sig); err != nil {
+		return err
+	}
+
+	b.Config.StopSignal = sig
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("STOPSIGNAL %v", args))
+}
+
+// ARG name[=default]
+//
+// Declares a build-time variable, which can be given a value with
+// --build-arg, or else gets its default if any. It can be used by the next
+// instructions, like a variable defined by ENV, but is not persisted in the
+// image.
+//
+func arg(b *Builder, args []string, attributes map[string]bool, original string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("ARG requires exactly one argument definition")
+	}
+
+	if err := b.BuilderFlags.Parse(); err != nil {
+		return err
+	}
+
+	parts := strings.SplitN(args[0], "=", 2)
+	name := parts[0]
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("ARG names can not be blank or contain whitespace: %q", name)
+	}
+
+	// The values given to the build override the default.
+	if len(parts) == 2 {
+		b.declareBuildArg(name, parts[1], true)
+	} else {
+		b.declareBuildArg(name, "", false)
+	}
+
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("ARG %s", args[0]))
+}
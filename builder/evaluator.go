@@ -24,6 +24,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -32,6 +33,7 @@ import (
 	"github.com/docker/docker/builder/parser"
 	"github.com/docker/docker/cliconfig"
 	"github.com/docker/docker/daemon"
+	imagepkg "github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/stringid"
@@ -43,14 +45,15 @@ import (
 
 // Environment variable interpolation will happen on these statements only.
 var replaceEnvAllowed = map[string]struct{}{
-	command.Env:     {},
-	command.Label:   {},
-	command.Add:     {},
-	command.Copy:    {},
-	command.Workdir: {},
-	command.Expose:  {},
-	command.Volume:  {},
-	command.User:    {},
+	command.Env:        {},
+	command.Label:      {},
+	command.Add:        {},
+	command.Copy:       {},
+	command.Workdir:    {},
+	command.Expose:     {},
+	command.Volume:     {},
+	command.User:       {},
+	command.StopSignal: {},
 }
 
 var evaluateTable map[string]func(*Builder, []string, map[string]bool, string) error
@@ -71,6 +74,8 @@ func init() {
 		command.Expose:     expose,
 		command.Volume:     volume,
 		command.User:       user,
+		command.Arg:        arg,
+		command.StopSignal: stopSignal,
 	}
 }
 
@@ -88,6 +93,26 @@ type Builder struct {
 	UtilizeCache bool
 	cacheBusted  bool
 
+	// CacheFrom are the images whose history is used as the cache, instead
+	// of all the local images. They are pulled if they are not available
+	// locally.
+	CacheFrom []string
+	cacheFrom []*imagepkg.Image
+	// NoCacheFromStep disables the cache from this step of the Dockerfile
+	// on, if greater than 0.
+	NoCacheFromStep int
+
+	// BuildArgs are the values of the build-time variables given to the
+	// build. Only the variables declared by ARG in the current stage, or
+	// the builtin ones, are visible to the build.
+	BuildArgs map[string]string
+	// allowedBuildArgs are the variables declared by ARG in the current
+	// stage, and buildArgDefaults their defaults. declaredBuildArgs are the
+	// variables declared in any stage.
+	allowedBuildArgs  map[string]bool
+	buildArgDefaults  map[string]string
+	declaredBuildArgs map[string]bool
+
 	// controls how images and containers are handled between steps.
 	Remove      bool
 	ForceRemove bool
@@ -119,6 +144,7 @@ type Builder struct {
 	context        tarsum.TarSum // the context is a tarball that is uploaded by the client
 	contextPath    string        // the path of the temporary directory the local context is unpacked to (server side)
 	noBaseImage    bool          // indicates that this build does not start from any base image, but is being built from an empty file system.
+	stages         []*buildStage // the stages started by each FROM, the last one is the current stage
 
 	// Set resource restrictions for build containers
 	cpuSetCpus   string
@@ -165,6 +191,17 @@ func (b *Builder) Run(context io.Reader) (string, error) {
 
 	b.TmpContainers = map[string]struct{}{}
 
+	if b.BuildArgs == nil {
+		b.BuildArgs = make(map[string]string)
+	}
+	b.allowedBuildArgs = make(map[string]bool)
+	b.buildArgDefaults = make(map[string]string)
+	b.declaredBuildArgs = make(map[string]bool)
+
+	if b.UtilizeCache {
+		b.loadCacheFrom()
+	}
+
 	for i, n := range b.dockerfile.Children {
 		select {
 		case <-b.cancelled:
@@ -174,6 +211,9 @@ func (b *Builder) Run(context io.Reader) (string, error) {
 		default:
 			// Not cancelled yet, keep going...
 		}
+		if b.NoCacheFromStep > 0 && i >= b.NoCacheFromStep {
+			b.cacheBusted = true
+		}
 		if err := b.dispatch(i, n); err != nil {
 			if b.ForceRemove {
 				b.clearTmp()
@@ -186,6 +226,19 @@ func (b *Builder) Run(context io.Reader) (string, error) {
 		}
 	}
 
+	// Build-time variables that were never declared by ARG are most likely
+	// typos, fail rather than silently ignoring them.
+	var leftoverArgs []string
+	for arg := range b.BuildArgs {
+		if !b.declaredBuildArgs[arg] && !builtinAllowedBuildArgs[arg] {
+			leftoverArgs = append(leftoverArgs, arg)
+		}
+	}
+	if len(leftoverArgs) > 0 {
+		sort.Strings(leftoverArgs)
+		return "", fmt.Errorf("One or more build-args %v were not consumed, failing build.", leftoverArgs)
+	}
+
 	if b.image == "" {
 		return "", fmt.Errorf("No image was generated. Is your Dockerfile empty?")
 	}
@@ -314,6 +367,11 @@ func (b *Builder) dispatch(stepN int, ast *parser.Node) error {
 	copy(strList, strs)
 	msgList := make([]string, n)
 
+	// The build-time variables come after the environment of the image, so
+	// that ENV overrides them: ProcessWord uses the first definition of a
+	// variable.
+	envs := append(append([]string{}, b.Config.Env...), b.buildArgsEnv()...)
+
 	var i int
 	for ast.Next != nil {
 		ast = ast.Next
@@ -321,7 +379,7 @@ func (b *Builder) dispatch(stepN int, ast *parser.Node) error {
 		str = ast.Value
 		if _, ok := replaceEnvAllowed[cmd]; ok {
 			var err error
-			str, err = ProcessWord(ast.Value, b.Config.Env)
+			str, err = ProcessWord(ast.Value, envs)
 			if err != nil {
 				return err
 			}
@@ -344,3 +402,51 @@ func (b *Builder) dispatch(stepN int, ast *parser.Node) error {
 
 	return fmt.Errorf("Unknown instruction: %s", strings.ToUpper(cmd))
 }
+
+// builtinAllowedBuildArgs are the build-time variables that can be given to
+// any build, without being declared by ARG.
+var builtinAllowedBuildArgs = map[string]bool{
+	"HTTP_PROXY":  true,
+	"http_proxy":  true,
+	"HTTPS_PROXY": true,
+	"https_proxy": true,
+	"FTP_PROXY":   true,
+	"ftp_proxy":   true,
+	"NO_PROXY":    true,
+	"no_proxy":    true,
+}
+
+func (b *Builder) isBuildArgAllowed(arg string) bool {
+	return b.allowedBuildArgs[arg] || builtinAllowedBuildArgs[arg]
+}
+
+// declareBuildArg makes the build-time variable name visible to the rest of
+// the current stage. It gets the value given to the build, or else def if
+// hasDefault.
+func (b *Builder) declareBuildArg(name, def string, hasDefault bool) {
+	b.allowedBuildArgs[name] = true
+	b.declaredBuildArgs[name] = true
+	if hasDefault {
+		b.buildArgDefaults[name] = def
+	} else {
+		delete(b.buildArgDefaults, name)
+	}
+}
+
+// buildArgsEnv returns the build-time variables visible at this point of the
+// build, as sorted KEY=value strings.
+func (b *Builder) buildArgsEnv() []string {
+	var env []string
+	for key, val := range b.BuildArgs {
+		if b.isBuildArgAllowed(key) {
+			env = append(env, key+"="+val)
+		}
+	}
+	for key, val := range b.buildArgDefaults {
+		if _, ok := b.BuildArgs[key]; !ok {
+			env = append(env, key+"="+val)
+		}
+	}
+	sort.Strings(env)
+	return env
+}
@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -27,11 +28,13 @@ import (
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/chrootarchive"
 	"github.com/docker/docker/pkg/httputils"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/progressreader"
 	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/pkg/tarsum"
 	"github.com/docker/docker/pkg/urlutil"
@@ -124,8 +127,18 @@ type copyInfo struct {
 	tmpDir     string
 }
 
-func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecompression bool, cmdName string) error {
-	if b.context == nil {
+// runContextCommand copies the files given by args from the context, or from
+// the root filesystem of srcImage if not empty, to a new layer.
+func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecompression bool, cmdName string, srcImage string) error {
+	srcRoot := b.contextPath
+	if srcImage != "" {
+		root, err := b.Daemon.GraphDriver().Get(srcImage, "")
+		if err != nil {
+			return err
+		}
+		defer b.Daemon.GraphDriver().Put(srcImage)
+		srcRoot = root
+	} else if b.context == nil {
 		return fmt.Errorf("No context given. Impossible to use %s", cmdName)
 	}
 
@@ -151,6 +164,12 @@ func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecomp
 	// do the copy (e.g. hash value if cached).  Don't actually do
 	// the copy until we've looked at all src files
 	for _, orig := range args[0 : len(args)-1] {
+		if srcImage != "" {
+			if err := calcImageCopyInfo(b, &copyInfos, srcImage, srcRoot, orig, dest); err != nil {
+				return err
+			}
+			continue
+		}
 		if err := calcCopyInfo(
 			b,
 			cmdName,
@@ -223,7 +242,7 @@ func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecomp
 	defer container.Unmount()
 
 	for _, ci := range copyInfos {
-		if err := b.addContext(container, ci.origPath, ci.destPath, ci.decompress); err != nil {
+		if err := b.addContext(container, srcRoot, ci.origPath, ci.destPath, ci.decompress); err != nil {
 			return err
 		}
 	}
@@ -234,6 +253,62 @@ func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecomp
 	return nil
 }
 
+// absDestPath makes a relative destination path relative to the WORKDIR,
+// preserving its trailing slash.
+func (b *Builder) absDestPath(destPath string) string {
+	if filepath.IsAbs(destPath) {
+		return destPath
+	}
+	hasSlash := strings.HasSuffix(destPath, "/")
+	destPath = filepath.Join("/", b.Config.WorkingDir, destPath)
+	if hasSlash {
+		destPath += "/"
+	}
+	return destPath
+}
+
+// calcImageCopyInfo adds the files matching origPath in the root filesystem
+// srcRoot of the image srcImage. The image ID stands for the content of the
+// files in the cache lookups.
+func calcImageCopyInfo(b *Builder, cInfos *[]*copyInfo, srcImage, srcRoot, origPath, destPath string) error {
+	destPath = b.absDestPath(destPath)
+
+	var paths []string
+	if ContainsWildcards(origPath) {
+		matches, err := filepath.Glob(filepath.Join(srcRoot, origPath))
+		if err != nil {
+			return err
+		}
+		paths = matches
+	} else {
+		paths = []string{filepath.Join(srcRoot, origPath)}
+	}
+
+	for _, path := range paths {
+		// Symlinks must not escape the root filesystem of the image.
+		resolved, err := symlink.FollowSymlinkInScope(path, srcRoot)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(resolved); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("%s: no such file or directory", origPath)
+			}
+			return err
+		}
+		rel, err := filepath.Rel(srcRoot, resolved)
+		if err != nil {
+			return err
+		}
+		*cInfos = append(*cInfos, &copyInfo{
+			origPath: rel,
+			destPath: destPath,
+			hash:     "image:" + srcImage + ":" + rel,
+		})
+	}
+	return nil
+}
+
 func calcCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, origPath string, destPath string, allowRemote bool, allowDecompression bool, allowWildcards bool) error {
 
 	if origPath != "" && origPath[0] == '/' && len(origPath) > 1 {
@@ -243,15 +318,7 @@ func calcCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, origPath stri
 
 	// Twiddle the destPath when its a relative path - meaning, make it
 	// relative to the WORKINGDIR
-	if !filepath.IsAbs(destPath) {
-		hasSlash := strings.HasSuffix(destPath, "/")
-		destPath = filepath.Join("/", b.Config.WorkingDir, destPath)
-
-		// Make sure we preserve any trailing slash
-		if hasSlash {
-			destPath += "/"
-		}
-	}
+	destPath = b.absDestPath(destPath)
 
 	// In the remote/URL case, download it and gen its hashcode
 	if urlutil.IsURL(origPath) {
@@ -482,6 +549,73 @@ func (b *Builder) pullImage(name string) (*imagepkg.Image, error) {
 	return image, nil
 }
 
+// buildStage is the part of a Dockerfile starting with a FROM instruction.
+type buildStage struct {
+	name  string // the name given with FROM ... AS, if any
+	image string // the ID of the last image committed by the stage
+}
+
+// startStage records the image built by the current stage, if any, and
+// starts a new one from an empty configuration.
+func (b *Builder) startStage(name string) {
+	if n := len(b.stages); n > 0 {
+		b.stages[n-1].image = b.image
+
+		b.Config = &runconfig.Config{}
+		b.image = ""
+		b.noBaseImage = false
+		b.maintainer = ""
+		b.cmdSet = false
+		// The new stage starts from another image, its cache is unrelated
+		// to the one of the previous stage.
+		b.cacheBusted = false
+		// The build-time variables are declared again by each stage
+		// using them.
+		b.allowedBuildArgs = make(map[string]bool)
+		b.buildArgDefaults = make(map[string]string)
+	}
+	b.stages = append(b.stages, &buildStage{name: name})
+}
+
+func (b *Builder) checkStageName(name string) error {
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("Invalid stage name %q, stage names can not be numbers", name)
+	}
+	for _, stage := range b.stages {
+		if stage.name == name {
+			return fmt.Errorf("Duplicate stage name %q", name)
+		}
+	}
+	return nil
+}
+
+// findStage returns the earlier stage with the given name or index, or nil if
+// there is none.
+func (b *Builder) findStage(name string) (*buildStage, error) {
+	if len(b.stages) == 0 {
+		return nil, nil
+	}
+	done := b.stages[:len(b.stages)-1]
+
+	var stage *buildStage
+	if i, err := strconv.Atoi(name); err == nil {
+		if i >= 0 && i < len(done) {
+			stage = done[i]
+		}
+	} else {
+		for _, s := range done {
+			if s.name == strings.ToLower(name) {
+				stage = s
+				break
+			}
+		}
+	}
+	if stage != nil && stage.image == "" {
+		return nil, fmt.Errorf("Build stage %s did not produce any image", name)
+	}
+	return stage, nil
+}
+
 func (b *Builder) processImageFrom(img *imagepkg.Image) error {
 	b.image = img.ID
 
@@ -528,9 +662,27 @@ func (b *Builder) processImageFrom(img *imagepkg.Image) error {
 	return nil
 }
 
+// loadCacheFrom looks up the images given as cache sources, pulling them if
+// needed. An image that can not be found is not an error, the build just
+// does not use it as cache.
+func (b *Builder) loadCacheFrom() {
+	for _, name := range b.CacheFrom {
+		img, err := b.Daemon.Repositories().LookupImage(name)
+		if err != nil && b.Daemon.Graph().IsNotExist(err, name) {
+			img, err = b.pullImage(name)
+		}
+		if err != nil {
+			fmt.Fprintf(b.ErrStream, "Couldn't use cache image %s: %v\n", name, err)
+			continue
+		}
+		b.cacheFrom = append(b.cacheFrom, img)
+	}
+}
+
 // probeCache checks to see if image-caching is enabled (`b.UtilizeCache`)
 // and if so attempts to look up the current `b.image` and `b.Config` pair
-// in the current server `b.Daemon`. If an image is found, probeCache returns
+// in the current server `b.Daemon`, or only in the history of the images of
+// `b.CacheFrom` if any. If an image is found, probeCache returns
 // `(true, nil)`. If no image is found, it returns `(false, nil)`. If there
 // is any error, it returns `(false, err)`.
 func (b *Builder) probeCache() (bool, error) {
@@ -538,9 +690,15 @@ func (b *Builder) probeCache() (bool, error) {
 		return false, nil
 	}
 
-	cache, err := b.Daemon.ImageGetCached(b.image, b.Config)
-	if err != nil {
-		return false, err
+	var cache *imagepkg.Image
+	if len(b.CacheFrom) > 0 {
+		cache = b.Daemon.ImageGetCachedFrom(b.image, b.Config, b.cacheFrom)
+	} else {
+		var err error
+		cache, err = b.Daemon.ImageGetCached(b.image, b.Config)
+		if err != nil {
+			return false, err
+		}
 	}
 	if cache == nil {
 		logrus.Debugf("[BUILDER] Cache miss")
@@ -663,11 +821,12 @@ func (b *Builder) checkPathForAddition(orig string) error {
 	return nil
 }
 
-func (b *Builder) addContext(container *daemon.Container, orig, dest string, decompress bool) error {
+// addContext copies orig, relative to srcRoot, to dest in the container.
+func (b *Builder) addContext(container *daemon.Container, srcRoot, orig, dest string, decompress bool) error {
 	var (
 		err        error
 		destExists = true
-		origPath   = filepath.Join(b.contextPath, orig)
+		origPath   = filepath.Join(srcRoot, orig)
 		destPath   string
 	)
 
@@ -697,8 +856,11 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 		return err
 	}
 
+	uidMaps, gidMaps := b.Daemon.GetUIDGIDMaps()
+	rootUID, rootGID := b.Daemon.GetRemappedUIDGID()
+
 	if fi.IsDir() {
-		return copyAsDirectory(origPath, destPath, destExists)
+		return copyAsDirectory(origPath, destPath, destExists, rootUID, rootGID)
 	}
 
 	// If we are adding a remote file (or we've been told not to decompress), do not try to untar it
@@ -713,7 +875,7 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 		}
 
 		// try to successfully untar the orig
-		if err := chrootarchive.UntarPath(origPath, tarDest); err == nil {
+		if err := untarPath(origPath, tarDest, uidMaps, gidMaps); err == nil {
 			return nil
 		} else if err != io.EOF {
 			logrus.Debugf("Couldn't untar %s to %s: %s", origPath, tarDest, err)
@@ -732,14 +894,30 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 		resPath = filepath.Join(destPath, filepath.Base(origPath))
 	}
 
-	return fixPermissions(origPath, resPath, 0, 0, destExists)
+	return fixPermissions(origPath, resPath, rootUID, rootGID, destExists)
 }
 
-func copyAsDirectory(source, destination string, destExisted bool) error {
+func copyAsDirectory(source, destination string, destExisted bool, rootUID, rootGID int) error {
 	if err := chrootarchive.CopyWithTar(source, destination); err != nil {
 		return err
 	}
-	return fixPermissions(source, destination, 0, 0, destExisted)
+	return fixPermissions(source, destination, rootUID, rootGID, destExisted)
+}
+
+// untarPath extracts the archive at src to dst, translating the owners of
+// its entries from the ids in the user namespace of the containers to the
+// ids on the host.
+func untarPath(src, dst string, uidMaps, gidMaps []idtools.IDMap) error {
+	if uidMaps == nil && gidMaps == nil {
+		return chrootarchive.UntarPath(src, dst)
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	layer := archive.ToHostIDs(f, uidMaps, gidMaps)
+	defer layer.Close()
+	return chrootarchive.Untar(layer, dst, nil)
 }
 
 func (b *Builder) clearTmp() {
@@ -0,0 +1,87 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindStage(t *testing.T) {
+	b := &Builder{}
+	if stage, err := b.findStage("build"); stage != nil || err != nil {
+		t.Fatalf("Expected no stage before the first FROM, got %v, %v", stage, err)
+	}
+
+	b.startStage("build")
+	b.image = "abc"
+	if stage, err := b.findStage("build"); stage != nil || err != nil {
+		t.Fatalf("Expected the current stage not to be found, got %v, %v", stage, err)
+	}
+
+	b.startStage("")
+	for _, name := range []string{"build", "BUILD", "0"} {
+		stage, err := b.findStage(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stage == nil || stage.image != "abc" {
+			t.Fatalf("Expected the first stage for %s, got %v", name, stage)
+		}
+	}
+	if b.image != "" || b.Config == nil {
+		t.Fatalf("Expected the new stage to start from scratch, got image %q and config %v", b.image, b.Config)
+	}
+	if stage, err := b.findStage("1"); stage != nil || err != nil {
+		t.Fatalf("Expected no stage for an out of range index, got %v, %v", stage, err)
+	}
+
+	b.startStage("empty")
+	b.startStage("")
+	if _, err := b.findStage("empty"); err == nil {
+		t.Fatal("Expected an error for a stage without image")
+	}
+}
+
+func TestCheckStageName(t *testing.T) {
+	b := &Builder{}
+	b.startStage("build")
+	if err := b.checkStageName("build"); err == nil {
+		t.Fatal("Expected an error for a duplicate stage name")
+	}
+	if err := b.checkStageName("1"); err == nil {
+		t.Fatal("Expected an error for a numeric stage name")
+	}
+	if err := b.checkStageName("test"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartStageBuildArgs(t *testing.T) {
+	b := &Builder{
+		BuildArgs:         map[string]string{"foo": "fromflag"},
+		allowedBuildArgs:  make(map[string]bool),
+		buildArgDefaults:  make(map[string]string),
+		declaredBuildArgs: make(map[string]bool),
+	}
+	b.startStage("build")
+	b.declareBuildArg("foo", "", false)
+	b.declareBuildArg("bar", "default", true)
+	if env := b.buildArgsEnv(); !reflect.DeepEqual(env, []string{"bar=default", "foo=fromflag"}) {
+		t.Fatalf("Expected the declared build args in the first stage, got %v", env)
+	}
+
+	b.startStage("")
+	if env := b.buildArgsEnv(); len(env) != 0 {
+		t.Fatalf("Expected no build args before they are declared by the second stage, got %v", env)
+	}
+	if b.isBuildArgAllowed("foo") {
+		t.Fatal("Expected foo not to be allowed in the second stage")
+	}
+	if !b.declaredBuildArgs["foo"] {
+		t.Fatal("Expected foo to stay declared by the build")
+	}
+
+	b.declareBuildArg("bar", "", false)
+	if env := b.buildArgsEnv(); len(env) != 0 {
+		t.Fatalf("Expected the default of the first stage not to be kept, got %v", env)
+	}
+}
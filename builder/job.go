@@ -34,27 +34,31 @@ var validCommitCommands = map[string]bool{
 	"volume":     true,
 	"expose":     true,
 	"onbuild":    true,
+	"stopsignal": true,
 }
 
 type Config struct {
-	DockerfileName string
-	RemoteURL      string
-	RepoName       string
-	SuppressOutput bool
-	NoCache        bool
-	Remove         bool
-	ForceRemove    bool
-	Pull           bool
-	Memory         int64
-	MemorySwap     int64
-	CpuShares      int64
-	CpuPeriod      int64
-	CpuQuota       int64
-	CpuSetCpus     string
-	CpuSetMems     string
-	CgroupParent   string
-	AuthConfig     *cliconfig.AuthConfig
-	ConfigFile     *cliconfig.ConfigFile
+	DockerfileName  string
+	RemoteURL       string
+	RepoName        string
+	SuppressOutput  bool
+	NoCache         bool
+	CacheFrom       []string
+	NoCacheFromStep int
+	Remove          bool
+	ForceRemove     bool
+	Pull            bool
+	Memory          int64
+	MemorySwap      int64
+	CpuShares       int64
+	CpuPeriod       int64
+	CpuQuota        int64
+	CpuSetCpus      string
+	CpuSetMems      string
+	CgroupParent    string
+	BuildArgs       map[string]string
+	AuthConfig      *cliconfig.AuthConfig
+	ConfigFile      *cliconfig.ConfigFile
 
 	Stdout  io.Writer
 	Context io.ReadCloser
@@ -155,6 +159,8 @@ func Build(d *daemon.Daemon, buildConfig *Config) error {
 		},
 		Verbose:         !buildConfig.SuppressOutput,
 		UtilizeCache:    !buildConfig.NoCache,
+		CacheFrom:       buildConfig.CacheFrom,
+		NoCacheFromStep: buildConfig.NoCacheFromStep,
 		Remove:          buildConfig.Remove,
 		ForceRemove:     buildConfig.ForceRemove,
 		Pull:            buildConfig.Pull,
@@ -171,6 +177,7 @@ func Build(d *daemon.Daemon, buildConfig *Config) error {
 		cgroupParent:    buildConfig.CgroupParent,
 		memory:          buildConfig.Memory,
 		memorySwap:      buildConfig.MemorySwap,
+		BuildArgs:       buildConfig.BuildArgs,
 		cancelled:       buildConfig.WaitCancelled(),
 	}
 
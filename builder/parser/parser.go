@@ -53,7 +53,7 @@ func init() {
 		command.Env:        parseEnv,
 		command.Label:      parseLabel,
 		command.Maintainer: parseString,
-		command.From:       parseString,
+		command.From:       parseStringsWhitespaceDelimited,
 		command.Add:        parseMaybeJSONToList,
 		command.Copy:       parseMaybeJSONToList,
 		command.Run:        parseMaybeJSON,
@@ -61,6 +61,8 @@ func init() {
 		command.Entrypoint: parseMaybeJSON,
 		command.Expose:     parseStringsWhitespaceDelimited,
 		command.Volume:     parseMaybeJSONToList,
+		command.Arg:        parseString,
+		command.StopSignal: parseString,
 	}
 }
 
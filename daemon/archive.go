@@ -0,0 +1,294 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/ioutils"
+)
+
+var (
+	// ErrExtractPointNotDirectory is returned when the path an archive is
+	// extracted to in a container is not a directory.
+	ErrExtractPointNotDirectory = errors.New("extraction point is not a directory")
+	// ErrRootFSReadOnly is returned when an archive is extracted to a
+	// read-only location of a container.
+	ErrRootFSReadOnly = errors.New("container rootfs is marked read-only")
+)
+
+// ContainerStatPath stats the filesystem resource at the specified path in the
+// container identified by the given name.
+func (daemon *Daemon) ContainerStatPath(name string, path string) (*types.ContainerPathStat, error) {
+	container, err := daemon.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return container.StatPath(path)
+}
+
+// ContainerArchivePath creates an archive of the filesystem resource at the
+// specified path in the container identified by the given name. Returns a
+// tar archive of the resource and stat info about the resource.
+func (daemon *Daemon) ContainerArchivePath(name string, path string) (io.ReadCloser, *types.ContainerPathStat, error) {
+	container, err := daemon.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return container.ArchivePath(path)
+}
+
+// ContainerExtractToDir extracts the given archive to the specified location
+// in the filesystem of the container identified by the given name. The given
+// path must be of a directory in the container. If it is not, the error will
+// be ErrExtractPointNotDirectory. If noOverwriteDirNonDir is true then it will
+// be an error if unpacking the given content would cause an existing directory
+// to be replaced with a non-directory and vice versa.
+func (daemon *Daemon) ContainerExtractToDir(name, path string, noOverwriteDirNonDir bool, content io.Reader) error {
+	container, err := daemon.Get(name)
+	if err != nil {
+		return err
+	}
+
+	return container.ExtractToDir(path, noOverwriteDirNonDir, content)
+}
+
+// resolvePath resolves the given path in the container to a resource on the
+// host. Symlinks are followed in the scope of the container's rootfs, except
+// for the last path component which is kept as is. Returns the resolved host
+// path and the absolute path in the container.
+func (container *Container) resolvePath(path string) (resolvedPath, absPath string, err error) {
+	absPath = filepath.Join("/", path)
+
+	dir, base := filepath.Split(absPath)
+	resolvedDir, err := container.GetResourcePath(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	return filepath.Join(resolvedDir, base), absPath, nil
+}
+
+// statPath is the unexported version of StatPath. Locks and mounts should
+// be acquired before calling this method and the given path should be fully
+// resolved to a path on the host corresponding to the given absolute path
+// inside the container.
+func (container *Container) statPath(resolvedPath, absPath string) (*types.ContainerPathStat, error) {
+	lstat, err := os.Lstat(resolvedPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("lstat %s: no such file or directory", absPath)
+		}
+		return nil, err
+	}
+
+	var linkTarget string
+	if lstat.Mode()&os.ModeSymlink != 0 {
+		// Fully evaluate the symlink in the scope of the container rootfs.
+		hostPath, err := container.GetResourcePath(absPath)
+		if err != nil {
+			return nil, err
+		}
+
+		linkTarget, err = filepath.Rel(container.basefs, hostPath)
+		if err != nil {
+			return nil, err
+		}
+
+		// Make it an absolute path.
+		linkTarget = filepath.Join("/", linkTarget)
+	}
+
+	return &types.ContainerPathStat{
+		Name:       filepath.Base(absPath),
+		Size:       lstat.Size(),
+		Mode:       lstat.Mode(),
+		Mtime:      lstat.ModTime(),
+		LinkTarget: linkTarget,
+	}, nil
+}
+
+// StatPath stats the filesystem resource at the specified path in this
+// container. Returns stat info about the resource.
+func (container *Container) StatPath(path string) (stat *types.ContainerPathStat, err error) {
+	container.Lock()
+	defer container.Unlock()
+
+	if err = container.Mount(); err != nil {
+		return nil, err
+	}
+	defer container.Unmount()
+
+	err = container.mountVolumes(true)
+	defer container.UnmountVolumes(true)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedPath, absPath, err := container.resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return container.statPath(resolvedPath, absPath)
+}
+
+// ArchivePath creates an archive of the filesystem resource at the specified
+// path in this container. Returns a tar archive of the resource and stat info
+// about the resource.
+func (container *Container) ArchivePath(path string) (content io.ReadCloser, stat *types.ContainerPathStat, err error) {
+	container.Lock()
+	defer container.Unlock()
+
+	if err = container.Mount(); err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			// unmount any volumes
+			container.UnmountVolumes(true)
+			// unmount the container's rootfs
+			container.Unmount()
+		}
+	}()
+
+	if err = container.mountVolumes(true); err != nil {
+		return nil, nil, err
+	}
+
+	resolvedPath, absPath, err := container.resolvePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stat, err = container.statPath(resolvedPath, absPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sourceDir, sourceBase := filepath.Split(resolvedPath)
+	data, err := archive.TarWithOptions(sourceDir, &archive.TarOptions{
+		Compression:  archive.Uncompressed,
+		IncludeFiles: []string{sourceBase},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data = archive.ToContainerIDs(data, container.daemon.uidMaps, container.daemon.gidMaps)
+	content = ioutils.NewReadCloserWrapper(data, func() error {
+		err := data.Close()
+		container.UnmountVolumes(true)
+		container.Unmount()
+		return err
+	})
+
+	container.LogEvent("archive-path")
+
+	return content, stat, nil
+}
+
+// ExtractToDir extracts the given tar archive to the specified location in the
+// filesystem of this container. The given path must be of a directory in the
+// container. If it is not, the error will be ErrExtractPointNotDirectory. If
+// noOverwriteDirNonDir is true then it will be an error if unpacking the
+// given content would cause an existing directory to be replaced with a non-
+// directory and vice versa.
+func (container *Container) ExtractToDir(path string, noOverwriteDirNonDir bool, content io.Reader) (err error) {
+	container.Lock()
+	defer container.Unlock()
+
+	if err = container.Mount(); err != nil {
+		return err
+	}
+	defer container.Unmount()
+
+	err = container.mountVolumes(false)
+	defer container.UnmountVolumes(true)
+	if err != nil {
+		return err
+	}
+
+	// The destination directory is fully resolved in the scope of the
+	// container's rootfs, and the content is extracted in a chroot of it, so
+	// that no symlink can make it escape the container.
+	resolvedPath, err := container.GetResourcePath(path)
+	if err != nil {
+		return err
+	}
+
+	stat, err := os.Lstat(resolvedPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("lstat %s: no such file or directory", filepath.Join("/", path))
+		}
+		return err
+	}
+
+	if !stat.IsDir() {
+		return ErrExtractPointNotDirectory
+	}
+
+	// The path of the destination directory inside the container, with
+	// symlinks resolved, to find out which mount it belongs to.
+	relPath, err := filepath.Rel(container.basefs, resolvedPath)
+	if err != nil {
+		return err
+	}
+	absPath := filepath.Join("/", relPath)
+
+	if !container.isPathWritable(absPath) {
+		return ErrRootFSReadOnly
+	}
+
+	options := &archive.TarOptions{
+		NoOverwriteDirNonDir: noOverwriteDirNonDir,
+	}
+
+	// content itself is closed by the caller.
+	hostContent := archive.ToHostIDs(ioutil.NopCloser(content), container.daemon.uidMaps, container.daemon.gidMaps)
+	defer hostContent.Close()
+	if err := chrootarchive.Untar(hostContent, resolvedPath, options); err != nil {
+		return err
+	}
+
+	container.LogEvent("extract-to-dir")
+
+	return nil
+}
+
+// isPathWritable returns whether the given absolute path in the container is
+// writable, according to the volume it is in or to the rootfs of the
+// container.
+func (container *Container) isPathWritable(absPath string) bool {
+	var (
+		mountPoint *mountPoint
+		longest    int
+	)
+	for _, m := range container.MountPoints {
+		dest := filepath.Clean(m.Destination)
+		if absPath != dest && !strings.HasPrefix(absPath, dest+"/") {
+			continue
+		}
+		if len(dest) > longest {
+			mountPoint = m
+			longest = len(dest)
+		}
+	}
+
+	if mountPoint != nil {
+		return mountPoint.RW
+	}
+
+	return !container.hostConfig.ReadonlyRootfs
+}
@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/runconfig"
+)
+
+// ContainerCheckpoint dumps the processes of a running container to its
+// checkpoint directory. Unless config.LeaveRunning is set, the container is
+// stopped once checkpointed.
+func (daemon *Daemon) ContainerCheckpoint(name string, config *runconfig.CheckpointConfig) error {
+	container, err := daemon.Get(name)
+	if err != nil {
+		return err
+	}
+
+	if err := container.Checkpoint(config); err != nil {
+		return fmt.Errorf("Cannot checkpoint container %s: %s", name, err)
+	}
+
+	return nil
+}
+
+// ContainerRestore restores the processes of a stopped container from its
+// last checkpoint.
+func (daemon *Daemon) ContainerRestore(name string) error {
+	container, err := daemon.Get(name)
+	if err != nil {
+		return err
+	}
+
+	if err := container.RestoreCheckpoint(); err != nil {
+		return fmt.Errorf("Cannot restore container %s: %s", name, err)
+	}
+
+	return nil
+}
+
+// checkpointDir returns the directory of the checkpoint of the container,
+// with the images of its processes and the logs of CRIU.
+func (container *Container) checkpointDir() string {
+	return filepath.Join(container.root, "checkpoint")
+}
+
+func (container *Container) checkpointOptions() *execdriver.CheckpointOptions {
+	dir := container.checkpointDir()
+	opts := &execdriver.CheckpointOptions{
+		ImagesDirectory: filepath.Join(dir, "images"),
+		WorkDirectory:   filepath.Join(dir, "work"),
+	}
+	if config := container.CheckpointConfig; config != nil {
+		opts.LeaveRunning = config.LeaveRunning
+		opts.TcpEstablished = config.TcpEstablished
+		opts.ExternalUnixConnections = config.ExternalUnixConnections
+		opts.ShellJob = config.ShellJob
+	}
+	return opts
+}
+
+// Checkpoint dumps the processes of the running container. The container
+// stays locked until they are dumped, and killed unless config.LeaveRunning
+// is set, so that its monitor knows its process exited because of the
+// checkpoint.
+func (container *Container) Checkpoint(config *runconfig.CheckpointConfig) error {
+	container.Lock()
+	defer container.Unlock()
+
+	if !container.Running {
+		return fmt.Errorf("Container %s is not running", container.ID)
+	}
+	if container.Paused {
+		return fmt.Errorf("Container %s is paused, unpause the container before checkpointing it", container.ID)
+	}
+	if container.Restarting {
+		return fmt.Errorf("Container %s is restarting", container.ID)
+	}
+	// The terminal of the container is held by the daemon, and its network
+	// namespace, but for the host one, by its network sandbox. CRIU can only
+	// restore the veth pairs libcontainer creates itself, not the ones of the
+	// sandbox.
+	if container.Config.Tty {
+		return fmt.Errorf("Containers with a TTY can't be checkpointed")
+	}
+	if mode := container.hostConfig.NetworkMode; !container.Config.NetworkDisabled && !mode.IsHost() && !mode.IsNone() {
+		return fmt.Errorf("Containers not using --net=host or --net=none can't be checkpointed")
+	}
+
+	// The previous checkpoint is replaced.
+	dir := container.checkpointDir()
+	container.CheckpointedAt = time.Time{}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	container.CheckpointConfig = config
+	if err := container.daemon.Checkpoint(container); err != nil {
+		container.toDisk()
+		return err
+	}
+	container.setCheckpointed(config.LeaveRunning)
+	container.LogEvent("checkpoint")
+
+	return container.toDisk()
+}
+
+// RestoreCheckpoint restores the processes of the stopped container from its
+// last checkpoint, and monitors them as Start does.
+func (container *Container) RestoreCheckpoint() (err error) {
+	container.Lock()
+	defer container.Unlock()
+
+	if container.Running {
+		return fmt.Errorf("Container %s is already running", container.ID)
+	}
+	if container.removalInProgress || container.Dead {
+		return fmt.Errorf("Container is marked for removal and cannot be restored.")
+	}
+	if container.CheckpointedAt.IsZero() {
+		return fmt.Errorf("Container %s has no checkpoint", container.ID)
+	}
+
+	// if we encounter an error during restore we need to ensure that any
+	// other setup has been cleaned up properly
+	defer func() {
+		if err != nil {
+			container.setError(err)
+			container.toDisk()
+			container.cleanup()
+		}
+	}()
+
+	if err := container.prepareCommand(); err != nil {
+		return err
+	}
+
+	container.monitor = newContainerMonitor(container, container.hostConfig.RestartPolicy)
+	container.monitor.restoringCheckpoint = true
+	return container.waitForMonitor()
+}
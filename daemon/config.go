@@ -27,6 +27,7 @@ type CommonConfig struct {
 	GraphDriver    string
 	GraphOptions   []string
 	Labels         []string
+	LiveRestore    bool
 	LogConfig      runconfig.LogConfig
 	Mtu            int
 	Pidfile        string
@@ -54,6 +55,7 @@ func (config *Config) InstallCommonFlags() {
 	opts.IPListVar(&config.Dns, []string{"#dns", "-dns"}, "DNS server to use")
 	opts.DnsSearchListVar(&config.DnsSearch, []string{"-dns-search"}, "DNS search domains to use")
 	opts.LabelListVar(&config.Labels, []string{"-label"}, "Set key=value labels to the daemon")
+	flag.BoolVar(&config.LiveRestore, []string{"-live-restore"}, false, "Keep containers running when the daemon exits")
 	flag.StringVar(&config.LogConfig.Type, []string{"-log-driver"}, "json-file", "Default driver for container logs")
 	opts.LogOptsVar(config.LogConfig.Config, []string{"-log-opt"}, "Set log driver options")
 }
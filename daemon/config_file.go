@@ -0,0 +1,142 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+// ConfigFile holds the options of a daemon configuration file, a JSON
+// object whose keys are the long names of the flags of the daemon, for
+// example "label" for --label.
+type ConfigFile map[string]interface{}
+
+// ReadConfigFile reads the daemon configuration file at path.
+func ReadConfigFile(path string) (ConfigFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var config ConfigFile
+	if err := json.NewDecoder(f).Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// Validate checks that the options of the file are flags of flags, and
+// that none of them is also set by a flag.
+func (config ConfigFile) Validate(flags *flag.FlagSet) error {
+	var conflicts []string
+	for _, name := range config.names() {
+		f := flags.Lookup("-" + name)
+		if f == nil {
+			return fmt.Errorf("unknown option %q in the configuration file", name)
+		}
+		for _, n := range f.Names {
+			if flags.IsSet(strings.TrimPrefix(n, "#")) {
+				conflicts = append(conflicts, name)
+				break
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("the following options are set both by a flag and in the configuration file: %s", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// Apply sets the flags of flags to the values of the options of the file.
+// The options which aren't flags of flags are ignored. The flags aren't
+// marked as set, so that Validate can still be called on the same flags.
+func (config ConfigFile) Apply(flags *flag.FlagSet) error {
+	for _, name := range config.names() {
+		f := flags.Lookup("-" + name)
+		if f == nil {
+			continue
+		}
+		values, err := flagValues(config[name])
+		if err != nil {
+			return fmt.Errorf("invalid value for %q in the configuration file: %v", name, err)
+		}
+		for _, v := range values {
+			if err := f.Value.Set(v); err != nil {
+				return fmt.Errorf("invalid value for %q in the configuration file: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MergeConfigFile validates the configuration file at path against the
+// flags already parsed, and sets the flags which weren't set on the command
+// line from it.
+func MergeConfigFile(flags *flag.FlagSet, path string) error {
+	config, err := ReadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(flags); err != nil {
+		return err
+	}
+	return config.Apply(flags)
+}
+
+func (config ConfigFile) names() []string {
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// flagValues converts a JSON value to the values to set the flag to, one
+// per element of an array or per key=value pair of an object.
+func flagValues(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case []interface{}:
+		var values []string
+		for _, e := range v {
+			switch e.(type) {
+			case []interface{}, map[string]interface{}:
+				return nil, fmt.Errorf("nested value %v", e)
+			}
+			ev, err := flagValues(e)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, ev...)
+		}
+		return values, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var values []string
+		for _, k := range keys {
+			s, ok := v[k].(string)
+			if !ok {
+				return nil, fmt.Errorf("value of %q is not a string", k)
+			}
+			values = append(values, k+"="+s)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v", v)
+}
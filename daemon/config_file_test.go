@@ -0,0 +1,116 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+type testFlags struct {
+	labels opts.ListOpts
+	debug  bool
+	mtu    int
+}
+
+func newTestFlags(args ...string) (*flag.FlagSet, *testFlags, error) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	values := &testFlags{labels: opts.NewListOpts(opts.ValidateLabel)}
+	flags.Var(&values.labels, []string{"-label"}, "")
+	flags.BoolVar(&values.debug, []string{"D", "-debug"}, false, "")
+	flags.IntVar(&values.mtu, []string{"#mtu", "-mtu"}, 0, "")
+	return flags, values, flags.Parse(args)
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "docker-config-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestMergeConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `{
+		"label": ["a=b", "c=d"],
+		"debug": true,
+		"mtu": 1450
+	}`)
+	defer os.Remove(path)
+
+	flags, values, err := newTestFlags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := MergeConfigFile(flags, path); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values.labels.GetAll(), []string{"a=b", "c=d"}) {
+		t.Fatalf("Wrong labels %v", values.labels.GetAll())
+	}
+	if !values.debug || values.mtu != 1450 {
+		t.Fatalf("Wrong options debug=%v mtu=%d", values.debug, values.mtu)
+	}
+	// The options of the file aren't flags set on the command line.
+	if flags.IsSet("-label") {
+		t.Fatal("The options of the file shouldn't be marked as set")
+	}
+}
+
+func TestMergeConfigFileConflicts(t *testing.T) {
+	path := writeConfigFile(t, `{"label": ["a=b"], "debug": true, "mtu": 1450}`)
+	defer os.Remove(path)
+
+	flags, _, err := newTestFlags("-D", "--label", "e=f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = MergeConfigFile(flags, path)
+	if err == nil || !strings.Contains(err.Error(), "debug, label") {
+		t.Fatalf("Expected a conflict for debug and label, got %v", err)
+	}
+}
+
+func TestMergeConfigFileInvalid(t *testing.T) {
+	for _, content := range []string{
+		`{"unknown": true}`,
+		`{"label": ["invalid"]}`,
+		`{"mtu": "large"}`,
+		`{"label": [["a=b"]]}`,
+		`{"label": {"a": 10}}`,
+		`not json`,
+	} {
+		path := writeConfigFile(t, content)
+		flags, _, err := newTestFlags()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := MergeConfigFile(flags, path); err == nil {
+			t.Fatalf("Expected an error merging %s", content)
+		}
+		os.Remove(path)
+	}
+}
+
+func TestConfigFileApplyIgnoresOtherFlags(t *testing.T) {
+	// Objects are set as key=value pairs.
+	config := ConfigFile{"label": map[string]interface{}{"a": "b"}, "mtu": float64(1450)}
+
+	flags := flag.NewFlagSet("reload", flag.ContinueOnError)
+	labels := opts.NewListOpts(opts.ValidateLabel)
+	flags.Var(&labels, []string{"-label"}, "")
+	if err := config.Apply(flags); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(labels.GetAll(), []string{"a=b"}) {
+		t.Fatalf("Wrong labels %v", labels.GetAll())
+	}
+}
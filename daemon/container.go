@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -16,6 +17,7 @@ import (
 	"github.com/docker/libcontainer/label"
 
 	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/logger/jsonfilelog"
@@ -25,12 +27,13 @@ import (
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/broadcastwriter"
 	"github.com/docker/docker/pkg/ioutils"
-	"github.com/docker/docker/pkg/jsonlog"
 	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/promise"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/volume"
+	"github.com/docker/docker/volume/store"
 )
 
 var (
@@ -73,6 +76,7 @@ type CommonContainer struct {
 	MountLabel, ProcessLabel string
 	RestartCount             int
 	UpdateDns                bool
+	CheckpointConfig         *runconfig.CheckpointConfig // options of the last checkpoint, to restore it
 
 	MountPoints map[string]*mountPoint
 	Volumes     map[string]string // Deprecated since 1.7, kept for backwards compatibility
@@ -178,13 +182,29 @@ func (container *Container) WriteHostConfig() error {
 	return ioutil.WriteFile(pth, data, 0666)
 }
 
+// LogEvent logs an event about the container. The image, the name and the
+// labels of the container are the attributes of the event.
 func (container *Container) LogEvent(action string) {
-	d := container.daemon
-	d.EventsService.Log(
-		action,
-		container.ID,
-		container.Config.Image,
-	)
+	container.LogEventWithAttributes(action, nil)
+}
+
+// LogEventWithAttributes logs an event about the container, with the given
+// attributes added to the image, the name and the labels of the container.
+func (container *Container) LogEventWithAttributes(action string, attributes map[string]string) {
+	actorAttributes := map[string]string{}
+	for k, v := range container.Config.Labels {
+		actorAttributes[k] = v
+	}
+	for k, v := range attributes {
+		actorAttributes[k] = v
+	}
+	actorAttributes["image"] = container.Config.Image
+	actorAttributes["name"] = strings.TrimPrefix(container.Name, "/")
+
+	container.daemon.EventsService.Log(action, eventtypes.ContainerEventType, eventtypes.Actor{
+		ID:         container.ID,
+		Attributes: actorAttributes,
+	})
 }
 
 // Evaluates `path` in the scope of the container's basefs, with proper path
@@ -245,16 +265,30 @@ func (container *Container) Start() (err error) {
 			}
 			container.toDisk()
 			container.cleanup()
-			container.LogEvent("die")
+			container.LogEventWithAttributes("die", map[string]string{
+				"exitCode": strconv.Itoa(container.ExitCode),
+			})
 		}
 	}()
 
+	if err := container.prepareCommand(); err != nil {
+		return err
+	}
+	return container.waitForStart()
+}
+
+// prepareCommand mounts the container and sets up its network, and the
+// command of its process.
+func (container *Container) prepareCommand() error {
 	if err := container.Mount(); err != nil {
 		return err
 	}
 	if err := container.initializeNetworking(); err != nil {
 		return err
 	}
+	if err := container.chownNetworkFiles(); err != nil {
+		return err
+	}
 	linkedEnv, err := container.setupLinkedContainers()
 	if err != nil {
 		return err
@@ -277,7 +311,7 @@ func (container *Container) Start() (err error) {
 	}
 
 	container.command.Mounts = mounts
-	return container.waitForStart()
+	return nil
 }
 
 func (container *Container) Run() error {
@@ -339,6 +373,44 @@ func (container *Container) isNetworkAllocated() bool {
 	return container.NetworkSettings.IPAddress != ""
 }
 
+// isConnectedTo returns whether the container uses the given network, either
+// as its network mode or as an additional user-defined network.
+func (container *Container) isConnectedTo(name string) bool {
+	container.Lock()
+	defer container.Unlock()
+
+	if container.hostConfig == nil {
+		return false
+	}
+	if string(container.hostConfig.NetworkMode) == name {
+		return true
+	}
+	for _, n := range container.hostConfig.Networks {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNetworkName returns whether name, compared case-insensitively, is the
+// name of the container or one of its network aliases. The caller must hold
+// the lock of the container.
+func (container *Container) hasNetworkName(name string) bool {
+	if strings.EqualFold(strings.TrimPrefix(container.Name, "/"), name) {
+		return true
+	}
+	if container.hostConfig == nil {
+		return false
+	}
+	for _, alias := range container.hostConfig.NetworkAliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
 // cleanup releases any network resources allocated to the container along with any rules
 // around how containers are linked together.  It also unmounts the container's root filesystem.
 func (container *Container) cleanup() {
@@ -385,7 +457,9 @@ func (container *Container) KillSig(sig int) error {
 	if err := container.daemon.Kill(container, sig); err != nil {
 		return err
 	}
-	container.LogEvent("kill")
+	container.LogEventWithAttributes("kill", map[string]string{
+		"signal": strconv.Itoa(sig),
+	})
 	return nil
 }
 
@@ -483,9 +557,10 @@ func (container *Container) Stop(seconds int) error {
 		return nil
 	}
 
-	// 1. Send a SIGTERM
-	if err := container.killPossiblyDeadProcess(15); err != nil {
-		logrus.Infof("Failed to send SIGTERM to the process, force killing")
+	// 1. Send the stop signal, SIGTERM unless the container has another one
+	stopSignal := container.stopSignal()
+	if err := container.killPossiblyDeadProcess(stopSignal); err != nil {
+		logrus.Infof("Failed to send signal %d to the process, force killing", stopSignal)
 		if err := container.killPossiblyDeadProcess(9); err != nil {
 			return err
 		}
@@ -493,7 +568,7 @@ func (container *Container) Stop(seconds int) error {
 
 	// 2. Wait for the process to exit on its own
 	if _, err := container.WaitStop(time.Duration(seconds) * time.Second); err != nil {
-		logrus.Infof("Container %v failed to exit within %d seconds of SIGTERM - using the force", container.ID, seconds)
+		logrus.Infof("Container %v failed to exit within %d seconds of signal %d - using the force", container.ID, seconds, stopSignal)
 		// 3. If it doesn't, then send SIGKILL
 		if err := container.Kill(); err != nil {
 			container.WaitStop(-1 * time.Second)
@@ -505,6 +580,18 @@ func (container *Container) Stop(seconds int) error {
 	return nil
 }
 
+// stopSignal returns the signal sent to stop the container.
+func (container *Container) stopSignal() int {
+	if container.Config.StopSignal != "" {
+		if stopSignal, err := signal.ParseSignal(container.Config.StopSignal); err == nil {
+			return int(stopSignal)
+		}
+		logrus.Warnf("Invalid stop signal %s for container %s, using %s", container.Config.StopSignal, container.ID, signal.DefaultStopSignal)
+	}
+	stopSignal, _ := signal.ParseSignal(signal.DefaultStopSignal)
+	return int(stopSignal)
+}
+
 func (container *Container) Restart(seconds int) error {
 	// Avoid unnecessarily unmounting and then directly mounting
 	// the container when the container stops and then starts
@@ -541,11 +628,12 @@ func (container *Container) Export() (archive.Archive, error) {
 		return nil, err
 	}
 
-	archive, err := archive.Tar(container.basefs, archive.Uncompressed)
+	rootfs, err := archive.Tar(container.basefs, archive.Uncompressed)
 	if err != nil {
 		container.Unmount()
 		return nil, err
 	}
+	archive := archive.ToContainerIDs(rootfs, container.daemon.uidMaps, container.daemon.gidMaps)
 	arch := ioutils.NewReadCloserWrapper(archive, func() error {
 		err := archive.Close()
 		container.Unmount()
@@ -555,6 +643,30 @@ func (container *Container) Export() (archive.Archive, error) {
 	return arch, err
 }
 
+// mountVolumes bind-mounts the volumes of the container into its mounted
+// rootfs so that they can be accessed from the host. When readOnly is false,
+// the volumes keep the writability they are configured with.
+func (container *Container) mountVolumes(readOnly bool) error {
+	mounts, err := container.setupMounts()
+	if err != nil {
+		return err
+	}
+	for _, m := range mounts {
+		dest, err := container.GetResourcePath(m.Destination)
+		if err != nil {
+			return err
+		}
+		opts := "rbind,ro"
+		if m.Writable && !readOnly {
+			opts = "rbind"
+		}
+		if err := mount.Mount(m.Source, dest, "bind", opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (container *Container) Mount() error {
 	return container.daemon.Mount(container)
 }
@@ -616,19 +728,9 @@ func (container *Container) Copy(resource string) (io.ReadCloser, error) {
 			container.Unmount()
 		}
 	}()
-	mounts, err := container.setupMounts()
-	if err != nil {
+	if err = container.mountVolumes(true); err != nil {
 		return nil, err
 	}
-	for _, m := range mounts {
-		dest, err := container.GetResourcePath(m.Destination)
-		if err != nil {
-			return nil, err
-		}
-		if err := mount.Mount(m.Source, dest, "bind", "rbind,ro"); err != nil {
-			return nil, err
-		}
-	}
 	basePath, err := container.GetResourcePath(resource)
 	if err != nil {
 		return nil, err
@@ -646,13 +748,14 @@ func (container *Container) Copy(resource string) (io.ReadCloser, error) {
 		filter = []string{filepath.Base(basePath)}
 		basePath = filepath.Dir(basePath)
 	}
-	archive, err := archive.TarWithOptions(basePath, &archive.TarOptions{
+	data, err := archive.TarWithOptions(basePath, &archive.TarOptions{
 		Compression:  archive.Uncompressed,
 		IncludeFiles: filter,
 	})
 	if err != nil {
 		return nil, err
 	}
+	archive := archive.ToContainerIDs(data, container.daemon.uidMaps, container.daemon.gidMaps)
 	reader := ioutils.NewReadCloserWrapper(archive, func() error {
 		err := archive.Close()
 		container.UnmountVolumes(true)
@@ -692,6 +795,15 @@ func (container *Container) getLogger() (logger.Logger, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get logging factory: %v", err)
 	}
+	ctx, err := container.logContext(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return c(ctx)
+}
+
+// logContext returns the context of the logging driver of the container.
+func (container *Container) logContext(cfg runconfig.LogConfig) (logger.Context, error) {
 	ctx := logger.Context{
 		Config:              cfg.Config,
 		ContainerID:         container.ID,
@@ -701,16 +813,19 @@ func (container *Container) getLogger() (logger.Logger, error) {
 		ContainerImageID:    container.ImageID,
 		ContainerImageName:  container.Config.Image,
 		ContainerCreated:    container.Created,
+		ContainerLabels:     container.Config.Labels,
+		ContainerEnv:        container.Config.Env,
 	}
 
 	// Set logging file for "json-logger"
 	if cfg.Type == jsonfilelog.Name {
+		var err error
 		ctx.LogPath, err = container.GetRootResourcePath(fmt.Sprintf("%s-json.log", container.ID))
 		if err != nil {
-			return nil, err
+			return ctx, err
 		}
 	}
-	return c(ctx)
+	return ctx, nil
 }
 
 func (container *Container) startLogging() error {
@@ -724,6 +839,20 @@ func (container *Container) startLogging() error {
 		return fmt.Errorf("Failed to initialize logging driver: %v", err)
 	}
 
+	// set LogPath field only for json-file logdriver
+	if jl, ok := l.(*jsonfilelog.JSONFileLogger); ok {
+		container.LogPath = jl.LogPath()
+	}
+
+	if cfg.Config["mode"] == logger.ModeNonBlocking {
+		maxSize, err := logger.ParseMaxBufferSize(cfg.Config)
+		if err != nil {
+			l.Close()
+			return err
+		}
+		l = logger.NewRingLogger(l, container.ID, maxSize)
+	}
+
 	copier, err := logger.NewCopier(container.ID, map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, l)
 	if err != nil {
 		return err
@@ -732,17 +861,75 @@ func (container *Container) startLogging() error {
 	copier.Run()
 	container.logDriver = l
 
-	// set LogPath field only for json-file logdriver
-	if jl, ok := l.(*jsonfilelog.JSONFileLogger); ok {
-		container.LogPath = jl.LogPath()
-	}
-
 	return nil
 }
 
+// getLogReader returns a reader of the container logs. The running logger
+// is used when there is one, so that the readers following the logs end
+// with it, otherwise a reader registered by the logging driver reads the
+// logs without creating the driver, which may connect to a remote server.
+func (container *Container) getLogReader() (logger.LogReader, error) {
+	container.Lock()
+	l := container.logDriver
+	container.Unlock()
+
+	if r, ok := l.(logger.LogReader); ok {
+		return r, nil
+	}
+	cfg := container.getLogConfig()
+	if cfg.Type == "none" {
+		return nil, logger.ReadLogsNotSupported
+	}
+	newReader, err := logger.GetLogReader(cfg.Type)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := container.logContext(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newReader(ctx)
+}
+
 func (container *Container) waitForStart() error {
 	container.monitor = newContainerMonitor(container, container.hostConfig.RestartPolicy)
+	return container.waitForMonitor()
+}
+
+// restore attaches to the process of the container, left running by the
+// previous daemon with live restore, and monitors it as Start does.
+func (container *Container) restore() error {
+	container.Lock()
+	defer container.Unlock()
+
+	if err := container.checkLiveRestore(); err != nil {
+		return err
+	}
+	if err := container.Mount(); err != nil {
+		return err
+	}
+	linkedEnv, err := container.setupLinkedContainers()
+	if err != nil {
+		return err
+	}
+	env := container.createDaemonEnvironment(linkedEnv)
+	if err := populateCommand(container, env); err != nil {
+		return err
+	}
+
+	container.monitor = newContainerMonitor(container, container.hostConfig.RestartPolicy)
+	container.monitor.restoring = true
+	return container.waitForMonitor()
+}
+
+// keepRunning reports whether the container is left running when the
+// daemon exits, for the next daemon to restore it. Containers with a TTY
+// aren't, since their terminal is held by the daemon.
+func (container *Container) keepRunning() bool {
+	return container.command != nil && container.command.LiveRestore && !container.Config.Tty
+}
 
+func (container *Container) waitForMonitor() error {
 	// block until we either receive an error from the initial start of the container's
 	// process or until the process is running in the container
 	select {
@@ -794,6 +981,7 @@ func (container *Container) Exec(execConfig *execConfig) error {
 	waitStart := make(chan struct{})
 
 	callback := func(processConfig *execdriver.ProcessConfig, pid int) {
+		execConfig.started(pid)
 		if processConfig.Tty {
 			// The callback is called after the process Start()
 			// so we are in the parent process. In TTY mode, stdin/out/err is the PtySlave
@@ -856,34 +1044,38 @@ func (c *Container) Attach(stdin io.ReadCloser, stdout io.Writer, stderr io.Writ
 	return attach(&c.StreamConfig, c.Config.OpenStdin, c.Config.StdinOnce, c.Config.Tty, stdin, stdout, stderr)
 }
 
+// writeLogs writes the logs of the container to stdout and stderr, by the
+// stream they were logged from.
+func (c *Container) writeLogs(stdout, stderr io.Writer) error {
+	r, err := c.getLogReader()
+	if err != nil {
+		return err
+	}
+
+	logs := r.ReadLogs(logger.ReadConfig{Tail: -1})
+	for {
+		select {
+		case err := <-logs.Err:
+			return err
+		case msg, ok := <-logs.Msg:
+			if !ok {
+				return nil
+			}
+			if msg.Source == "stdout" && stdout != nil {
+				stdout.Write(msg.Line)
+			}
+			if msg.Source == "stderr" && stderr != nil {
+				stderr.Write(msg.Line)
+			}
+		}
+	}
+}
+
 func (c *Container) AttachWithLogs(stdin io.ReadCloser, stdout, stderr io.Writer, logs, stream bool) error {
 
 	if logs {
-		logDriver, err := c.getLogger()
-		cLog, err := logDriver.GetReader()
-
-		if err != nil {
+		if err := c.writeLogs(stdout, stderr); err != nil {
 			logrus.Errorf("Error reading logs: %s", err)
-		} else if c.LogDriverType() != jsonfilelog.Name {
-			logrus.Errorf("Reading logs not implemented for driver %s", c.LogDriverType())
-		} else {
-			dec := json.NewDecoder(cLog)
-			for {
-				l := &jsonlog.JSONLog{}
-
-				if err := dec.Decode(l); err == io.EOF {
-					break
-				} else if err != nil {
-					logrus.Errorf("Error streaming logs: %s", err)
-					break
-				}
-				if l.Stream == "stdout" && stdout != nil {
-					io.WriteString(stdout, l.Log)
-				}
-				if l.Stream == "stderr" && stderr != nil {
-					io.WriteString(stderr, l.Log)
-				}
-			}
 		}
 	}
 
@@ -1123,7 +1315,7 @@ func (container *Container) isDestinationMounted(destination string) bool {
 func (container *Container) prepareMountPoints() error {
 	for _, config := range container.MountPoints {
 		if len(config.Driver) > 0 {
-			v, err := createVolume(config.Name, config.Driver)
+			v, err := container.daemon.createVolume(config.Name, config.Driver, container.ID)
 			if err != nil {
 				return err
 			}
@@ -1133,18 +1325,36 @@ func (container *Container) prepareMountPoints() error {
 	return nil
 }
 
-func (container *Container) removeMountPoints() error {
+// removeMountPoints drops the references of the container to its volumes.
+// When rm is true, it also removes the volumes that are not used by any
+// other container.
+func (container *Container) removeMountPoints(rm bool) error {
+	var rmErrors []string
 	for _, m := range container.MountPoints {
-		if m.Volume != nil {
-			if err := removeVolume(m.Volume); err != nil {
-				return err
+		if m.Volume == nil {
+			continue
+		}
+		container.daemon.volumes.Dereference(m.Volume, container.ID)
+		if rm {
+			// ErrVolumeInUse means another container still uses the volume, keep it.
+			if err := container.daemon.volumes.Remove(m.Volume); err != nil && err != store.ErrVolumeInUse {
+				rmErrors = append(rmErrors, fmt.Sprintf("%s: %v", m.Volume.Name(), err))
 			}
 		}
 	}
+	if len(rmErrors) > 0 {
+		return fmt.Errorf("Error removing volumes:\n%s", strings.Join(rmErrors, "\n"))
+	}
 	return nil
 }
 
+// shouldRestart reports whether the restart policy of the container
+// restarts it when the daemon starts. Checkpointed containers are left for
+// the user to restore.
 func (container *Container) shouldRestart() bool {
+	if container.Checkpointed {
+		return false
+	}
 	return container.hostConfig.RestartPolicy.Name == "always" ||
 		(container.hostConfig.RestartPolicy.Name == "on-failure" && container.ExitCode != 0)
 }
@@ -3,6 +3,7 @@
 package daemon
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -17,11 +18,15 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/daemon/network"
+	"github.com/docker/docker/daemon/resolver"
 	"github.com/docker/docker/links"
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/blkiodev"
 	"github.com/docker/docker/pkg/directory"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/seccomp"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/runconfig"
@@ -31,6 +36,7 @@ import (
 	"github.com/docker/libnetwork"
 	"github.com/docker/libnetwork/netlabel"
 	"github.com/docker/libnetwork/options"
+	"github.com/docker/libnetwork/resolvconf"
 	"github.com/docker/libnetwork/types"
 )
 
@@ -42,7 +48,9 @@ type Container struct {
 	// Fields below here are platform specific.
 
 	AppArmorProfile string
+	SeccompProfile  string
 	activeLinks     map[string]*links.Link
+	resolver        *resolver.Resolver
 }
 
 func killProcessDirectly(container *Container) error {
@@ -142,6 +150,24 @@ func (container *Container) createDaemonEnvironment(linkedEnv []string) []string
 	return env
 }
 
+// getThrottleDevices returns the rate limits of the block IO of a container
+// on the devices of the host, identified by their major and minor numbers.
+func getThrottleDevices(devs []*blkiodev.ThrottleDevice) ([]*execdriver.ThrottleDevice, error) {
+	var throttleDevices []*execdriver.ThrottleDevice
+	for _, d := range devs {
+		device, err := devices.DeviceFromPath(d.Path, "rwm")
+		if err != nil {
+			return nil, fmt.Errorf("Cannot throttle block IO on %s: %v", d.Path, err)
+		}
+		throttleDevices = append(throttleDevices, &execdriver.ThrottleDevice{
+			Major: device.Major,
+			Minor: device.Minor,
+			Rate:  d.Rate,
+		})
+	}
+	return throttleDevices, nil
+}
+
 func getDevicesFromPath(deviceMapping runconfig.DeviceMapping) (devs []*configs.Device, err error) {
 	device, err := devices.DeviceFromPath(deviceMapping.PathOnHost, deviceMapping.CgroupPermissions)
 	// if there was no error, return the device
@@ -260,17 +286,47 @@ func populateCommand(c *Container, env []string) error {
 		rlimits = append(rlimits, rl)
 	}
 
+	readBpsDevice, err := getThrottleDevices(c.hostConfig.BlkioDeviceReadBps)
+	if err != nil {
+		return err
+	}
+	writeBpsDevice, err := getThrottleDevices(c.hostConfig.BlkioDeviceWriteBps)
+	if err != nil {
+		return err
+	}
+	readIOpsDevice, err := getThrottleDevices(c.hostConfig.BlkioDeviceReadIOps)
+	if err != nil {
+		return err
+	}
+	writeIOpsDevice, err := getThrottleDevices(c.hostConfig.BlkioDeviceWriteIOps)
+	if err != nil {
+		return err
+	}
+
 	resources := &execdriver.Resources{
-		Memory:         c.hostConfig.Memory,
-		MemorySwap:     c.hostConfig.MemorySwap,
-		CpuShares:      c.hostConfig.CpuShares,
-		CpusetCpus:     c.hostConfig.CpusetCpus,
-		CpusetMems:     c.hostConfig.CpusetMems,
-		CpuPeriod:      c.hostConfig.CpuPeriod,
-		CpuQuota:       c.hostConfig.CpuQuota,
-		BlkioWeight:    c.hostConfig.BlkioWeight,
-		Rlimits:        rlimits,
-		OomKillDisable: c.hostConfig.OomKillDisable,
+		Memory:                       c.hostConfig.Memory,
+		MemorySwap:                   c.hostConfig.MemorySwap,
+		CpuShares:                    c.hostConfig.CpuShares,
+		CpusetCpus:                   c.hostConfig.CpusetCpus,
+		CpusetMems:                   c.hostConfig.CpusetMems,
+		CpuPeriod:                    c.hostConfig.CpuPeriod,
+		CpuQuota:                     c.hostConfig.CpuQuota,
+		BlkioWeight:                  c.hostConfig.BlkioWeight,
+		BlkioThrottleReadBpsDevice:   readBpsDevice,
+		BlkioThrottleWriteBpsDevice:  writeBpsDevice,
+		BlkioThrottleReadIOpsDevice:  readIOpsDevice,
+		BlkioThrottleWriteIOpsDevice: writeIOpsDevice,
+		Rlimits:                      rlimits,
+		OomKillDisable:               c.hostConfig.OomKillDisable,
+		PidsLimit:                    c.hostConfig.PidsLimit,
+	}
+
+	liveRestore := c.daemon.liveRestoreEnabled()
+	if liveRestore {
+		if err := c.checkLiveRestore(); err != nil {
+			logrus.Warnf("Container %s: %v", c.ID, err)
+			liveRestore = false
+		}
 	}
 
 	processConfig := execdriver.ProcessConfig{
@@ -305,11 +361,34 @@ func populateCommand(c *Container, env []string) error {
 		LxcConfig:          lxcConfig,
 		AppArmorProfile:    c.AppArmorProfile,
 		CgroupParent:       c.hostConfig.CgroupParent,
+		LiveRestore:        liveRestore,
+	}
+	if c.command.SeccompProfile, err = c.seccompProfile(); err != nil {
+		return err
+	}
+	if c.hostConfig.UsernsMode.IsPrivate() {
+		c.command.UIDMapping = c.daemon.uidMaps
+		c.command.GIDMapping = c.daemon.gidMaps
 	}
 
 	return nil
 }
 
+// seccompProfile returns the profile filtering the system calls of the
+// container, the default one unless another one was given, or nil if they
+// aren't filtered.
+func (container *Container) seccompProfile() (*seccomp.Profile, error) {
+	switch {
+	case container.hostConfig.Privileged, container.SeccompProfile == "unconfined":
+		return nil, nil
+	case container.SeccompProfile != "":
+		return seccomp.LoadProfile([]byte(container.SeccompProfile))
+	case container.daemon.seccompSupported():
+		return seccomp.DefaultProfile, nil
+	}
+	return nil, nil
+}
+
 func mergeDevices(defaultDevices, userDevices []*configs.Device) []*configs.Device {
 	if len(userDevices) == 0 {
 		return defaultDevices
@@ -673,10 +752,54 @@ func (container *Container) updateNetworkSettings(n libnetwork.Network, ep libne
 		networkSettings.Bridge = container.daemon.config.Bridge.Iface
 	}
 
+	networkSettings.Networks = map[string]*network.EndpointSettings{
+		n.Name(): {NetworkID: n.ID(), EndpointID: ep.ID()},
+	}
+
 	container.NetworkSettings = networkSettings
 	return nil
 }
 
+// buildEndpointSettings returns the addresses of the container on the
+// network of ep.
+func buildEndpointSettings(n libnetwork.Network, ep libnetwork.Endpoint) *network.EndpointSettings {
+	settings := &network.EndpointSettings{NetworkID: n.ID(), EndpointID: ep.ID()}
+
+	epInfo := ep.Info()
+	if epInfo == nil {
+		return settings
+	}
+
+	if gw := epInfo.Gateway(); gw.To4() != nil {
+		settings.Gateway = gw.String()
+	}
+	if epInfo.GatewayIPv6().To16() != nil {
+		settings.IPv6Gateway = epInfo.GatewayIPv6().String()
+	}
+
+	ifaceList := epInfo.InterfaceList()
+	if len(ifaceList) == 0 {
+		return settings
+	}
+
+	iface := ifaceList[0]
+	if iface.MacAddress() != nil {
+		settings.MacAddress = iface.MacAddress().String()
+	}
+	if iface.Address().IP.To4() != nil {
+		ones, _ := iface.Address().Mask.Size()
+		settings.IPAddress = iface.Address().IP.String()
+		settings.IPPrefixLen = ones
+	}
+	if iface.AddressIPv6().IP.To16() != nil {
+		onesv6, _ := iface.AddressIPv6().Mask.Size()
+		settings.GlobalIPv6Address = iface.AddressIPv6().IP.String()
+		settings.GlobalIPv6PrefixLen = onesv6
+	}
+
+	return settings
+}
+
 func (container *Container) UpdateNetwork() error {
 	n, err := container.daemon.netController.NetworkByID(container.NetworkSettings.NetworkID)
 	if err != nil {
@@ -706,7 +829,7 @@ func (container *Container) UpdateNetwork() error {
 		return fmt.Errorf("Updating join info failed: %v", err)
 	}
 
-	return nil
+	return container.setupResolver()
 }
 
 func (container *Container) buildCreateEndpointOptions() ([]libnetwork.EndpointOption, error) {
@@ -822,6 +945,17 @@ func (container *Container) AllocateNetwork() error {
 	if err := container.updateJoinInfo(ep); err != nil {
 		return fmt.Errorf("Updating join info failed: %v", err)
 	}
+	container.NetworkSettings.Networks[n.Name()] = buildEndpointSettings(n, ep)
+
+	for _, name := range container.hostConfig.Networks {
+		if err := container.connectToNetwork(name); err != nil {
+			return err
+		}
+	}
+
+	if err := container.setupResolver(); err != nil {
+		return err
+	}
 
 	if err := container.WriteHostConfig(); err != nil {
 		return err
@@ -830,6 +964,237 @@ func (container *Container) AllocateNetwork() error {
 	return nil
 }
 
+// connectToNetwork creates an endpoint for the container on the given
+// user-defined network and joins it to the sandbox of the container, next to
+// the endpoint of its network mode.
+func (container *Container) connectToNetwork(name string) error {
+	n, err := container.daemon.netController.NetworkByName(name)
+	if err != nil {
+		return fmt.Errorf("error locating network with name %s: %v", name, err)
+	}
+
+	ep, err := n.CreateEndpoint(container.Name)
+	if err != nil {
+		return err
+	}
+
+	// Record the endpoint before joining it so that ReleaseNetwork cleans it
+	// up if anything goes wrong from now on.
+	if container.NetworkSettings.Networks == nil {
+		container.NetworkSettings.Networks = make(map[string]*network.EndpointSettings)
+	}
+	container.NetworkSettings.Networks[n.Name()] = &network.EndpointSettings{NetworkID: n.ID(), EndpointID: ep.ID()}
+
+	joinOptions, err := container.buildJoinOptions()
+	if err != nil {
+		return err
+	}
+
+	if _, err := ep.Join(container.ID, joinOptions...); err != nil {
+		return err
+	}
+
+	container.NetworkSettings.Networks[n.Name()] = buildEndpointSettings(n, ep)
+	return nil
+}
+
+// ConnectToNetwork connects the container to a user-defined network. The
+// network is saved in the host config of the container, and joined right
+// away if the container is running.
+func (container *Container) ConnectToNetwork(name string) error {
+	container.Lock()
+	defer container.Unlock()
+
+	mode := container.hostConfig.NetworkMode
+	if mode.IsHost() || mode.IsContainer() {
+		return fmt.Errorf("Container %s uses the network mode %s and cannot be connected to other networks", container.ID, mode)
+	}
+	if string(mode) == name {
+		return fmt.Errorf("Conflict: container %s is already connected to network %s", container.ID, name)
+	}
+	for _, n := range container.hostConfig.Networks {
+		if n == name {
+			return fmt.Errorf("Conflict: container %s is already connected to network %s", container.ID, name)
+		}
+	}
+
+	container.hostConfig.Networks = append(container.hostConfig.Networks, name)
+
+	if container.Running && !container.Config.NetworkDisabled {
+		if err := container.connectToNetwork(name); err != nil {
+			container.hostConfig.Networks = container.hostConfig.Networks[:len(container.hostConfig.Networks)-1]
+			return err
+		}
+		if err := container.setupResolver(); err != nil {
+			return err
+		}
+	}
+
+	return container.toDisk()
+}
+
+// disconnectFromNetwork removes the endpoint of the running container on the
+// given user-defined network. libnetwork moves all the interfaces of the
+// sandbox out of it when one of its endpoints is left, those of the other
+// networks of the container are moved back into it.
+func (container *Container) disconnectFromNetwork(name string) error {
+	settings, ok := container.NetworkSettings.Networks[name]
+	if !ok {
+		return nil
+	}
+
+	n, err := container.daemon.netController.NetworkByID(settings.NetworkID)
+	if err != nil {
+		return fmt.Errorf("error locating network id %s: %v", settings.NetworkID, err)
+	}
+	ep, err := n.EndpointByID(settings.EndpointID)
+	if err != nil {
+		return fmt.Errorf("error locating endpoint id %s: %v", settings.EndpointID, err)
+	}
+
+	var macs []string
+	for other, s := range container.NetworkSettings.Networks {
+		if other != name && s.MacAddress != "" {
+			macs = append(macs, s.MacAddress)
+		}
+	}
+	key := container.NetworkSettings.SandboxKey
+	state, err := saveSandbox(key, macs)
+	if err != nil {
+		return fmt.Errorf("Error saving the interfaces of container %s: %v", container.ID, err)
+	}
+
+	leaveErr := ep.Leave(container.ID)
+	if err := restoreSandbox(key, state); err != nil {
+		return fmt.Errorf("Error restoring the interfaces of container %s: %v", container.ID, err)
+	}
+	if leaveErr != nil {
+		return fmt.Errorf("endpoint leave failed: %v", leaveErr)
+	}
+
+	delete(container.NetworkSettings.Networks, name)
+	if err := ep.Delete(); err != nil {
+		logrus.Errorf("deleting endpoint failed: %v", err)
+	}
+	return nil
+}
+
+// DisconnectFromNetwork removes a user-defined network from the networks
+// the container connects to when it starts. A running container leaves the
+// network right away.
+func (container *Container) DisconnectFromNetwork(name string) error {
+	container.Lock()
+	defer container.Unlock()
+
+	if string(container.hostConfig.NetworkMode) == name {
+		return fmt.Errorf("Cannot disconnect container %s from network %s, it is its network mode", container.ID, name)
+	}
+
+	for i, n := range container.hostConfig.Networks {
+		if n != name {
+			continue
+		}
+		if container.Running && !container.Config.NetworkDisabled {
+			if err := container.disconnectFromNetwork(name); err != nil {
+				return err
+			}
+		}
+		container.hostConfig.Networks = append(container.hostConfig.Networks[:i], container.hostConfig.Networks[i+1:]...)
+		return container.toDisk()
+	}
+	return fmt.Errorf("Container %s is not connected to network %s", container.ID, name)
+}
+
+// usesUserDefinedNetworks returns whether the container is connected to at
+// least one user-defined network.
+func (container *Container) usesUserDefinedNetworks() bool {
+	if container.Config.NetworkDisabled {
+		return false
+	}
+	return container.hostConfig.NetworkMode.IsUserDefined() || len(container.hostConfig.Networks) > 0
+}
+
+// setupResolver starts the embedded DNS server in the sandbox of a container
+// connected to user-defined networks, and points its resolv.conf to it. The
+// containers sharing one of these networks can then be reached by name, and
+// other names are resolved by the DNS servers the container would have used
+// otherwise.
+func (container *Container) setupResolver() error {
+	if !container.usesUserDefinedNetworks() {
+		return nil
+	}
+
+	dns, dnsSearch, err := container.dnsConfig()
+	if err != nil {
+		return err
+	}
+
+	if container.resolver == nil {
+		conn, l, err := resolver.ListenInNamespace(container.NetworkSettings.SandboxKey)
+		if err != nil {
+			return fmt.Errorf("Error starting the DNS server of container %s: %v", container.ID, err)
+		}
+		var forwarders []string
+		for _, d := range dns {
+			forwarders = append(forwarders, net.JoinHostPort(d, "53"))
+		}
+		container.resolver = resolver.New(conn, l, func(name string) []net.IP {
+			return container.daemon.resolveName(container, name)
+		}, forwarders)
+		container.resolver.Start()
+	}
+
+	// libnetwork rewrites resolv.conf on every join, unless it was changed
+	// since it last wrote it. Update the hash too, so that libnetwork takes
+	// the file over again if the container leaves its user-defined networks.
+	if err := resolvconf.Build(container.ResolvConfPath, []string{resolver.Address}, dnsSearch); err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(container.ResolvConfPath)
+	if err != nil {
+		return err
+	}
+	hash, err := ioutils.HashData(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(container.ResolvConfPath+".hash", []byte(hash), 0644)
+}
+
+// dnsConfig returns the DNS servers and search domains of the container, as
+// given to the container or to the daemon, or else as configured on the host.
+func (container *Container) dnsConfig() ([]string, []string, error) {
+	var dns, dnsSearch []string
+
+	if len(container.hostConfig.Dns) > 0 {
+		dns = container.hostConfig.Dns
+	} else if len(container.daemon.config.Dns) > 0 {
+		dns = container.daemon.config.Dns
+	}
+
+	if len(container.hostConfig.DnsSearch) > 0 {
+		dnsSearch = container.hostConfig.DnsSearch
+	} else if len(container.daemon.config.DnsSearch) > 0 {
+		dnsSearch = container.daemon.config.DnsSearch
+	}
+
+	if len(dns) == 0 || len(dnsSearch) == 0 {
+		resolvConf, err := resolvconf.Get()
+		if err != nil {
+			return nil, nil, err
+		}
+		// Queries are forwarded from the host, local nameservers of
+		// the host can be used as they are.
+		if len(dns) == 0 {
+			dns = resolvconf.GetNameservers(resolvConf)
+		}
+		if len(dnsSearch) == 0 {
+			dnsSearch = resolvconf.GetSearchDomains(resolvConf)
+		}
+	}
+	return dns, dnsSearch, nil
+}
+
 func (container *Container) initializeNetworking() error {
 	var err error
 
@@ -920,7 +1285,8 @@ func (container *Container) setupWorkingDirectory() error {
 				return err
 			}
 
-			if err := os.MkdirAll(pth, 0755); err != nil {
+			rootUID, rootGID := container.daemon.GetRemappedUIDGID()
+			if err := idtools.MkdirAllAs(pth, 0755, rootUID, rootGID); err != nil {
 				return err
 			}
 		}
@@ -965,29 +1331,82 @@ func (container *Container) ReleaseNetwork() {
 		return
 	}
 
-	n, err := container.daemon.netController.NetworkByID(container.NetworkSettings.NetworkID)
-	if err != nil {
-		logrus.Errorf("error locating network id %s: %v", container.NetworkSettings.NetworkID, err)
-		return
+	if container.resolver != nil {
+		if err := container.resolver.Stop(); err != nil {
+			logrus.Errorf("stopping DNS server failed: %v", err)
+		}
+		container.resolver = nil
 	}
 
-	ep, err := n.EndpointByID(container.NetworkSettings.EndpointID)
-	if err != nil {
-		logrus.Errorf("error locating endpoint id %s: %v", container.NetworkSettings.EndpointID, err)
-		return
+	endpoints := map[string]string{container.NetworkSettings.NetworkID: container.NetworkSettings.EndpointID}
+	for _, settings := range container.NetworkSettings.Networks {
+		endpoints[settings.NetworkID] = settings.EndpointID
 	}
 
-	if err := ep.Leave(container.ID); err != nil {
-		logrus.Errorf("leaving endpoint failed: %v", err)
-	}
+	for nid, eid := range endpoints {
+		n, err := container.daemon.netController.NetworkByID(nid)
+		if err != nil {
+			logrus.Errorf("error locating network id %s: %v", nid, err)
+			continue
+		}
 
-	if err := ep.Delete(); err != nil {
-		logrus.Errorf("deleting endpoint failed: %v", err)
+		ep, err := n.EndpointByID(eid)
+		if err != nil {
+			logrus.Errorf("error locating endpoint id %s: %v", eid, err)
+			continue
+		}
+
+		if err := ep.Leave(container.ID); err != nil {
+			logrus.Errorf("leaving endpoint failed: %v", err)
+		}
+
+		if err := ep.Delete(); err != nil {
+			logrus.Errorf("deleting endpoint failed: %v", err)
+		}
 	}
 
 	container.NetworkSettings = &network.Settings{}
 }
 
+// checkLiveRestore returns an error if the container can't be kept running
+// when the daemon exits. The terminal of a container with a TTY is held by
+// the daemon. The state of libnetwork doesn't outlive the daemon either: the
+// endpoints of the container, the address allocated to it by the bridge
+// driver and the proxies of its published ports would be lost, so only the
+// containers using the network of the host, or none, are kept running.
+func (container *Container) checkLiveRestore() error {
+	if container.Config.Tty {
+		return fmt.Errorf("Containers with a TTY are stopped when the daemon exits, even with live restore")
+	}
+	if container.Config.NetworkDisabled || container.daemon.config.DisableNetwork {
+		return nil
+	}
+	mode := container.hostConfig.NetworkMode
+	if (mode.IsHost() || mode.IsNone()) && len(container.hostConfig.Networks) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Containers not using --net=host or --net=none are stopped when the daemon exits, even with live restore")
+}
+
+// chownNetworkFiles makes the remapped root the owner of the hostname,
+// hosts and resolv.conf files of the container, so that root in the
+// container can still update them.
+func (container *Container) chownNetworkFiles() error {
+	if container.daemon.uidMaps == nil || container.hostConfig.NetworkMode.IsContainer() {
+		return nil
+	}
+	rootUID, rootGID := container.daemon.GetRemappedUIDGID()
+	for _, path := range []string{container.HostnamePath, container.HostsPath, container.ResolvConfPath} {
+		if path == "" {
+			continue
+		}
+		if err := os.Chown(path, rootUID, rootGID); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
 func disableAllActiveLinks(container *Container) {
 	if container.activeLinks != nil {
 		for _, link := range container.activeLinks {
@@ -0,0 +1,38 @@
+// +build linux
+
+package daemon
+
+import (
+	"testing"
+
+	"github.com/docker/docker/runconfig"
+)
+
+func TestCheckLiveRestore(t *testing.T) {
+	for _, tc := range []struct {
+		mode     runconfig.NetworkMode
+		networks []string
+		tty      bool
+		ok       bool
+	}{
+		{"host", nil, false, true},
+		{"none", nil, false, true},
+		{"host", nil, true, false},
+		{"bridge", nil, false, false},
+		{"front", nil, false, false},
+		{"container:db", nil, false, false},
+		{"none", []string{"back"}, false, false},
+	} {
+		c := &Container{
+			CommonContainer: CommonContainer{
+				ID:         "container_id",
+				Config:     &runconfig.Config{Tty: tc.tty},
+				hostConfig: &runconfig.HostConfig{NetworkMode: tc.mode, Networks: tc.networks},
+				daemon:     &Daemon{config: &Config{}},
+			},
+		}
+		if err := c.checkLiveRestore(); (err == nil) != tc.ok {
+			t.Fatalf("Network mode %s with networks %v and TTY %v: expected live restore %v, got %v", tc.mode, tc.networks, tc.tty, tc.ok, err)
+		}
+	}
+}
@@ -152,8 +152,22 @@ func (container *Container) ReleaseNetwork() {
 	// TODO Windows. Rework with libnetwork
 }
 
-func (container *Container) RestoreNetwork() error {
+func (container *Container) ConnectToNetwork(name string) error {
 	// TODO Windows. Rework with libnetwork
+	return fmt.Errorf("Connecting containers to networks is not supported on Windows")
+}
+
+func (container *Container) DisconnectFromNetwork(name string) error {
+	// TODO Windows. Rework with libnetwork
+	return fmt.Errorf("Disconnecting containers from networks is not supported on Windows")
+}
+
+func (container *Container) checkLiveRestore() error {
+	// TODO Windows. Rework with libnetwork
+	return nil
+}
+
+func (container *Container) chownNetworkFiles() error {
 	return nil
 }
 
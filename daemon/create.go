@@ -6,9 +6,12 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/libcontainer/label"
@@ -24,6 +27,12 @@ func (daemon *Daemon) ContainerCreate(name string, config *runconfig.Config, hos
 		return "", warnings, err
 	}
 
+	if hostConfig != nil && hostConfig.LogConfig.Type != "" {
+		if err := logger.ValidateLogOpts(hostConfig.LogConfig.Type, hostConfig.LogConfig.Config); err != nil {
+			return "", warnings, err
+		}
+	}
+
 	// The check for a valid workdir path is made on the server rather than in the
 	// client. This is because we don't know the type of path (Linux or Windows)
 	// to validate on the client.
@@ -31,6 +40,12 @@ func (daemon *Daemon) ContainerCreate(name string, config *runconfig.Config, hos
 		return "", warnings, fmt.Errorf("The working directory '%s' is invalid. It needs to be an absolute path.", config.WorkingDir)
 	}
 
+	if config.StopSignal != "" {
+		if _, err := signal.ParseSignal(config.StopSignal); err != nil {
+			return "", warnings, err
+		}
+	}
+
 	container, buildWarnings, err := daemon.Create(config, hostConfig, name)
 	if err != nil {
 		if daemon.Graph().IsNotExist(err, config.Image) {
@@ -96,6 +111,11 @@ func (daemon *Daemon) Create(config *runconfig.Config, hostConfig *runconfig.Hos
 	if err := daemon.setHostConfig(container, hostConfig); err != nil {
 		return nil, nil, err
 	}
+	if daemon.liveRestoreEnabled() {
+		if err := container.checkLiveRestore(); err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	}
 	if err := container.Mount(); err != nil {
 		return nil, nil, err
 	}
@@ -128,7 +148,7 @@ func (daemon *Daemon) Create(config *runconfig.Config, hostConfig *runconfig.Hos
 			return nil, nil, fmt.Errorf("cannot mount volume over existing file, file exists %s", path)
 		}
 
-		v, err := createVolume(name, config.VolumeDriver)
+		v, err := daemon.createVolume(name, config.VolumeDriver, container.ID)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -163,3 +183,21 @@ func (daemon *Daemon) GenerateSecurityOpt(ipcMode runconfig.IpcMode, pidMode run
 	}
 	return nil, nil
 }
+
+// VolumeCreate creates a volume with the given name, driver, driver options
+// and labels. A random name is generated if none is given.
+func (daemon *Daemon) VolumeCreate(name, driverName string, opts, labels map[string]string) (*types.Volume, error) {
+	if name == "" {
+		name = stringid.GenerateRandomID()
+	} else if !validVolumeNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("Invalid volume name (%s), only %s are allowed", name, validContainerNameChars)
+	}
+
+	v, err := daemon.volumes.Create(name, driverName, opts, labels)
+	if err != nil {
+		return nil, err
+	}
+	apiV := daemon.volumeToAPIType(v)
+	daemon.logVolumeEvent(apiV.Name, apiV.Driver, "create", apiV.Labels)
+	return apiV, nil
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/docker/docker/pkg/broadcastwriter"
 	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/graphdb"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/namesgenerator"
 	"github.com/docker/docker/pkg/parsers"
@@ -36,6 +37,7 @@ import (
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/trust"
+	"github.com/docker/docker/volume/store"
 	"github.com/docker/libnetwork"
 )
 
@@ -93,6 +95,7 @@ type Daemon struct {
 	idIndex          *truncindex.TruncIndex
 	sysInfo          *sysinfo.SysInfo
 	config           *Config
+	configLock       sync.RWMutex
 	containerGraph   *graphdb.Database
 	driver           graphdriver.Driver
 	execDriver       execdriver.Driver
@@ -101,7 +104,10 @@ type Daemon struct {
 	RegistryService  *registry.Service
 	EventsService    *events.Events
 	netController    libnetwork.NetworkController
+	volumes          *store.VolumeStore
 	root             string
+	uidMaps          []idtools.IDMap
+	gidMaps          []idtools.IDMap
 }
 
 // Get looks for a container using the provided information, which could be
@@ -203,26 +209,58 @@ func (daemon *Daemon) register(container *Container, updateSuffixarray bool) err
 		return err
 	}
 
-	if container.IsRunning() {
-		logrus.Debugf("killing old running container %s", container.ID)
+	// with live restore, restore attaches to the running containers once
+	// they are all registered
+	if container.IsRunning() && !daemon.config.LiveRestore {
+		daemon.stopStaleContainer(container)
+	}
 
-		container.SetStopped(&execdriver.ExitStatus{ExitCode: 0})
+	return nil
+}
 
-		// use the current driver and ensure that the container is dead x.x
-		cmd := &execdriver.Command{
-			ID: container.ID,
-		}
-		daemon.execDriver.Terminate(cmd)
+// stopStaleContainer kills the process of a container which was running
+// when the previous daemon exited, and marks the container as stopped.
+func (daemon *Daemon) stopStaleContainer(container *Container) {
+	logrus.Debugf("killing old running container %s", container.ID)
 
-		if err := container.Unmount(); err != nil {
-			logrus.Debugf("unmount error %s", err)
-		}
-		if err := container.ToDisk(); err != nil {
-			logrus.Debugf("saving stopped state to disk %s", err)
-		}
+	container.SetStopped(&execdriver.ExitStatus{ExitCode: 0})
+
+	// use the current driver and ensure that the container is dead x.x
+	cmd := &execdriver.Command{
+		ID: container.ID,
 	}
+	daemon.execDriver.Terminate(cmd)
 
-	return nil
+	if err := container.Unmount(); err != nil {
+		logrus.Debugf("unmount error %s", err)
+	}
+	if err := container.ToDisk(); err != nil {
+		logrus.Debugf("saving stopped state to disk %s", err)
+	}
+}
+
+// liveRestoreEnabled reports whether the containers are kept running when
+// the daemon exits, which requires an execution driver able to restore
+// them.
+func (daemon *Daemon) liveRestoreEnabled() bool {
+	if !daemon.config.LiveRestore {
+		return false
+	}
+	_, ok := daemon.execDriver.(execdriver.Restorer)
+	return ok
+}
+
+// GetUIDGIDMaps returns the mappings of the user namespace of the containers,
+// none without remapping.
+func (daemon *Daemon) GetUIDGIDMaps() ([]idtools.IDMap, []idtools.IDMap) {
+	return daemon.uidMaps, daemon.gidMaps
+}
+
+// GetRemappedUIDGID returns the uid and gid on the host of root in the user
+// namespace of the containers, which is root itself without remapping.
+func (daemon *Daemon) GetRemappedUIDGID() (int, int) {
+	uid, gid, _ := idtools.GetRootUIDGID(daemon.uidMaps, daemon.gidMaps)
+	return uid, gid
 }
 
 func (daemon *Daemon) ensureName(container *Container) error {
@@ -327,6 +365,32 @@ func (daemon *Daemon) restore() error {
 	}
 	group.Wait()
 
+	if daemon.config.LiveRestore {
+		for _, c := range containers {
+			if !c.container.IsRunning() || !daemon.Exists(c.container.ID) {
+				continue
+			}
+			group.Add(1)
+
+			go func(container *Container) {
+				defer group.Done()
+
+				logrus.Debugf("Restoring container %s", container.ID)
+				if err := container.restore(); err != nil {
+					logrus.Warnf("Failed to restore container %s: %v", container.ID, err)
+					daemon.stopStaleContainer(container)
+
+					if daemon.config.AutoRestart && container.shouldRestart() {
+						if err := container.Start(); err != nil {
+							logrus.Debugf("Failed to start container %s: %s", container.ID, err)
+						}
+					}
+				}
+			}(c.container)
+		}
+		group.Wait()
+	}
+
 	if !debug {
 		if logrus.GetLevel() == logrus.InfoLevel {
 			fmt.Println()
@@ -605,6 +669,15 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	// set up SIGUSR1 handler to dump Go routine stacks
 	setupSigusr1Trap()
 
+	uidMaps, gidMaps, err := setupRemappedRoot(config)
+	if err != nil {
+		return nil, err
+	}
+	rootUID, rootGID, err := idtools.GetRootUIDGID(uidMaps, gidMaps)
+	if err != nil {
+		return nil, err
+	}
+
 	// set up the tmpDir to use a canonical path
 	tmp, err := tempDir(config.Root)
 	if err != nil {
@@ -626,9 +699,8 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 			return nil, fmt.Errorf("Unable to get the full path to root (%s): %s", config.Root, err)
 		}
 	}
-	config.Root = realRoot
 	// Create the root directory if it doesn't exists
-	if err := system.MkdirAll(config.Root, 0700); err != nil && !os.IsExist(err) {
+	if err := setupDaemonRoot(config, realRoot, rootUID, rootGID); err != nil {
 		return nil, err
 	}
 
@@ -636,7 +708,7 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	graphdriver.DefaultDriver = config.GraphDriver
 
 	// Load storage driver
-	driver, err := graphdriver.New(config.Root, config.GraphOptions)
+	driver, err := graphdriver.New(config.Root, config.GraphOptions, uidMaps, gidMaps)
 	if err != nil {
 		return nil, fmt.Errorf("error initializing graphdriver: %v", err)
 	}
@@ -644,6 +716,8 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 
 	d := &Daemon{}
 	d.driver = driver
+	d.uidMaps = uidMaps
+	d.gidMaps = gidMaps
 
 	// Ensure the graph driver is shutdown at a later point
 	defer func() {
@@ -659,6 +733,9 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		if _, err := logger.GetLogDriver(config.LogConfig.Type); err != nil {
 			return nil, fmt.Errorf("error finding the logging driver: %v", err)
 		}
+		if err := logger.ValidateLogOpts(config.LogConfig.Type, config.LogConfig.Config); err != nil {
+			return nil, fmt.Errorf("error validating the logging options: %v", err)
+		}
 	}
 	logrus.Debugf("Using default logging driver %s", config.LogConfig.Type)
 
@@ -669,23 +746,24 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 
 	daemonRepo := filepath.Join(config.Root, "containers")
 
-	if err := system.MkdirAll(daemonRepo, 0700); err != nil && !os.IsExist(err) {
+	if err := idtools.MkdirAllAs(daemonRepo, 0700, rootUID, rootGID); err != nil {
 		return nil, err
 	}
 
 	// Migrate the container if it is aufs and aufs is enabled
-	if err := migrateIfDownlevel(d.driver, config.Root); err != nil {
+	if err := migrateIfDownlevel(d.driver, config.Root, rootUID, rootGID); err != nil {
 		return nil, err
 	}
 
 	logrus.Debug("Creating images graph")
-	g, err := graph.NewGraph(filepath.Join(config.Root, "graph"), d.driver)
+	g, err := graph.NewGraph(filepath.Join(config.Root, "graph"), d.driver, uidMaps, gidMaps)
 	if err != nil {
 		return nil, err
 	}
 
 	// Configure the volumes driver
-	if err := configureVolumes(config); err != nil {
+	volStore, err := configureVolumes(config, rootUID, rootGID)
+	if err != nil {
 		return nil, err
 	}
 
@@ -759,8 +837,15 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	d.defaultLogConfig = config.LogConfig
 	d.RegistryService = registryService
 	d.EventsService = eventsService
+	d.volumes = volStore
 	d.root = config.Root
 
+	if d.netController != nil {
+		if err := d.restoreNetworks(); err != nil {
+			return nil, fmt.Errorf("Error restoring networks: %v", err)
+		}
+	}
+
 	if err := d.restore(); err != nil {
 		return nil, err
 	}
@@ -769,11 +854,17 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 }
 
 func (daemon *Daemon) Shutdown() error {
+	keptRunning := false
 	if daemon.containers != nil {
 		group := sync.WaitGroup{}
 		logrus.Debug("starting clean shutdown of all containers...")
 		for _, container := range daemon.List() {
 			c := container
+			if c.IsRunning() && c.keepRunning() {
+				logrus.Debugf("keeping %s running", c.ID)
+				keptRunning = true
+				continue
+			}
 			if c.IsRunning() {
 				logrus.Debugf("stopping %s", c.ID)
 				group.Add(1)
@@ -803,7 +894,9 @@ func (daemon *Daemon) Shutdown() error {
 		}
 	}
 
-	if daemon.driver != nil {
+	// the storage driver would unmount the filesystems of the containers
+	// kept running
+	if daemon.driver != nil && !keptRunning {
 		if err := daemon.driver.Cleanup(); err != nil {
 			logrus.Errorf("Error during graph storage driver.Cleanup(): %v", err)
 		}
@@ -841,6 +934,36 @@ func (daemon *Daemon) Run(c *Container, pipes *execdriver.Pipes, startCallback e
 	return daemon.execDriver.Run(c.command, pipes, startCallback)
 }
 
+// Restore attaches to the process of a container left running by the
+// previous daemon.
+func (daemon *Daemon) Restore(c *Container, pipes *execdriver.Pipes, restoreCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	r, ok := daemon.execDriver.(execdriver.Restorer)
+	if !ok {
+		return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("The %s execution driver can't restore containers", daemon.execDriver.Name())
+	}
+	return r.Restore(c.command, pipes, restoreCallback)
+}
+
+// Checkpoint dumps the processes of a running container to its checkpoint
+// directory.
+func (daemon *Daemon) Checkpoint(c *Container) error {
+	cp, ok := daemon.execDriver.(execdriver.Checkpointer)
+	if !ok {
+		return fmt.Errorf("The %s execution driver can't checkpoint containers", daemon.execDriver.Name())
+	}
+	return cp.Checkpoint(c.command, c.checkpointOptions())
+}
+
+// RestoreCheckpoint restores the processes of a container from its
+// checkpoint directory.
+func (daemon *Daemon) RestoreCheckpoint(c *Container, pipes *execdriver.Pipes, restoreCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	cp, ok := daemon.execDriver.(execdriver.Checkpointer)
+	if !ok {
+		return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("The %s execution driver can't restore containers", daemon.execDriver.Name())
+	}
+	return cp.RestoreCheckpoint(c.command, pipes, restoreCallback, c.checkpointOptions())
+}
+
 func (daemon *Daemon) Kill(c *Container, sig int) error {
 	return daemon.execDriver.Kill(c.command, sig)
 }
@@ -883,6 +1006,21 @@ func (daemon *Daemon) Config() *Config {
 	return daemon.config
 }
 
+// Labels returns the labels of the daemon.
+func (daemon *Daemon) Labels() []string {
+	daemon.configLock.RLock()
+	defer daemon.configLock.RUnlock()
+	return daemon.config.Labels
+}
+
+// Reload updates the options of the daemon which can be changed while it
+// runs, the labels, from config.
+func (daemon *Daemon) Reload(config *Config) {
+	daemon.configLock.Lock()
+	daemon.config.Labels = config.Labels
+	daemon.configLock.Unlock()
+}
+
 func (daemon *Daemon) SystemConfig() *sysinfo.SysInfo {
 	return daemon.sysInfo
 }
@@ -935,6 +1073,30 @@ func (daemon *Daemon) ImageGetCached(imgID string, config *runconfig.Config) (*i
 	return match, nil
 }
 
+// ImageGetCachedFrom is like ImageGetCached, but only looks for the image in
+// the history of the source images. The history of a source image does not
+// need to be complete, the lookup stops at the first parent that is not
+// available locally.
+func (daemon *Daemon) ImageGetCachedFrom(imgID string, config *runconfig.Config, sources []*image.Image) *image.Image {
+	var match *image.Image
+	for _, img := range sources {
+		for img != nil {
+			if img.Parent == imgID && runconfig.Compare(&img.ContainerConfig, config) {
+				if match == nil || match.Created.Before(img.Created) {
+					match = img
+				}
+			}
+			parent, err := img.GetParent()
+			if err != nil {
+				logrus.Debugf("Stopping the cache lookup in the history of %s: %v", img.ID, err)
+				break
+			}
+			img = parent
+		}
+	}
+	return match
+}
+
 // tempDir returns the default directory to use for temporary files.
 func tempDir(rootDir string) (string, error) {
 	var tmpDir string
@@ -11,10 +11,13 @@ import (
 
 // Given the graphdriver ad, if it is aufs, then migrate it.
 // If aufs driver is not built, this func is a noop.
-func migrateIfAufs(driver graphdriver.Driver, root string) error {
+func migrateIfAufs(driver graphdriver.Driver, root string, rootUID, rootGID int) error {
 	if ad, ok := driver.(*aufs.Driver); ok {
 		logrus.Debugf("Migrating existing containers")
-		if err := ad.Migrate(root, graph.SetupInitLayer); err != nil {
+		setupInit := func(initLayer string) error {
+			return graph.SetupInitLayer(initLayer, rootUID, rootGID)
+		}
+		if err := ad.Migrate(root, setupInit); err != nil {
 			return err
 		}
 	}
@@ -14,6 +14,7 @@ import (
 	"github.com/docker/docker/volume"
 	"github.com/docker/docker/volume/drivers"
 	"github.com/docker/docker/volume/local"
+	"github.com/docker/docker/volume/store"
 )
 
 //
@@ -485,12 +486,12 @@ func TestRemoveLocalVolumesFollowingSymlinks(t *testing.T) {
 	}
 
 	m := c.MountPoints["/vol1"]
-	v, err := createVolume(m.Name, m.Driver)
+	v, err := daemon.volumes.Create(m.Name, m.Driver, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if err := removeVolume(v); err != nil {
+	if err := daemon.volumes.Remove(v); err != nil {
 		t.Fatal(err)
 	}
 
@@ -501,12 +502,17 @@ func TestRemoveLocalVolumesFollowingSymlinks(t *testing.T) {
 }
 
 func initDaemonForVolumesTest(tmp string) (*Daemon, error) {
+	volumes, err := store.New("", getVolumeDriver)
+	if err != nil {
+		return nil, err
+	}
 	daemon := &Daemon{
 		repository: tmp,
 		root:       tmp,
+		volumes:    volumes,
 	}
 
-	volumesDriver, err := local.New(tmp)
+	volumesDriver, err := local.New(tmp, os.Getuid(), os.Getgid())
 	if err != nil {
 		return nil, err
 	}
@@ -19,6 +19,16 @@ func TestParseSecurityOpt(t *testing.T) {
 		t.Fatalf("Unexpected AppArmorProfile, expected: \"test_profile\", got %q", container.AppArmorProfile)
 	}
 
+	// test seccomp
+	profile := `{"defaultAction":"allow","syscalls":[{"name":"keyctl","action":"errno"}]}`
+	config.SecurityOpt = []string{"seccomp=" + profile}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.SeccompProfile != profile {
+		t.Fatalf("Unexpected SeccompProfile, expected: %q, got %q", profile, container.SeccompProfile)
+	}
+
 	// test valid label
 	config.SecurityOpt = []string{"label:user:USER"}
 	if err := parseSecurityOpt(container, config); err != nil {
@@ -14,16 +14,23 @@ import (
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/daemon/graphdriver"
 	_ "github.com/docker/docker/daemon/graphdriver/vfs"
+	"github.com/docker/docker/daemon/network/bridge"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/parsers/kernel"
+	"github.com/docker/docker/pkg/seccomp"
+	"github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 	volumedrivers "github.com/docker/docker/volume/drivers"
 	"github.com/docker/docker/volume/local"
+	"github.com/docker/docker/volume/store"
 	"github.com/docker/libcontainer/label"
 	"github.com/docker/libnetwork"
+	"github.com/docker/libnetwork/driverapi"
+	lnbridge "github.com/docker/libnetwork/drivers/bridge"
 	"github.com/docker/libnetwork/netlabel"
 	"github.com/docker/libnetwork/options"
 )
@@ -38,7 +45,11 @@ func (daemon *Daemon) Changes(container *Container) ([]archive.Change, error) {
 
 func (daemon *Daemon) Diff(container *Container) (archive.Archive, error) {
 	initID := fmt.Sprintf("%s-init", container.ID)
-	return daemon.driver.Diff(container.ID, initID)
+	layer, err := daemon.driver.Diff(container.ID, initID)
+	if err != nil {
+		return nil, err
+	}
+	return archive.ToContainerIDs(layer, daemon.uidMaps, daemon.gidMaps), nil
 }
 
 func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error {
@@ -48,15 +59,17 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 	)
 
 	for _, opt := range config.SecurityOpt {
-		con := strings.SplitN(opt, ":", 2)
-		if len(con) == 1 {
-			return fmt.Errorf("Invalid --security-opt: %q", opt)
+		key, value, err := splitSecurityOpt(opt)
+		if err != nil {
+			return err
 		}
-		switch con[0] {
+		switch key {
 		case "label":
-			labelOpts = append(labelOpts, con[1])
+			labelOpts = append(labelOpts, value)
 		case "apparmor":
-			container.AppArmorProfile = con[1]
+			container.AppArmorProfile = value
+		case "seccomp":
+			container.SeccompProfile = value
 		default:
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
 		}
@@ -66,12 +79,53 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 	return err
 }
 
+// splitSecurityOpt splits the security option opt, of the form key:value or
+// key=value.
+func splitSecurityOpt(opt string) (string, string, error) {
+	i := strings.IndexAny(opt, ":=")
+	if i == -1 {
+		return "", "", fmt.Errorf("Invalid --security-opt: %q", opt)
+	}
+	return opt[:i], opt[i+1:], nil
+}
+
+// seccompSupported returns whether the system calls of the containers can
+// be filtered.
+func (daemon *Daemon) seccompSupported() bool {
+	return daemon.SystemConfig().Seccomp && seccomp.Supported() && strings.HasPrefix(daemon.ExecutionDriver().Name(), "native")
+}
+
+// verifySeccompProfile checks the seccomp profile given in the security
+// options, if any.
+func (daemon *Daemon) verifySeccompProfile(securityOpt []string) error {
+	for _, opt := range securityOpt {
+		key, value, err := splitSecurityOpt(opt)
+		if err != nil {
+			return err
+		}
+		if key != "seccomp" || value == "unconfined" {
+			continue
+		}
+		if !daemon.seccompSupported() {
+			return fmt.Errorf("Seccomp profiles are not supported: the kernel must support seccomp filters, and the daemon use the native execution driver")
+		}
+		if _, err := seccomp.LoadProfile([]byte(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (daemon *Daemon) createRootfs(container *Container) error {
 	// Step 1: create the container directory.
 	// This doubles as a barrier to avoid race conditions.
 	if err := os.Mkdir(container.root, 0700); err != nil {
 		return err
 	}
+	rootUID, rootGID := daemon.GetRemappedUIDGID()
+	if err := os.Chown(container.root, rootUID, rootGID); err != nil {
+		return err
+	}
 	initID := fmt.Sprintf("%s-init", container.ID)
 	if err := daemon.driver.Create(initID, container.ImageID); err != nil {
 		return err
@@ -82,7 +136,7 @@ func (daemon *Daemon) createRootfs(container *Container) error {
 	}
 	defer daemon.driver.Put(initID)
 
-	if err := graph.SetupInitLayer(initPath); err != nil {
+	if err := graph.SetupInitLayer(initPath, rootUID, rootGID); err != nil {
 		return err
 	}
 
@@ -122,6 +176,50 @@ func (daemon *Daemon) verifyContainerSettings(hostConfig *runconfig.HostConfig)
 	if hostConfig.LxcConf.Len() > 0 && !strings.Contains(daemon.ExecutionDriver().Name(), "lxc") {
 		return warnings, fmt.Errorf("Cannot use --lxc-conf with execdriver: %s", daemon.ExecutionDriver().Name())
 	}
+	resourcesWarnings, err := daemon.verifyResources(hostConfig)
+	warnings = append(warnings, resourcesWarnings...)
+	if err != nil {
+		return warnings, err
+	}
+	if daemon.uidMaps != nil && hostConfig.UsernsMode.IsPrivate() {
+		if hostConfig.Privileged {
+			return warnings, fmt.Errorf("Privileged mode is incompatible with user namespaces, use --userns=host to run this container without them")
+		}
+		if hostConfig.NetworkMode.IsHost() {
+			return warnings, fmt.Errorf("Cannot share the host's network namespace when user namespaces are enabled, use --userns=host to run this container without them")
+		}
+		if hostConfig.PidMode.IsHost() {
+			return warnings, fmt.Errorf("Cannot share the host's PID namespace when user namespaces are enabled, use --userns=host to run this container without them")
+		}
+	}
+	if err := daemon.verifySeccompProfile(hostConfig.SecurityOpt); err != nil {
+		return warnings, err
+	}
+	networks := hostConfig.Networks
+	if hostConfig.NetworkMode.IsUserDefined() {
+		networks = append([]string{string(hostConfig.NetworkMode)}, networks...)
+	}
+	for _, name := range networks {
+		if daemon.netController == nil {
+			return warnings, fmt.Errorf("Cannot use the network %s, networking is disabled", name)
+		}
+		if _, err := daemon.netController.NetworkByName(name); err != nil {
+			return warnings, fmt.Errorf("No such network: %s", name)
+		}
+	}
+	if daemon.SystemConfig().IPv4ForwardingDisabled {
+		warnings = append(warnings, "IPv4 forwarding is disabled. Networking will not work.")
+		logrus.Warnf("IPv4 forwarding is disabled. Networking will not work")
+	}
+	return warnings, nil
+}
+
+// verifyResources validates the resource limits of the host config against
+// the capabilities of the system. Limits which aren't supported are discarded
+// with a warning.
+func (daemon *Daemon) verifyResources(hostConfig *runconfig.HostConfig) ([]string, error) {
+	var warnings []string
+
 	if hostConfig.Memory != 0 && hostConfig.Memory < 4194304 {
 		return warnings, fmt.Errorf("Minimum memory limit allowed is 4MB")
 	}
@@ -154,14 +252,38 @@ func (daemon *Daemon) verifyContainerSettings(hostConfig *runconfig.HostConfig)
 	if hostConfig.BlkioWeight > 0 && (hostConfig.BlkioWeight < 10 || hostConfig.BlkioWeight > 1000) {
 		return warnings, fmt.Errorf("Range of blkio weight is from 10 to 1000.")
 	}
+	if len(hostConfig.BlkioDeviceReadBps) > 0 && !daemon.SystemConfig().BlkioReadBpsDevice {
+		warnings = append(warnings, "Your kernel does not support BPS Block I/O read limit. Block I/O BPS read limit discarded.")
+		logrus.Warnf("Your kernel does not support BPS Block I/O read limit. Block I/O BPS read limit discarded.")
+		hostConfig.BlkioDeviceReadBps = nil
+	}
+	if len(hostConfig.BlkioDeviceWriteBps) > 0 && !daemon.SystemConfig().BlkioWriteBpsDevice {
+		warnings = append(warnings, "Your kernel does not support BPS Block I/O write limit. Block I/O BPS write limit discarded.")
+		logrus.Warnf("Your kernel does not support BPS Block I/O write limit. Block I/O BPS write limit discarded.")
+		hostConfig.BlkioDeviceWriteBps = nil
+	}
+	if len(hostConfig.BlkioDeviceReadIOps) > 0 && !daemon.SystemConfig().BlkioReadIOpsDevice {
+		warnings = append(warnings, "Your kernel does not support IOPS Block I/O read limit. Block I/O IOPS read limit discarded.")
+		logrus.Warnf("Your kernel does not support IOPS Block I/O read limit. Block I/O IOPS read limit discarded.")
+		hostConfig.BlkioDeviceReadIOps = nil
+	}
+	if len(hostConfig.BlkioDeviceWriteIOps) > 0 && !daemon.SystemConfig().BlkioWriteIOpsDevice {
+		warnings = append(warnings, "Your kernel does not support IOPS Block I/O write limit. Block I/O IOPS write limit discarded.")
+		logrus.Warnf("Your kernel does not support IOPS Block I/O write limit. Block I/O IOPS write limit discarded.")
+		hostConfig.BlkioDeviceWriteIOps = nil
+	}
+	if hostConfig.PidsLimit < 0 {
+		return warnings, fmt.Errorf("Invalid pids limit %d, it can't be negative.", hostConfig.PidsLimit)
+	}
+	if hostConfig.PidsLimit > 0 && !daemon.SystemConfig().PidsLimit {
+		warnings = append(warnings, "Your kernel does not support pids limit capabilities. Pids limit discarded.")
+		logrus.Warnf("Your kernel does not support pids limit capabilities. Pids limit discarded.")
+		hostConfig.PidsLimit = 0
+	}
 	if hostConfig.OomKillDisable && !daemon.SystemConfig().OomKillDisable {
 		hostConfig.OomKillDisable = false
 		return warnings, fmt.Errorf("Your kernel does not support oom kill disable.")
 	}
-	if daemon.SystemConfig().IPv4ForwardingDisabled {
-		warnings = append(warnings, "IPv4 forwarding is disabled. Networking will not work.")
-		logrus.Warnf("IPv4 forwarding is disabled. Networking will not work")
-	}
 	return warnings, nil
 }
 
@@ -177,9 +299,65 @@ func checkConfigOptions(config *Config) error {
 	if !config.Bridge.EnableIPTables && config.Bridge.EnableIPMasq {
 		config.Bridge.EnableIPMasq = false
 	}
+	if config.RemappedRoot != "" && config.ExecDriver != "native" {
+		return fmt.Errorf("User namespaces are only supported by the native execdriver, not by %s", config.ExecDriver)
+	}
 	return nil
 }
 
+// parseRemappedRoot splits the user[:group] value of --userns-remap, the
+// group defaults to the user.
+func parseRemappedRoot(usergrp string) (string, string, error) {
+	parts := strings.Split(usergrp, ":")
+	if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+		return "", "", fmt.Errorf("Invalid --userns-remap %q, expected user[:group]", usergrp)
+	}
+	if len(parts) == 1 {
+		return parts[0], parts[0], nil
+	}
+	return parts[0], parts[1], nil
+}
+
+// setupRemappedRoot returns the mappings of the user namespace of the
+// containers to the subordinate ids of the user and group of --userns-remap,
+// or none if it isn't set.
+func setupRemappedRoot(config *Config) ([]idtools.IDMap, []idtools.IDMap, error) {
+	if config.RemappedRoot == "" {
+		return nil, nil, nil
+	}
+	username, groupname, err := parseRemappedRoot(config.RemappedRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	uidMaps, gidMaps, err := idtools.CreateIDMappings(username, groupname)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Can't create the ID mappings for %s: %v", config.RemappedRoot, err)
+	}
+	return uidMaps, gidMaps, nil
+}
+
+// setupDaemonRoot creates the root directory of the daemon rootDir. With
+// remapped ids, the files of the daemon live in a subdirectory of it named
+// after the ids of the remapped root, owned by it, so that the containers
+// can reach their files and the layers of one mapping don't mix with the
+// ones of another.
+func setupDaemonRoot(config *Config, rootDir string, rootUID, rootGID int) error {
+	config.Root = rootDir
+	if err := system.MkdirAll(rootDir, 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+	if config.RemappedRoot == "" {
+		return nil
+	}
+	// The remapped root must be able to traverse the root directory.
+	if err := os.Chmod(rootDir, 0711); err != nil {
+		return err
+	}
+	config.Root = filepath.Join(rootDir, fmt.Sprintf("%d.%d", rootUID, rootGID))
+	logrus.Debugf("Creating the remapped root directory %s", config.Root)
+	return idtools.MkdirAllAs(config.Root, 0700, rootUID, rootGID)
+}
+
 // checkSystem validates the system is supported and we have sufficient privileges
 func checkSystem() error {
 	// TODO Windows. Once daemon is running on Windows, move this code back to
@@ -215,17 +393,25 @@ func configureKernelSecuritySupport(config *Config, driverName string) error {
 }
 
 // MigrateIfDownlevel is a wrapper for AUFS migration for downlevel
-func migrateIfDownlevel(driver graphdriver.Driver, root string) error {
-	return migrateIfAufs(driver, root)
+func migrateIfDownlevel(driver graphdriver.Driver, root string, rootUID, rootGID int) error {
+	return migrateIfAufs(driver, root, rootUID, rootGID)
 }
 
-func configureVolumes(config *Config) error {
-	volumesDriver, err := local.New(config.Root)
+func configureVolumes(config *Config, rootUID, rootGID int) (*store.VolumeStore, error) {
+	volumesDriver, err := local.New(config.Root, rootUID, rootGID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	volumedrivers.Register(volumesDriver, volumesDriver.Name())
-	return nil
+
+	s, err := store.New(filepath.Join(config.Root, "volumes-metadata"), getVolumeDriver)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range volumesDriver.List() {
+		s.Add(v)
+	}
+	return s, nil
 }
 
 func configureSysInit(config *Config) (string, error) {
@@ -243,11 +429,13 @@ func configureSysInit(config *Config) (string, error) {
 		if _, err := fileutils.CopyFile(sysInitPath, localCopy); err != nil {
 			return "", err
 		}
-		if err := os.Chmod(localCopy, 0700); err != nil {
-			return "", err
-		}
 		sysInitPath = localCopy
 	}
+	// The processes of the containers execute dockerinit as their user to
+	// install their seccomp profile.
+	if err := os.Chmod(sysInitPath, 0755); err != nil {
+		return "", err
+	}
 	return sysInitPath, nil
 }
 
@@ -350,5 +538,26 @@ func initNetworkController(config *Config) (libnetwork.NetworkController, error)
 		return nil, fmt.Errorf("Error creating default \"bridge\" network: %v", err)
 	}
 
+	// Initialize the driver of the user-defined bridge networks, the "bridge"
+	// driver only manages the default one.
+	dc, ok := controller.(driverapi.DriverCallback)
+	if !ok {
+		return nil, fmt.Errorf("Error initializing user-defined bridge driver: drivers can't be registered")
+	}
+	defaultBridge := config.Bridge.Iface
+	if defaultBridge == "" {
+		defaultBridge = lnbridge.DefaultBridgeName
+	}
+	if err := bridge.Init(dc, bridge.Config{
+		DefaultBridge:       defaultBridge,
+		Mtu:                 config.Mtu,
+		EnableIPTables:      config.Bridge.EnableIPTables,
+		EnableIPMasquerade:  config.Bridge.EnableIPMasq,
+		EnableUserlandProxy: config.Bridge.EnableUserlandProxy,
+		DefaultBindingIP:    config.Bridge.DefaultIP,
+	}); err != nil {
+		return nil, fmt.Errorf("Error initializing user-defined bridge driver: %v", err)
+	}
+
 	return controller, nil
 }
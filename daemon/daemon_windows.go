@@ -8,7 +8,10 @@ import (
 
 	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/volume/store"
 	"github.com/docker/libnetwork"
 )
 
@@ -47,6 +50,11 @@ func (daemon *Daemon) verifyContainerSettings(hostConfig *runconfig.HostConfig)
 	return nil, nil
 }
 
+func (daemon *Daemon) verifyResources(hostConfig *runconfig.HostConfig) ([]string, error) {
+	// TODO Windows. Verifications TBC
+	return nil, nil
+}
+
 // checkConfigOptions checks for mutually incompatible config options
 func checkConfigOptions(config *Config) error {
 	return nil
@@ -83,15 +91,29 @@ func configureKernelSecuritySupport(config *Config, driverName string) error {
 	return nil
 }
 
-func migrateIfDownlevel(driver graphdriver.Driver, root string) error {
+func migrateIfDownlevel(driver graphdriver.Driver, root string, rootUID, rootGID int) error {
 	return nil
 }
 
-func configureVolumes(config *Config) error {
-	// Windows does not support volumes at this time
+// setupRemappedRoot returns no mappings, Windows has no user namespaces.
+func setupRemappedRoot(config *Config) ([]idtools.IDMap, []idtools.IDMap, error) {
+	return nil, nil, nil
+}
+
+// setupDaemonRoot creates the root directory of the daemon rootDir.
+func setupDaemonRoot(config *Config, rootDir string, rootUID, rootGID int) error {
+	config.Root = rootDir
+	if err := system.MkdirAll(rootDir, 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
 	return nil
 }
 
+func configureVolumes(config *Config, rootUID, rootGID int) (*store.VolumeStore, error) {
+	// Windows does not support volumes at this time
+	return store.New("", getVolumeDriver)
+}
+
 func configureSysInit(config *Config) (string, error) {
 	// TODO Windows.
 	return os.Getenv("TEMP"), nil
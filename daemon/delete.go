@@ -6,6 +6,7 @@ import (
 	"path"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/volume/store"
 )
 
 type ContainerRmConfig struct {
@@ -48,8 +49,8 @@ func (daemon *Daemon) ContainerRm(name string, config *ContainerRmConfig) error
 		return fmt.Errorf("Cannot destroy container %s: %v", name, err)
 	}
 
-	if config.RemoveVolume {
-		container.removeMountPoints()
+	if err := container.removeMountPoints(config.RemoveVolume); err != nil {
+		logrus.Error(err)
 	}
 	return nil
 }
@@ -134,5 +135,26 @@ func (daemon *Daemon) rm(container *Container, forceRemove bool) (err error) {
 }
 
 func (daemon *Daemon) DeleteVolumes(c *Container) error {
-	return c.removeMountPoints()
+	return c.removeMountPoints(true)
+}
+
+// VolumeRm removes the volume with the given name. It fails if the volume is
+// still used by a container.
+func (daemon *Daemon) VolumeRm(name string) error {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		if err == store.ErrNoSuchVolume {
+			return fmt.Errorf("No such volume: %s", name)
+		}
+		return err
+	}
+	labels := daemon.volumes.Labels(v)
+	if err := daemon.volumes.Remove(v); err != nil {
+		if err == store.ErrVolumeInUse {
+			return fmt.Errorf("Conflict: unable to remove volume %s, volume is in use", name)
+		}
+		return fmt.Errorf("Error while removing volume %s: %v", name, err)
+	}
+	daemon.logVolumeEvent(v.Name(), v.DriverName(), "destroy", labels)
+	return nil
 }
@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// logVolumeEvent logs an event about the volume named name. The driver and
+// the labels of the volume are the attributes of the event.
+func (daemon *Daemon) logVolumeEvent(name, driver, action string, labels map[string]string) {
+	attributes := map[string]string{}
+	for k, v := range labels {
+		attributes[k] = v
+	}
+	attributes["driver"] = driver
+	daemon.EventsService.Log(action, eventtypes.VolumeEventType, eventtypes.Actor{
+		ID:         name,
+		Attributes: attributes,
+	})
+}
+
+// logNetworkEvent logs an event about the network with the given ID. The
+// name and the driver of the network are added to the given attributes.
+func (daemon *Daemon) logNetworkEvent(id, name, driver, action string, attributes map[string]string) {
+	actorAttributes := map[string]string{
+		"name": name,
+		"type": driver,
+	}
+	for k, v := range attributes {
+		actorAttributes[k] = v
+	}
+	daemon.EventsService.Log(action, eventtypes.NetworkEventType, eventtypes.Actor{
+		ID:         id,
+		Attributes: actorAttributes,
+	})
+}
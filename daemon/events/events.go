@@ -1,63 +1,164 @@
 package events
 
 import (
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/docker/docker/pkg/jsonmessage"
+	eventtypes "github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/pkg/pubsub"
 )
 
-const eventsLimit = 64
+// eventsLimit is the number of events kept in memory, to be replayed to the
+// listeners subscribing with a time range.
+const eventsLimit = 1024
 
-// Events is pubsub channel for *jsonmessage.JSONMessage
+// Events is pubsub channel for events generated by the engine.
 type Events struct {
-	mu     sync.Mutex
-	events []*jsonmessage.JSONMessage
-	pub    *pubsub.Publisher
+	mu      sync.Mutex
+	events  []eventtypes.Message
+	pending []eventtypes.Message // logged events not published yet
+	wake    chan struct{}        // signaled when events are logged
+	pubMu   sync.Mutex           // held while publishing and subscribing
+	pub     *pubsub.Publisher
 }
 
 // New returns new *Events instance
 func New() *Events {
-	return &Events{
-		events: make([]*jsonmessage.JSONMessage, 0, eventsLimit),
+	e := &Events{
+		events: make([]eventtypes.Message, 0, eventsLimit),
+		wake:   make(chan struct{}, 1),
 		pub:    pubsub.NewPublisher(100*time.Millisecond, 1024),
 	}
+	go e.publishLoop()
+	return e
 }
 
-// Subscribe adds new listener to events, returns slice of 64 stored last events
-// channel in which you can expect new events in form of interface{}, so you
-// need type assertion.
-func (e *Events) Subscribe() ([]*jsonmessage.JSONMessage, chan interface{}) {
-	e.mu.Lock()
-	current := make([]*jsonmessage.JSONMessage, len(e.events))
-	copy(current, e.events)
+// publishLoop publishes the logged events in the background, so that Log
+// doesn't wait for slow listeners.
+func (e *Events) publishLoop() {
+	for range e.wake {
+		e.pubMu.Lock()
+		e.flush()
+		e.pubMu.Unlock()
+	}
+}
+
+// flush publishes the pending events in the order they were logged. It must
+// be called with pubMu held.
+func (e *Events) flush() {
+	for {
+		e.mu.Lock()
+		pending := e.pending
+		e.pending = nil
+		e.mu.Unlock()
+		if len(pending) == 0 {
+			return
+		}
+		for _, jm := range pending {
+			e.pub.Publish(jm)
+		}
+	}
+}
+
+// Subscribe adds new listener to events, returns slice of the stored events
+// and a channel in which you can expect new events in form of interface{},
+// so you need type assertion.
+func (e *Events) Subscribe() ([]eventtypes.Message, chan interface{}) {
+	return e.SubscribeTimeRange(time.Time{}, time.Time{})
+}
+
+// SubscribeTimeRange adds new listener to events, returns slice of the
+// stored events which happened since `since` and until `until`, and a
+// channel in which you can expect new events. A zero `until` means that
+// there is no upper bound.
+func (e *Events) SubscribeTimeRange(since, until time.Time) ([]eventtypes.Message, chan interface{}) {
+	// The pending events are published first, so that the new listener gets
+	// each event either in the stored events or in its channel, not both.
+	e.pubMu.Lock()
+	defer e.pubMu.Unlock()
+	for {
+		e.flush()
+		e.mu.Lock()
+		if len(e.pending) == 0 {
+			break
+		}
+		e.mu.Unlock()
+	}
+	current := e.loadBufferedEvents(since, until)
 	l := e.pub.Subscribe()
 	e.mu.Unlock()
 	return current, l
 }
 
+// loadBufferedEvents returns a copy of the stored events in the given time
+// range. The events are stored in the order they are logged, so that the
+// bounds of the range are looked up with a binary search.
+func (e *Events) loadBufferedEvents(since, until time.Time) []eventtypes.Message {
+	sinceNano := since.UnixNano()
+	start := sort.Search(len(e.events), func(i int) bool {
+		return e.events[i].TimeNano >= sinceNano
+	})
+	end := len(e.events)
+	if !until.IsZero() {
+		untilNano := until.UnixNano()
+		end = sort.Search(len(e.events), func(i int) bool {
+			return e.events[i].TimeNano > untilNano
+		})
+	}
+	if end < start {
+		end = start
+	}
+
+	current := make([]eventtypes.Message, end-start)
+	copy(current, e.events[start:end])
+	return current
+}
+
 // Evict evicts listener from pubsub
 func (e *Events) Evict(l chan interface{}) {
 	e.pub.Evict(l)
 }
 
-// Log broadcasts event to listeners. Each listener has 100 millisecond for
+// Log stores the event of the given type about actor and broadcasts it to
+// listeners in the background. Each listener has 100 millisecond for
 // receiving event or it will be skipped.
-func (e *Events) Log(action, id, from string) {
-	go func() {
-		e.mu.Lock()
-		jm := &jsonmessage.JSONMessage{Status: action, ID: id, From: from, Time: time.Now().UTC().Unix()}
-		if len(e.events) == cap(e.events) {
-			// discard oldest event
-			copy(e.events, e.events[1:])
-			e.events[len(e.events)-1] = jm
-		} else {
-			e.events = append(e.events, jm)
-		}
-		e.mu.Unlock()
-		e.pub.Publish(jm)
-	}()
+func (e *Events) Log(action, eventType string, actor eventtypes.Actor) {
+	e.mu.Lock()
+	now := time.Now().UTC()
+	jm := eventtypes.Message{
+		Action:   action,
+		Type:     eventType,
+		Actor:    actor,
+		Time:     now.Unix(),
+		TimeNano: now.UnixNano(),
+	}
+
+	// fill deprecated fields for container and images
+	switch eventType {
+	case eventtypes.ContainerEventType:
+		jm.ID = actor.ID
+		jm.Status = action
+		jm.From = actor.Attributes["image"]
+	case eventtypes.ImageEventType:
+		jm.ID = actor.ID
+		jm.Status = action
+	}
+
+	if len(e.events) == cap(e.events) {
+		// discard oldest event
+		copy(e.events, e.events[1:])
+		e.events[len(e.events)-1] = jm
+	} else {
+		e.events = append(e.events, jm)
+	}
+	e.pending = append(e.pending, jm)
+	e.mu.Unlock()
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
 }
 
 // SubscribersCount returns number of event listeners
@@ -5,9 +5,13 @@ import (
 	"testing"
 	"time"
 
-	"github.com/docker/docker/pkg/jsonmessage"
+	eventtypes "github.com/docker/docker/api/types/events"
 )
 
+func containerActor(id, image string) eventtypes.Actor {
+	return eventtypes.Actor{ID: id, Attributes: map[string]string{"image": image}}
+}
+
 func TestEventsLog(t *testing.T) {
 	e := New()
 	_, l1 := e.Subscribe()
@@ -18,48 +22,53 @@ func TestEventsLog(t *testing.T) {
 	if count != 2 {
 		t.Fatalf("Must be 2 subscribers, got %d", count)
 	}
-	e.Log("test", "cont", "image")
-	select {
-	case msg := <-l1:
-		jmsg, ok := msg.(*jsonmessage.JSONMessage)
-		if !ok {
-			t.Fatalf("Unexpected type %T", msg)
-		}
-		if len(e.events) != 1 {
-			t.Fatalf("Must be only one event, got %d", len(e.events))
-		}
-		if jmsg.Status != "test" {
-			t.Fatalf("Status should be test, got %s", jmsg.Status)
+	e.Log("test", eventtypes.ContainerEventType, containerActor("cont", "image"))
+	for _, l := range []chan interface{}{l1, l2} {
+		select {
+		case msg := <-l:
+			jmsg, ok := msg.(eventtypes.Message)
+			if !ok {
+				t.Fatalf("Unexpected type %T", msg)
+			}
+			if len(e.events) != 1 {
+				t.Fatalf("Must be only one event, got %d", len(e.events))
+			}
+			if jmsg.Status != "test" || jmsg.Action != "test" {
+				t.Fatalf("Status and action should be test, got %s and %s", jmsg.Status, jmsg.Action)
+			}
+			if jmsg.ID != "cont" || jmsg.Actor.ID != "cont" {
+				t.Fatalf("ID should be cont, got %s and %s", jmsg.ID, jmsg.Actor.ID)
+			}
+			if jmsg.From != "image" {
+				t.Fatalf("From should be image, got %s", jmsg.From)
+			}
+			if jmsg.Type != eventtypes.ContainerEventType {
+				t.Fatalf("Type should be container, got %s", jmsg.Type)
+			}
+			if jmsg.TimeNano == 0 || jmsg.TimeNano/int64(time.Second) != jmsg.Time {
+				t.Fatalf("Time and TimeNano don't match, got %d and %d", jmsg.Time, jmsg.TimeNano)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatal("Timeout waiting for broadcasted message")
 		}
-		if jmsg.ID != "cont" {
-			t.Fatalf("ID should be cont, got %s", jmsg.ID)
-		}
-		if jmsg.From != "image" {
-			t.Fatalf("From should be image, got %s", jmsg.From)
-		}
-	case <-time.After(1 * time.Second):
-		t.Fatal("Timeout waiting for broadcasted message")
 	}
-	select {
-	case msg := <-l2:
-		jmsg, ok := msg.(*jsonmessage.JSONMessage)
-		if !ok {
-			t.Fatalf("Unexpected type %T", msg)
-		}
-		if len(e.events) != 1 {
-			t.Fatalf("Must be only one event, got %d", len(e.events))
-		}
-		if jmsg.Status != "test" {
-			t.Fatalf("Status should be test, got %s", jmsg.Status)
-		}
-		if jmsg.ID != "cont" {
-			t.Fatalf("ID should be cont, got %s", jmsg.ID)
-		}
-		if jmsg.From != "image" {
-			t.Fatalf("From should be image, got %s", jmsg.From)
-		}
-	case <-time.After(1 * time.Second):
-		t.Fatal("Timeout waiting for broadcasted message")
+}
+
+func TestEventsLogDeprecatedFields(t *testing.T) {
+	e := New()
+	e.Log("create", eventtypes.VolumeEventType, eventtypes.Actor{ID: "vol"})
+	e.Log("tag", eventtypes.ImageEventType, eventtypes.Actor{ID: "busybox:latest"})
+
+	current, l := e.Subscribe()
+	defer e.Evict(l)
+	if len(current) != 2 {
+		t.Fatalf("Must be 2 events, got %d", len(current))
+	}
+	if vol := current[0]; vol.Status != "" || vol.ID != "" || vol.Actor.ID != "vol" {
+		t.Fatalf("Unexpected volume event %+v", vol)
+	}
+	if img := current[1]; img.Status != "tag" || img.ID != "busybox:latest" || img.From != "" {
+		t.Fatalf("Unexpected image event %+v", img)
 	}
 }
 
@@ -70,7 +79,7 @@ func TestEventsLogTimeout(t *testing.T) {
 
 	c := make(chan struct{})
 	go func() {
-		e.Log("test", "cont", "image")
+		e.Log("test", eventtypes.ContainerEventType, containerActor("cont", "image"))
 		close(c)
 	}()
 
@@ -88,25 +97,24 @@ func TestLogEvents(t *testing.T) {
 		action := fmt.Sprintf("action_%d", i)
 		id := fmt.Sprintf("cont_%d", i)
 		from := fmt.Sprintf("image_%d", i)
-		e.Log(action, id, from)
+		e.Log(action, eventtypes.ContainerEventType, containerActor(id, from))
 	}
-	time.Sleep(50 * time.Millisecond)
 	current, l := e.Subscribe()
 	for i := 0; i < 10; i++ {
 		num := i + eventsLimit + 16
 		action := fmt.Sprintf("action_%d", num)
 		id := fmt.Sprintf("cont_%d", num)
 		from := fmt.Sprintf("image_%d", num)
-		e.Log(action, id, from)
+		e.Log(action, eventtypes.ContainerEventType, containerActor(id, from))
 	}
 	if len(e.events) != eventsLimit {
 		t.Fatalf("Must be %d events, got %d", eventsLimit, len(e.events))
 	}
 
-	var msgs []*jsonmessage.JSONMessage
+	var msgs []eventtypes.Message
 	for len(msgs) < 10 {
 		m := <-l
-		jm, ok := (m).(*jsonmessage.JSONMessage)
+		jm, ok := (m).(eventtypes.Message)
 		if !ok {
 			t.Fatalf("Unexpected type %T", m)
 		}
@@ -120,16 +128,65 @@ func TestLogEvents(t *testing.T) {
 		t.Fatalf("First action is %s, must be action_16", first.Status)
 	}
 	last := current[len(current)-1]
-	if last.Status != "action_79" {
-		t.Fatalf("Last action is %s, must be action_79", last.Status)
+	if expected := fmt.Sprintf("action_%d", eventsLimit+15); last.Status != expected {
+		t.Fatalf("Last action is %s, must be %s", last.Status, expected)
 	}
 
 	firstC := msgs[0]
-	if firstC.Status != "action_80" {
-		t.Fatalf("First action is %s, must be action_80", firstC.Status)
+	if expected := fmt.Sprintf("action_%d", eventsLimit+16); firstC.Status != expected {
+		t.Fatalf("First action is %s, must be %s", firstC.Status, expected)
 	}
 	lastC := msgs[len(msgs)-1]
-	if lastC.Status != "action_89" {
-		t.Fatalf("Last action is %s, must be action_89", lastC.Status)
+	if expected := fmt.Sprintf("action_%d", eventsLimit+25); lastC.Status != expected {
+		t.Fatalf("Last action is %s, must be %s", lastC.Status, expected)
+	}
+}
+
+func TestSubscribeTimeRange(t *testing.T) {
+	e := New()
+
+	for i := 0; i < 3; i++ {
+		e.Log(fmt.Sprintf("action_%d", i), eventtypes.ContainerEventType, containerActor("cont", "image"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	since := time.Unix(0, e.events[1].TimeNano)
+	until := time.Unix(0, e.events[1].TimeNano)
+
+	current, l := e.SubscribeTimeRange(since, until)
+	defer e.Evict(l)
+	if len(current) != 1 || current[0].Action != "action_1" {
+		t.Fatalf("Expected only action_1, got %+v", current)
+	}
+
+	current, l2 := e.SubscribeTimeRange(since, time.Time{})
+	defer e.Evict(l2)
+	if len(current) != 2 || current[0].Action != "action_1" || current[1].Action != "action_2" {
+		t.Fatalf("Expected action_1 and action_2, got %+v", current)
+	}
+
+	current, l3 := e.SubscribeTimeRange(time.Now().Add(time.Hour), time.Time{})
+	defer e.Evict(l3)
+	if len(current) != 0 {
+		t.Fatalf("Expected no events, got %+v", current)
+	}
+}
+
+func TestEventsLogStalledListener(t *testing.T) {
+	e := New()
+	// The buffer of the listener fills up, and it is never read.
+	_, stalled := e.Subscribe()
+	defer e.Evict(stalled)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1100; i++ {
+			e.Log(fmt.Sprintf("action_%d", i), eventtypes.ContainerEventType, containerActor("cont", "image"))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Log is blocked by a stalled listener")
 	}
 }
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"strings"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/pkg/parsers/filters"
+)
+
+// Filter can filter out docker events from a stream
+type Filter struct {
+	filter filters.Args
+}
+
+// NewFilter creates a new Filter
+func NewFilter(filter filters.Args) *Filter {
+	return &Filter{filter: filter}
+}
+
+// Include returns true when the event ev is included by the filters
+func (ef *Filter) Include(ev events.Message) bool {
+	return isFieldIncluded(ev.Action, ef.filter["event"]) &&
+		isFieldIncluded(ev.Type, ef.filter["type"]) &&
+		ef.matchActor(ev, events.ContainerEventType, "container") &&
+		ef.matchActor(ev, events.VolumeEventType, "volume") &&
+		ef.matchActor(ev, events.NetworkEventType, "network") &&
+		ef.matchImage(ev) &&
+		ef.matchLabels(ev.Actor.Attributes)
+}
+
+// matchActor matches the events of the given type against the filter key,
+// with the ID or the name of their actor. Events of other types are
+// excluded when the filter key is set.
+func (ef *Filter) matchActor(ev events.Message, eventType, key string) bool {
+	if len(ef.filter[key]) == 0 {
+		return true
+	}
+	if ev.Type != eventType {
+		return false
+	}
+	return isFieldIncluded(ev.Actor.ID, ef.filter[key]) ||
+		isFieldIncluded(ev.Actor.Attributes["name"], ef.filter[key])
+}
+
+// matchImage matches the image container events come from, and the image
+// events, against the image filter.
+func (ef *Filter) matchImage(ev events.Message) bool {
+	if len(ef.filter["image"]) == 0 {
+		return true
+	}
+	switch ev.Type {
+	case events.ContainerEventType:
+		return isFieldIncluded(ev.Actor.Attributes["image"], ef.filter["image"])
+	case events.ImageEventType:
+		return isFieldIncluded(ev.Actor.ID, ef.filter["image"]) ||
+			isFieldIncluded(ev.Actor.Attributes["name"], ef.filter["image"])
+	}
+	return false
+}
+
+func (ef *Filter) matchLabels(attributes map[string]string) bool {
+	if _, ok := ef.filter["label"]; !ok {
+		return true
+	}
+	return ef.filter.MatchKVList("label", attributes)
+}
+
+func isFieldIncluded(field string, filter []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, v := range filter {
+		if v == field {
+			return true
+		}
+		if strings.Contains(field, ":") {
+			image := strings.Split(field, ":")
+			if image[0] == v {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/pkg/parsers/filters"
+)
+
+func TestFilterInclude(t *testing.T) {
+	containerEvent := events.Message{
+		Type:   events.ContainerEventType,
+		Action: "start",
+		Actor: events.Actor{
+			ID: "cont",
+			Attributes: map[string]string{
+				"image":           "busybox:latest",
+				"name":            "web",
+				"com.example.app": "web",
+			},
+		},
+	}
+	imageEvent := events.Message{
+		Type:   events.ImageEventType,
+		Action: "untag",
+		Actor: events.Actor{
+			ID: "ubuntu",
+			Attributes: map[string]string{
+				"com.example.app": "base",
+			},
+		},
+	}
+	volumeEvent := events.Message{
+		Type:   events.VolumeEventType,
+		Action: "create",
+		Actor: events.Actor{
+			ID: "data",
+			Attributes: map[string]string{
+				"driver": "local",
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		filter    filters.Args
+		container bool
+		image     bool
+		volume    bool
+	}{
+		{filters.Args{}, true, true, true},
+		{filters.Args{"event": {"start"}}, true, false, false},
+		{filters.Args{"event": {"create"}}, false, false, true},
+		{filters.Args{"image": {"busybox"}}, true, false, false},
+		{filters.Args{"image": {"ubuntu"}}, false, true, false},
+		{filters.Args{"container": {"cont"}}, true, false, false},
+		{filters.Args{"container": {"web"}}, true, false, false},
+		{filters.Args{"volume": {"data"}}, false, false, true},
+		{filters.Args{"network": {"data"}}, false, false, false},
+		{filters.Args{"type": {"container"}}, true, false, false},
+		{filters.Args{"type": {"image"}}, false, true, false},
+		{filters.Args{"type": {"volume"}}, false, false, true},
+		{filters.Args{"type": {"container", "image"}}, true, true, false},
+		{filters.Args{"label": {"com.example.app"}}, true, true, false},
+		{filters.Args{"label": {"com.example.app=web"}}, true, false, false},
+		{filters.Args{"label": {"com.example.app=base"}, "type": {"image"}}, false, true, false},
+		{filters.Args{"label": {"com.example.other"}}, false, false, false},
+	} {
+		ef := NewFilter(tc.filter)
+		if ef.Include(containerEvent) != tc.container {
+			t.Fatalf("Expected %v for the container event with filter %v", tc.container, tc.filter)
+		}
+		if ef.Include(imageEvent) != tc.image {
+			t.Fatalf("Expected %v for the image event with filter %v", tc.image, tc.filter)
+		}
+		if ef.Include(volumeEvent) != tc.volume {
+			t.Fatalf("Expected %v for the volume event with filter %v", tc.volume, tc.filter)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"strings"
 	"sync"
 
@@ -20,6 +21,8 @@ type execConfig struct {
 	ID            string
 	Running       bool
 	ExitCode      int
+	pid           int  // pid of the running process on the host
+	killed        bool // the process is killed as soon as it starts
 	ProcessConfig execdriver.ProcessConfig
 	StreamConfig
 	OpenStdin  bool
@@ -70,6 +73,38 @@ func (execConfig *execConfig) Resize(h, w int) error {
 	return execConfig.ProcessConfig.Terminal.Resize(h, w)
 }
 
+// started records the pid of the process of the exec command, and kills it
+// right away if kill was called before it started.
+func (execConfig *execConfig) started(pid int) {
+	execConfig.Lock()
+	defer execConfig.Unlock()
+	execConfig.pid = pid
+	if execConfig.killed {
+		killProcess(pid)
+	}
+}
+
+// kill kills the process of the exec command, or kills it as soon as it
+// starts if it isn't running yet.
+func (execConfig *execConfig) kill() {
+	execConfig.Lock()
+	defer execConfig.Unlock()
+	execConfig.killed = true
+	if execConfig.pid != 0 {
+		killProcess(execConfig.pid)
+	}
+}
+
+func killProcess(pid int) {
+	p, err := os.FindProcess(pid)
+	if err == nil {
+		err = p.Kill()
+	}
+	if err != nil {
+		logrus.Debugf("Cannot kill process %d: %v", pid, err)
+	}
+}
+
 func (d *Daemon) registerExecCommand(execConfig *execConfig) {
 	// Storing execs in container in order to kill them gracefully whenever the container is stopped or removed.
 	execConfig.Container.execCommands.Add(execConfig.ID, execConfig)
@@ -238,8 +273,11 @@ func (d *Daemon) Exec(c *Container, execConfig *execConfig, pipes *execdriver.Pi
 		exitStatus = 128
 	}
 
+	execConfig.Lock()
 	execConfig.ExitCode = exitStatus
 	execConfig.Running = false
+	execConfig.pid = 0
+	execConfig.Unlock()
 
 	return exitStatus, err
 }
@@ -2,10 +2,13 @@ package execdriver
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os/exec"
 	"time"
 
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/seccomp"
 	// TODO Windows: Factor out ulimit
 	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/libcontainer"
@@ -52,6 +55,40 @@ type ExitStatus struct {
 	OOMKilled bool
 }
 
+// Restorer is implemented by the drivers which can keep a container
+// running when the daemon exits, and attach to it again once the daemon is
+// restarted.
+type Restorer interface {
+	// Restore attaches pipes to the running container c, started by Run
+	// with LiveRestore set, and blocks until its process exits. The process
+	// isn't a child of the daemon, its exit code is -1 when the driver
+	// can't learn it otherwise.
+	Restore(c *Command, pipes *Pipes, restoreCallback StartCallback) (ExitStatus, error)
+}
+
+// CheckpointOptions are the options of the checkpoint of a container, and
+// of its restore.
+type CheckpointOptions struct {
+	ImagesDirectory         string // directory of the images of the processes
+	WorkDirectory           string // directory of the logs of the checkpoint and restore
+	LeaveRunning            bool   // leave the container running once checkpointed
+	TcpEstablished          bool   // checkpoint and restore established TCP connections
+	ExternalUnixConnections bool   // allow connections to external unix sockets
+	ShellJob                bool   // allow processes of a shell job
+}
+
+// Checkpointer is implemented by the drivers which can dump the processes
+// of a running container to disk, and restore them later.
+type Checkpointer interface {
+	// Checkpoint dumps the processes of the running container c to
+	// opts.ImagesDirectory. Unless opts.LeaveRunning is set, they are
+	// killed once dumped, and Run returns.
+	Checkpoint(c *Command, opts *CheckpointOptions) error
+	// RestoreCheckpoint restores the processes of the container c from
+	// opts.ImagesDirectory, and blocks until its process exits as Run does.
+	RestoreCheckpoint(c *Command, pipes *Pipes, restoreCallback StartCallback, opts *CheckpointOptions) (ExitStatus, error)
+}
+
 type Driver interface {
 	Run(c *Command, pipes *Pipes, startCallback StartCallback) (ExitStatus, error) // Run executes the process and blocks until the process exits and returns the exit code
 	// Exec executes the process in an existing container, blocks until the process exits and returns the exit code
@@ -65,6 +102,7 @@ type Driver interface {
 	Terminate(c *Command) error                   // kill it with fire
 	Clean(id string) error                        // clean all traces of container exec
 	Stats(id string) (*ResourceStats, error)      // Get resource stats for a running container
+	Update(c *Command) error                      // Update the resource limits of a running container
 }
 
 // Network settings of the container
@@ -107,16 +145,35 @@ type NetworkInterface struct {
 
 // TODO Windows: Factor out ulimit.Rlimit
 type Resources struct {
-	Memory         int64            `json:"memory"`
-	MemorySwap     int64            `json:"memory_swap"`
-	CpuShares      int64            `json:"cpu_shares"`
-	CpusetCpus     string           `json:"cpuset_cpus"`
-	CpusetMems     string           `json:"cpuset_mems"`
-	CpuPeriod      int64            `json:"cpu_period"`
-	CpuQuota       int64            `json:"cpu_quota"`
-	BlkioWeight    int64            `json:"blkio_weight"`
-	Rlimits        []*ulimit.Rlimit `json:"rlimits"`
-	OomKillDisable bool             `json:"oom_kill_disable"`
+	Memory                       int64             `json:"memory"`
+	MemorySwap                   int64             `json:"memory_swap"`
+	CpuShares                    int64             `json:"cpu_shares"`
+	CpusetCpus                   string            `json:"cpuset_cpus"`
+	CpusetMems                   string            `json:"cpuset_mems"`
+	CpuPeriod                    int64             `json:"cpu_period"`
+	CpuQuota                     int64             `json:"cpu_quota"`
+	BlkioWeight                  int64             `json:"blkio_weight"`
+	BlkioThrottleReadBpsDevice   []*ThrottleDevice `json:"blkio_throttle_read_bps_device"`
+	BlkioThrottleWriteBpsDevice  []*ThrottleDevice `json:"blkio_throttle_write_bps_device"`
+	BlkioThrottleReadIOpsDevice  []*ThrottleDevice `json:"blkio_throttle_read_iops_device"`
+	BlkioThrottleWriteIOpsDevice []*ThrottleDevice `json:"blkio_throttle_write_iops_device"`
+	Rlimits                      []*ulimit.Rlimit  `json:"rlimits"`
+	OomKillDisable               bool              `json:"oom_kill_disable"`
+	PidsLimit                    int64             `json:"pids_limit"`
+}
+
+// ThrottleDevice is a rate limit of the block IO of a container on the
+// device Major:Minor, in bytes or IO operations per second.
+type ThrottleDevice struct {
+	Major int64  `json:"major"`
+	Minor int64  `json:"minor"`
+	Rate  uint64 `json:"rate"`
+}
+
+// String returns the rate limit as written to the throttle files of the
+// blkio cgroup.
+func (t *ThrottleDevice) String() string {
+	return fmt.Sprintf("%d:%d %d", t.Major, t.Minor, t.Rate)
 }
 
 type ResourceStats struct {
@@ -124,6 +181,8 @@ type ResourceStats struct {
 	Read        time.Time `json:"read"`
 	MemoryLimit int64     `json:"memory_limit"`
 	SystemUsage uint64    `json:"system_usage"`
+	PidsCurrent uint64    `json:"pids_current"`
+	PidsLimit   uint64    `json:"pids_limit"`
 }
 
 type Mount struct {
@@ -174,5 +233,9 @@ type Command struct {
 	MountLabel         string            `json:"mount_label"`
 	LxcConfig          []string          `json:"lxc_config"`
 	AppArmorProfile    string            `json:"apparmor_profile"`
+	SeccompProfile     *seccomp.Profile  `json:"seccomp_profile"`
 	CgroupParent       string            `json:"cgroup_parent"` // The parent cgroup for this command.
+	LiveRestore        bool              `json:"live_restore"`  // keep the process running when the daemon exits
+	UIDMapping         []idtools.IDMap   `json:"uidmapping"`    // user namespace mappings of the uids, none to share the one of the host
+	GIDMapping         []idtools.IDMap   `json:"gidmapping"`
 }
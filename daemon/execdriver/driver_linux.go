@@ -57,12 +57,26 @@ func SetupCgroups(container *configs.Config, c *Command) error {
 		container.Cgroups.CpuPeriod = c.Resources.CpuPeriod
 		container.Cgroups.CpuQuota = c.Resources.CpuQuota
 		container.Cgroups.BlkioWeight = c.Resources.BlkioWeight
+		container.Cgroups.BlkioThrottleReadBpsDevice = firstThrottleDevice(c.Resources.BlkioThrottleReadBpsDevice)
+		container.Cgroups.BlkioThrottleWriteBpsDevice = firstThrottleDevice(c.Resources.BlkioThrottleWriteBpsDevice)
+		container.Cgroups.BlkioThrottleReadIOpsDevice = firstThrottleDevice(c.Resources.BlkioThrottleReadIOpsDevice)
+		container.Cgroups.BlkioThrottleWriteIOpsDevice = firstThrottleDevice(c.Resources.BlkioThrottleWriteIOpsDevice)
 		container.Cgroups.OomKillDisable = c.Resources.OomKillDisable
 	}
 
 	return nil
 }
 
+// firstThrottleDevice returns the first rate limit of devs. The kernel reads
+// a single rate limit from each write to the throttle files of the blkio
+// cgroup, the driver has to write them all once the cgroup is set up.
+func firstThrottleDevice(devs []*ThrottleDevice) string {
+	if len(devs) == 0 {
+		return ""
+	}
+	return devs[0].String()
+}
+
 // Returns the network statistics for the network interfaces represented by the NetworkRuntimeInfo.
 func getNetworkInterfaceStats(interfaceName string) (*libcontainer.NetworkInterface, error) {
 	out := &libcontainer.NetworkInterface{Name: interfaceName}
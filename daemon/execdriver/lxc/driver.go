@@ -36,7 +36,10 @@ import (
 
 const DriverName = "lxc"
 
-var ErrExec = errors.New("Unsupported: Exec is not supported by the lxc driver")
+var (
+	ErrExec   = errors.New("Unsupported: Exec is not supported by the lxc driver")
+	ErrUpdate = errors.New("Unsupported: Update is not supported by the lxc driver")
+)
 
 type driver struct {
 	root             string // root path for the driver to use
@@ -852,6 +855,10 @@ func (d *driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessCo
 	return -1, ErrExec
 }
 
+func (d *driver) Update(c *execdriver.Command) error {
+	return ErrUpdate
+}
+
 func (d *driver) Stats(id string) (*execdriver.ResourceStats, error) {
 	if _, ok := d.activeContainers[id]; !ok {
 		return nil, fmt.Errorf("%s is not a key in active containers", id)
@@ -109,6 +109,18 @@ lxc.cgroup.cpu.cfs_quota_us = {{.Resources.CpuQuota}}
 {{if .Resources.BlkioWeight}}
 lxc.cgroup.blkio.weight = {{.Resources.BlkioWeight}}
 {{end}}
+{{range .Resources.BlkioThrottleReadBpsDevice}}
+lxc.cgroup.blkio.throttle.read_bps_device = {{.}}
+{{end}}
+{{range .Resources.BlkioThrottleWriteBpsDevice}}
+lxc.cgroup.blkio.throttle.write_bps_device = {{.}}
+{{end}}
+{{range .Resources.BlkioThrottleReadIOpsDevice}}
+lxc.cgroup.blkio.throttle.read_iops_device = {{.}}
+{{end}}
+{{range .Resources.BlkioThrottleWriteIOpsDevice}}
+lxc.cgroup.blkio.throttle.write_iops_device = {{.}}
+{{end}}
 {{if .Resources.OomKillDisable}}
 lxc.cgroup.memory.oom_control = {{.Resources.OomKillDisable}}
 {{end}}
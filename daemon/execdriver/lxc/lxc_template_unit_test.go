@@ -48,6 +48,13 @@ func TestLXCConfig(t *testing.T) {
 		Resources: &execdriver.Resources{
 			Memory:    int64(mem),
 			CpuShares: int64(cpu),
+			BlkioThrottleReadBpsDevice: []*execdriver.ThrottleDevice{
+				{Major: 8, Minor: 0, Rate: 1048576},
+				{Major: 8, Minor: 16, Rate: 2097152},
+			},
+			BlkioThrottleWriteIOpsDevice: []*execdriver.ThrottleDevice{
+				{Major: 8, Minor: 0, Rate: 100},
+			},
 		},
 		Network: &execdriver.Network{
 			Mtu:       1500,
@@ -65,6 +72,10 @@ func TestLXCConfig(t *testing.T) {
 
 	grepFile(t, p,
 		fmt.Sprintf("lxc.cgroup.memory.memsw.limit_in_bytes = %d", mem*2))
+
+	grepFile(t, p, "lxc.cgroup.blkio.throttle.read_bps_device = 8:0 1048576")
+	grepFile(t, p, "lxc.cgroup.blkio.throttle.read_bps_device = 8:16 2097152")
+	grepFile(t, p, "lxc.cgroup.blkio.throttle.write_iops_device = 8:0 100")
 }
 
 func TestCustomLxcConfig(t *testing.T) {
@@ -0,0 +1,51 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer"
+)
+
+// setupBlkioThrottle writes all the rate limits of the block IO of c to the
+// cgroup of the container cont. libcontainer writes only the first of each
+// kind, this is called whenever it sets up the cgroup: when the container is
+// started, restored from a checkpoint or updated.
+func setupBlkioThrottle(c *execdriver.Command, cont libcontainer.Container) error {
+	r := c.Resources
+	if r == nil {
+		return nil
+	}
+	throttles := []struct {
+		file string
+		devs []*execdriver.ThrottleDevice
+	}{
+		{"blkio.throttle.read_bps_device", r.BlkioThrottleReadBpsDevice},
+		{"blkio.throttle.write_bps_device", r.BlkioThrottleWriteBpsDevice},
+		{"blkio.throttle.read_iops_device", r.BlkioThrottleReadIOpsDevice},
+		{"blkio.throttle.write_iops_device", r.BlkioThrottleWriteIOpsDevice},
+	}
+	var dir string
+	for _, t := range throttles {
+		if len(t.devs) == 0 {
+			continue
+		}
+		if dir == "" {
+			state, err := cont.State()
+			if err != nil {
+				return err
+			}
+			if dir = state.CgroupPaths["blkio"]; dir == "" {
+				return fmt.Errorf("blkio cgroup of container %s not found", cont.ID())
+			}
+		}
+		for _, d := range t.devs {
+			if err := writeCgroupFile(dir, t.file, d.String()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
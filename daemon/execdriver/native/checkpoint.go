@@ -0,0 +1,148 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/configs"
+	"github.com/docker/libcontainer/utils"
+)
+
+// checkpointMarker is the file libcontainer creates in the root of a
+// container once its processes were dumped and killed. The container can't
+// be destroyed while it exists.
+const checkpointMarker = "checkpoint"
+
+func criuOpts(opts *execdriver.CheckpointOptions) *libcontainer.CriuOpts {
+	return &libcontainer.CriuOpts{
+		ImagesDirectory:         opts.ImagesDirectory,
+		WorkDirectory:           opts.WorkDirectory,
+		LeaveRunning:            opts.LeaveRunning,
+		TcpEstablished:          opts.TcpEstablished,
+		ExternalUnixConnections: opts.ExternalUnixConnections,
+		ShellJob:                opts.ShellJob,
+	}
+}
+
+// Checkpoint dumps the processes of the container c with CRIU.
+func (d *driver) Checkpoint(c *execdriver.Command, opts *execdriver.CheckpointOptions) error {
+	d.Lock()
+	active := d.activeContainers[c.ID]
+	d.Unlock()
+	if active == nil {
+		return fmt.Errorf("active container for %s does not exist", c.ID)
+	}
+	return active.Checkpoint(criuOpts(opts))
+}
+
+// RestoreCheckpoint restores the processes of the container c with CRIU,
+// and blocks until its process exits.
+func (d *driver) RestoreCheckpoint(c *execdriver.Command, pipes *execdriver.Pipes, restoreCallback execdriver.StartCallback, opts *execdriver.CheckpointOptions) (execdriver.ExitStatus, error) {
+	container, err := d.createContainer(c)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	p := &libcontainer.Process{}
+	if err := setupPipes(container, &c.ProcessConfig, p, pipes); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	// The FIFOs of a container started with live restore are opened again
+	// by their path.
+	liveRestore := c.LiveRestore && !c.ProcessConfig.Tty
+	var stdio []*os.File
+	if liveRestore {
+		stdout, stderr, err := d.createStdio(c.ID)
+		if err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		stdio = []*os.File{stdout, stderr}
+		defer closeFiles(stdio)
+		p.Stdout, p.Stderr = stdout, stderr
+	}
+
+	cont, err := d.createLibcontainer(c, container)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	d.Lock()
+	d.activeContainers[c.ID] = cont
+	d.Unlock()
+	defer func() {
+		cont.Destroy()
+		d.cleanContainer(c.ID)
+	}()
+
+	if err := cont.Restore(p, criuOpts(opts)); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	if err := setupBlkioThrottle(c, cont); err != nil {
+		p.Signal(os.Kill)
+		p.Wait()
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	if err := applyPidsLimit(c, cont); err != nil {
+		p.Signal(os.Kill)
+		p.Wait()
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	var copied <-chan struct{}
+	if liveRestore {
+		closeFiles(stdio)
+		if copied, err = d.copyStdio(c.ID, pipes); err != nil {
+			p.Signal(os.Kill)
+			p.Wait()
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+	}
+
+	// The process restored isn't the one which was started, but a sibling
+	// of CRIU, and so a child of the daemon.
+	pid, err := p.Pid()
+	if err != nil {
+		p.Signal(os.Kill)
+		p.Wait()
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	if restoreCallback != nil {
+		restoreCallback(&c.ProcessConfig, pid)
+	}
+
+	oom := notifyOnOOM(cont)
+	waitF := p.Wait
+	if nss := cont.Config().Namespaces; !nss.Contains(configs.NEWPID) {
+		waitF = waitInPIDHost(p, cont)
+	}
+	ps, err := waitF()
+	if err != nil {
+		execErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		ps = execErr.ProcessState
+	}
+	d.destroyCheckpointed(c.ID, cont)
+	if copied != nil {
+		<-copied
+	}
+	_, oomKill := <-oom
+	return execdriver.ExitStatus{ExitCode: utils.ExitStatus(ps.Sys().(syscall.WaitStatus)), OOMKilled: oomKill}, nil
+}
+
+// destroyCheckpointed destroys the container id, whose process exited,
+// even if it exited because it was checkpointed.
+func (d *driver) destroyCheckpointed(id string, cont libcontainer.Container) {
+	os.Remove(filepath.Join(d.root, id, checkpointMarker))
+	cont.Destroy()
+}
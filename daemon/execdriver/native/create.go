@@ -33,6 +33,8 @@ func (d *driver) createContainer(c *execdriver.Command) (*configs.Config, error)
 		return nil, err
 	}
 
+	d.createUserns(container, c)
+
 	if err := d.createNetwork(container, c); err != nil {
 		return nil, err
 	}
@@ -55,6 +57,11 @@ func (d *driver) createContainer(c *execdriver.Command) (*configs.Config, error)
 		}
 	}
 
+	if c.SeccompProfile != nil {
+		// The capabilities are dropped once the profile is installed.
+		container.Capabilities = append(container.Capabilities, missingSeccompCapabilities(container.Capabilities)...)
+	}
+
 	if c.AppArmorProfile != "" {
 		container.AppArmorProfile = c.AppArmorProfile
 	}
@@ -162,6 +169,29 @@ func (d *driver) createUTS(container *configs.Config, c *execdriver.Command) err
 	return nil
 }
 
+// createUserns runs the container in a new user namespace with the
+// mappings of the command, if any.
+func (d *driver) createUserns(container *configs.Config, c *execdriver.Command) {
+	if len(c.UIDMapping) == 0 && len(c.GIDMapping) == 0 {
+		return
+	}
+	container.Namespaces.Add(configs.NEWUSER, "")
+	for _, m := range c.UIDMapping {
+		container.UidMappings = append(container.UidMappings, configs.IDMap{
+			ContainerID: m.ContainerID,
+			HostID:      m.HostID,
+			Size:        m.Size,
+		})
+	}
+	for _, m := range c.GIDMapping {
+		container.GidMappings = append(container.GidMappings, configs.IDMap{
+			ContainerID: m.ContainerID,
+			HostID:      m.HostID,
+			Size:        m.Size,
+		})
+	}
+}
+
 func (d *driver) setPrivileged(container *configs.Config) (err error) {
 	container.Capabilities = execdriver.GetAllCapabilities()
 	container.Cgroups.AllowAllDevices = true
@@ -38,6 +38,7 @@ type driver struct {
 	activeContainers map[string]libcontainer.Container
 	machineMemory    int64
 	factory          libcontainer.Factory
+	pidsLimits       map[string]int64
 	sync.Mutex
 }
 
@@ -91,22 +92,24 @@ func NewDriver(root, initPath string, options []string) (*driver, error) {
 		}
 	}
 
+	d := &driver{
+		root:             root,
+		initPath:         initPath,
+		activeContainers: make(map[string]libcontainer.Container),
+		machineMemory:    meminfo.MemTotal,
+		pidsLimits:       make(map[string]int64),
+	}
 	f, err := libcontainer.New(
 		root,
 		cgm,
+		d.pidsCgroups,
 		libcontainer.InitPath(reexec.Self(), DriverName),
 	)
 	if err != nil {
 		return nil, err
 	}
-
-	return &driver{
-		root:             root,
-		initPath:         initPath,
-		activeContainers: make(map[string]libcontainer.Container),
-		machineMemory:    meminfo.MemTotal,
-		factory:          f,
-	}, nil
+	d.factory = f
+	return d, nil
 }
 
 type execOutput struct {
@@ -132,7 +135,29 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
 
-	cont, err := d.factory.Create(c.ID, container)
+	if c.SeccompProfile != nil {
+		init, err := d.setupSeccomp(c, p)
+		if err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		defer init.Close()
+	}
+
+	// The stdout and stderr of a container kept running when the daemon
+	// exits are FIFOs, which the next daemon reads again.
+	liveRestore := c.LiveRestore && !c.ProcessConfig.Tty
+	var stdio []*os.File
+	if liveRestore {
+		stdout, stderr, err := d.createStdio(c.ID)
+		if err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		stdio = []*os.File{stdout, stderr}
+		defer closeFiles(stdio)
+		p.Stdout, p.Stderr = stdout, stderr
+	}
+
+	cont, err := d.createLibcontainer(c, container)
 	if err != nil {
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
@@ -148,6 +173,24 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
 
+	if err := setupBlkioThrottle(c, cont); err != nil {
+		p.Signal(os.Kill)
+		p.Wait()
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	var copied <-chan struct{}
+	if liveRestore {
+		// Only the process holds the FIFOs now, so that their readers
+		// get io.EOF once it exited.
+		closeFiles(stdio)
+		if copied, err = d.copyStdio(c.ID, pipes); err != nil {
+			p.Signal(os.Kill)
+			p.Wait()
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+	}
+
 	if startCallback != nil {
 		pid, err := p.Pid()
 		if err != nil {
@@ -173,7 +216,10 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		}
 		ps = execErr.ProcessState
 	}
-	cont.Destroy()
+	d.destroyCheckpointed(c.ID, cont)
+	if copied != nil {
+		<-copied
+	}
 	_, oomKill := <-oom
 	return execdriver.ExitStatus{ExitCode: utils.ExitStatus(ps.Sys().(syscall.WaitStatus)), OOMKilled: oomKill}, nil
 }
@@ -276,6 +322,26 @@ func (d *driver) Unpause(c *execdriver.Command) error {
 	return active.Resume()
 }
 
+func (d *driver) Update(c *execdriver.Command) error {
+	d.Lock()
+	active := d.activeContainers[c.ID]
+	d.Unlock()
+	if active == nil {
+		return fmt.Errorf("active container for %s does not exist", c.ID)
+	}
+	config := active.Config()
+	if err := execdriver.SetupCgroups(&config, c); err != nil {
+		return err
+	}
+	if err := raiseMemorySwapLimit(active, config.Cgroups.MemorySwap); err != nil {
+		return err
+	}
+	if err := active.Set(config); err != nil {
+		return err
+	}
+	return setupBlkioThrottle(c, active)
+}
+
 func (d *driver) Terminate(c *execdriver.Command) error {
 	defer d.cleanContainer(c.ID)
 	container, err := d.factory.Load(c.ID)
@@ -324,7 +390,11 @@ func (d *driver) GetPidsForContainer(id string) ([]int, error) {
 func (d *driver) cleanContainer(id string) error {
 	d.Lock()
 	delete(d.activeContainers, id)
+	delete(d.pidsLimits, id)
 	d.Unlock()
+	if err := os.RemoveAll(d.stdioDir(id)); err != nil {
+		return err
+	}
 	return os.RemoveAll(filepath.Join(d.root, id))
 }
 
@@ -333,6 +403,9 @@ func (d *driver) createContainerRoot(id string) error {
 }
 
 func (d *driver) Clean(id string) error {
+	if err := os.RemoveAll(d.stdioDir(id)); err != nil {
+		return err
+	}
 	return os.RemoveAll(filepath.Join(d.root, id))
 }
 
@@ -354,10 +427,16 @@ func (d *driver) Stats(id string) (*execdriver.ResourceStats, error) {
 	if memoryLimit == 0 {
 		memoryLimit = d.machineMemory
 	}
+	pidsCurrent, pidsLimit, err := pidsStats(c)
+	if err != nil {
+		logrus.Debugf("Failed to get pids stats of container %s: %v", id, err)
+	}
 	return &execdriver.ResourceStats{
 		Stats:       stats,
 		Read:        now,
 		MemoryLimit: memoryLimit,
+		PidsCurrent: pidsCurrent,
+		PidsLimit:   pidsLimit,
 	}, nil
 }
 
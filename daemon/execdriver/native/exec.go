@@ -33,6 +33,14 @@ func (d *driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessCo
 		return -1, err
 	}
 
+	if c.SeccompProfile != nil {
+		init, err := d.setupSeccomp(c, p)
+		if err != nil {
+			return -1, err
+		}
+		defer init.Close()
+	}
+
 	if err := active.Start(p); err != nil {
 		return -1, err
 	}
@@ -0,0 +1,128 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/vishvananda/netlink/nl"
+)
+
+// Constants of the proc connector, from linux/connector.h and
+// linux/cn_proc.h.
+const (
+	cnIdxProc         = 0x1
+	cnValProc         = 0x1
+	procCnMcastListen = 0x1
+	procEventExit     = 0x80000000
+
+	cnMsgLen = 20 // struct cn_msg, without its data
+)
+
+// byteOrder is the byte order of the netlink messages, the one of the host.
+var byteOrder = nl.NativeEndian()
+
+// exitWatcher receives the exit statuses of the processes of the host from
+// the proc connector of the kernel. The process of a restored container
+// isn't a child of the daemon, it can't be waited for.
+type exitWatcher struct {
+	fd int
+}
+
+// newExitWatcher subscribes to the process events of the kernel. The exits
+// of the processes are received from then on.
+func newExitWatcher() (*exitWatcher, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.NETLINK_CONNECTOR)
+	if err != nil {
+		return nil, err
+	}
+	w := &exitWatcher{fd: fd}
+	if err := w.subscribe(); err != nil {
+		w.Close()
+		return nil, err
+	}
+	// Time out the reads, so that wait checks the process regularly in case
+	// its exit was missed.
+	tv := syscall.NsecToTimeval(int64(restorePollInterval))
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		w.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *exitWatcher) subscribe() error {
+	if err := syscall.Bind(w.fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: cnIdxProc}); err != nil {
+		return err
+	}
+	msg := make([]byte, syscall.NLMSG_HDRLEN+cnMsgLen+4)
+	byteOrder.PutUint32(msg[0:], uint32(len(msg)))
+	byteOrder.PutUint16(msg[4:], syscall.NLMSG_DONE)
+	byteOrder.PutUint32(msg[12:], uint32(syscall.Getpid()))
+	cn := msg[syscall.NLMSG_HDRLEN:]
+	byteOrder.PutUint32(cn[0:], cnIdxProc)
+	byteOrder.PutUint32(cn[4:], cnValProc)
+	byteOrder.PutUint16(cn[16:], 4)
+	byteOrder.PutUint32(cn[cnMsgLen:], procCnMcastListen)
+	return syscall.Sendto(w.fd, msg, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+}
+
+// wait blocks until the process pid, started at startTime, exits, and
+// returns its status. It returns false when the process exited without its
+// exit being received.
+func (w *exitWatcher) wait(pid int, startTime string) (syscall.WaitStatus, bool) {
+	buf := make([]byte, syscall.Getpagesize())
+	gone := false
+	for {
+		n, _, err := syscall.Recvfrom(w.fd, buf, 0)
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			// The read timed out, or events were dropped. The exit is
+			// sent before the process is reaped, read once more once it
+			// is gone in case it was sent since the last read.
+			if gone {
+				return 0, false
+			}
+			gone = !processRunning(pid, startTime)
+			if err != syscall.EAGAIN && err != syscall.ENOBUFS {
+				time.Sleep(restorePollInterval)
+			}
+			continue
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		for _, m := range msgs {
+			if status, ok := parseExitEvent(m.Data, pid); ok {
+				return status, true
+			}
+		}
+	}
+}
+
+// parseExitEvent returns the status of the process pid if data is the
+// connector message of its exit.
+func parseExitEvent(data []byte, pid int) (syscall.WaitStatus, bool) {
+	// struct proc_event: what, cpu, timestamp_ns, then for an exit
+	// process_pid, process_tgid, exit_code and exit_signal.
+	if len(data) < cnMsgLen+32 {
+		return 0, false
+	}
+	ev := data[cnMsgLen:]
+	if byteOrder.Uint32(ev[0:]) != procEventExit {
+		return 0, false
+	}
+	if int(byteOrder.Uint32(ev[16:])) != pid || int(byteOrder.Uint32(ev[20:])) != pid {
+		return 0, false
+	}
+	return syscall.WaitStatus(byteOrder.Uint32(ev[24:])), true
+}
+
+// Close ends the subscription.
+func (w *exitWatcher) Close() error {
+	return syscall.Close(w.fd)
+}
@@ -0,0 +1,46 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/libcontainer"
+)
+
+// raiseMemorySwapLimit writes the memory+swap limit of the container cont
+// when it is raised. libcontainer writes the memory limit first, which the
+// kernel refuses above the current memory+swap limit.
+func raiseMemorySwapLimit(cont libcontainer.Container, memorySwap int64) error {
+	if memorySwap <= 0 {
+		return nil
+	}
+	state, err := cont.State()
+	if err != nil {
+		return err
+	}
+	dir, ok := state.CgroupPaths["memory"]
+	if !ok {
+		return nil
+	}
+	current, err := ioutil.ReadFile(filepath.Join(dir, "memory.memsw.limit_in_bytes"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The kernel doesn't account swap.
+			return nil
+		}
+		return err
+	}
+	limit, err := strconv.ParseInt(strings.TrimSpace(string(current)), 10, 64)
+	if err != nil {
+		return err
+	}
+	if memorySwap <= limit {
+		return nil
+	}
+	return writeCgroupFile(dir, "memory.memsw.limit_in_bytes", strconv.FormatInt(memorySwap, 10))
+}
@@ -0,0 +1,238 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/cgroups"
+	"github.com/docker/libcontainer/configs"
+)
+
+// cgroupPath returns the path of the cgroup of the container cont in the
+// hierarchy of subsystem. It is the path of its devices cgroup, which all
+// containers have, relative to the mountpoint of the subsystem.
+func cgroupPath(cont libcontainer.Container, subsystem string) (string, error) {
+	state, err := cont.State()
+	if err != nil {
+		return "", err
+	}
+	path, err := siblingCgroupPath(state.CgroupPaths, subsystem)
+	if err != nil {
+		return "", fmt.Errorf("%v of container %s", err, cont.ID())
+	}
+	return path, nil
+}
+
+// siblingCgroupPath returns the path, in the hierarchy of subsystem, of the
+// cgroup whose paths in the other hierarchies are paths.
+func siblingCgroupPath(paths map[string]string, subsystem string) (string, error) {
+	devices, ok := paths["devices"]
+	if !ok {
+		return "", fmt.Errorf("devices cgroup not found")
+	}
+	devicesRoot, err := cgroups.FindCgroupMountpoint("devices")
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(devicesRoot, devices)
+	if err != nil {
+		return "", err
+	}
+	root, err := cgroups.FindCgroupMountpoint(subsystem)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, rel), nil
+}
+
+// pidsManager is the cgroups manager of the containers. libcontainer doesn't
+// manage the pids cgroup, pidsManager puts the init process of a container
+// with a pids limit in it when libcontainer applies the other cgroups, before
+// the process executes. The processes executed in the container later enter
+// it as they enter the other cgroups, since it is part of the paths of the
+// container.
+type pidsManager struct {
+	cgroups.Manager
+	limit int64
+}
+
+// pidsCgroups is an option of the libcontainer factory making the cgroups
+// managers of its containers pidsManagers.
+func (d *driver) pidsCgroups(l *libcontainer.LinuxFactory) error {
+	newManager := l.NewCgroupsManager
+	l.NewCgroupsManager = func(config *configs.Cgroup, paths map[string]string) cgroups.Manager {
+		d.Lock()
+		limit := d.pidsLimits[config.Name]
+		d.Unlock()
+		return &pidsManager{Manager: newManager(config, paths), limit: limit}
+	}
+	return nil
+}
+
+// createLibcontainer creates the libcontainer container of c from config,
+// recording the pids limit of c for its cgroups manager. The limit is
+// forgotten when the container is cleaned.
+func (d *driver) createLibcontainer(c *execdriver.Command, config *configs.Config) (libcontainer.Container, error) {
+	d.Lock()
+	if c.Resources != nil && c.Resources.PidsLimit > 0 {
+		d.pidsLimits[c.ID] = c.Resources.PidsLimit
+	}
+	d.Unlock()
+	cont, err := d.factory.Create(c.ID, config)
+	if err != nil {
+		d.Lock()
+		delete(d.pidsLimits, c.ID)
+		d.Unlock()
+		return nil, err
+	}
+	return cont, nil
+}
+
+// path returns the path of the pids cgroup of the container, empty if it has
+// none.
+func (m *pidsManager) path() string {
+	paths := m.Manager.GetPaths()
+	if path, ok := paths["pids"]; ok {
+		// The container was loaded from its state.
+		return path
+	}
+	if m.limit <= 0 {
+		return ""
+	}
+	path, err := siblingCgroupPath(paths, "pids")
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+func (m *pidsManager) Apply(pid int) error {
+	if err := m.Manager.Apply(pid); err != nil {
+		return err
+	}
+	if m.limit <= 0 {
+		return nil
+	}
+	dir, err := siblingCgroupPath(m.Manager.GetPaths(), "pids")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := writeCgroupFile(dir, "pids.max", strconv.FormatInt(m.limit, 10)); err != nil {
+		return err
+	}
+	return writeCgroupFile(dir, "cgroup.procs", strconv.Itoa(pid))
+}
+
+func (m *pidsManager) GetPaths() map[string]string {
+	paths := make(map[string]string)
+	for k, v := range m.Manager.GetPaths() {
+		paths[k] = v
+	}
+	if path := m.path(); path != "" {
+		paths["pids"] = path
+	}
+	return paths
+}
+
+func (m *pidsManager) Destroy() error {
+	path := m.path()
+	if err := m.Manager.Destroy(); err != nil {
+		return err
+	}
+	if path != "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyPidsLimit moves the processes of the container cont to its pids
+// cgroup when c has a pids limit. The processes restored in a container
+// didn't enter it through its cgroups manager.
+func applyPidsLimit(c *execdriver.Command, cont libcontainer.Container) error {
+	if c.Resources == nil || c.Resources.PidsLimit <= 0 {
+		return nil
+	}
+	return setupPidsLimit(cont, c.Resources.PidsLimit)
+}
+
+// setupPidsLimit moves the processes of the container cont to a pids cgroup
+// allowing limit of them. The container is frozen while its processes are
+// moved so that none of them forks out of it.
+func setupPidsLimit(cont libcontainer.Container, limit int64) error {
+	dir, err := cgroupPath(cont, "pids")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := writeCgroupFile(dir, "pids.max", strconv.FormatInt(limit, 10)); err != nil {
+		return err
+	}
+
+	if err := cont.Pause(); err != nil {
+		return err
+	}
+	defer cont.Resume()
+	pids, err := cont.Processes()
+	if err != nil {
+		return err
+	}
+	for _, pid := range pids {
+		if err := writeCgroupFile(dir, "cgroup.procs", strconv.Itoa(pid)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pidsStats returns the number of tasks of the container cont, and the
+// limit of its pids cgroup, 0 if it has none.
+func pidsStats(cont libcontainer.Container) (current, limit uint64, err error) {
+	devices, err := cgroupPath(cont, "devices")
+	if err != nil {
+		return 0, 0, err
+	}
+	tasks, err := ioutil.ReadFile(filepath.Join(devices, "tasks"))
+	if err != nil {
+		return 0, 0, err
+	}
+	current = uint64(bytes.Count(tasks, []byte("\n")))
+
+	dir, err := cgroupPath(cont, "pids")
+	if err != nil {
+		// The kernel has no pids cgroup.
+		return current, 0, nil
+	}
+	max, err := ioutil.ReadFile(filepath.Join(dir, "pids.max"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return current, 0, nil
+		}
+		return 0, 0, err
+	}
+	if s := strings.TrimSpace(string(max)); s != "max" {
+		if limit, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return 0, 0, err
+		}
+	}
+	return current, limit, nil
+}
+
+func writeCgroupFile(dir, file, data string) error {
+	return ioutil.WriteFile(filepath.Join(dir, file), []byte(data), 0700)
+}
@@ -0,0 +1,89 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/docker/libcontainer/cgroups"
+	"github.com/docker/libcontainer/cgroups/fs"
+	"github.com/docker/libcontainer/configs"
+)
+
+// TestPidsManagerApply checks that the process applied to the cgroups of a
+// container with a pids limit enters its pids cgroup, which is removed with
+// the other cgroups.
+func TestPidsManagerApply(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("the test needs to run as root")
+	}
+	if _, err := cgroups.FindCgroupMountpoint("pids"); err != nil {
+		t.Skip("the kernel has no pids cgroup")
+	}
+
+	m := &pidsManager{
+		Manager: &fs.Manager{Cgroups: &configs.Cgroup{Name: "native-pids-test", AllowAllDevices: true}},
+		limit:   5,
+	}
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	if err := m.Apply(cmd.Process.Pid); err != nil {
+		m.Destroy()
+		t.Fatal(err)
+	}
+	dir, ok := m.GetPaths()["pids"]
+	if !ok {
+		m.Destroy()
+		t.Fatal("Expected the pids cgroup in the paths of the container")
+	}
+	procs, err := ioutil.ReadFile(filepath.Join(dir, "cgroup.procs"))
+	if err != nil {
+		m.Destroy()
+		t.Fatal(err)
+	}
+	max, err := ioutil.ReadFile(filepath.Join(dir, "pids.max"))
+	if err != nil {
+		m.Destroy()
+		t.Fatal(err)
+	}
+
+	cmd.Process.Kill()
+	cmd.Wait()
+	if err := m.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(procs)) != strconv.Itoa(cmd.Process.Pid) {
+		t.Fatalf("Expected process %d in the pids cgroup, got %q", cmd.Process.Pid, procs)
+	}
+	if strings.TrimSpace(string(max)) != "5" {
+		t.Fatalf("Expected a limit of 5 processes, got %q", max)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("Expected the pids cgroup to be removed, got %v", err)
+	}
+}
+
+func TestPidsManagerWithoutLimit(t *testing.T) {
+	m := &pidsManager{
+		Manager: &fs.Manager{Paths: map[string]string{"devices": "/sys/fs/cgroup/devices/docker/id"}},
+	}
+	if _, ok := m.GetPaths()["pids"]; ok {
+		t.Fatal("Expected no pids cgroup without a limit")
+	}
+
+	m.Manager = &fs.Manager{Paths: map[string]string{"pids": "/sys/fs/cgroup/pids/docker/id"}}
+	if path := m.GetPaths()["pids"]; path != "/sys/fs/cgroup/pids/docker/id" {
+		t.Fatalf("Expected the pids cgroup of a loaded container to be kept, got %q", path)
+	}
+}
@@ -0,0 +1,190 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer/system"
+	"github.com/docker/libcontainer/utils"
+)
+
+// restorePollInterval is how often the process of a restored container is
+// checked, it can't be waited for since it isn't a child of the daemon.
+const restorePollInterval = 500 * time.Millisecond
+
+// stdioDir returns the directory of the FIFOs of the stdout and stderr of
+// the container id, which outlive the daemon.
+func (d *driver) stdioDir(id string) string {
+	return filepath.Join(d.root, id+"-stdio")
+}
+
+// createStdio creates the FIFOs of the stdout and stderr of the container
+// id, and opens them for the process of the container. The process opens
+// them for reading and writing, so that its writes block, rather than fail,
+// while no daemon reads them.
+func (d *driver) createStdio(id string) (stdout, stderr *os.File, err error) {
+	dir := d.stdioDir(id)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, err
+	}
+	var files []*os.File
+	for _, name := range []string{"stdout", "stderr"} {
+		path := filepath.Join(dir, name)
+		if err := syscall.Mkfifo(path, 0600); err != nil {
+			closeFiles(files)
+			return nil, nil, err
+		}
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			closeFiles(files)
+			return nil, nil, err
+		}
+		files = append(files, f)
+	}
+	return files[0], files[1], nil
+}
+
+// copyStdio copies the FIFOs of the stdout and stderr of the container id
+// to pipes. The returned channel is closed once all the processes writing
+// to them exited.
+func (d *driver) copyStdio(id string, pipes *execdriver.Pipes) (<-chan struct{}, error) {
+	dir := d.stdioDir(id)
+	stdout, err := openFifoReader(filepath.Join(dir, "stdout"))
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := openFifoReader(filepath.Join(dir, "stderr"))
+	if err != nil {
+		stdout.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	copied := make(chan struct{}, 2)
+	for _, c := range []struct {
+		w io.Writer
+		r *os.File
+	}{{pipes.Stdout, stdout}, {pipes.Stderr, stderr}} {
+		go func(w io.Writer, r *os.File) {
+			io.Copy(w, r)
+			r.Close()
+			copied <- struct{}{}
+		}(c.w, c.r)
+	}
+	go func() {
+		<-copied
+		<-copied
+		close(done)
+	}()
+	return done, nil
+}
+
+// openFifoReader opens the FIFO at path for reading without waiting for a
+// writer, reads then return io.EOF if there is none.
+func openFifoReader(path string) (*os.File, error) {
+	fd, err := syscall.Open(path, syscall.O_RDONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.SetNonblock(fd, false); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), path), nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// processRunning reports whether the process pid, started at startTime, is
+// still running, and not another process which reused its pid.
+func processRunning(pid int, startTime string) bool {
+	t, err := system.GetProcessStartTime(pid)
+	return err == nil && t == startTime
+}
+
+// Restore attaches pipes to the container c, left running by a previous
+// daemon, and blocks until its process exits.
+func (d *driver) Restore(c *execdriver.Command, pipes *execdriver.Pipes, restoreCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	if _, err := os.Stat(d.stdioDir(c.ID)); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("container %s wasn't started with live restore", c.ID)
+	}
+	cont, err := d.factory.Load(c.ID)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	d.Lock()
+	d.activeContainers[c.ID] = cont
+	d.Unlock()
+	defer func() {
+		cont.Destroy()
+		d.cleanContainer(c.ID)
+	}()
+
+	state, err := cont.State()
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	// Subscribe to the exits before checking the process, so that its exit
+	// isn't missed.
+	exits, err := newExitWatcher()
+	if err != nil {
+		logrus.Warnf("The exit code of container %s can't be known: %v", c.ID, err)
+	} else {
+		defer exits.Close()
+	}
+	pid, startTime := state.InitProcessPid, state.InitProcessStartTime
+	if !processRunning(pid, startTime) {
+		return execdriver.ExitStatus{ExitCode: -1}, execdriver.ErrNotRunning
+	}
+	if err := applyPidsLimit(c, cont); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	copied, err := d.copyStdio(c.ID, pipes)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	c.ProcessConfig.Terminal = &execdriver.StdConsole{}
+	if restoreCallback != nil {
+		restoreCallback(&c.ProcessConfig, pid)
+	}
+
+	oom := notifyOnOOM(cont)
+	exitCode := -1
+	if exits != nil {
+		if status, ok := exits.wait(pid, startTime); ok {
+			exitCode = utils.ExitStatus(status)
+		}
+	} else {
+		eof := copied
+		for processRunning(pid, startTime) {
+			select {
+			case <-eof:
+				// The process most likely exited, check it right away.
+				eof = nil
+			case <-time.After(restorePollInterval):
+			}
+		}
+	}
+	// Destroy kills the processes left in the cgroup when the container
+	// shares the pid namespace of the host.
+	cont.Destroy()
+	<-copied
+	_, oomKill := <-oom
+	return execdriver.ExitStatus{ExitCode: exitCode, OOMKilled: oomKill}, nil
+}
@@ -0,0 +1,98 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer/system"
+)
+
+func TestLiveStdio(t *testing.T) {
+	root, err := ioutil.TempDir("", "native-stdio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	d := &driver{root: root}
+
+	stdout, stderr, err := d.createStdio("cont")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing reads the FIFOs yet, as while the daemon is down.
+	if _, err := stdout.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	var outBuf, errBuf bytes.Buffer
+	pipes := execdriver.NewPipes(nil, &outBuf, &errBuf, false)
+	copied, err := d.copyStdio("cont", pipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdout.Write([]byte("after\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stderr.Write([]byte("error\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-copied:
+		t.Fatal("The copy should go on while the FIFOs are open")
+	case <-time.After(100 * time.Millisecond):
+	}
+	stdout.Close()
+	stderr.Close()
+	select {
+	case <-copied:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The copy should end once the FIFOs are closed")
+	}
+	if outBuf.String() != "before\nafter\n" || errBuf.String() != "error\n" {
+		t.Fatalf("Unexpected output %q and %q", outBuf.String(), errBuf.String())
+	}
+
+	// Without writer, the copy ends right away.
+	copied, err = d.copyStdio("cont", pipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-copied:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The copy should end when there is no writer")
+	}
+}
+
+func TestExitWatcher(t *testing.T) {
+	w, err := newExitWatcher()
+	if err != nil {
+		t.Skipf("the proc connector isn't available: %v", err)
+	}
+	defer w.Close()
+
+	cmd := exec.Command("sh", "-c", "sleep 0.2; exit 3")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	startTime, err := system.GetProcessStartTime(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, ok := w.wait(cmd.Process.Pid, startTime)
+	cmd.Wait()
+	if !ok {
+		t.Fatal("the exit of the process wasn't received")
+	}
+	if status.ExitStatus() != 3 {
+		t.Fatalf("Expected exit status 3, got %d", status.ExitStatus())
+	}
+}
@@ -0,0 +1,225 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/daemon/execdriver/native/template"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/seccomp"
+	"github.com/docker/docker/pkg/stringutils"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/system"
+	"github.com/docker/libcontainer/user"
+	"github.com/syndtr/gocapability/capability"
+)
+
+// seccompInitPath is the path, in the container, of the dockerinit binary
+// which installs the seccomp profile of a process before executing it. The
+// binary is passed to the process as its first extra file, so that it
+// doesn't have to be mounted in the container.
+const seccompInitPath = "/proc/self/fd/3"
+
+// seccompCapabilities are needed to install a seccomp profile without
+// setting no_new_privs, which would keep setuid binaries from gaining
+// privileges, and to switch to the user of the process afterwards. They are
+// given to the processes of the containers which don't have them until their
+// profile is installed.
+var seccompCapabilities = []string{"SYS_ADMIN", "SETPCAP", "SETUID", "SETGID"}
+
+func init() {
+	reexec.Register(seccompInitPath, seccompInitializer)
+}
+
+// missingSeccompCapabilities returns the capabilities needed to install a
+// seccomp profile which aren't in caps.
+func missingSeccompCapabilities(caps []string) []string {
+	var missing []string
+	for _, c := range seccompCapabilities {
+		if !stringutils.InSlice(caps, c) {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// setupSeccomp makes the process p of the container c install its seccomp
+// profile before executing. libcontainer starts the process as root, which
+// switches to the user of the process once the profile is installed. The
+// returned file must be closed once the process started.
+func (d *driver) setupSeccomp(c *execdriver.Command, p *libcontainer.Process) (*os.File, error) {
+	caps := execdriver.GetAllCapabilities()
+	if !c.ProcessConfig.Privileged {
+		var err error
+		if caps, err = execdriver.TweakCapabilities(template.New().Capabilities, c.CapAdd, c.CapDrop); err != nil {
+			return nil, err
+		}
+	}
+	profile, err := json.Marshal(c.SeccompProfile)
+	if err != nil {
+		return nil, err
+	}
+	init, err := os.Open(d.initPath)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{
+		seccompInitPath,
+		"-user", p.User,
+		"-drop-caps", strings.Join(missingSeccompCapabilities(caps), ","),
+		"-profile", string(profile),
+	}
+	// libcontainer sets HOME for root when it isn't set, the initializer
+	// sets it for the user of the process instead.
+	if !hasEnv(p.Env, "HOME") {
+		args = append(args, "-set-home")
+	}
+	p.ExtraFiles = []*os.File{init}
+	p.Args = append(append(args, "--"), p.Args...)
+	p.User = ""
+	return init, nil
+}
+
+func hasEnv(env []string, name string) bool {
+	for _, e := range env {
+		if strings.HasPrefix(e, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// seccompInitializer runs in the container, as root, once libcontainer set
+// it up. It installs the seccomp profile, switches to the user of the
+// process, drops the capabilities only needed for this, and executes the
+// process.
+func seccompInitializer() {
+	// The profile only filters the thread installing it, which must be the
+	// one executing the process.
+	runtime.LockOSThread()
+
+	var (
+		flags    = flag.NewFlagSet(seccompInitPath, flag.ExitOnError)
+		dropCaps = flags.String("drop-caps", "", "capabilities to drop once the profile is installed")
+		profile  = flags.String("profile", "", "seccomp profile")
+		username = flags.String("user", "", "user of the process")
+		setHome  = flags.Bool("set-home", false, "set HOME to the home directory of the user")
+	)
+	flags.Parse(os.Args[1:])
+	args := flags.Args()
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "no command to execute")
+		os.Exit(1)
+	}
+
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(127)
+	}
+	if err := seccompExec(path, args, *profile, *dropCaps, *username, *setHome); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func seccompExec(path string, args []string, profileData, dropCaps, username string, setHome bool) error {
+	profile, err := seccomp.LoadProfile([]byte(profileData))
+	if err != nil {
+		return err
+	}
+	var drop []capability.Cap
+	if dropCaps != "" {
+		for _, name := range strings.Split(dropCaps, ",") {
+			c := execdriver.GetCapability(name)
+			if c == nil {
+				return fmt.Errorf("Unknown capability %s", name)
+			}
+			drop = append(drop, c.Value)
+		}
+	}
+	caps, err := capability.NewPid(0)
+	if err != nil {
+		return err
+	}
+	pdeath, err := system.GetParentDeathSignal()
+	if err != nil {
+		return err
+	}
+
+	// The process must not see the dockerinit binary.
+	syscall.CloseOnExec(3)
+	// Dropping capabilities from the bounding set needs SETPCAP, but leaves
+	// them effective until they are dropped from the process below, after
+	// the profile is installed.
+	caps.Unset(capability.BOUNDING, drop...)
+	if err := caps.Apply(capability.BOUNDING); err != nil {
+		return err
+	}
+	if err := setupUser(username, setHome); err != nil {
+		return fmt.Errorf("Cannot switch to user %q: %v", username, err)
+	}
+	// Switching users cleared the effective capabilities, SYS_ADMIN is
+	// needed to install the profile without no_new_privs.
+	if err := caps.Apply(capability.CAPS); err != nil {
+		return err
+	}
+	// Switching users also cleared the parent death signal.
+	if err := pdeath.Restore(); err != nil {
+		return err
+	}
+	if err := profile.Install(); err != nil {
+		return fmt.Errorf("Cannot install the seccomp profile: %v", err)
+	}
+	caps.Unset(capability.CAPS, drop...)
+	if err := caps.Apply(capability.CAPS); err != nil {
+		return err
+	}
+	return syscall.Exec(path, args, os.Environ())
+}
+
+// setupUser switches the calling thread from root to the user username, as
+// libcontainer does, keeping its capabilities.
+func setupUser(username string, setHome bool) error {
+	defaultExecUser := user.ExecUser{Uid: 0, Gid: 0, Home: "/"}
+	passwdPath, err := user.GetPasswdPath()
+	if err != nil {
+		return err
+	}
+	groupPath, err := user.GetGroupPath()
+	if err != nil {
+		return err
+	}
+	execUser, err := user.GetExecUserPath(username, &defaultExecUser, passwdPath, groupPath)
+	if err != nil {
+		return err
+	}
+	if err := system.SetKeepCaps(); err != nil {
+		return err
+	}
+	if err := syscall.Setgroups(execUser.Sgids); err != nil {
+		return err
+	}
+	if err := system.Setgid(execUser.Gid); err != nil {
+		return err
+	}
+	if err := system.Setuid(execUser.Uid); err != nil {
+		return err
+	}
+	if err := system.ClearKeepCaps(); err != nil {
+		return err
+	}
+	if setHome {
+		return os.Setenv("HOME", execUser.Home)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// +build linux,cgo,amd64
+
+package native
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/reexec"
+)
+
+func init() {
+	reexec.Init()
+}
+
+// TestSeccompInitializer installs a profile denying mkdir for root, and for
+// another user, without setting no_new_privs.
+func TestSeccompInitializer(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("the test needs to run as root")
+	}
+	if status, err := ioutil.ReadFile("/proc/self/status"); err != nil || !strings.Contains(string(status), "Seccomp:") {
+		t.Skip("the kernel doesn't support seccomp")
+	}
+
+	tmp, err := ioutil.TempDir("", "native-seccomp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	if err := os.Chmod(tmp, 0777); err != nil {
+		t.Fatal(err)
+	}
+	profile := `{"defaultAction": "allow", "syscalls": [{"name": "execve", "action": "allow"}, {"name": "capset", "action": "allow"}, {"name": "mkdir", "action": "errno"}, {"name": "mkdirat", "action": "errno"}]}`
+	for _, u := range []struct {
+		name, uid string
+	}{
+		{"", "0"},
+		{"65534:65534", "65534"},
+	} {
+		dir := filepath.Join(tmp, "dir")
+		script := "id -u; grep NoNewPrivs /proc/self/status; mkdir " + dir
+		cmd := reexec.Command(seccompInitPath, "-user", u.name, "-drop-caps", "SYS_ADMIN,SETPCAP", "-profile", profile, "--", "sh", "-c", script)
+		out, err := cmd.CombinedOutput()
+		if err == nil {
+			t.Fatalf("mkdir as user %s should have been denied", u.uid)
+		}
+		if !strings.Contains(string(out), "Operation not permitted") {
+			t.Fatalf("Expected mkdir as user %s to fail with EPERM, got %s", u.uid, out)
+		}
+		if !strings.HasPrefix(string(out), u.uid+"\n") {
+			t.Fatalf("Expected the process to run as user %s, got %s", u.uid, out)
+		}
+		if strings.Contains(string(out), "NoNewPrivs:\t1") {
+			t.Fatalf("Expected no_new_privs to be unset for user %s, got %s", u.uid, out)
+		}
+		if _, err := os.Stat(dir); err == nil {
+			t.Fatalf("%s was created by user %s", dir, u.uid)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/runconfig"
+)
+
+const (
+	// Longest probe output to keep. Longer output is truncated.
+	maxOutputLen = 4096
+
+	// Default interval between the end of a probe and the start of the next one.
+	defaultProbeInterval = 30 * time.Second
+
+	// Default time a probe may run before it is considered to have failed.
+	defaultProbeTimeout = 30 * time.Second
+
+	// Default number of consecutive failures before the container is unhealthy.
+	defaultProbeRetries = 3
+
+	// Number of probe results to keep in the container state.
+	maxLogEntries = 5
+)
+
+// Health statuses of a container with a health check.
+const (
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+
+	// HealthNone is reported for containers without a health check.
+	HealthNone = "none"
+)
+
+// Health is the health check state of a container, stored in its State.
+type Health struct {
+	types.Health
+
+	stop chan struct{} // closed to stop the health monitor
+}
+
+// String returns the health status of the container.
+func (h *Health) String() string {
+	if h == nil {
+		return HealthNone
+	}
+	return h.Status
+}
+
+// healthcheckEnabled reports whether the config defines a health check.
+func healthcheckEnabled(config *runconfig.Config) bool {
+	if config.Healthcheck == nil || len(config.Healthcheck.Test) == 0 {
+		return false
+	}
+	return config.Healthcheck.Test[0] != "NONE"
+}
+
+// initHealthMonitor starts probing the container if it has a health check,
+// replacing any monitor from a previous run. Must be called with the
+// container lock held.
+func (daemon *Daemon) initHealthMonitor(c *Container) {
+	stopHealthMonitor(c)
+	if !healthcheckEnabled(c.Config) {
+		c.State.Health = nil
+		return
+	}
+
+	h := c.State.Health
+	if h == nil {
+		h = &Health{}
+		c.State.Health = h
+	}
+	h.Status = HealthStarting
+	h.FailingStreak = 0
+	h.stop = make(chan struct{})
+
+	go daemon.healthMonitor(c, h.stop)
+}
+
+// stopHealthMonitor stops the health monitor of the container, if any. Must
+// be called with the container lock held.
+func stopHealthMonitor(c *Container) {
+	h := c.State.Health
+	if h == nil || h.stop == nil {
+		return
+	}
+	logrus.Debugf("Stopping health monitor of container %s", c.ID)
+	close(h.stop)
+	h.stop = nil
+}
+
+func durationWithDefault(d, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+	return d
+}
+
+// healthMonitor runs a probe every interval until stop is closed.
+func (daemon *Daemon) healthMonitor(c *Container, stop chan struct{}) {
+	interval := durationWithDefault(c.Config.Healthcheck.Interval, defaultProbeInterval)
+	timeout := durationWithDefault(c.Config.Healthcheck.Timeout, defaultProbeTimeout)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+
+		if c.IsPaused() {
+			continue
+		}
+
+		start := time.Now().UTC()
+		results := make(chan *types.HealthcheckResult, 1)
+		probe := newProbeExec(c)
+		go func() {
+			result, err := daemon.runHealthcheck(probe)
+			if err != nil {
+				result = &types.HealthcheckResult{
+					Start:    start,
+					End:      time.Now().UTC(),
+					ExitCode: -1,
+					Output:   err.Error(),
+				}
+			}
+			results <- result
+		}()
+
+		select {
+		case <-stop:
+			return
+		case result := <-results:
+			daemon.handleProbeResult(c, result)
+		case <-time.After(timeout):
+			// The probe is hung, don't let it pile up with the next ones.
+			probe.kill()
+			daemon.handleProbeResult(c, &types.HealthcheckResult{
+				Start:    start,
+				End:      time.Now().UTC(),
+				ExitCode: -1,
+				Output:   fmt.Sprintf("Health check exceeded timeout (%v)", timeout),
+			})
+		}
+	}
+}
+
+// newProbeExec returns the exec command running a probe of the container.
+func newProbeExec(c *Container) *execConfig {
+	return &execConfig{
+		ID:         stringid.GenerateRandomID(),
+		OpenStdout: true,
+		OpenStderr: true,
+		Container:  c,
+	}
+}
+
+// runHealthcheck runs the health check command of the container through
+// the exec path and waits for it to exit.
+func (daemon *Daemon) runHealthcheck(execConfig *execConfig) (*types.HealthcheckResult, error) {
+	c := execConfig.Container
+	test := c.Config.Healthcheck.Test
+	var cmdSlice []string
+	switch test[0] {
+	case "CMD":
+		cmdSlice = test[1:]
+	case "CMD-SHELL":
+		cmdSlice = []string{"/bin/sh", "-c", strings.Join(test[1:], " ")}
+	default:
+		return nil, fmt.Errorf("Unknown health check type %q", test[0])
+	}
+	if len(cmdSlice) == 0 {
+		return nil, fmt.Errorf("Health check command is empty")
+	}
+
+	entrypoint, args := daemon.getEntrypointAndArgs(runconfig.NewEntrypoint(), runconfig.NewCommand(cmdSlice...))
+	execConfig.ProcessConfig = execdriver.ProcessConfig{
+		Entrypoint: entrypoint,
+		Arguments:  args,
+		User:       c.Config.User,
+	}
+	daemon.registerExecCommand(execConfig)
+	defer daemon.unregisterExecCommand(execConfig)
+
+	output := &limitedBuffer{}
+	start := time.Now().UTC()
+	if err := daemon.ContainerExecStart(execConfig.ID, nil, output, output); err != nil {
+		return nil, err
+	}
+	return &types.HealthcheckResult{
+		Start:    start,
+		End:      time.Now().UTC(),
+		ExitCode: execConfig.ExitCode,
+		Output:   output.String(),
+	}, nil
+}
+
+// handleProbeResult records the result of a probe in the container state
+// and emits an event if the health status changed.
+func (daemon *Daemon) handleProbeResult(c *Container, result *types.HealthcheckResult) {
+	c.Lock()
+	defer c.Unlock()
+
+	h := c.State.Health
+	if h == nil || h.stop == nil {
+		// the monitor was stopped while the probe was running
+		return
+	}
+
+	retries := c.Config.Healthcheck.Retries
+	if retries <= 0 {
+		retries = defaultProbeRetries
+	}
+
+	h.Log = append(h.Log, result)
+	if len(h.Log) > maxLogEntries {
+		h.Log = h.Log[len(h.Log)-maxLogEntries:]
+	}
+
+	oldStatus := h.Status
+	if result.ExitCode == 0 {
+		h.FailingStreak = 0
+		h.Status = HealthHealthy
+	} else {
+		h.FailingStreak++
+		if h.FailingStreak >= retries {
+			h.Status = HealthUnhealthy
+		}
+		// Otherwise the status is unchanged; a starting container stays
+		// starting until it either passes or exhausts its retries.
+	}
+
+	if err := c.toDisk(); err != nil {
+		logrus.Errorf("Error saving health state of container %s: %v", c.ID, err)
+	}
+	if oldStatus != h.Status {
+		c.LogEvent("health_status: " + h.Status)
+	}
+}
+
+// limitedBuffer is a goroutine-safe buffer that keeps at most maxOutputLen
+// bytes of the probe output.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	mu        sync.Mutex
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bufLen := b.buf.Len()
+	dataLen := len(data)
+	keep := maxOutputLen - bufLen
+	if keep > dataLen {
+		keep = dataLen
+	}
+	if keep > 0 {
+		b.buf.Write(data[:keep])
+	}
+	if keep < dataLen {
+		b.truncated = true
+	}
+	return dataLen, nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := b.buf.String()
+	if b.truncated {
+		out = out + "..."
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/runconfig"
+)
+
+func newHealthTestContainer(t *testing.T, retries int) (*Container, func()) {
+	root, err := ioutil.TempDir("", "docker-health-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Container{
+		CommonContainer: CommonContainer{
+			ID:   "container_id",
+			Name: "container_name",
+			root: root,
+			Config: &runconfig.Config{
+				Image: "image_name",
+				Healthcheck: &runconfig.HealthConfig{
+					Test:    []string{"CMD-SHELL", "false"},
+					Retries: retries,
+				},
+			},
+			State:  NewState(),
+			daemon: &Daemon{EventsService: events.New()},
+		},
+	}
+	return c, func() { os.RemoveAll(root) }
+}
+
+func TestHealthStates(t *testing.T) {
+	c, cleanup := newHealthTestContainer(t, 2)
+	defer cleanup()
+
+	c.Lock()
+	c.daemon.initHealthMonitor(c)
+	c.Unlock()
+	defer func() {
+		c.Lock()
+		stopHealthMonitor(c)
+		c.Unlock()
+	}()
+
+	_, l := c.daemon.EventsService.Subscribe()
+	defer c.daemon.EventsService.Evict(l)
+
+	expect := func(status string) {
+		select {
+		case event := <-l:
+			ev := event.(eventtypes.Message)
+			if ev.Status != "health_status: "+status {
+				t.Fatalf("Expected status %q, got %q", status, ev.Status)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timeout waiting for %q event", status)
+		}
+	}
+
+	result := func(exitCode int) *types.HealthcheckResult {
+		now := time.Now().UTC()
+		return &types.HealthcheckResult{Start: now, End: now, ExitCode: exitCode}
+	}
+
+	if status := c.State.Health.String(); status != HealthStarting {
+		t.Fatalf("Expected %q, got %q", HealthStarting, status)
+	}
+
+	c.daemon.handleProbeResult(c, result(0))
+	expect(HealthHealthy)
+	c.daemon.handleProbeResult(c, result(1))
+	if status := c.State.Health.String(); status != HealthHealthy {
+		t.Fatalf("Expected a single failure to keep the container healthy, got %q", status)
+	}
+	c.daemon.handleProbeResult(c, result(1))
+	expect(HealthUnhealthy)
+	c.daemon.handleProbeResult(c, result(0))
+	expect(HealthHealthy)
+
+	for i := 0; i < 2*maxLogEntries; i++ {
+		c.daemon.handleProbeResult(c, result(0))
+	}
+	if n := len(c.State.Health.Log); n != maxLogEntries {
+		t.Fatalf("Expected %d log entries, got %d", maxLogEntries, n)
+	}
+	c.State.SetRunning(1)
+	if !strings.Contains(c.State.String(), "(healthy)") {
+		t.Fatalf("Expected health in state string, got %q", c.State.String())
+	}
+}
+
+func TestHealthDisabled(t *testing.T) {
+	c, cleanup := newHealthTestContainer(t, 0)
+	defer cleanup()
+
+	c.Config.Healthcheck.Test = []string{"NONE"}
+	c.Lock()
+	c.daemon.initHealthMonitor(c)
+	c.Unlock()
+	if c.State.Health != nil {
+		t.Fatalf("Expected no health state, got %#v", c.State.Health)
+	}
+	if status := c.State.Health.String(); status != HealthNone {
+		t.Fatalf("Expected %q, got %q", HealthNone, status)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{}
+	b.Write([]byte(strings.Repeat("a", maxOutputLen-1)))
+	b.Write([]byte("bc"))
+	out := b.String()
+	if len(out) != maxOutputLen+3 || !strings.HasSuffix(out, "ab...") {
+		t.Fatalf("Unexpected truncated output: %q", out[len(out)-10:])
+	}
+}
+
+func TestProbeKill(t *testing.T) {
+	// A probe is killed whether it times out before or after it started.
+	for _, killFirst := range []bool{true, false} {
+		cmd := exec.Command("sleep", "10")
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		probe := newProbeExec(nil)
+		if killFirst {
+			probe.kill()
+			probe.started(cmd.Process.Pid)
+		} else {
+			probe.started(cmd.Process.Pid)
+			probe.kill()
+		}
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Fatal("Expected the probe to be killed")
+			}
+		case <-time.After(5 * time.Second):
+			cmd.Process.Kill()
+			t.Fatalf("Probe not killed (kill before start: %v)", killFirst)
+		}
+	}
+}
@@ -110,7 +110,7 @@ func (daemon *Daemon) imgDeleteHelper(name string, list *[]types.ImageDelete, fi
 				*list = append(*list, types.ImageDelete{
 					Untagged: utils.ImageReference(repoName, tag),
 				})
-				daemon.EventsService.Log("untag", img.ID, "")
+				daemon.Repositories().LogImageEvent("untag", img.ID, utils.ImageReference(repoName, tag))
 			}
 		}
 	}
@@ -126,7 +126,7 @@ func (daemon *Daemon) imgDeleteHelper(name string, list *[]types.ImageDelete, fi
 			*list = append(*list, types.ImageDelete{
 				Deleted: img.ID,
 			})
-			daemon.EventsService.Log("delete", img.ID, "")
+			daemon.Repositories().LogImageEvent("delete", img.ID, "")
 			if img.Parent != "" && !noprune {
 				err := daemon.imgDeleteHelper(img.Parent, list, false, force, noprune)
 				if first {
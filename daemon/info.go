@@ -78,13 +78,13 @@ func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 		KernelVersion:      kernelVersion,
 		OperatingSystem:    operatingSystem,
 		IndexServerAddress: registry.IndexServerAddress(),
-		RegistryConfig:     daemon.RegistryService.Config,
+		RegistryConfig:     daemon.RegistryService.Config(),
 		InitSha1:           dockerversion.INITSHA1,
 		InitPath:           initPath,
 		NCPU:               runtime.NumCPU(),
 		MemTotal:           meminfo.MemTotal,
 		DockerRootDir:      daemon.Config().Root,
-		Labels:             daemon.Config().Labels,
+		Labels:             daemon.Labels(),
 		ExperimentalBuild:  utils.ExperimentalBuild(),
 	}
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/volume/store"
 )
 
 func (daemon *Daemon) ContainerInspect(name string) (*types.ContainerJSON, error) {
@@ -75,6 +76,11 @@ func (daemon *Daemon) getInspectData(container *Container) (*types.ContainerJSON
 		StartedAt:  container.State.StartedAt,
 		FinishedAt: container.State.FinishedAt,
 	}
+	if h := container.State.Health; h != nil {
+		health := h.Health
+		health.Log = append([]*types.HealthcheckResult(nil), h.Log...)
+		containerState.Health = &health
+	}
 
 	volumes := make(map[string]string)
 	volumesRW := make(map[string]bool)
@@ -120,3 +126,15 @@ func (daemon *Daemon) ContainerExecInspect(id string) (*execConfig, error) {
 
 	return eConfig, nil
 }
+
+// VolumeInspect looks up a volume by name.
+func (daemon *Daemon) VolumeInspect(name string) (*types.Volume, error) {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		if err == store.ErrNoSuchVolume {
+			return nil, fmt.Errorf("No such volume: %s", name)
+		}
+		return nil, err
+	}
+	return daemon.volumeToAPIType(v), nil
+}
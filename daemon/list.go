@@ -10,6 +10,7 @@ import (
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/pkg/graphdb"
 	"github.com/docker/docker/pkg/parsers/filters"
+	"github.com/docker/docker/pkg/signal"
 )
 
 // List returns an array of all containers registered in the daemon.
@@ -34,6 +35,8 @@ func (daemon *Daemon) Containers(config *ContainersConfig) ([]*types.Container,
 		n           = config.Limit
 		psFilters   filters.Args
 		filtExited  []int
+		filtSignals []int
+		ancestors   map[string]bool
 	)
 	containers := []*types.Container{}
 
@@ -51,6 +54,33 @@ func (daemon *Daemon) Containers(config *ContainersConfig) ([]*types.Container,
 		}
 	}
 
+	if i, ok := psFilters["exit-signal"]; ok {
+		if filtSignals, err = parseExitSignals(i); err != nil {
+			return nil, err
+		}
+	}
+
+	if i, ok := psFilters["health"]; ok {
+		for _, value := range i {
+			switch value {
+			case HealthStarting, HealthHealthy, HealthUnhealthy, HealthNone:
+			default:
+				return nil, fmt.Errorf("Unrecognised filter value for health: %s", value)
+			}
+		}
+	}
+
+	if i, ok := psFilters["ancestor"]; ok {
+		ancestors = make(map[string]bool)
+		for _, value := range i {
+			img, err := daemon.repositories.LookupImage(value)
+			if err != nil || img == nil {
+				return nil, fmt.Errorf("No such image: %s", value)
+			}
+			ancestors[img.ID] = true
+		}
+	}
+
 	if i, ok := psFilters["status"]; ok {
 		for _, value := range i {
 			if value == "exited" || value == "created" {
@@ -112,22 +142,44 @@ func (daemon *Daemon) Containers(config *ContainersConfig) ([]*types.Container,
 				return errLast
 			}
 		}
-		if len(filtExited) > 0 {
-			shouldSkip := true
-			for _, code := range filtExited {
-				if code == container.ExitCode && !container.Running {
-					shouldSkip = false
+		if len(filtExited) > 0 && !exitedWithCode(container, filtExited) {
+			return nil
+		}
+
+		if len(filtSignals) > 0 && !exitedWithCode(container, filtSignals) {
+			return nil
+		}
+
+		if !psFilters.Match("status", container.State.StateString()) {
+			return nil
+		}
+
+		if ancestors != nil && !daemon.imageDescendsFrom(container.ImageID, ancestors) {
+			return nil
+		}
+
+		if values, ok := psFilters["volume"]; ok && !matchAny(values, containerVolumes(container)) {
+			return nil
+		}
+
+		if values, ok := psFilters["network"]; ok && !matchAny(values, containerNetworks(container)) {
+			return nil
+		}
+
+		if values, ok := psFilters["health"]; ok {
+			// exact match, "healthy" must not match "unhealthy"
+			health := container.State.Health.String()
+			matched := false
+			for _, value := range values {
+				if value == health {
+					matched = true
 					break
 				}
 			}
-			if shouldSkip {
+			if !matched {
 				return nil
 			}
 		}
-
-		if !psFilters.Match("status", container.State.StateString()) {
-			return nil
-		}
 		displayed++
 		newC := &types.Container{
 			ID:    container.ID,
@@ -193,3 +245,117 @@ func (daemon *Daemon) Containers(config *ContainersConfig) ([]*types.Container,
 	}
 	return containers, nil
 }
+
+// imageDescendsFrom returns whether the image imgID is one of the ancestors
+// images, or one of their descendants, walking its chain of parents in the
+// graph.
+func (daemon *Daemon) imageDescendsFrom(imgID string, ancestors map[string]bool) bool {
+	for img, err := daemon.graph.Get(imgID); err == nil && img != nil; img, err = img.GetParent() {
+		if ancestors[img.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExitSignals returns the exit codes of the processes killed by the
+// signals of the exit-signal filter, 128 plus the number of the signal.
+func parseExitSignals(values []string) ([]int, error) {
+	var codes []int
+	for _, value := range values {
+		sig, err := signal.ParseSignal(value)
+		if err != nil {
+			return nil, fmt.Errorf("Unrecognised filter value for exit-signal: %s", value)
+		}
+		codes = append(codes, 128+int(sig))
+	}
+	return codes, nil
+}
+
+// exitedWithCode returns whether the container is stopped, and exited with
+// one of the codes.
+func exitedWithCode(container *Container, codes []int) bool {
+	if container.Running {
+		return false
+	}
+	for _, code := range codes {
+		if code == container.ExitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// containerVolumes returns the names of the volumes of the container, and
+// the paths they are mounted to, for the volume filter.
+func containerVolumes(container *Container) []string {
+	var volumes []string
+	for _, m := range container.MountPoints {
+		if m.Name != "" {
+			volumes = append(volumes, m.Name)
+		}
+		volumes = append(volumes, m.Destination)
+	}
+	return volumes
+}
+
+// containerNetworks returns the names and IDs of the networks the container
+// is connected to, or will be connected to when it starts, for the network
+// filter.
+func containerNetworks(container *Container) []string {
+	var networks []string
+	for name, settings := range container.NetworkSettings.Networks {
+		networks = append(networks, name, settings.NetworkID)
+	}
+	if mode := container.hostConfig.NetworkMode; mode != "" && !mode.IsContainer() {
+		networks = append(networks, string(mode))
+	}
+	return append(networks, container.hostConfig.Networks...)
+}
+
+// matchAny returns whether any of the filter values is one of the sources.
+func matchAny(values, sources []string) bool {
+	for _, value := range values {
+		for _, source := range sources {
+			if value == source {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Volumes lists the volumes known by the daemon. The only supported filter
+// is dangling, which selects the volumes that are not used by any container.
+func (daemon *Daemon) Volumes(filter string) ([]*types.Volume, error) {
+	volFilters, err := filters.FromParam(filter)
+	if err != nil {
+		return nil, err
+	}
+	for name := range volFilters {
+		if name != "dangling" {
+			return nil, fmt.Errorf("Invalid filter '%s'", name)
+		}
+	}
+
+	vols := daemon.volumes.List()
+	if i, ok := volFilters["dangling"]; ok {
+		if len(i) != 1 {
+			return nil, fmt.Errorf("Invalid filter 'dangling', only one value is allowed")
+		}
+		switch strings.ToLower(i[0]) {
+		case "true", "1":
+			vols = daemon.volumes.FilterByUsed(false)
+		case "false", "0":
+			vols = daemon.volumes.FilterByUsed(true)
+		default:
+			return nil, fmt.Errorf("Invalid filter 'dangling=%s'", i[0])
+		}
+	}
+
+	volumesOut := []*types.Volume{}
+	for _, v := range vols {
+		volumesOut = append(volumesOut, daemon.volumeToAPIType(v))
+	}
+	return volumesOut, nil
+}
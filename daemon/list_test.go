@@ -0,0 +1,38 @@
+package daemon
+
+import "testing"
+
+func TestParseExitSignals(t *testing.T) {
+	codes, err := parseExitSignals([]string{"9", "SIGTERM", "int"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{137, 143, 130}
+	if len(codes) != len(expected) {
+		t.Fatalf("Expected exit codes %v, got %v", expected, codes)
+	}
+	for i := range expected {
+		if codes[i] != expected[i] {
+			t.Fatalf("Expected exit codes %v, got %v", expected, codes)
+		}
+	}
+
+	if _, err := parseExitSignals([]string{"SIGFOO"}); err == nil {
+		t.Fatal("Expected an error for an unknown signal")
+	}
+}
+
+func TestExitedWithCode(t *testing.T) {
+	c := &Container{CommonContainer: CommonContainer{State: NewState()}}
+	c.ExitCode = 137
+	if !exitedWithCode(c, []int{143, 137}) {
+		t.Fatal("Expected the container killed by SIGKILL to match")
+	}
+	if exitedWithCode(c, []int{143}) {
+		t.Fatal("Expected the container killed by SIGKILL not to match SIGTERM")
+	}
+	c.Running = true
+	if exitedWithCode(c, []int{137}) {
+		t.Fatal("Expected a running container not to match")
+	}
+}
@@ -5,12 +5,21 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 )
 
 // Creator is a method that builds a logging driver instance with given context
 type Creator func(Context) (Logger, error)
 
+// ReaderCreator builds a reader of the logs written by a logging driver,
+// for the containers which aren't running, without creating the driver.
+type ReaderCreator func(Context) (LogReader, error)
+
+// LogOptValidator checks the options specific to the underlying
+// logging implementation.
+type LogOptValidator func(cfg map[string]string) error
+
 // Context provides enough information for a logging driver to do its function
 type Context struct {
 	Config              map[string]string
@@ -21,6 +30,8 @@ type Context struct {
 	ContainerImageID    string
 	ContainerImageName  string
 	ContainerCreated    time.Time
+	ContainerLabels     map[string]string
+	ContainerEnv        []string
 	LogPath             string
 }
 
@@ -32,6 +43,102 @@ func (ctx *Context) Hostname() (string, error) {
 	return hostname, nil
 }
 
+// ID returns the container ID shortened to 12 characters.
+func (ctx *Context) ID() string {
+	if len(ctx.ContainerID) > 12 {
+		return ctx.ContainerID[:12]
+	}
+	return ctx.ContainerID
+}
+
+// FullID returns the full ID of the container.
+func (ctx *Context) FullID() string {
+	return ctx.ContainerID
+}
+
+// Name returns the name of the container, without its leading slash.
+func (ctx *Context) Name() string {
+	return strings.TrimPrefix(ctx.ContainerName, "/")
+}
+
+// ImageID returns the ID of the container image shortened to 12 characters.
+func (ctx *Context) ImageID() string {
+	if len(ctx.ContainerImageID) > 12 {
+		return ctx.ContainerImageID[:12]
+	}
+	return ctx.ContainerImageID
+}
+
+// ImageFullID returns the full ID of the container image.
+func (ctx *Context) ImageFullID() string {
+	return ctx.ContainerImageID
+}
+
+// ImageName returns the name of the image the container was created from.
+func (ctx *Context) ImageName() string {
+	return ctx.ContainerImageName
+}
+
+// Label returns the value of the container label key, or an empty string.
+func (ctx *Context) Label(key string) string {
+	return ctx.ContainerLabels[key]
+}
+
+// Env returns the value of the container environment variable key, or an
+// empty string.
+func (ctx *Context) Env(key string) string {
+	for _, kv := range ctx.ContainerEnv {
+		if k, v := splitEnv(kv); k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// ExtraAttributes returns the container labels and environment variables
+// selected with the comma separated "labels" and "env" options, which are
+// set on the container. keyMod, if not nil, changes the keys of the
+// attributes to the format the logging driver supports.
+func (ctx *Context) ExtraAttributes(keyMod func(string) string) map[string]string {
+	extra := make(map[string]string)
+	add := func(k, v string) {
+		if keyMod != nil {
+			k = keyMod(k)
+		}
+		extra[k] = v
+	}
+
+	if labels, ok := ctx.Config["labels"]; ok && labels != "" {
+		for _, l := range strings.Split(labels, ",") {
+			if v, ok := ctx.ContainerLabels[l]; ok {
+				add(l, v)
+			}
+		}
+	}
+
+	if env, ok := ctx.Config["env"]; ok && env != "" {
+		selected := make(map[string]bool)
+		for _, e := range strings.Split(env, ",") {
+			selected[e] = true
+		}
+		for _, kv := range ctx.ContainerEnv {
+			if k, v := splitEnv(kv); selected[k] {
+				add(k, v)
+			}
+		}
+	}
+
+	return extra
+}
+
+func splitEnv(kv string) (string, string) {
+	parts := strings.SplitN(kv, "=", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
 func (ctx *Context) Command() string {
 	terms := []string{ctx.ContainerEntrypoint}
 	for _, arg := range ctx.ContainerArgs {
@@ -42,8 +149,10 @@ func (ctx *Context) Command() string {
 }
 
 type logdriverFactory struct {
-	registry map[string]Creator
-	m        sync.Mutex
+	registry     map[string]Creator
+	optValidator map[string]LogOptValidator
+	readers      map[string]ReaderCreator
+	m            sync.Mutex
 }
 
 func (lf *logdriverFactory) register(name string, c Creator) error {
@@ -57,6 +166,46 @@ func (lf *logdriverFactory) register(name string, c Creator) error {
 	return nil
 }
 
+func (lf *logdriverFactory) registerLogOptValidator(name string, l LogOptValidator) error {
+	lf.m.Lock()
+	defer lf.m.Unlock()
+
+	if _, ok := lf.optValidator[name]; ok {
+		return fmt.Errorf("logger: log validator named '%s' is already registered", name)
+	}
+	lf.optValidator[name] = l
+	return nil
+}
+
+func (lf *logdriverFactory) registerLogReader(name string, r ReaderCreator) error {
+	lf.m.Lock()
+	defer lf.m.Unlock()
+
+	if _, ok := lf.readers[name]; ok {
+		return fmt.Errorf("logger: log reader named '%s' is already registered", name)
+	}
+	lf.readers[name] = r
+	return nil
+}
+
+func (lf *logdriverFactory) getLogReader(name string) (ReaderCreator, error) {
+	lf.m.Lock()
+	defer lf.m.Unlock()
+
+	r, ok := lf.readers[name]
+	if !ok {
+		return nil, ReadLogsNotSupported
+	}
+	return r, nil
+}
+
+func (lf *logdriverFactory) getLogOptValidator(name string) LogOptValidator {
+	lf.m.Lock()
+	defer lf.m.Unlock()
+
+	return lf.optValidator[name]
+}
+
 func (lf *logdriverFactory) get(name string) (Creator, error) {
 	lf.m.Lock()
 	defer lf.m.Unlock()
@@ -68,7 +217,7 @@ func (lf *logdriverFactory) get(name string) (Creator, error) {
 	return c, nil
 }
 
-var factory = &logdriverFactory{registry: make(map[string]Creator)} // global factory instance
+var factory = &logdriverFactory{registry: make(map[string]Creator), optValidator: make(map[string]LogOptValidator), readers: make(map[string]ReaderCreator)} // global factory instance
 
 // RegisterLogDriver registers the given logging driver builder with given logging
 // driver name.
@@ -80,3 +229,60 @@ func RegisterLogDriver(name string, c Creator) error {
 func GetLogDriver(name string) (Creator, error) {
 	return factory.get(name)
 }
+
+// RegisterLogOptValidator registers the validator for the options of the
+// logging driver with given name.
+func RegisterLogOptValidator(name string, l LogOptValidator) error {
+	return factory.registerLogOptValidator(name, l)
+}
+
+// RegisterLogReader registers the builder of the readers of the logs
+// written by the logging driver with given name.
+func RegisterLogReader(name string, r ReaderCreator) error {
+	return factory.registerLogReader(name, r)
+}
+
+// GetLogReader provides the builder of the readers of the logs written by
+// the logging driver with given name. It returns ReadLogsNotSupported when
+// the driver can't read its logs back.
+func GetLogReader(name string) (ReaderCreator, error) {
+	return factory.getLogReader(name)
+}
+
+// builtInLogOpts are the options handled by the daemon for all the drivers,
+// they aren't passed to the validators of the drivers.
+var builtInLogOpts = map[string]bool{
+	"mode":            true,
+	"max-buffer-size": true,
+}
+
+// ValidateLogOpts checks the options for the given log driver. Drivers
+// that did not register a validator accept any options. The "tag" option,
+// common to all the drivers, must be a valid template.
+func ValidateLogOpts(name string, cfg map[string]string) error {
+	if name == "none" {
+		return nil
+	}
+	if _, err := GetLogDriver(name); err != nil {
+		return err
+	}
+	if err := validateMode(cfg); err != nil {
+		return err
+	}
+	if tag, ok := cfg["tag"]; ok {
+		if _, err := template.New("tag").Parse(tag); err != nil {
+			return fmt.Errorf("invalid tag %q: %v", tag, err)
+		}
+	}
+	validator := factory.getLogOptValidator(name)
+	if validator == nil {
+		return nil
+	}
+	driverCfg := make(map[string]string, len(cfg))
+	for k, v := range cfg {
+		if !builtInLogOpts[k] {
+			driverCfg[k] = v
+		}
+	}
+	return validator(driverCfg)
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContextExtraAttributes(t *testing.T) {
+	ctx := Context{
+		Config: map[string]string{
+			"labels": "com.example.service,missing",
+			"env":    "STAGE,EMPTY,MISSING",
+		},
+		ContainerLabels: map[string]string{
+			"com.example.service": "web",
+			"other":               "ignored",
+		},
+		ContainerEnv: []string{"PATH=/bin", "STAGE=prod", "EMPTY="},
+	}
+
+	expected := map[string]string{
+		"com.example.service": "web",
+		"STAGE":               "prod",
+		"EMPTY":               "",
+	}
+	if extra := ctx.ExtraAttributes(nil); !reflect.DeepEqual(extra, expected) {
+		t.Fatalf("Expected %v, got %v", expected, extra)
+	}
+
+	expected = map[string]string{
+		"COM.EXAMPLE.SERVICE": "web",
+		"STAGE":               "prod",
+		"EMPTY":               "",
+	}
+	if extra := ctx.ExtraAttributes(strings.ToUpper); !reflect.DeepEqual(extra, expected) {
+		t.Fatalf("Expected %v, got %v", expected, extra)
+	}
+
+	if extra := (&Context{ContainerEnv: ctx.ContainerEnv}).ExtraAttributes(nil); len(extra) != 0 {
+		t.Fatalf("Expected no attributes without options, got %v", extra)
+	}
+}
+
+func TestValidateLogOptsTag(t *testing.T) {
+	if err := ValidateLogOpts("test-mode", map[string]string{"tag": "{{.Name}}"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateLogOpts("test-mode", map[string]string{"tag": "{{.Name"}); err == nil {
+		t.Fatal("Expected an error for an invalid tag template")
+	}
+}
@@ -0,0 +1,332 @@
+package fluentd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
+	"github.com/docker/docker/pkg/units"
+)
+
+const (
+	name = "fluentd"
+
+	defaultHost        = "127.0.0.1"
+	defaultPort        = 24224
+	defaultTagTemplate = "docker.{{.ID}}"
+	defaultBufferLimit = 1024 * 1024
+	defaultRetryWait   = time.Second
+	defaultMaxRetries  = 10
+
+	// maxRetryWait caps the exponential backoff between retries.
+	maxRetryWait = time.Minute
+	dialTimeout  = 5 * time.Second
+	writeTimeout = 10 * time.Second
+)
+
+var errClosed = errors.New("fluentd: logger is closed")
+
+// Fluentd is the logger forwarding the messages to a fluentd collector. The
+// messages are buffered in memory, and sent in the background, so that
+// logging goes on while the collector is down.
+type Fluentd struct {
+	tag           string
+	containerID   string
+	containerName string
+	extra         map[string]string
+	address       string
+	bufferLimit   int
+	retryWait     time.Duration
+	maxRetries    int
+
+	mu      sync.Mutex
+	pending []byte // encoded entries not sent yet
+	dropped int    // messages dropped because the buffer was full
+	closed  bool
+
+	conn    net.Conn      // only used by the sending goroutine
+	flush   chan struct{} // notifies the sending goroutine of new entries
+	closing chan struct{} // closed by Close
+	done    chan struct{} // closed when the sending goroutine returns
+}
+
+func init() {
+	if err := logger.RegisterLogDriver(name, New); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := logger.RegisterLogOptValidator(name, ValidateLogOpt); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// New creates a fluentd logger using the configuration passed in on the
+// context. The collector doesn't need to be up, the messages are sent once
+// it can be reached.
+func New(ctx logger.Context) (logger.Logger, error) {
+	address, err := parseAddress(ctx.Config["fluentd-address"])
+	if err != nil {
+		return nil, err
+	}
+	tag, err := loggerutils.ParseLogTag(ctx, defaultTagTemplate)
+	if err != nil {
+		return nil, err
+	}
+	bufferLimit, retryWait, maxRetries, err := parseBufferOpts(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Fluentd{
+		tag:           tag,
+		containerID:   ctx.ContainerID,
+		containerName: ctx.ContainerName,
+		extra:         ctx.ExtraAttributes(nil),
+		address:       address,
+		bufferLimit:   bufferLimit,
+		retryWait:     retryWait,
+		maxRetries:    maxRetries,
+		flush:         make(chan struct{}, 1),
+		closing:       make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go f.run()
+	return f, nil
+}
+
+// Log encodes the message and queues it for sending. The message is
+// dropped, and counted, when the buffer of the messages not sent yet is
+// full.
+func (f *Fluentd) Log(msg *logger.Message) error {
+	record := make(map[string]string, len(f.extra)+4)
+	for k, v := range f.extra {
+		record[k] = v
+	}
+	record["container_id"] = f.containerID
+	record["container_name"] = f.containerName
+	record["source"] = msg.Source
+	record["log"] = string(msg.Line)
+	entry := appendEntry(nil, f.tag, msg.Timestamp.Unix(), record)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return errClosed
+	}
+	if len(f.pending)+len(entry) > f.bufferLimit {
+		if f.dropped++; f.dropped == 1 {
+			logrus.Warnf("fluentd: the buffer of container %s is full, dropping messages until %s can be reached", f.containerID, f.address)
+		}
+		return nil
+	}
+	f.pending = append(f.pending, entry...)
+	select {
+	case f.flush <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// run sends the pending entries until the logger is closed.
+func (f *Fluentd) run() {
+	defer close(f.done)
+	for {
+		select {
+		case <-f.flush:
+			f.send(false)
+		case <-f.closing:
+			f.send(true)
+			if f.conn != nil {
+				f.conn.Close()
+			}
+			return
+		}
+	}
+}
+
+// send writes the pending entries to the collector, reconnecting with an
+// exponential backoff while it is down. The entries are dropped after
+// maxRetries failed attempts, or after the first one once closing.
+func (f *Fluentd) send(closing bool) {
+	var (
+		retries int
+		wait    = f.retryWait
+	)
+	for {
+		f.mu.Lock()
+		data := f.pending
+		f.mu.Unlock()
+		if len(data) == 0 {
+			return
+		}
+
+		err := f.write(data)
+		if err == nil {
+			f.discard(len(data))
+			retries, wait = 0, f.retryWait
+			continue
+		}
+		retries++
+		if closing || retries > f.maxRetries {
+			logrus.Errorf("fluentd: dropping %d bytes of logs of container %s after %d attempts: %v", len(data), f.containerID, retries, err)
+			f.discard(len(data))
+			if closing {
+				return
+			}
+			retries, wait = 0, f.retryWait
+			continue
+		}
+		logrus.Debugf("fluentd: cannot send logs to %s, retrying in %s: %v", f.address, wait, err)
+
+		select {
+		case <-time.After(wait):
+		case <-f.closing:
+			closing = true
+		}
+		if wait *= 2; wait > maxRetryWait {
+			wait = maxRetryWait
+		}
+	}
+}
+
+// write writes data to the collector, connecting to it first if needed.
+func (f *Fluentd) write(data []byte) error {
+	if f.conn == nil {
+		conn, err := net.DialTimeout("tcp", f.address, dialTimeout)
+		if err != nil {
+			return err
+		}
+		f.conn = conn
+	}
+	f.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := f.conn.Write(data); err != nil {
+		f.conn.Close()
+		f.conn = nil
+		return err
+	}
+	return nil
+}
+
+// discard removes the first n bytes of the pending entries.
+func (f *Fluentd) discard(n int) {
+	f.mu.Lock()
+	f.pending = append([]byte(nil), f.pending[n:]...)
+	f.mu.Unlock()
+}
+
+// Close sends the pending entries, making a last attempt if the collector
+// is down, and closes the connection.
+func (f *Fluentd) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	f.mu.Unlock()
+
+	close(f.closing)
+	<-f.done
+	if dropped := f.Dropped(); dropped > 0 {
+		logrus.Warnf("fluentd: dropped %d log messages of container %s, the buffer was full", dropped, f.containerID)
+	}
+	return nil
+}
+
+// Dropped returns the number of messages dropped because the buffer was
+// full.
+func (f *Fluentd) Dropped() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dropped
+}
+
+// Name returns the name of the logger.
+func (f *Fluentd) Name() string {
+	return name
+}
+
+// ValidateLogOpt looks for the fluentd specific log options.
+func ValidateLogOpt(cfg map[string]string) error {
+	for key := range cfg {
+		switch key {
+		case "tag":
+		case "labels":
+		case "env":
+		case "fluentd-address":
+		case "fluentd-tag":
+		case "fluentd-buffer-limit":
+		case "fluentd-retry-wait":
+		case "fluentd-max-retries":
+		default:
+			return fmt.Errorf("unknown log opt '%s' for fluentd log driver", key)
+		}
+	}
+	if _, err := parseAddress(cfg["fluentd-address"]); err != nil {
+		return err
+	}
+	if _, err := template.New("tag").Parse(cfg["fluentd-tag"]); err != nil {
+		return fmt.Errorf("invalid fluentd-tag %q: %v", cfg["fluentd-tag"], err)
+	}
+	_, _, _, err := parseBufferOpts(cfg)
+	return err
+}
+
+// parseAddress returns the host:port address of the collector, the host
+// and the port being optional.
+func parseAddress(address string) (string, error) {
+	if address == "" {
+		return net.JoinHostPort(defaultHost, strconv.Itoa(defaultPort)), nil
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		if !strings.Contains(err.Error(), "missing port in address") {
+			return "", fmt.Errorf("invalid fluentd-address %q: %v", address, err)
+		}
+		host, port = address, strconv.Itoa(defaultPort)
+	}
+	if host == "" {
+		host = defaultHost
+	}
+	if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+		return "", fmt.Errorf("invalid fluentd-address %q: invalid port %q", address, port)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// parseBufferOpts reads the "fluentd-buffer-limit", "fluentd-retry-wait"
+// and "fluentd-max-retries" options.
+func parseBufferOpts(cfg map[string]string) (int, time.Duration, int, error) {
+	bufferLimit := defaultBufferLimit
+	if s, ok := cfg["fluentd-buffer-limit"]; ok {
+		size, err := units.RAMInBytes(s)
+		if err != nil || size <= 0 {
+			return 0, 0, 0, fmt.Errorf("invalid fluentd-buffer-limit %q", s)
+		}
+		bufferLimit = int(size)
+	}
+	retryWait := defaultRetryWait
+	if s, ok := cfg["fluentd-retry-wait"]; ok {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return 0, 0, 0, fmt.Errorf("invalid fluentd-retry-wait %q", s)
+		}
+		retryWait = d
+	}
+	maxRetries := defaultMaxRetries
+	if s, ok := cfg["fluentd-max-retries"]; ok {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return 0, 0, 0, fmt.Errorf("invalid fluentd-max-retries %q", s)
+		}
+		maxRetries = n
+	}
+	return bufferLimit, retryWait, maxRetries, nil
+}
@@ -0,0 +1,326 @@
+package fluentd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+)
+
+type entry struct {
+	tag    string
+	time   uint64
+	record map[string]string
+}
+
+// forwardServer is a stand-in fluentd collector, decoding the entries sent
+// with the forward protocol.
+type forwardServer struct {
+	l       net.Listener
+	entries chan entry
+}
+
+func newForwardServer(t *testing.T, address string) *forwardServer {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &forwardServer{l: l, entries: make(chan entry, 100)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *forwardServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		e, err := decodeEntry(r)
+		if err != nil {
+			return
+		}
+		s.entries <- e
+	}
+}
+
+func (s *forwardServer) next(t *testing.T) entry {
+	select {
+	case e := <-s.entries:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for an entry")
+	}
+	return entry{}
+}
+
+func (s *forwardServer) Close() {
+	s.l.Close()
+}
+
+func decodeEntry(r *bufio.Reader) (entry, error) {
+	var e entry
+	b, err := r.ReadByte()
+	if err != nil {
+		return e, err
+	}
+	if b != 0x93 {
+		return e, fmt.Errorf("expected an array of 3 elements, got %#x", b)
+	}
+	if e.tag, err = decodeString(r); err != nil {
+		return e, err
+	}
+	if e.time, err = decodeUint(r); err != nil {
+		return e, err
+	}
+	b, err = r.ReadByte()
+	if err != nil {
+		return e, err
+	}
+	var n int
+	switch {
+	case b&0xf0 == 0x80:
+		n = int(b & 0x0f)
+	case b == 0xde:
+		var l uint16
+		if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+			return e, err
+		}
+		n = int(l)
+	default:
+		return e, fmt.Errorf("expected a map, got %#x", b)
+	}
+	e.record = make(map[string]string)
+	for i := 0; i < n; i++ {
+		k, err := decodeString(r)
+		if err != nil {
+			return e, err
+		}
+		if e.record[k], err = decodeString(r); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
+}
+
+func decodeString(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	var n int
+	switch {
+	case b&0xe0 == 0xa0:
+		n = int(b & 0x1f)
+	case b == 0xd9:
+		l, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		n = int(l)
+	case b == 0xda:
+		var l uint16
+		if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+			return "", err
+		}
+		n = int(l)
+	case b == 0xdb:
+		var l uint32
+		if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+			return "", err
+		}
+		n = int(l)
+	default:
+		return "", fmt.Errorf("expected a string, got %#x", b)
+	}
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	return string(buf), err
+}
+
+func decodeUint(r *bufio.Reader) (uint64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case b < 0x80:
+		return uint64(b), nil
+	case b == 0xce:
+		var u uint32
+		err := binary.Read(r, binary.BigEndian, &u)
+		return uint64(u), err
+	case b == 0xcf:
+		var u uint64
+		err := binary.Read(r, binary.BigEndian, &u)
+		return u, err
+	}
+	return 0, fmt.Errorf("expected an unsigned integer, got %#x", b)
+}
+
+// freeAddress returns an address nothing listens on.
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+const cid = "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+
+func TestFluentdLog(t *testing.T) {
+	s := newForwardServer(t, "127.0.0.1:0")
+	defer s.Close()
+
+	l, err := New(logger.Context{
+		ContainerID:     cid,
+		ContainerName:   "/test-container",
+		ContainerLabels: map[string]string{"service": "web", "log": "overridden"},
+		Config: map[string]string{
+			"fluentd-address": s.l.Addr().String(),
+			"tag":             "docker.{{.Name}}.{{.ID}}",
+			"labels":          "service,log",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	now := time.Now()
+	long := strings.Repeat("x", 300)
+	if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte("line1"), Source: "stdout", Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte(long), Source: "stderr", Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	e := s.next(t)
+	if e.tag != "docker.test-container.a7317399f3f8" {
+		t.Fatalf("Wrong tag %q", e.tag)
+	}
+	if e.time != uint64(now.Unix()) {
+		t.Fatalf("Wrong time %d, expected %d", e.time, now.Unix())
+	}
+	if e.record["log"] != "line1" || e.record["source"] != "stdout" || e.record["container_id"] != cid || e.record["container_name"] != "/test-container" || e.record["service"] != "web" {
+		t.Fatalf("Wrong record %v", e.record)
+	}
+	if e = s.next(t); e.record["log"] != long || e.record["source"] != "stderr" {
+		t.Fatalf("Wrong record %v", e.record)
+	}
+}
+
+func TestFluentdBufferWhileDown(t *testing.T) {
+	address := freeAddress(t)
+	l, err := New(logger.Context{
+		ContainerID: cid,
+		Config: map[string]string{
+			"fluentd-address":    address,
+			"fluentd-retry-wait": "10ms",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte(fmt.Sprintf("line%d", i)), Source: "stdout"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	s := newForwardServer(t, address)
+	defer s.Close()
+	for i := 0; i < 3; i++ {
+		e := s.next(t)
+		if e.tag != "docker.a7317399f3f8" {
+			t.Fatalf("Wrong tag %q", e.tag)
+		}
+		if expected := fmt.Sprintf("line%d", i); e.record["log"] != expected {
+			t.Fatalf("Wrong log %q, expected %q", e.record["log"], expected)
+		}
+	}
+}
+
+func TestFluentdBufferLimit(t *testing.T) {
+	l, err := New(logger.Context{
+		ContainerID: cid,
+		Config: map[string]string{
+			"fluentd-address":      freeAddress(t),
+			"fluentd-buffer-limit": "1k",
+			"fluentd-retry-wait":   "1h",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &logger.Message{ContainerID: cid, Line: []byte(strings.Repeat("x", 100)), Source: "stdout"}
+	for i := 0; i < 20; i++ {
+		if err := l.Log(msg); err != nil {
+			t.Fatalf("Expected the messages to be dropped without error, got %v", err)
+		}
+	}
+	if dropped := l.(*Fluentd).Dropped(); dropped == 20 || dropped == 0 {
+		t.Fatalf("Expected the buffer to be full after a few messages, got %d messages dropped", dropped)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		l.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(dialTimeout + time.Second):
+		t.Fatal("Close should not wait for the retries")
+	}
+	if err := l.Log(msg); err != errClosed {
+		t.Fatalf("Expected %v, got %v", errClosed, err)
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	valid := []map[string]string{
+		{},
+		{"fluentd-address": "localhost"},
+		{"fluentd-address": "127.0.0.1:24225", "fluentd-tag": "app.{{.Name}}"},
+		{"tag": "app.{{.Name}}", "labels": "service", "env": "STAGE"},
+		{"fluentd-buffer-limit": "8m", "fluentd-retry-wait": "500ms", "fluentd-max-retries": "0"},
+	}
+	for _, cfg := range valid {
+		if err := ValidateLogOpt(cfg); err != nil {
+			t.Fatalf("expected %v to be valid, got %v", cfg, err)
+		}
+	}
+	invalid := []map[string]string{
+		{"fluentd-address": "localhost:port"},
+		{"fluentd-tag": "{{.ID"},
+		{"fluentd-buffer-limit": "0"},
+		{"fluentd-retry-wait": "1"},
+		{"fluentd-max-retries": "-1"},
+		{"max-size": "10m"},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateLogOpt(cfg); err == nil {
+			t.Fatalf("expected %v to be invalid", cfg)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package fluentd
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// The forward protocol of fluentd is MessagePack over TCP. Only the few
+// types used by the entries are encoded here: see
+// https://github.com/msgpack/msgpack/blob/master/spec.md
+
+// appendEntry appends the MessagePack encoding of the forward protocol
+// entry [tag, time, record] to b.
+func appendEntry(b []byte, tag string, time int64, record map[string]string) []byte {
+	b = append(b, 0x93) // fixarray of 3 elements
+	b = appendString(b, tag)
+	b = appendUint(b, uint64(time))
+	return appendStringMap(b, record)
+}
+
+func appendString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n < 1<<8:
+		b = append(b, 0xd9, byte(n))
+	case n < 1<<16:
+		b = append(b, 0xda, 0, 0)
+		binary.BigEndian.PutUint16(b[len(b)-2:], uint16(n))
+	default:
+		b = append(b, 0xdb, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[len(b)-4:], uint32(n))
+	}
+	return append(b, s...)
+}
+
+func appendUint(b []byte, u uint64) []byte {
+	switch {
+	case u < 1<<7:
+		return append(b, byte(u))
+	case u < 1<<32:
+		b = append(b, 0xce, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[len(b)-4:], uint32(u))
+	default:
+		b = append(b, 0xcf, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(b[len(b)-8:], u)
+	}
+	return b
+}
+
+// appendStringMap appends m with its keys sorted, so that the encoding of a
+// record is always the same.
+func appendStringMap(b []byte, m map[string]string) []byte {
+	n := len(m)
+	if n < 16 {
+		b = append(b, 0x80|byte(n))
+	} else {
+		b = append(b, 0xde, 0, 0)
+		binary.BigEndian.PutUint16(b[len(b)-2:], uint16(n))
+	}
+	keys := make([]string, 0, n)
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b = appendString(b, k)
+		b = appendString(b, m[k])
+	}
+	return b
+}
@@ -5,7 +5,6 @@ package gelf
 import (
 	"bytes"
 	"fmt"
-	"io"
 	"net"
 	"net/url"
 	"time"
@@ -13,6 +12,7 @@ import (
 	"github.com/Graylog2/go-gelf/gelf"
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
 	"github.com/docker/docker/pkg/urlutil"
 )
 
@@ -22,6 +22,7 @@ type GelfLogger struct {
 	writer *gelf.Writer
 	ctx    logger.Context
 	fields GelfFields
+	extra  map[string]string
 }
 
 type GelfFields struct {
@@ -57,6 +58,11 @@ func New(ctx logger.Context) (logger.Logger, error) {
 	// remove trailing slash from container name
 	containerName := bytes.TrimLeft([]byte(ctx.ContainerName), "/")
 
+	tag, err := loggerutils.ParseLogTag(ctx, loggerutils.DefaultTemplate)
+	if err != nil {
+		return nil, err
+	}
+
 	fields := GelfFields{
 		hostname:      hostname,
 		containerId:   ctx.ContainerID,
@@ -64,7 +70,7 @@ func New(ctx logger.Context) (logger.Logger, error) {
 		imageId:       ctx.ContainerImageID,
 		imageName:     ctx.ContainerImageName,
 		command:       ctx.Command(),
-		tag:           ctx.Config["gelf-tag"],
+		tag:           tag,
 		created:       ctx.ContainerCreated,
 	}
 
@@ -78,6 +84,7 @@ func New(ctx logger.Context) (logger.Logger, error) {
 		writer: gelfWriter,
 		ctx:    ctx,
 		fields: fields,
+		extra:  ctx.ExtraAttributes(func(key string) string { return "_" + key }),
 	}, nil
 }
 
@@ -106,6 +113,11 @@ func (s *GelfLogger) Log(msg *logger.Message) error {
 			"_created":        s.fields.created,
 		},
 	}
+	for k, v := range s.extra {
+		if _, ok := m.Extra[k]; !ok {
+			m.Extra[k] = v
+		}
+	}
 
 	if err := s.writer.WriteMessage(&m); err != nil {
 		return fmt.Errorf("gelf: cannot send GELF message: %v", err)
@@ -113,10 +125,6 @@ func (s *GelfLogger) Log(msg *logger.Message) error {
 	return nil
 }
 
-func (s *GelfLogger) GetReader() (io.Reader, error) {
-	return nil, logger.ReadLogsNotSupported
-}
-
 func (s *GelfLogger) Close() error {
 	return s.writer.Close()
 }
@@ -4,17 +4,28 @@ package journald
 
 import (
 	"fmt"
-	"io"
+	"strings"
+	"sync"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/coreos/go-systemd/journal"
 	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
 )
 
 const name = "journald"
 
 type Journald struct {
-	Jmap map[string]string
+	Jmap    map[string]string
+	readers readerList
+}
+
+// readerList is the list of the watchers following the logs, they are
+// closed when the logger is closed.
+type readerList struct {
+	mu      sync.Mutex
+	closed  bool
+	readers map[*logger.LogWatcher]struct{}
 }
 
 func init() {
@@ -33,11 +44,35 @@ func New(ctx logger.Context) (logger.Logger, error) {
 	if name[0] == '/' {
 		name = name[1:]
 	}
-	jmap := map[string]string{
-		"CONTAINER_ID":      ctx.ContainerID[:12],
-		"CONTAINER_ID_FULL": ctx.ContainerID,
-		"CONTAINER_NAME":    name}
-	return &Journald{Jmap: jmap}, nil
+	tag, err := loggerutils.ParseLogTag(ctx, loggerutils.DefaultTemplate)
+	if err != nil {
+		return nil, err
+	}
+	jmap := ctx.ExtraAttributes(sanitizeKeyMod)
+	jmap["CONTAINER_ID"] = ctx.ContainerID[:12]
+	jmap["CONTAINER_ID_FULL"] = ctx.ContainerID
+	jmap["CONTAINER_NAME"] = name
+	jmap["CONTAINER_TAG"] = tag
+	return &Journald{
+		Jmap:    jmap,
+		readers: readerList{readers: make(map[*logger.LogWatcher]struct{})},
+	}, nil
+}
+
+// sanitizeKeyMod makes the keys of the extra attributes valid journal field
+// names: upper case letters, digits and underscores, not starting with an
+// underscore, which is reserved for the trusted fields.
+func sanitizeKeyMod(key string) string {
+	key = strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z':
+			return r - 'a' + 'A'
+		case 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+	return strings.TrimLeft(key, "_")
 }
 
 func (s *Journald) Log(msg *logger.Message) error {
@@ -47,14 +82,17 @@ func (s *Journald) Log(msg *logger.Message) error {
 	return journal.Send(string(msg.Line), journal.PriInfo, s.Jmap)
 }
 
+// Close ends the readers following the logs.
 func (s *Journald) Close() error {
+	s.readers.mu.Lock()
+	s.readers.closed = true
+	for r := range s.readers.readers {
+		r.Close()
+	}
+	s.readers.mu.Unlock()
 	return nil
 }
 
 func (s *Journald) Name() string {
 	return name
 }
-
-func (s *Journald) GetReader() (io.Reader, error) {
-	return nil, logger.ReadLogsNotSupported
-}
@@ -0,0 +1,297 @@
+// +build linux,cgo,!static_build,journald
+
+package journald
+
+// #cgo pkg-config: libsystemd-journal
+// #include <sys/types.h>
+// #include <sys/poll.h>
+// #include <systemd/sd-journal.h>
+// #include <errno.h>
+// #include <stdlib.h>
+// #include <string.h>
+// #include <time.h>
+// #include <unistd.h>
+//
+// static int get_message(sd_journal *j, const char **msg, size_t *length)
+// {
+// 	int rc;
+// 	*msg = NULL;
+// 	*length = 0;
+// 	rc = sd_journal_get_data(j, "MESSAGE", (const void **) msg, length);
+// 	if (rc == 0) {
+// 		if (*length > 8) {
+// 			(*msg) += 8;
+// 			*length -= 8;
+// 		} else {
+// 			*msg = NULL;
+// 			*length = 0;
+// 			rc = -ENOENT;
+// 		}
+// 	}
+// 	return rc;
+// }
+//
+// static int get_priority(sd_journal *j, int *priority)
+// {
+// 	const void *data;
+// 	size_t i, length;
+// 	int rc;
+// 	*priority = -1;
+// 	rc = sd_journal_get_data(j, "PRIORITY", &data, &length);
+// 	if (rc == 0) {
+// 		if ((length > 9) && (strncmp(data, "PRIORITY=", 9) == 0)) {
+// 			*priority = 0;
+// 			for (i = 9; i < length; i++) {
+// 				*priority = *priority * 10 + ((const char *)data)[i] - '0';
+// 			}
+// 		} else {
+// 			rc = -ENOENT;
+// 		}
+// 	}
+// 	return rc;
+// }
+//
+// /* wait_for_data_or_close returns 1 when entries were appended to the
+//  * journal, 0 when the other end of pipefd was closed, and a negative
+//  * value on errors. */
+// static int wait_for_data_or_close(sd_journal *j, int pipefd)
+// {
+// 	struct pollfd fds[2];
+// 	uint64_t when = 0, now;
+// 	struct timespec ts;
+// 	int timeout, jevents, rc;
+// 	for (;;) {
+// 		memset(&fds, 0, sizeof(fds));
+// 		fds[0].fd = pipefd;
+// 		fds[0].events = POLLHUP;
+// 		fds[1].fd = sd_journal_get_fd(j);
+// 		if (fds[1].fd < 0) {
+// 			return fds[1].fd;
+// 		}
+// 		jevents = sd_journal_get_events(j);
+// 		if (jevents < 0) {
+// 			return jevents;
+// 		}
+// 		fds[1].events = jevents;
+// 		sd_journal_get_timeout(j, &when);
+// 		if (when == (uint64_t) -1) {
+// 			timeout = -1;
+// 		} else {
+// 			clock_gettime(CLOCK_MONOTONIC, &ts);
+// 			now = (uint64_t) ts.tv_sec * 1000000 + ts.tv_nsec / 1000;
+// 			timeout = when > now ? (int) ((when - now + 999) / 1000) : 0;
+// 		}
+// 		if (poll(fds, 2, timeout) == -1 && errno != EINTR) {
+// 			return -errno;
+// 		}
+// 		if (fds[0].revents & POLLHUP) {
+// 			return 0;
+// 		}
+// 		rc = sd_journal_process(j);
+// 		if (rc < 0) {
+// 			return rc;
+// 		}
+// 		if (rc == SD_JOURNAL_APPEND) {
+// 			return 1;
+// 		}
+// 	}
+// }
+import "C"
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/coreos/go-systemd/journal"
+	"github.com/docker/docker/daemon/logger"
+)
+
+func init() {
+	if err := logger.RegisterLogReader(name, NewReader); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// NewReader returns a reader of the journal entries of a container which
+// isn't running. Following the logs stops at the end of the journal.
+func NewReader(ctx logger.Context) (logger.LogReader, error) {
+	return &Journald{
+		Jmap:    map[string]string{"CONTAINER_ID_FULL": ctx.ContainerID},
+		readers: readerList{closed: true},
+	}, nil
+}
+
+// ReadLogs implements the logger.LogReader interface, querying the journal
+// for the entries of the container, by CONTAINER_ID_FULL.
+func (s *Journald) ReadLogs(config logger.ReadConfig) *logger.LogWatcher {
+	logWatcher := logger.NewLogWatcher()
+	go s.readLogs(logWatcher, config)
+	return logWatcher
+}
+
+func (s *Journald) readLogs(logWatcher *logger.LogWatcher, config logger.ReadConfig) {
+	defer close(logWatcher.Msg)
+
+	var j *C.sd_journal
+	if rc := C.sd_journal_open(&j, C.int(0)); rc != 0 {
+		logWatcher.Err <- fmt.Errorf("error opening journal: %s", strerror(rc))
+		return
+	}
+	defer C.sd_journal_close(j)
+
+	// Remove limits on the size of the data items read.
+	if rc := C.sd_journal_set_data_threshold(j, C.size_t(0)); rc != 0 {
+		logWatcher.Err <- fmt.Errorf("error setting journal data threshold: %s", strerror(rc))
+		return
+	}
+	// Let the library look up the entries of the container.
+	match := C.CString("CONTAINER_ID_FULL=" + s.Jmap["CONTAINER_ID_FULL"])
+	defer C.free(unsafe.Pointer(match))
+	if rc := C.sd_journal_add_match(j, unsafe.Pointer(match), C.strlen(match)); rc != 0 {
+		logWatcher.Err <- fmt.Errorf("error setting journal match: %s", strerror(rc))
+		return
+	}
+
+	var sinceUsec C.uint64_t
+	if !config.Since.IsZero() {
+		sinceUsec = C.uint64_t(config.Since.UnixNano() / 1000)
+	}
+
+	if config.Tail >= 0 {
+		// Start at the end of the journal, and walk backward to the entry
+		// before the first one to send, stopping early at the since time.
+		if rc := C.sd_journal_seek_tail(j); rc < 0 {
+			logWatcher.Err <- fmt.Errorf("error seeking to end of journal: %s", strerror(rc))
+			return
+		}
+		var stamp C.uint64_t
+		for i := 0; i <= config.Tail; i++ {
+			if C.sd_journal_previous(j) <= 0 {
+				// The start of the journal was reached.
+				C.sd_journal_seek_head(j)
+				break
+			}
+			if sinceUsec != 0 && C.sd_journal_get_realtime_usec(j, &stamp) == 0 && stamp < sinceUsec {
+				break
+			}
+		}
+	} else {
+		// Start at the beginning of the journal, or at the since time.
+		if rc := C.sd_journal_seek_head(j); rc < 0 {
+			logWatcher.Err <- fmt.Errorf("error seeking to start of journal: %s", strerror(rc))
+			return
+		}
+		if sinceUsec != 0 {
+			if rc := C.sd_journal_seek_realtime_usec(j, sinceUsec); rc < 0 {
+				logWatcher.Err <- fmt.Errorf("error seeking to start time in journal: %s", strerror(rc))
+				return
+			}
+		}
+	}
+
+	if !s.drainJournal(logWatcher, config, j) || !config.Follow {
+		return
+	}
+	s.followJournal(logWatcher, config, j)
+}
+
+// drainJournal sends the entries from the one after the current position
+// to the end of the journal. It returns false when the watcher was closed.
+func (s *Journald) drainJournal(logWatcher *logger.LogWatcher, config logger.ReadConfig, j *C.sd_journal) bool {
+	var (
+		msg      *C.char
+		length   C.size_t
+		stamp    C.uint64_t
+		priority C.int
+	)
+	for C.sd_journal_next(j) > 0 {
+		if C.get_message(j, &msg, &length) != 0 {
+			continue
+		}
+		if C.sd_journal_get_realtime_usec(j, &stamp) != 0 {
+			continue
+		}
+		timestamp := time.Unix(int64(stamp)/1000000, (int64(stamp)%1000000)*1000)
+		if timestamp.Before(config.Since) {
+			continue
+		}
+		line := append(C.GoBytes(unsafe.Pointer(msg), C.int(length)), '\n')
+		// Map the priority back to the stream it was assigned from.
+		source := ""
+		if C.get_priority(j, &priority) == 0 {
+			switch priority {
+			case C.int(journal.PriErr):
+				source = "stderr"
+			case C.int(journal.PriInfo):
+				source = "stdout"
+			}
+		}
+		select {
+		case logWatcher.Msg <- &logger.Message{
+			ContainerID: s.Jmap["CONTAINER_ID_FULL"],
+			Line:        line,
+			Source:      source,
+			Timestamp:   timestamp,
+		}:
+		case <-logWatcher.WatchClose():
+			return false
+		}
+	}
+	return true
+}
+
+// followJournal sends the entries appended to the journal until the
+// watcher is closed, either by its consumer or by closing the logger.
+func (s *Journald) followJournal(logWatcher *logger.LogWatcher, config logger.ReadConfig, j *C.sd_journal) {
+	// The reading end of the pipe is polled together with the journal, it
+	// is notified when the writing end is closed.
+	var pipe [2]C.int
+	if C.pipe(&pipe[0]) != 0 {
+		logWatcher.Err <- fmt.Errorf("error opening journald close notification pipe")
+		return
+	}
+	defer C.close(pipe[0])
+
+	s.readers.mu.Lock()
+	if s.readers.closed {
+		s.readers.mu.Unlock()
+		C.close(pipe[1])
+		return
+	}
+	s.readers.readers[logWatcher] = struct{}{}
+	s.readers.mu.Unlock()
+	defer func() {
+		s.readers.mu.Lock()
+		delete(s.readers.readers, logWatcher)
+		s.readers.mu.Unlock()
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-logWatcher.WatchClose():
+		case <-done:
+		}
+		C.close(pipe[1])
+	}()
+
+	for {
+		rc := C.wait_for_data_or_close(j, pipe[0])
+		if rc < 0 {
+			logrus.Errorf("Error following journal for container %s: %s", s.Jmap["CONTAINER_ID_FULL"], strerror(rc))
+			return
+		}
+		if rc == 0 || !s.drainJournal(logWatcher, config, j) {
+			return
+		}
+	}
+}
+
+// strerror returns the message of the negative errno values returned by
+// the sd_journal functions.
+func strerror(rc C.int) string {
+	return C.GoString(C.strerror(-rc))
+}
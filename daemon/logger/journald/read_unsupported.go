@@ -0,0 +1,25 @@
+// +build linux,!cgo linux,static_build linux,!journald
+
+package journald
+
+import (
+	"errors"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger"
+)
+
+// errReadNotSupported is returned when reading the logs of a container,
+// the journal can only be read with libsystemd-journal.
+var errReadNotSupported = errors.New("reading journald logs not supported in this build")
+
+func init() {
+	if err := logger.RegisterLogReader(name, NewReader); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// NewReader returns errReadNotSupported.
+func NewReader(ctx logger.Context) (logger.LogReader, error) {
+	return nil, errReadNotSupported
+}
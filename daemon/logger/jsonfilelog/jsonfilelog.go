@@ -2,14 +2,17 @@ package jsonfilelog
 
 import (
 	"bytes"
-	"io"
+	"fmt"
 	"os"
+	"strconv"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
 	"github.com/docker/docker/pkg/jsonlog"
 	"github.com/docker/docker/pkg/timeutils"
+	"github.com/docker/docker/pkg/units"
 )
 
 const (
@@ -19,9 +22,14 @@ const (
 // JSONFileLogger is Logger implementation for default docker logging:
 // JSON objects to file
 type JSONFileLogger struct {
-	buf *bytes.Buffer
-	f   *os.File   // store for closing
-	mu  sync.Mutex // protects buffer
+	buf      *bytes.Buffer
+	f        *os.File          // store for closing
+	mu       sync.Mutex        // protects buffer
+	capacity int64             // maximum size of each file, -1 means unlimited
+	n        int               // maximum number of files
+	written  chan struct{}     // closed and replaced after each write, for readers following the logs
+	closed   chan struct{}     // closed when the logger is closed
+	extra    map[string]string // tag, labels and environment variables written with each message
 
 	ctx logger.Context
 }
@@ -30,21 +38,92 @@ func init() {
 	if err := logger.RegisterLogDriver(Name, New); err != nil {
 		logrus.Fatal(err)
 	}
+	if err := logger.RegisterLogOptValidator(Name, ValidateLogOpt); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := logger.RegisterLogReader(Name, NewReader); err != nil {
+		logrus.Fatal(err)
+	}
 }
 
 // New creates new JSONFileLogger which writes to filename
 func New(ctx logger.Context) (logger.Logger, error) {
+	capacity, maxFiles, err := parseRotateOpts(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
+	extra := ctx.ExtraAttributes(nil)
+	// The tag is only written when set, json-file has no default one.
+	if _, ok := ctx.Config["tag"]; ok {
+		tag, err := loggerutils.ParseLogTag(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		extra["tag"] = tag
+	}
 	log, err := os.OpenFile(ctx.LogPath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
 	if err != nil {
 		return nil, err
 	}
 	return &JSONFileLogger{
-		f:   log,
-		buf: bytes.NewBuffer(nil),
-		ctx: ctx,
+		f:        log,
+		buf:      bytes.NewBuffer(nil),
+		capacity: capacity,
+		n:        maxFiles,
+		written:  make(chan struct{}),
+		closed:   make(chan struct{}),
+		extra:    extra,
+		ctx:      ctx,
 	}, nil
 }
 
+// parseRotateOpts reads the "max-size" and "max-file" options.
+func parseRotateOpts(cfg map[string]string) (int64, int, error) {
+	var capacity int64 = -1
+	if size, ok := cfg["max-size"]; ok {
+		var err error
+		capacity, err = units.FromHumanSize(size)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid max-size %q: %v", size, err)
+		}
+		if capacity <= 0 {
+			return 0, 0, fmt.Errorf("max-size must be a positive size, got %q", size)
+		}
+	}
+	maxFiles := 1
+	if files, ok := cfg["max-file"]; ok {
+		var err error
+		maxFiles, err = strconv.Atoi(files)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid max-file %q: %v", files, err)
+		}
+		if maxFiles < 1 {
+			return 0, 0, fmt.Errorf("max-file cannot be less than 1")
+		}
+		if capacity == -1 && maxFiles > 1 {
+			return 0, 0, fmt.Errorf("max-file cannot be set without max-size")
+		}
+	}
+	return capacity, maxFiles, nil
+}
+
+// ValidateLogOpt looks for json specific log options max-file & max-size.
+func ValidateLogOpt(cfg map[string]string) error {
+	for key := range cfg {
+		switch key {
+		case "max-file":
+		case "max-size":
+		case "labels":
+		case "env":
+		case "tag":
+		default:
+			return fmt.Errorf("unknown log opt '%s' for json-file log driver", key)
+		}
+	}
+	_, _, err := parseRotateOpts(cfg)
+	return err
+}
+
 // Log converts logger.Message to jsonlog.JSONLog and serializes it to file
 func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	l.mu.Lock()
@@ -54,30 +133,101 @@ func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	if err != nil {
 		return err
 	}
-	err = (&jsonlog.JSONLogBytes{Log: append(msg.Line, '\n'), Stream: msg.Source, Created: timestamp}).MarshalJSONBuf(l.buf)
+	err = (&jsonlog.JSONLogBytes{Log: append(msg.Line, '\n'), Stream: msg.Source, Created: timestamp, Attrs: l.extra}).MarshalJSONBuf(l.buf)
 	if err != nil {
 		return err
 	}
 	l.buf.WriteByte('\n')
+	if err := l.rotateIfNeeded(int64(l.buf.Len())); err != nil {
+		l.buf.Reset()
+		return err
+	}
 	_, err = l.buf.WriteTo(l.f)
 	if err != nil {
 		// this buffer is screwed, replace it with another to avoid races
 		l.buf = bytes.NewBuffer(nil)
 		return err
 	}
+	close(l.written)
+	l.written = make(chan struct{})
+	return nil
+}
+
+// rotateIfNeeded rotates the log files if writing pending more bytes would
+// make the current file exceed the configured capacity. Must be called with
+// l.mu held.
+func (l *JSONFileLogger) rotateIfNeeded(pending int64) error {
+	if l.capacity == -1 {
+		return nil
+	}
+	meta, err := l.f.Stat()
+	if err != nil {
+		return err
+	}
+	if meta.Size() == 0 || meta.Size()+pending <= l.capacity {
+		return nil
+	}
+
+	name := l.f.Name()
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if err := rotate(name, l.n); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	l.f = file
 	return nil
 }
 
-func (l *JSONFileLogger) GetReader() (io.Reader, error) {
-	return os.Open(l.ctx.LogPath)
+// rotate shifts name.1 ... name.(n-2) one position up, dropping the oldest
+// file, and moves name to name.1. With n < 2 the current file is simply
+// truncated by the caller.
+func rotate(name string, n int) error {
+	if n < 2 {
+		return nil
+	}
+	for i := n - 1; i > 1; i-- {
+		if err := backup(rotatedName(name, i), rotatedName(name, i-1)); err != nil {
+			return err
+		}
+	}
+	return backup(rotatedName(name, 1), name)
+}
+
+// backup renames replacing to old, replacing old if it exists.
+func backup(old, replacing string) error {
+	if _, err := os.Stat(replacing); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(old); err == nil {
+		if err := os.Remove(old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(replacing, old)
+}
+
+func rotatedName(name string, i int) string {
+	return name + "." + strconv.Itoa(i)
 }
 
 func (l *JSONFileLogger) LogPath() string {
 	return l.ctx.LogPath
 }
 
-// Close closes underlying file
+// Close closes underlying file, and ends the readers following the logs
 func (l *JSONFileLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-l.closed:
+	default:
+		close(l.closed)
+	}
 	return l.f.Close()
 }
 
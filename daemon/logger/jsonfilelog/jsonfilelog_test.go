@@ -1,9 +1,11 @@
 package jsonfilelog
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
@@ -84,3 +86,302 @@ func BenchmarkJSONFileLogger(b *testing.B) {
 		}
 	}
 }
+
+func TestJSONFileLoggerWithOpts(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	config := map[string]string{"max-file": "3", "max-size": "1k"}
+	l, err := New(logger.Context{
+		ContainerID: cid,
+		LogPath:     filename,
+		Config:      config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := 0; i < 20; i++ {
+		if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte("line" + strconv.Itoa(i)), Source: "src1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	penUlt, err := ioutil.ReadFile(filename + ".1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPenultimate := `{"log":"line0\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line1\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line2\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line3\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line4\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line5\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line6\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line7\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line8\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line9\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line10\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line11\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line12\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line13\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line14\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+`
+	expected := `{"log":"line15\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line16\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line17\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line18\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+{"log":"line19\n","stream":"src1","time":"0001-01-01T00:00:00Z"}
+`
+
+	if string(res) != expected {
+		t.Fatalf("Wrong log content: %q, expected %q", res, expected)
+	}
+	if string(penUlt) != expectedPenultimate {
+		t.Fatalf("Wrong log content: %q, expected %q", penUlt, expectedPenultimate)
+	}
+
+	msgs, err := readAll(l.(logger.LogReader).ReadLogs(logger.ReadConfig{Tail: -1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 20 {
+		t.Fatalf("Expected 20 messages, got %d", len(msgs))
+	}
+	for i, msg := range msgs {
+		if expected := "line" + strconv.Itoa(i) + "\n"; string(msg.Line) != expected || msg.Source != "src1" || msg.ContainerID != cid {
+			t.Fatalf("Wrong message %d: %+v, expected line %q", i, msg, expected)
+		}
+	}
+}
+
+// readAll returns the messages sent on the watcher until it is done.
+func readAll(w *logger.LogWatcher) ([]*logger.Message, error) {
+	var msgs []*logger.Message
+	for {
+		select {
+		case msg, ok := <-w.Msg:
+			if !ok {
+				return msgs, nil
+			}
+			msgs = append(msgs, msg)
+		case err := <-w.Err:
+			return nil, err
+		case <-time.After(5 * time.Second):
+			return nil, fmt.Errorf("timeout reading the logs")
+		}
+	}
+}
+
+func TestJSONFileLoggerReadLogs(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	l, err := New(logger.Context{
+		ContainerID: cid,
+		LogPath:     filepath.Join(tmp, "container.log"),
+		Config:      map[string]string{"max-file": "2", "max-size": "1k"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	r := l.(logger.LogReader)
+
+	start := time.Now().UTC()
+	for i := 0; i < 20; i++ {
+		if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte("line" + strconv.Itoa(i)), Source: "stdout", Timestamp: start.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msgs, err := readAll(r.ReadLogs(logger.ReadConfig{Tail: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 3 || string(msgs[0].Line) != "line17\n" || string(msgs[2].Line) != "line19\n" {
+		t.Fatalf("Expected the last 3 lines, got %d messages: %q %q", len(msgs), msgs[0].Line, msgs[2].Line)
+	}
+	if !msgs[0].Timestamp.Equal(start.Add(17 * time.Second)) {
+		t.Fatalf("Wrong timestamp %v", msgs[0].Timestamp)
+	}
+
+	msgs, err = readAll(r.ReadLogs(logger.ReadConfig{Tail: 0}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 0 {
+		t.Fatalf("Expected no messages, got %d", len(msgs))
+	}
+
+	msgs, err = readAll(r.ReadLogs(logger.ReadConfig{Tail: -1, Since: start.Add(18 * time.Second)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || string(msgs[0].Line) != "line18\n" {
+		t.Fatalf("Expected the lines since line18, got %d messages", len(msgs))
+	}
+
+	w := r.ReadLogs(logger.ReadConfig{Tail: 1, Follow: true})
+	for i := 20; i < 30; i++ {
+		if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte("line" + strconv.Itoa(i)), Source: "stdout"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	msgs, err = readAll(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 11 {
+		t.Fatalf("Expected 11 messages, got %d", len(msgs))
+	}
+	for i, msg := range msgs {
+		if expected := "line" + strconv.Itoa(i+19) + "\n"; string(msg.Line) != expected {
+			t.Fatalf("Wrong message %d: %q, expected %q", i, msg.Line, expected)
+		}
+	}
+}
+
+func TestJSONFileReader(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	ctx := logger.Context{
+		ContainerID: cid,
+		LogPath:     filepath.Join(tmp, "container.log"),
+		Config:      map[string]string{"max-file": "2", "max-size": "1k"},
+	}
+	l, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte("line" + strconv.Itoa(i)), Source: "stdout"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Following the logs of a stopped container ends with the current file.
+	msgs, err := readAll(r.ReadLogs(logger.ReadConfig{Tail: 2, Follow: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || string(msgs[0].Line) != "line18\n" || string(msgs[1].Line) != "line19\n" {
+		t.Fatalf("Expected the last 2 lines, got %d messages", len(msgs))
+	}
+}
+
+func TestJSONFileLoggerWithLabelsEnv(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	l, err := New(logger.Context{
+		ContainerID:     cid,
+		LogPath:         filename,
+		Config:          map[string]string{"labels": "rack,dc", "env": "environ,debug,ssl"},
+		ContainerLabels: map[string]string{"rack": "101", "dc": "lhr"},
+		ContainerEnv:    []string{"environ=production", "debug=false", "port=10001", "ssl=true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte("line"), Source: "src1"}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"log":"line\n","stream":"src1","time":"0001-01-01T00:00:00Z","attrs":{"dc":"lhr","debug":"false","environ":"production","rack":"101","ssl":"true"}}
+`
+	if string(res) != expected {
+		t.Fatalf("Wrong log content: %q, expected %q", res, expected)
+	}
+}
+
+func TestJSONFileLoggerWithTag(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	l, err := New(logger.Context{
+		ContainerID:   cid,
+		ContainerName: "/web",
+		LogPath:       filename,
+		Config:        map[string]string{"tag": "{{.Name}}/{{.ID}}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte("line"), Source: "src1"}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"log":"line\n","stream":"src1","time":"0001-01-01T00:00:00Z","attrs":{"tag":"web/a7317399f3f8"}}
+`
+	if string(res) != expected {
+		t.Fatalf("Wrong log content: %q, expected %q", res, expected)
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	valid := []map[string]string{
+		{},
+		{"max-size": "10m"},
+		{"max-size": "10m", "max-file": "5"},
+		{"labels": "rack,dc", "env": "environ"},
+		{"tag": "{{.Name}}"},
+	}
+	for _, cfg := range valid {
+		if err := ValidateLogOpt(cfg); err != nil {
+			t.Fatalf("expected %v to be valid, got %v", cfg, err)
+		}
+	}
+	invalid := []map[string]string{
+		{"max-size": "foo"},
+		{"max-size": "10m", "max-file": "0"},
+		{"max-file": "3"},
+		{"syslog-tag": "foo"},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateLogOpt(cfg); err == nil {
+			t.Fatalf("expected %v to be invalid", cfg)
+		}
+	}
+}
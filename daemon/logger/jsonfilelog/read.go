@@ -0,0 +1,269 @@
+package jsonfilelog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/jsonlog"
+	"github.com/docker/docker/pkg/tailfile"
+)
+
+// NewReader returns a reader of the log files at ctx.LogPath, for a
+// container which isn't running. The files aren't opened for writing, and
+// following the logs stops at the end of the current file.
+func NewReader(ctx logger.Context) (logger.LogReader, error) {
+	_, maxFiles, err := parseRotateOpts(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
+	closed := make(chan struct{})
+	close(closed)
+	return &JSONFileLogger{
+		n:       maxFiles,
+		written: make(chan struct{}),
+		closed:  closed,
+		ctx:     ctx,
+	}, nil
+}
+
+// ReadLogs implements the logger.LogReader interface, reading back the
+// messages from the log files, oldest first.
+func (l *JSONFileLogger) ReadLogs(config logger.ReadConfig) *logger.LogWatcher {
+	logWatcher := logger.NewLogWatcher()
+
+	// The files are opened and the tail is read with the lock held, so that
+	// the logs are read as of now, and followed from the end of the current
+	// file without missing nor repeating lines.
+	var tail [][]byte
+	l.mu.Lock()
+	files, err := l.openLogFiles()
+	if err == nil && config.Tail >= 0 {
+		tail, err = tailFiles(files, config.Tail)
+	}
+	l.mu.Unlock()
+	if err != nil {
+		closeFiles(files)
+		logWatcher.Err <- err
+		close(logWatcher.Msg)
+		return logWatcher
+	}
+
+	go l.readLogs(logWatcher, config, files, tail)
+	return logWatcher
+}
+
+func (l *JSONFileLogger) readLogs(logWatcher *logger.LogWatcher, config logger.ReadConfig, files []*os.File, tail [][]byte) {
+	defer close(logWatcher.Msg)
+
+	current := files[len(files)-1]
+	rotated := files[:len(files)-1]
+	defer func() {
+		current.Close()
+		closeFiles(rotated)
+	}()
+
+	jl := &jsonlog.JSONLog{}
+	send := func(line []byte) bool {
+		jl.Reset()
+		if err := json.Unmarshal(line, jl); err != nil {
+			logrus.Errorf("Error reading log line %q: %v", line, err)
+			return true
+		}
+		if !config.Since.IsZero() && jl.Created.Before(config.Since) {
+			return true
+		}
+		msg := &logger.Message{
+			ContainerID: l.ctx.ContainerID,
+			Line:        []byte(jl.Log),
+			Source:      jl.Stream,
+			Timestamp:   jl.Created,
+		}
+		select {
+		case logWatcher.Msg <- msg:
+			return true
+		case <-logWatcher.WatchClose():
+			return false
+		}
+	}
+
+	if config.Tail >= 0 {
+		for _, line := range tail {
+			if !send(line) {
+				return
+			}
+		}
+	} else {
+		for _, f := range rotated {
+			if !readLines(bufio.NewReader(f), send) {
+				return
+			}
+		}
+	}
+
+	if !config.Follow {
+		if config.Tail < 0 {
+			readLines(bufio.NewReader(current), send)
+		}
+		return
+	}
+	current = l.followLogs(current, logWatcher, send)
+}
+
+// openLogFiles opens all the log files, oldest first. The last one is the
+// current log file. Must be called with l.mu held.
+func (l *JSONFileLogger) openLogFiles() ([]*os.File, error) {
+	var files []*os.File
+	for i := l.n - 1; i > 0; i-- {
+		f, err := os.Open(rotatedName(l.ctx.LogPath, i))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			closeFiles(files)
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	f, err := os.Open(l.ctx.LogPath)
+	if err != nil {
+		closeFiles(files)
+		return nil, err
+	}
+	return append(files, f), nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// tailFiles returns the last n lines of the given log files, and moves the
+// offset of the last one, the current log file, to its end.
+func tailFiles(files []*os.File, n int) ([][]byte, error) {
+	var lines [][]byte
+	if n > 0 {
+		readers := make([]io.ReadSeeker, len(files))
+		for i, f := range files {
+			readers[i] = f
+		}
+		var err error
+		if lines, err = tailfile.TailFile(ioutils.MultiReadSeeker(readers...), n); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := files[len(files)-1].Seek(0, os.SEEK_END); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// readLines reads r until EOF, and calls send with each line. It returns
+// false when send does.
+func readLines(r *bufio.Reader, send func([]byte) bool) bool {
+	ok, _ := readLinesFrom(r, nil, send)
+	return ok
+}
+
+// readLinesFrom is like readLines, but prepends partial to the first line
+// read, and returns the incomplete line found at EOF instead of sending it,
+// so that it is completed by the next read while following the logs.
+func readLinesFrom(r *bufio.Reader, partial []byte, send func([]byte) bool) (bool, []byte) {
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			if err != io.EOF {
+				logrus.Errorf("Error reading logs: %v", err)
+			}
+			return true, append(partial, line...)
+		}
+		if len(partial) > 0 {
+			line, partial = append(partial, line...), nil
+		}
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		if len(line) > 0 && !send(line) {
+			return false, nil
+		}
+	}
+}
+
+// followLogs sends the lines of the current log file f, from its current
+// offset, and the lines written to the log files afterwards, until the
+// logger or the watcher are closed. It returns the log file it was reading
+// when it stopped, which may be another file than f when the logs were
+// rotated.
+func (l *JSONFileLogger) followLogs(f *os.File, logWatcher *logger.LogWatcher, send func([]byte) bool) *os.File {
+	var (
+		r       = bufio.NewReader(f)
+		partial []byte
+	)
+	read := func() bool {
+		var ok bool
+		ok, partial = readLinesFrom(r, partial, send)
+		return ok
+	}
+
+	for {
+		// Get the notification channels before reading, so that a write
+		// done while reading isn't missed.
+		l.mu.Lock()
+		written, closed := l.written, l.closed
+		l.mu.Unlock()
+
+		if !read() {
+			return f
+		}
+
+		fi, err := f.Stat()
+		if err != nil {
+			logWatcher.Err <- err
+			return f
+		}
+		offset, err := f.Seek(0, os.SEEK_CUR)
+		if err != nil {
+			logWatcher.Err <- err
+			return f
+		}
+		if fi.Size() < offset {
+			// The file was truncated by a rotation without backups.
+			if _, err := f.Seek(0, os.SEEK_SET); err != nil {
+				logWatcher.Err <- err
+				return f
+			}
+			partial = nil
+			r.Reset(f)
+			continue
+		}
+		if pfi, err := os.Stat(l.ctx.LogPath); err == nil && !os.SameFile(fi, pfi) {
+			// The file was rotated, read what was written to it before
+			// the rotation and go on with the new one.
+			if !read() {
+				return f
+			}
+			nf, err := os.Open(l.ctx.LogPath)
+			if err != nil {
+				logWatcher.Err <- err
+				return f
+			}
+			f.Close()
+			f, partial = nf, nil
+			r.Reset(f)
+			continue
+		}
+
+		select {
+		case <-written:
+		case <-closed:
+			read()
+			return f
+		case <-logWatcher.WatchClose():
+			return f
+		}
+	}
+}
@@ -2,7 +2,7 @@ package logger
 
 import (
 	"errors"
-	"io"
+	"sync"
 	"time"
 )
 
@@ -21,5 +21,60 @@ type Logger interface {
 	Log(*Message) error
 	Name() string
 	Close() error
-	GetReader() (io.Reader, error)
+}
+
+// ReadConfig is the configuration passed into ReadLogs.
+type ReadConfig struct {
+	// Since is the time of the oldest message to read, the zero time means
+	// that there is no limit.
+	Since time.Time
+	// Tail is the number of messages to read from the end of the logs, -1
+	// means all of them.
+	Tail int
+	// Follow makes the reader send the new messages, until the logger is
+	// closed or the watcher is closed.
+	Follow bool
+}
+
+// LogReader is the interface of the logging drivers which can read back the
+// messages they logged.
+type LogReader interface {
+	// ReadLogs reads the messages from the underlying logging backend,
+	// they are sent on the Msg channel of the returned watcher.
+	ReadLogs(ReadConfig) *LogWatcher
+}
+
+// LogWatcher is used when consuming logs read from the LogReader interface.
+// The Msg channel is closed when all the messages have been sent.
+type LogWatcher struct {
+	// Msg receives the log messages, oldest first.
+	Msg chan *Message
+	// Err receives the error which stopped the reader, if any.
+	Err chan error
+
+	closeOnce     sync.Once
+	closeNotifier chan struct{}
+}
+
+// NewLogWatcher returns a new LogWatcher.
+func NewLogWatcher() *LogWatcher {
+	return &LogWatcher{
+		Msg:           make(chan *Message, 4096),
+		Err:           make(chan error, 1),
+		closeNotifier: make(chan struct{}),
+	}
+}
+
+// Close notifies the reader that the consumer of the watcher is gone, and
+// that it should stop sending messages.
+func (w *LogWatcher) Close() {
+	w.closeOnce.Do(func() {
+		close(w.closeNotifier)
+	})
+}
+
+// WatchClose returns a channel receiver that receives notification when the
+// watcher has been closed.
+func (w *LogWatcher) WatchClose() <-chan struct{} {
+	return w.closeNotifier
 }
@@ -0,0 +1,47 @@
+package loggerutils
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger"
+)
+
+// DefaultTemplate is the default template of the tag, the short ID of the
+// container.
+const DefaultTemplate = "{{.ID}}"
+
+// deprecatedTagKeys are the driver specific tag options, used when the
+// "tag" option isn't set.
+var deprecatedTagKeys = []string{"syslog-tag", "gelf-tag", "fluentd-tag"}
+
+// ParseLogTag executes the template of the "tag" option with the context of
+// the container, using defaultTemplate when it isn't set.
+func ParseLogTag(ctx logger.Context, defaultTemplate string) (string, error) {
+	tagTemplate := lookupTagTemplate(ctx, defaultTemplate)
+
+	t, err := template.New("tag").Parse(tagTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid tag %q: %v", tagTemplate, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, &ctx); err != nil {
+		return "", fmt.Errorf("invalid tag %q: %v", tagTemplate, err)
+	}
+	return buf.String(), nil
+}
+
+func lookupTagTemplate(ctx logger.Context, defaultTemplate string) string {
+	if tagTemplate := ctx.Config["tag"]; tagTemplate != "" {
+		return tagTemplate
+	}
+	for _, key := range deprecatedTagKeys {
+		if tagTemplate := ctx.Config[key]; tagTemplate != "" {
+			logrus.Warnf("The %q log option is deprecated, use \"tag\" instead", key)
+			return tagTemplate
+		}
+	}
+	return defaultTemplate
+}
@@ -0,0 +1,53 @@
+package loggerutils
+
+import (
+	"testing"
+
+	"github.com/docker/docker/daemon/logger"
+)
+
+func buildContext(cfg map[string]string) logger.Context {
+	return logger.Context{
+		ContainerID:        "container-abcdefghijklmnopqrstuvwxyz01234567890",
+		ContainerName:      "/test-container",
+		ContainerImageID:   "image-abcdefghijklmnopqrstuvwxyz01234567890",
+		ContainerImageName: "test-image",
+		ContainerLabels:    map[string]string{"com.example.service": "web"},
+		ContainerEnv:       []string{"PATH=/bin", "STAGE=prod"},
+		Config:             cfg,
+	}
+}
+
+func assertTag(t *testing.T, cfg map[string]string, expected string) {
+	tag, err := ParseLogTag(buildContext(cfg), DefaultTemplate)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %v: %v", cfg, err)
+	}
+	if tag != expected {
+		t.Fatalf("Expected tag %q for %v, got %q", expected, cfg, tag)
+	}
+}
+
+func TestParseLogTagDefault(t *testing.T) {
+	assertTag(t, map[string]string{}, "container-ab")
+}
+
+func TestParseLogTag(t *testing.T) {
+	assertTag(t, map[string]string{"tag": "{{.ImageName}}/{{.Name}}/{{.ID}}"}, "test-image/test-container/container-ab")
+	assertTag(t, map[string]string{"tag": "{{.FullID}} {{.ImageID}} {{.ImageFullID}}"}, "container-abcdefghijklmnopqrstuvwxyz01234567890 image-abcdef image-abcdefghijklmnopqrstuvwxyz01234567890")
+	assertTag(t, map[string]string{"tag": `{{.Label "com.example.service"}}.{{.Env "STAGE"}}.{{.Env "MISSING"}}`}, "web.prod.")
+}
+
+func TestParseLogTagDeprecated(t *testing.T) {
+	assertTag(t, map[string]string{"syslog-tag": "mailer"}, "mailer")
+	assertTag(t, map[string]string{"gelf-tag": "{{.Name}}"}, "test-container")
+	assertTag(t, map[string]string{"tag": "new", "syslog-tag": "old"}, "new")
+}
+
+func TestParseLogTagInvalid(t *testing.T) {
+	for _, tag := range []string{"{{.ID", "{{.Unknown}}"} {
+		if _, err := ParseLogTag(buildContext(map[string]string{"tag": tag}), DefaultTemplate); err == nil {
+			t.Fatalf("Expected an error for tag %q", tag)
+		}
+	}
+}
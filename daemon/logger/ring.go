@@ -0,0 +1,210 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/units"
+)
+
+const (
+	// ModeBlocking makes the container block on the logging driver when
+	// it is slow, this is the default delivery mode.
+	ModeBlocking = "blocking"
+	// ModeNonBlocking queues the messages in a buffer, sent to the logging
+	// driver in the background, dropping them when the buffer is full.
+	ModeNonBlocking = "non-blocking"
+
+	// DefaultMaxBufferSize is the default size of the buffer of the
+	// non-blocking mode.
+	DefaultMaxBufferSize = 1024 * 1024
+)
+
+var (
+	errRingClosed = errors.New("logger: ring buffer is closed")
+	errRingFull   = errors.New("logger: ring buffer is full")
+)
+
+// ParseMaxBufferSize reads the "max-buffer-size" option, the size of the
+// buffer of the non-blocking mode.
+func ParseMaxBufferSize(cfg map[string]string) (int64, error) {
+	s, ok := cfg["max-buffer-size"]
+	if !ok {
+		return DefaultMaxBufferSize, nil
+	}
+	size, err := units.RAMInBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max-buffer-size %q: %v", s, err)
+	}
+	if size <= 0 {
+		return 0, fmt.Errorf("max-buffer-size must be a positive size, got %q", s)
+	}
+	return size, nil
+}
+
+// validateMode checks the "mode" and "max-buffer-size" options.
+func validateMode(cfg map[string]string) error {
+	switch mode := cfg["mode"]; mode {
+	case "", ModeBlocking:
+		if _, ok := cfg["max-buffer-size"]; ok {
+			return fmt.Errorf("max-buffer-size is only supported with the %s mode", ModeNonBlocking)
+		}
+		return nil
+	case ModeNonBlocking:
+		_, err := ParseMaxBufferSize(cfg)
+		return err
+	default:
+		return fmt.Errorf("unknown log mode %q, expected %s or %s", mode, ModeBlocking, ModeNonBlocking)
+	}
+}
+
+// RingLogger queues the messages in a bounded buffer, and sends them to the
+// wrapped logging driver in the background, so that a slow driver doesn't
+// block the container. The messages are dropped, and counted, when the
+// buffer is full.
+type RingLogger struct {
+	buffer      *messageRing
+	l           Logger
+	containerID string
+	dropped     int64 // accessed atomically
+	done        chan struct{}
+}
+
+// ringWithReader is the RingLogger of a driver which can read the logs back.
+type ringWithReader struct {
+	*RingLogger
+}
+
+// ReadLogs reads the logs with the wrapped driver.
+func (r *ringWithReader) ReadLogs(config ReadConfig) *LogWatcher {
+	return r.l.(LogReader).ReadLogs(config)
+}
+
+// NewRingLogger wraps driver in a RingLogger buffering up to maxSize bytes
+// of messages. The returned logger is a LogReader if driver is one.
+func NewRingLogger(driver Logger, containerID string, maxSize int64) Logger {
+	r := &RingLogger{
+		buffer:      newRing(maxSize),
+		l:           driver,
+		containerID: containerID,
+		done:        make(chan struct{}),
+	}
+	go r.run()
+	if _, ok := driver.(LogReader); ok {
+		return &ringWithReader{r}
+	}
+	return r
+}
+
+// Log queues the message. It never blocks, the message is dropped if the
+// buffer is full.
+func (r *RingLogger) Log(msg *Message) error {
+	err := r.buffer.Enqueue(msg)
+	if err == errRingFull {
+		if atomic.AddInt64(&r.dropped, 1) == 1 {
+			logrus.Warnf("The log buffer of container %s is full, dropping messages", r.containerID)
+		}
+		return nil
+	}
+	return err
+}
+
+// Dropped returns the number of messages dropped because the buffer was
+// full.
+func (r *RingLogger) Dropped() int64 {
+	return atomic.LoadInt64(&r.dropped)
+}
+
+// Name returns the name of the wrapped driver.
+func (r *RingLogger) Name() string {
+	return r.l.Name()
+}
+
+// Close sends the messages left in the buffer to the wrapped driver, and
+// closes it.
+func (r *RingLogger) Close() error {
+	r.buffer.Close()
+	<-r.done
+	if dropped := r.Dropped(); dropped > 0 {
+		logrus.Warnf("Dropped %d log messages of container %s, the log buffer was full", dropped, r.containerID)
+	}
+	return r.l.Close()
+}
+
+func (r *RingLogger) run() {
+	defer close(r.done)
+	for {
+		msg, err := r.buffer.Dequeue()
+		if err != nil {
+			return
+		}
+		if err := r.l.Log(msg); err != nil {
+			logrus.Errorf("Failed to log msg %q for logger %s: %s", msg.Line, r.l.Name(), err)
+		}
+	}
+}
+
+// messageRing is a FIFO of messages bounded by the size of their lines.
+type messageRing struct {
+	mu        sync.Mutex
+	wait      *sync.Cond
+	queue     []*Message
+	sizeBytes int64
+	maxBytes  int64
+	closed    bool
+}
+
+func newRing(maxBytes int64) *messageRing {
+	r := &messageRing{maxBytes: maxBytes}
+	r.wait = sync.NewCond(&r.mu)
+	return r
+}
+
+// Enqueue adds msg to the ring. A message bigger than the ring is accepted
+// only when the ring is empty.
+func (r *messageRing) Enqueue(msg *Message) error {
+	size := int64(len(msg.Line))
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return errRingClosed
+	}
+	if r.sizeBytes+size > r.maxBytes && len(r.queue) > 0 {
+		r.mu.Unlock()
+		return errRingFull
+	}
+	r.queue = append(r.queue, msg)
+	r.sizeBytes += size
+	r.mu.Unlock()
+	r.wait.Signal()
+	return nil
+}
+
+// Dequeue removes the oldest message from the ring, waiting for one if it
+// is empty. It fails once the ring is closed and empty.
+func (r *messageRing) Dequeue() (*Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.queue) == 0 && !r.closed {
+		r.wait.Wait()
+	}
+	if len(r.queue) == 0 {
+		return nil, errRingClosed
+	}
+	msg := r.queue[0]
+	r.queue[0] = nil
+	r.queue = r.queue[1:]
+	r.sizeBytes -= int64(len(msg.Line))
+	return msg, nil
+}
+
+// Close makes Enqueue fail, and Dequeue fail once the ring is empty.
+func (r *messageRing) Close() {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	r.wait.Broadcast()
+}
@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingLogger is a Logger which blocks until unblock is closed.
+type blockingLogger struct {
+	mu      sync.Mutex
+	msgs    []*Message
+	unblock chan struct{}
+	closed  bool
+}
+
+func (l *blockingLogger) Log(msg *Message) error {
+	<-l.unblock
+	l.mu.Lock()
+	l.msgs = append(l.msgs, msg)
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *blockingLogger) Close() error {
+	l.mu.Lock()
+	l.closed = true
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *blockingLogger) Name() string { return "blocking" }
+
+type readingLogger struct {
+	blockingLogger
+}
+
+func (l *readingLogger) ReadLogs(ReadConfig) *LogWatcher {
+	w := NewLogWatcher()
+	close(w.Msg)
+	return w
+}
+
+func TestRingLoggerDoesNotBlock(t *testing.T) {
+	driver := &blockingLogger{unblock: make(chan struct{})}
+	l := NewRingLogger(driver, "cont", 10)
+	r := l.(*RingLogger)
+
+	// Wait for the first message to be held by the blocked driver.
+	if err := l.Log(&Message{Line: []byte("line0")}); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		r.buffer.mu.Lock()
+		empty := len(r.buffer.queue) == 0
+		r.buffer.mu.Unlock()
+		if empty {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	logged := make(chan struct{})
+	go func() {
+		for i := 1; i < 10; i++ {
+			if err := l.Log(&Message{Line: []byte(fmt.Sprintf("line%d", i))}); err != nil {
+				t.Error(err)
+			}
+		}
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Log blocked on the driver")
+	}
+
+	// Two more messages fit in the buffer.
+	if dropped := r.Dropped(); dropped != 7 {
+		t.Fatalf("Expected 7 messages dropped, got %d", dropped)
+	}
+
+	close(driver.unblock)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !driver.closed {
+		t.Fatal("The driver should be closed")
+	}
+	if len(driver.msgs) != 3 {
+		t.Fatalf("Expected 3 messages sent to the driver, got %d", len(driver.msgs))
+	}
+	for i, msg := range driver.msgs {
+		if expected := fmt.Sprintf("line%d", i); string(msg.Line) != expected {
+			t.Fatalf("Expected %q, got %q", expected, msg.Line)
+		}
+	}
+	if err := l.Log(&Message{Line: []byte("closed")}); err == nil {
+		t.Fatal("Expected an error logging to a closed logger")
+	}
+}
+
+func TestRingLoggerReader(t *testing.T) {
+	driver := &blockingLogger{unblock: make(chan struct{})}
+	close(driver.unblock)
+	l := NewRingLogger(driver, "cont", DefaultMaxBufferSize)
+	defer l.Close()
+	if _, ok := l.(LogReader); ok {
+		t.Fatal("The ring logger of a driver which can't read logs shouldn't be a LogReader")
+	}
+
+	reader := &readingLogger{blockingLogger{unblock: driver.unblock}}
+	l = NewRingLogger(reader, "cont", DefaultMaxBufferSize)
+	defer l.Close()
+	if _, ok := l.(LogReader); !ok {
+		t.Fatal("The ring logger of a LogReader should be a LogReader")
+	}
+}
+
+func TestRingBigMessage(t *testing.T) {
+	r := newRing(4)
+	if err := r.Enqueue(&Message{Line: []byte("too big")}); err != nil {
+		t.Fatalf("A message bigger than the empty ring should be accepted, got %v", err)
+	}
+	if err := r.Enqueue(&Message{Line: []byte("a")}); err != errRingFull {
+		t.Fatalf("Expected %v, got %v", errRingFull, err)
+	}
+	if msg, err := r.Dequeue(); err != nil || string(msg.Line) != "too big" {
+		t.Fatalf("Unexpected message %v, %v", msg, err)
+	}
+	if err := r.Enqueue(&Message{Line: []byte("a")}); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if msg, err := r.Dequeue(); err != nil || string(msg.Line) != "a" {
+		t.Fatalf("Messages left should be dequeued once closed, got %v, %v", msg, err)
+	}
+	if _, err := r.Dequeue(); err != errRingClosed {
+		t.Fatalf("Expected %v, got %v", errRingClosed, err)
+	}
+}
+
+// driverCfg is the configuration passed to the validator of the test-mode
+// driver.
+var driverCfg map[string]string
+
+func init() {
+	RegisterLogDriver("test-mode", func(Context) (Logger, error) { return nil, nil })
+	RegisterLogOptValidator("test-mode", func(cfg map[string]string) error {
+		driverCfg = cfg
+		return nil
+	})
+}
+
+func TestValidateLogOptsMode(t *testing.T) {
+	valid := []map[string]string{
+		{},
+		{"mode": "blocking"},
+		{"mode": "non-blocking"},
+		{"mode": "non-blocking", "max-buffer-size": "4m"},
+	}
+	for _, cfg := range valid {
+		if err := ValidateLogOpts("test-mode", cfg); err != nil {
+			t.Fatalf("expected %v to be valid, got %v", cfg, err)
+		}
+		if _, ok := driverCfg["mode"]; ok {
+			t.Fatalf("The mode shouldn't be passed to the driver validator, got %v", driverCfg)
+		}
+	}
+	invalid := []map[string]string{
+		{"mode": "async"},
+		{"mode": "non-blocking", "max-buffer-size": "foo"},
+		{"mode": "non-blocking", "max-buffer-size": "0"},
+		{"max-buffer-size": "4m"},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateLogOpts("test-mode", cfg); err == nil {
+			t.Fatalf("expected %v to be invalid", cfg)
+		}
+	}
+}
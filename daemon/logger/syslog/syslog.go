@@ -3,7 +3,6 @@
 package syslog
 
 import (
-	"io"
 	"log/syslog"
 	"net"
 	"net/url"
@@ -13,6 +12,7 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
 	"github.com/docker/docker/pkg/urlutil"
 )
 
@@ -29,9 +29,9 @@ func init() {
 }
 
 func New(ctx logger.Context) (logger.Logger, error) {
-	tag := ctx.Config["syslog-tag"]
-	if tag == "" {
-		tag = ctx.ContainerID[:12]
+	tag, err := loggerutils.ParseLogTag(ctx, loggerutils.DefaultTemplate)
+	if err != nil {
+		return nil, err
 	}
 
 	proto, address, err := parseAddress(ctx.Config["syslog-address"])
@@ -69,10 +69,6 @@ func (s *Syslog) Name() string {
 	return name
 }
 
-func (s *Syslog) GetReader() (io.Reader, error) {
-	return nil, logger.ReadLogsNotSupported
-}
-
 func parseAddress(address string) (string, string, error) {
 	if urlutil.IsTransportURL(address) {
 		url, err := url.Parse(address)
@@ -3,6 +3,7 @@ package daemon
 import (
 	"io"
 	"os/exec"
+	"strconv"
 	"sync"
 	"time"
 
@@ -50,6 +51,14 @@ type containerMonitor struct {
 
 	// lastStartTime is the time which the monitor last exec'd the container's process
 	lastStartTime time.Time
+
+	// restoring is set until the monitor attached to the process of the
+	// container, started by the previous daemon
+	restoring bool
+
+	// restoringCheckpoint is set until the monitor restored the processes
+	// of the container from its checkpoint
+	restoringCheckpoint bool
 }
 
 // newContainerMonitor returns an initialized containerMonitor for the provided container
@@ -131,11 +140,22 @@ func (m *containerMonitor) Start() error {
 
 		pipes := execdriver.NewPipes(m.container.stdin, m.container.stdout, m.container.stderr, m.container.Config.OpenStdin)
 
-		m.container.LogEvent("start")
-
-		m.lastStartTime = time.Now()
-
-		if exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback); err != nil {
+		switch {
+		case m.restoring:
+			m.lastStartTime = m.container.StartedAt
+			exitStatus, err = m.container.daemon.Restore(m.container, pipes, m.callback)
+			m.restoring = false
+		case m.restoringCheckpoint:
+			m.container.LogEvent("restore")
+			m.lastStartTime = time.Now()
+			exitStatus, err = m.container.daemon.RestoreCheckpoint(m.container, pipes, m.callback)
+			m.restoringCheckpoint = false
+		default:
+			m.container.LogEvent("start")
+			m.lastStartTime = time.Now()
+			exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback)
+		}
+		if err != nil {
 			// if we receive an internal error from the initial start of a container then lets
 			// return it instead of entering the restart loop
 			if m.container.RestartCount == 0 {
@@ -151,14 +171,28 @@ func (m *containerMonitor) Start() error {
 		// here container.Lock is already lost
 		afterRun = true
 
+		// The container is locked while it is checkpointed, its process is
+		// known to be dumped once the lock is released.
+		m.container.Lock()
+		stopHealthMonitor(m.container)
+		checkpointed := m.container.Checkpointed
+		m.container.Unlock()
+
 		m.resetMonitor(err == nil && exitStatus.ExitCode == 0)
 
+		if checkpointed {
+			// The process was killed once dumped, the container is stopped
+			// until it is restored.
+			m.resetContainer(true)
+			return err
+		}
+
 		if m.shouldRestart(exitStatus.ExitCode) {
 			m.container.SetRestarting(&exitStatus)
 			if exitStatus.OOMKilled {
 				m.container.LogEvent("oom")
 			}
-			m.container.LogEvent("die")
+			m.logDieEvent(exitStatus)
 			m.resetContainer(true)
 
 			// sleep with a small time increment between each restart to help avoid issues cased by quickly
@@ -175,7 +209,7 @@ func (m *containerMonitor) Start() error {
 		if exitStatus.OOMKilled {
 			m.container.LogEvent("oom")
 		}
-		m.container.LogEvent("die")
+		m.logDieEvent(exitStatus)
 		m.resetContainer(true)
 		return err
 	}
@@ -252,7 +286,13 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 		}
 	}
 
+	startedAt := m.container.StartedAt
 	m.container.setRunning(pid)
+	if m.restoring {
+		// the process was started by the previous daemon
+		m.container.StartedAt = startedAt
+	}
+	m.container.daemon.initHealthMonitor(m.container)
 
 	// signal that the process has started
 	// close channel only if not closed
@@ -267,6 +307,14 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 	}
 }
 
+// logDieEvent logs the die event of the container, with the exit code of
+// its process.
+func (m *containerMonitor) logDieEvent(exitStatus execdriver.ExitStatus) {
+	m.container.LogEventWithAttributes("die", map[string]string{
+		"exitCode": strconv.Itoa(exitStatus.ExitCode),
+	})
+}
+
 // resetContainer resets the container's IO and ensures that the command is able to be executed again
 // by copying the data into a new struct
 // if lock is true, then container locked during reset
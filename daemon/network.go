@@ -0,0 +1,359 @@
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/network/bridge"
+	"github.com/docker/libnetwork"
+	"github.com/docker/libnetwork/netlabel"
+	"github.com/docker/libnetwork/options"
+)
+
+// predefinedNetworks are the networks created by the daemon itself, backing
+// the builtin network modes. They cannot be removed.
+var predefinedNetworks = []string{"bridge", "host", "none"}
+
+// networkConfig is what the daemon saves about a user-defined network, so
+// that it can create it again when it restarts. libnetwork only keeps the
+// networks in memory.
+type networkConfig struct {
+	Name    string
+	Driver  string
+	Options map[string]string `json:",omitempty"`
+}
+
+func isPredefinedNetwork(name string) bool {
+	for _, n := range predefinedNetworks {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (daemon *Daemon) networksPath() string {
+	return filepath.Join(daemon.config.Root, "networks")
+}
+
+func (daemon *Daemon) networkController() (libnetwork.NetworkController, error) {
+	if daemon.netController == nil {
+		return nil, fmt.Errorf("Networking is disabled on this daemon")
+	}
+	return daemon.netController, nil
+}
+
+// GetNetwork looks up a network by name, ID or unique ID prefix.
+func (daemon *Daemon) GetNetwork(idName string) (libnetwork.Network, error) {
+	c, err := daemon.networkController()
+	if err != nil {
+		return nil, err
+	}
+	if n, err := c.NetworkByName(idName); err == nil {
+		return n, nil
+	}
+	if n, err := c.NetworkByID(idName); err == nil {
+		return n, nil
+	}
+
+	var found libnetwork.Network
+	for _, n := range c.Networks() {
+		if !strings.HasPrefix(n.ID(), idName) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("Network name or ID %s is ambiguous", idName)
+		}
+		found = n
+	}
+	if found == nil || idName == "" {
+		return nil, fmt.Errorf("No such network: %s", idName)
+	}
+	return found, nil
+}
+
+// NetworkCreate creates a user-defined network with the given driver, and
+// returns its ID.
+func (daemon *Daemon) NetworkCreate(name, driver string, opts map[string]string) (string, error) {
+	c, err := daemon.networkController()
+	if err != nil {
+		return "", err
+	}
+	if !validVolumeNamePattern.MatchString(name) {
+		return "", fmt.Errorf("Invalid network name (%s), only %s are allowed", name, validContainerNameChars)
+	}
+	if _, err := c.NetworkByName(name); err == nil {
+		return "", fmt.Errorf("Conflict: network with name %s already exists", name)
+	}
+	if driver == "" {
+		driver = "bridge"
+	}
+
+	cfg := &networkConfig{Name: name, Driver: driver, Options: opts}
+	n, err := daemon.createNetwork(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	if err := daemon.saveNetworkConfig(cfg); err != nil {
+		if err := n.Delete(); err != nil {
+			logrus.Errorf("Error removing network %s after failing to save it: %v", name, err)
+		}
+		return "", err
+	}
+	daemon.logNetworkEvent(n.ID(), n.Name(), n.Type(), "create", nil)
+	return n.ID(), nil
+}
+
+func (daemon *Daemon) createNetwork(cfg *networkConfig) (libnetwork.Network, error) {
+	driver := cfg.Driver
+	generic := options.Generic{}
+	for k, v := range cfg.Options {
+		generic[k] = v
+	}
+	if driver == "bridge" {
+		// The bridge driver of libnetwork only manages the default bridge
+		// network, the user-defined ones get their own bridge.
+		driver = bridge.DriverName
+		if _, ok := generic[bridge.BridgeNameOption]; !ok {
+			generic[bridge.BridgeNameOption] = bridgeName(cfg.Name)
+		}
+	}
+
+	var netOptions []libnetwork.NetworkOption
+	if len(generic) > 0 {
+		netOptions = append(netOptions, libnetwork.NetworkOptionGeneric(options.Generic{
+			netlabel.GenericData: generic,
+		}))
+	}
+	return daemon.netController.NewNetwork(driver, cfg.Name, netOptions...)
+}
+
+// bridgeName returns the name of the Linux bridge of the user-defined bridge
+// network name. It only depends on the name of the network, so that the
+// network gets the same bridge when the daemon restarts.
+func bridgeName(name string) string {
+	h := sha256.Sum256([]byte(name))
+	return "br-" + hex.EncodeToString(h[:])[:12]
+}
+
+func (daemon *Daemon) saveNetworkConfig(cfg *networkConfig) error {
+	if err := os.MkdirAll(daemon.networksPath(), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(daemon.networksPath(), cfg.Name+".json"), data, 0600)
+}
+
+// restoreNetworks creates again the user-defined networks saved by a
+// previous run of the daemon.
+func (daemon *Daemon) restoreNetworks() error {
+	files, err := ioutil.ReadDir(daemon.networksPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(daemon.networksPath(), f.Name()))
+		if err != nil {
+			return err
+		}
+		var cfg networkConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			logrus.Errorf("Ignoring invalid network config %s: %v", f.Name(), err)
+			continue
+		}
+		if _, err := daemon.createNetwork(&cfg); err != nil {
+			logrus.Errorf("Error restoring network %s with driver %s: %v", cfg.Name, cfg.Driver, err)
+		}
+	}
+	return nil
+}
+
+// NetworkRm removes a user-defined network. It fails if a container is
+// connected to it, or configured to connect to it when it starts.
+func (daemon *Daemon) NetworkRm(idName string) error {
+	n, err := daemon.GetNetwork(idName)
+	if err != nil {
+		return err
+	}
+	name := n.Name()
+	if isPredefinedNetwork(name) {
+		return fmt.Errorf("%s is a pre-defined network and cannot be removed", name)
+	}
+
+	for _, c := range daemon.List() {
+		if c.isConnectedTo(name) {
+			return fmt.Errorf("Conflict: unable to remove network %s, it is used by container %s", name, c.ID)
+		}
+	}
+
+	if err := n.Delete(); err != nil {
+		if _, ok := err.(*libnetwork.ActiveEndpointsError); ok {
+			return fmt.Errorf("Conflict: unable to remove network %s, it has active endpoints", name)
+		}
+		return fmt.Errorf("Error while removing network %s: %v", name, err)
+	}
+
+	if err := os.Remove(filepath.Join(daemon.networksPath(), name+".json")); err != nil && !os.IsNotExist(err) {
+		logrus.Errorf("Error removing config of network %s: %v", name, err)
+	}
+	daemon.logNetworkEvent(n.ID(), name, n.Type(), "destroy", nil)
+	return nil
+}
+
+// NetworkInspect looks up a network by name, ID or unique ID prefix.
+func (daemon *Daemon) NetworkInspect(idName string) (*types.NetworkResource, error) {
+	n, err := daemon.GetNetwork(idName)
+	if err != nil {
+		return nil, err
+	}
+	return daemon.networkToAPIType(n), nil
+}
+
+// Networks returns all the networks known by the daemon, the pre-defined
+// ones included.
+func (daemon *Daemon) Networks() ([]*types.NetworkResource, error) {
+	c, err := daemon.networkController()
+	if err != nil {
+		return nil, err
+	}
+	networks := []*types.NetworkResource{}
+	for _, n := range c.Networks() {
+		networks = append(networks, daemon.networkToAPIType(n))
+	}
+	return networks, nil
+}
+
+func (daemon *Daemon) networkToAPIType(n libnetwork.Network) *types.NetworkResource {
+	r := &types.NetworkResource{
+		Name:       n.Name(),
+		ID:         n.ID(),
+		Driver:     n.Type(),
+		Containers: make(map[string]types.EndpointResource),
+	}
+
+	for _, c := range daemon.List() {
+		c.Lock()
+		if c.NetworkSettings != nil {
+			if settings, ok := c.NetworkSettings.Networks[r.Name]; ok && settings.NetworkID == r.ID {
+				r.Containers[c.ID] = types.EndpointResource{
+					EndpointID:  settings.EndpointID,
+					MacAddress:  settings.MacAddress,
+					IPv4Address: formatAddress(settings.IPAddress, settings.IPPrefixLen),
+					IPv6Address: formatAddress(settings.GlobalIPv6Address, settings.GlobalIPv6PrefixLen),
+				}
+			}
+		}
+		c.Unlock()
+	}
+	return r
+}
+
+func formatAddress(ip string, prefixLen int) string {
+	if ip == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%d", ip, prefixLen)
+}
+
+// ConnectContainerToNetwork connects a container to a user-defined network.
+// A running container is connected right away, a stopped one the next time
+// it starts.
+func (daemon *Daemon) ConnectContainerToNetwork(containerName, networkName string) error {
+	container, err := daemon.Get(containerName)
+	if err != nil {
+		return err
+	}
+	n, err := daemon.GetNetwork(networkName)
+	if err != nil {
+		return err
+	}
+	if isPredefinedNetwork(n.Name()) {
+		return fmt.Errorf("Containers can only be connected to user-defined networks, %s is a pre-defined network", n.Name())
+	}
+	if err := container.ConnectToNetwork(n.Name()); err != nil {
+		return err
+	}
+	daemon.logNetworkEvent(n.ID(), n.Name(), n.Type(), "connect", map[string]string{
+		"container": container.ID,
+	})
+	return nil
+}
+
+// DisconnectContainerFromNetwork disconnects a container from a user-defined
+// network. A running container leaves the network right away, a stopped one
+// doesn't join it anymore when it starts.
+func (daemon *Daemon) DisconnectContainerFromNetwork(containerName, networkName string) error {
+	container, err := daemon.Get(containerName)
+	if err != nil {
+		return err
+	}
+	n, err := daemon.GetNetwork(networkName)
+	if err != nil {
+		return err
+	}
+	if err := container.DisconnectFromNetwork(n.Name()); err != nil {
+		return err
+	}
+	daemon.logNetworkEvent(n.ID(), n.Name(), n.Type(), "disconnect", map[string]string{
+		"container": container.ID,
+	})
+	return nil
+}
+
+// resolveName returns the addresses of the running containers named name, or
+// having name as a network alias, on the user-defined networks shared with
+// container. It backs the embedded DNS server of the container, nil means
+// that the query is forwarded to the external DNS servers.
+func (daemon *Daemon) resolveName(container *Container, name string) []net.IP {
+	name = strings.TrimSuffix(name, ".")
+
+	var networks []string
+	container.Lock()
+	if container.NetworkSettings != nil {
+		for n := range container.NetworkSettings.Networks {
+			if !isPredefinedNetwork(n) {
+				networks = append(networks, n)
+			}
+		}
+	}
+	container.Unlock()
+
+	var ips []net.IP
+	for _, c := range daemon.List() {
+		c.Lock()
+		if c.Running && c.NetworkSettings != nil && c.hasNetworkName(name) {
+			for _, n := range networks {
+				settings, ok := c.NetworkSettings.Networks[n]
+				if !ok {
+					continue
+				}
+				for _, addr := range []string{settings.IPAddress, settings.GlobalIPv6Address} {
+					if ip := net.ParseIP(addr); ip != nil {
+						ips = append(ips, ip)
+					}
+				}
+			}
+		}
+		c.Unlock()
+	}
+	return ips
+}
@@ -0,0 +1,339 @@
+// Package bridge implements the driver of the user-defined bridge networks.
+//
+// The bridge driver of libnetwork manages a single network, the default
+// bridge network of the daemon. This driver creates an instance of it for
+// each user-defined network, so that every network gets its own Linux bridge
+// and subnet, and programs the iptables rules of these bridges itself: those
+// of libnetwork are tied to the default bridge.
+package bridge
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/docker/libnetwork/driverapi"
+	lnbridge "github.com/docker/libnetwork/drivers/bridge"
+	"github.com/docker/libnetwork/iptables"
+	"github.com/docker/libnetwork/netlabel"
+	"github.com/docker/libnetwork/netutils"
+	"github.com/docker/libnetwork/options"
+	"github.com/docker/libnetwork/types"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+const (
+	// DriverName is the name the driver is registered with in libnetwork.
+	// The bridge name is taken by the driver of the default network, the
+	// networks of this driver have the bridge type nonetheless.
+	DriverName = "user-bridge"
+
+	// BridgeNameOption is the option giving the name of the Linux bridge of
+	// a network.
+	BridgeNameOption = "com.docker.network.bridge.name"
+
+	networkType = "bridge"
+)
+
+// Config is the configuration of the bridges of the user-defined networks,
+// it follows the one of the default bridge, DefaultBridge.
+type Config struct {
+	DefaultBridge       string
+	Mtu                 int
+	EnableIPTables      bool
+	EnableIPMasquerade  bool
+	EnableUserlandProxy bool
+	DefaultBindingIP    net.IP
+}
+
+type bridgeNetwork struct {
+	bridgeName string
+	driver     driverapi.Driver
+}
+
+type driver struct {
+	config   Config
+	networks map[types.UUID]*bridgeNetwork
+	sync.Mutex
+}
+
+// Init registers the driver with libnetwork.
+func Init(dc driverapi.DriverCallback, config Config) error {
+	return dc.RegisterDriver(DriverName, &driver{
+		config:   config,
+		networks: make(map[types.UUID]*bridgeNetwork),
+	})
+}
+
+// driverCapture gets the instance of the libnetwork bridge driver its Init
+// function registers.
+type driverCapture struct {
+	driver driverapi.Driver
+}
+
+func (c *driverCapture) RegisterDriver(name string, driver driverapi.Driver) error {
+	c.driver = driver
+	return nil
+}
+
+func (d *driver) Config(option map[string]interface{}) error {
+	return nil
+}
+
+func (d *driver) Type() string {
+	return networkType
+}
+
+func (d *driver) getNetwork(nid types.UUID) (*bridgeNetwork, error) {
+	d.Lock()
+	defer d.Unlock()
+	n, ok := d.networks[nid]
+	if !ok {
+		return nil, driverapi.ErrNoNetwork(nid)
+	}
+	return n, nil
+}
+
+// parseBridgeName returns the name of the Linux bridge given in the options
+// of a network. It is the only option of the driver.
+func parseBridgeName(option map[string]interface{}) (string, error) {
+	var name string
+	if generic, ok := option[netlabel.GenericData].(options.Generic); ok {
+		for k, v := range generic {
+			if k != BridgeNameOption {
+				return "", fmt.Errorf("Unsupported option %s for the bridge driver", k)
+			}
+			if name, ok = v.(string); !ok {
+				return "", fmt.Errorf("Invalid value for option %s of the bridge driver", k)
+			}
+		}
+	}
+	if name == "" {
+		return "", fmt.Errorf("The bridge driver needs the name of the bridge of the network")
+	}
+	return name, nil
+}
+
+func (d *driver) CreateNetwork(nid types.UUID, option map[string]interface{}) error {
+	name, err := parseBridgeName(option)
+	if err != nil {
+		return err
+	}
+	if name == d.config.DefaultBridge {
+		return fmt.Errorf("Bridge %s is used by the default bridge network", name)
+	}
+
+	d.Lock()
+	for _, n := range d.networks {
+		if n.bridgeName == name {
+			d.Unlock()
+			return fmt.Errorf("Bridge %s is already used by another network", name)
+		}
+	}
+	d.Unlock()
+
+	capture := &driverCapture{}
+	if err := lnbridge.Init(capture); err != nil {
+		return err
+	}
+	config := &lnbridge.NetworkConfiguration{
+		BridgeName:            name,
+		Mtu:                   d.config.Mtu,
+		EnableICC:             true,
+		EnableUserlandProxy:   d.config.EnableUserlandProxy,
+		DefaultBindingIP:      d.config.DefaultBindingIP,
+		AllowNonDefaultBridge: true,
+	}
+	if err := capture.driver.CreateNetwork(nid, map[string]interface{}{netlabel.GenericData: config}); err != nil {
+		return err
+	}
+
+	if d.config.EnableIPTables {
+		if err := d.programIPTables(name, true); err != nil {
+			capture.driver.DeleteNetwork(nid)
+			return err
+		}
+	}
+
+	d.Lock()
+	d.networks[nid] = &bridgeNetwork{bridgeName: name, driver: capture.driver}
+	d.Unlock()
+	return nil
+}
+
+func (d *driver) DeleteNetwork(nid types.UUID) error {
+	n, err := d.getNetwork(nid)
+	if err != nil {
+		return err
+	}
+	if d.config.EnableIPTables {
+		if err := d.programIPTables(n.bridgeName, false); err != nil {
+			return err
+		}
+	}
+	if err := n.driver.DeleteNetwork(nid); err != nil {
+		return err
+	}
+	d.Lock()
+	delete(d.networks, nid)
+	d.Unlock()
+	return nil
+}
+
+func (d *driver) CreateEndpoint(nid, eid types.UUID, epInfo driverapi.EndpointInfo, epOptions map[string]interface{}) error {
+	n, err := d.getNetwork(nid)
+	if err != nil {
+		return err
+	}
+	return n.driver.CreateEndpoint(nid, eid, epInfo, epOptions)
+}
+
+func (d *driver) DeleteEndpoint(nid, eid types.UUID) error {
+	n, err := d.getNetwork(nid)
+	if err != nil {
+		return err
+	}
+	return n.driver.DeleteEndpoint(nid, eid)
+}
+
+func (d *driver) EndpointOperInfo(nid, eid types.UUID) (map[string]interface{}, error) {
+	n, err := d.getNetwork(nid)
+	if err != nil {
+		return nil, err
+	}
+	return n.driver.EndpointOperInfo(nid, eid)
+}
+
+// Join joins the endpoint to the sandbox of a container. The network becomes
+// the default route of the container, unless it already has one: a container
+// connected to several networks keeps the gateway of the first.
+func (d *driver) Join(nid, eid types.UUID, sboxKey string, jinfo driverapi.JoinInfo, options map[string]interface{}) error {
+	n, err := d.getNetwork(nid)
+	if err != nil {
+		return err
+	}
+	if hasDefaultRoute(sboxKey) {
+		jinfo = noGatewayJoinInfo{jinfo}
+	}
+	return n.driver.Join(nid, eid, sboxKey, jinfo, options)
+}
+
+func (d *driver) Leave(nid, eid types.UUID) error {
+	n, err := d.getNetwork(nid)
+	if err != nil {
+		return err
+	}
+	return n.driver.Leave(nid, eid)
+}
+
+// noGatewayJoinInfo ignores the gateways set by the driver of a network.
+type noGatewayJoinInfo struct {
+	driverapi.JoinInfo
+}
+
+func (noGatewayJoinInfo) SetGateway(net.IP) error {
+	return nil
+}
+
+func (noGatewayJoinInfo) SetGatewayIPv6(net.IP) error {
+	return nil
+}
+
+// hasDefaultRoute returns whether the network namespace at path has an IPv4
+// default route. The namespace doesn't exist yet when a container joins its
+// first network.
+func hasDefaultRoute(path string) bool {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	origns, err := netns.Get()
+	if err != nil {
+		return false
+	}
+	defer origns.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if err := netns.Set(netns.NsHandle(f.Fd())); err != nil {
+		return false
+	}
+	defer netns.Set(origns)
+
+	routes, err := netlink.RouteList(nil, netlink.FAMILY_V4)
+	if err != nil {
+		return false
+	}
+	for _, r := range routes {
+		if r.Dst == nil {
+			return true
+		}
+	}
+	return false
+}
+
+type iptRule struct {
+	table iptables.Table
+	chain string
+	args  []string
+}
+
+// programIPTables adds, or removes, the rules letting the containers of the
+// bridge bridgeName communicate with each other and with the outside, and
+// letting the ports they publish be reached.
+func (d *driver) programIPTables(bridgeName string, insert bool) error {
+	addr, _, err := netutils.GetIfaceAddr(bridgeName)
+	if err != nil {
+		return fmt.Errorf("Failed to program the iptables rules of bridge %s: %v", bridgeName, err)
+	}
+	ipNet, ok := addr.(*net.IPNet)
+	if !ok {
+		return fmt.Errorf("Failed to program the iptables rules of bridge %s: invalid address %s", bridgeName, addr)
+	}
+	subnet := &net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask), Mask: ipNet.Mask}
+
+	rules := []iptRule{
+		{iptables.Filter, "FORWARD", []string{"-i", bridgeName, "-o", bridgeName, "-j", "ACCEPT"}},
+		{iptables.Filter, "FORWARD", []string{"-i", bridgeName, "!", "-o", bridgeName, "-j", "ACCEPT"}},
+		{iptables.Filter, "FORWARD", []string{"-o", bridgeName, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"}},
+		// The published ports are forwarded by the rules of the DOCKER
+		// chain, which only accepts the traffic to the default bridge.
+		{iptables.Filter, "FORWARD", []string{"-o", bridgeName, "-m", "conntrack", "--ctstate", "DNAT", "-j", "ACCEPT"}},
+	}
+	if d.config.EnableIPMasquerade {
+		rules = append(rules, iptRule{iptables.Nat, "POSTROUTING", []string{"-s", subnet.String(), "!", "-o", bridgeName, "-j", "MASQUERADE"}})
+	}
+	if !d.config.EnableUserlandProxy {
+		rules = append(rules, iptRule{iptables.Nat, "POSTROUTING", []string{"-m", "addrtype", "--src-type", "LOCAL", "-o", bridgeName, "-j", "MASQUERADE"}})
+	}
+
+	for _, r := range rules {
+		if err := r.program(insert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r iptRule) program(insert bool) error {
+	exists := iptables.Exists(r.table, r.chain, r.args...)
+	if insert == exists {
+		return nil
+	}
+	action := "-D"
+	if insert {
+		action = "-I"
+	}
+	args := append([]string{"-t", string(r.table), action, r.chain}, r.args...)
+	if output, err := iptables.Raw(args...); err != nil {
+		return err
+	} else if len(output) != 0 {
+		return iptables.ChainError{Chain: r.chain, Output: output}
+	}
+	return nil
+}
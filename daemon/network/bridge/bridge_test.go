@@ -0,0 +1,110 @@
+package bridge
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/docker/libnetwork/netlabel"
+	"github.com/docker/libnetwork/netutils"
+	"github.com/docker/libnetwork/options"
+	"github.com/docker/libnetwork/types"
+	"github.com/vishvananda/netlink"
+)
+
+func networkOptions(generic options.Generic) map[string]interface{} {
+	return map[string]interface{}{netlabel.GenericData: generic}
+}
+
+func TestParseBridgeName(t *testing.T) {
+	name, err := parseBridgeName(networkOptions(options.Generic{BridgeNameOption: "br-test"}))
+	if err != nil || name != "br-test" {
+		t.Fatalf("Expected br-test, got %q (%v)", name, err)
+	}
+	for _, generic := range []options.Generic{
+		nil,
+		{},
+		{BridgeNameOption: 1},
+		{BridgeNameOption: "br-test", "foo": "bar"},
+	} {
+		if _, err := parseBridgeName(networkOptions(generic)); err == nil {
+			t.Fatalf("Expected an error for the options %v", generic)
+		}
+	}
+}
+
+func TestCreateNetworks(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("Test requires root")
+	}
+	defer netutils.SetupTestNetNS(t)()
+
+	d := &driver{config: Config{DefaultBridge: "docker0"}, networks: make(map[types.UUID]*bridgeNetwork)}
+	subnets := map[string]bool{}
+	for _, name := range []string{"br-test1", "br-test2"} {
+		if err := d.CreateNetwork(types.UUID(name), networkOptions(options.Generic{BridgeNameOption: name})); err != nil {
+			t.Fatal(err)
+		}
+		addr, _, err := netutils.GetIfaceAddr(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subnet := addr.(*net.IPNet).IP.Mask(addr.(*net.IPNet).Mask).String()
+		if subnets[subnet] {
+			t.Fatalf("Expected each bridge to get its own subnet, got %s twice", subnet)
+		}
+		subnets[subnet] = true
+	}
+
+	for _, name := range []string{"br-test1", "docker0"} {
+		if err := d.CreateNetwork("other", networkOptions(options.Generic{BridgeNameOption: name})); err == nil {
+			t.Fatalf("Expected an error creating a network with the bridge %s", name)
+		}
+	}
+
+	if err := d.DeleteNetwork("br-test1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := netlink.LinkByName("br-test1"); err == nil {
+		t.Fatal("Expected the bridge of the deleted network to be removed")
+	}
+	if _, err := d.getNetwork("br-test1"); err == nil {
+		t.Fatal("Expected the deleted network to be forgotten")
+	}
+}
+
+func TestHasDefaultRoute(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("Test requires root")
+	}
+	defer netutils.SetupTestNetNS(t)()
+
+	path := fmt.Sprintf("/proc/self/task/%d/ns/net", syscall.Gettid())
+	if hasDefaultRoute(path) {
+		t.Fatal("Expected no default route in a new network namespace")
+	}
+	if hasDefaultRoute("/nonexistent") {
+		t.Fatal("Expected no default route without a network namespace")
+	}
+
+	link := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: "br-test"}}
+	if err := netlink.LinkAdd(link); err != nil {
+		t.Fatal(err)
+	}
+	ip, ipNet, _ := net.ParseCIDR("192.168.42.2/24")
+	ipNet.IP = ip
+	if err := netlink.AddrAdd(link, &netlink.Addr{IPNet: ipNet}); err != nil {
+		t.Fatal(err)
+	}
+	if err := netlink.LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := netlink.RouteAdd(&netlink.Route{LinkIndex: link.Attrs().Index, Gw: net.ParseIP("192.168.42.1")}); err != nil {
+		t.Fatal(err)
+	}
+	if !hasDefaultRoute(path) {
+		t.Fatal("Expected the default route to be found")
+	}
+}
@@ -26,4 +26,19 @@ type Settings struct {
 	SandboxKey             string
 	SecondaryIPAddresses   []Address
 	SecondaryIPv6Addresses []Address
+	Networks               map[string]*EndpointSettings
+}
+
+// EndpointSettings stores the addresses of a container on one of the
+// networks it is connected to.
+type EndpointSettings struct {
+	NetworkID           string
+	EndpointID          string
+	Gateway             string
+	IPAddress           string
+	IPPrefixLen         int
+	IPv6Gateway         string
+	GlobalIPv6Address   string
+	GlobalIPv6PrefixLen int
+	MacAddress          string
 }
@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/docker/docker/daemon/network"
+	"github.com/docker/docker/runconfig"
+)
+
+func newNetworkedContainer(id, name string, running bool, aliases []string, networks map[string]string) *Container {
+	settings := &network.Settings{Networks: make(map[string]*network.EndpointSettings)}
+	for n, ip := range networks {
+		settings.Networks[n] = &network.EndpointSettings{IPAddress: ip}
+	}
+	return &Container{
+		CommonContainer: CommonContainer{
+			ID:              id,
+			Name:            "/" + name,
+			State:           &State{Running: running},
+			NetworkSettings: settings,
+			hostConfig:      &runconfig.HostConfig{NetworkAliases: aliases},
+		},
+	}
+}
+
+func TestResolveName(t *testing.T) {
+	client := newNetworkedContainer("1", "client", true, nil, map[string]string{"bridge": "172.17.0.2", "front": "10.0.1.2", "back": "10.0.2.2"})
+	web := newNetworkedContainer("2", "web", true, []string{"www"}, map[string]string{"front": "10.0.1.3"})
+	db1 := newNetworkedContainer("3", "db1", true, []string{"db"}, map[string]string{"back": "10.0.2.3", "bridge": "172.17.0.3"})
+	db2 := newNetworkedContainer("4", "db2", true, []string{"db"}, map[string]string{"back": "10.0.2.4"})
+	stopped := newNetworkedContainer("5", "stopped", false, nil, map[string]string{"front": "10.0.1.5"})
+	other := newNetworkedContainer("6", "other", true, nil, map[string]string{"bridge": "172.17.0.6", "private": "10.0.3.6"})
+
+	daemon := &Daemon{containers: &contStore{s: make(map[string]*Container)}}
+	for _, c := range []*Container{client, web, db1, db2, stopped, other} {
+		daemon.containers.Add(c.ID, c)
+	}
+
+	for name, expected := range map[string][]string{
+		"web":     {"10.0.1.3"},
+		"WWW.":    {"10.0.1.3"},
+		"db1":     {"10.0.2.3"},
+		"db":      {"10.0.2.3", "10.0.2.4"},
+		"client":  {"10.0.1.2", "10.0.2.2"},
+		"stopped": nil,
+		"other":   nil,
+		"unknown": nil,
+	} {
+		ips := daemon.resolveName(client, name)
+		if len(ips) != len(expected) {
+			t.Fatalf("Expected %v for %s, got %v", expected, name, ips)
+		}
+		found := make(map[string]bool)
+		for _, ip := range ips {
+			found[ip.String()] = true
+		}
+		for _, ip := range expected {
+			if !found[ip] {
+				t.Fatalf("Expected %v for %s, got %v", expected, name, ips)
+			}
+		}
+	}
+
+	// Containers only resolve names on their user-defined networks.
+	if ips := daemon.resolveName(other, "db1"); ips != nil {
+		t.Fatalf("Expected no address for db1 from a container on another network, got %v", ips)
+	}
+	if ips := daemon.resolveName(other, "client"); ips != nil {
+		t.Fatalf("Expected no address for a container sharing the bridge network only, got %v", ips)
+	}
+}
@@ -0,0 +1,46 @@
+package resolver
+
+import (
+	"net"
+	"os"
+	"runtime"
+
+	"github.com/vishvananda/netns"
+)
+
+// ListenInNamespace opens the UDP socket and the TCP listener of the
+// resolver, in the network namespace at nsPath, usually the sandbox of a
+// container.
+func ListenInNamespace(nsPath string) (net.PacketConn, net.Listener, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	origns, err := netns.Get()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer origns.Close()
+
+	f, err := os.Open(nsPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	if err := netns.Set(netns.NsHandle(f.Fd())); err != nil {
+		return nil, nil, err
+	}
+	defer netns.Set(origns)
+
+	addr := net.JoinHostPort(Address, "53")
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, l, nil
+}
@@ -0,0 +1,368 @@
+// Package resolver implements the DNS server embedded in the daemon, which
+// answers the queries of the containers connected to user-defined networks.
+//
+// Names of containers sharing a network with the container that sent the
+// query are resolved to their current addresses, every other query is
+// forwarded to the external DNS servers. Queries are served over UDP and
+// TCP, and forwarded over the protocol they were received with.
+package resolver
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	// Address is the address the resolver listens on, in the network
+	// namespace of the containers.
+	Address = "127.0.0.11"
+
+	// ttl is the time to live of the answers of the resolver, in seconds.
+	// Containers get new addresses when they restart, keep it short.
+	ttl = 10
+
+	forwardTimeout = 4 * time.Second
+	tcpIdleTimeout = 10 * time.Second
+	maxMessageSize = 65535
+	headerLen      = 12
+
+	// maxUDPSize is the size of the largest response sent over UDP to
+	// clients which don't advertise a larger one with EDNS.
+	maxUDPSize = 512
+
+	typeA    = 1
+	typeAAAA = 28
+	classIN  = 1
+
+	rcodeServFail = 2
+)
+
+var errMalformedQuery = errors.New("malformed DNS query")
+
+// LookupFunc returns the addresses of the containers known by name, or nil if
+// the name is unknown and the query must be forwarded.
+type LookupFunc func(name string) []net.IP
+
+// Resolver is a DNS server answering the queries received on a UDP socket
+// and on a TCP listener.
+type Resolver struct {
+	conn       net.PacketConn
+	listener   net.Listener
+	lookup     LookupFunc
+	forwarders []string
+}
+
+// New returns a resolver answering the queries received on conn and on the
+// connections accepted by listener. Names are resolved with lookup, or by
+// the forwarders, given as host:port.
+func New(conn net.PacketConn, listener net.Listener, lookup LookupFunc, forwarders []string) *Resolver {
+	return &Resolver{
+		conn:       conn,
+		listener:   listener,
+		lookup:     lookup,
+		forwarders: forwarders,
+	}
+}
+
+// Start serves the queries in the background, until Stop is called.
+func (r *Resolver) Start() {
+	go r.serve()
+	go r.serveTCP()
+}
+
+// Stop closes the sockets of the resolver.
+func (r *Resolver) Stop() error {
+	err := r.conn.Close()
+	if lerr := r.listener.Close(); err == nil {
+		err = lerr
+	}
+	return err
+}
+
+func (r *Resolver) serve() {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := r.conn.ReadFrom(buf)
+		if err != nil {
+			if nerr, ok := err.(net.Error); ok && nerr.Temporary() {
+				continue
+			}
+			// The socket is closed when the resolver is stopped.
+			return
+		}
+		query := make([]byte, n)
+		copy(query, buf[:n])
+		go r.handle(query, addr)
+	}
+}
+
+func (r *Resolver) handle(query []byte, addr net.Addr) {
+	resp, err := r.answer(query, "udp")
+	if err != nil {
+		logrus.Debugf("Dropping DNS query from %s: %v", addr, err)
+		return
+	}
+	if _, err := r.conn.WriteTo(resp, addr); err != nil {
+		logrus.Debugf("Error sending DNS response to %s: %v", addr, err)
+	}
+}
+
+func (r *Resolver) serveTCP() {
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			if nerr, ok := err.(net.Error); ok && nerr.Temporary() {
+				continue
+			}
+			// The listener is closed when the resolver is stopped.
+			return
+		}
+		go r.handleTCP(conn)
+	}
+}
+
+// handleTCP answers the queries received on conn, until the client closes
+// it or stays idle.
+func (r *Resolver) handleTCP(conn net.Conn) {
+	defer conn.Close()
+	for {
+		conn.SetDeadline(time.Now().Add(tcpIdleTimeout))
+		query, err := readTCPMessage(conn)
+		if err != nil {
+			if err != io.EOF {
+				logrus.Debugf("Error reading DNS query from %s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		resp, err := r.answer(query, "tcp")
+		if err != nil {
+			logrus.Debugf("Dropping DNS query from %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+		if err := writeTCPMessage(conn, resp); err != nil {
+			logrus.Debugf("Error sending DNS response to %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+// answer returns the response to query, received over network. Responses
+// too large for UDP are truncated, for the client to query again over TCP.
+func (r *Resolver) answer(query []byte, network string) ([]byte, error) {
+	q, err := parseQuestion(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.class == classIN && (q.qtype == typeA || q.qtype == typeAAAA) {
+		if ips := r.lookup(q.name); ips != nil {
+			resp := buildResponse(query, q, ips)
+			if network == "udp" && len(resp) > maxUDPSize {
+				resp = truncate(query, q)
+			}
+			return resp, nil
+		}
+	}
+	// The responses of the forwarders are already truncated as needed.
+	return r.forward(query, q, network), nil
+}
+
+// forward sends the query to the forwarders over network, in order, and
+// returns the first response received.
+func (r *Resolver) forward(query []byte, q *question, network string) []byte {
+	for _, fwd := range r.forwarders {
+		conn, err := net.DialTimeout(network, fwd, forwardTimeout)
+		if err != nil {
+			logrus.Debugf("Error connecting to DNS server %s: %v", fwd, err)
+			continue
+		}
+		var resp []byte
+		if network == "tcp" {
+			resp, err = exchangeTCP(conn, query)
+		} else {
+			resp, err = exchange(conn, query)
+		}
+		conn.Close()
+		if err != nil {
+			logrus.Debugf("Error forwarding DNS query for %s to %s over %s: %v", q.name, fwd, network, err)
+			continue
+		}
+		return resp
+	}
+	return buildError(query, q, rcodeServFail)
+}
+
+func exchange(conn net.Conn, query []byte) ([]byte, error) {
+	conn.SetDeadline(time.Now().Add(forwardTimeout))
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		// Ignore stray responses to other queries.
+		if n >= headerLen && buf[0] == query[0] && buf[1] == query[1] {
+			return buf[:n], nil
+		}
+	}
+}
+
+func exchangeTCP(conn net.Conn, query []byte) ([]byte, error) {
+	conn.SetDeadline(time.Now().Add(forwardTimeout))
+	if err := writeTCPMessage(conn, query); err != nil {
+		return nil, err
+	}
+	resp, err := readTCPMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < headerLen || resp[0] != query[0] || resp[1] != query[1] {
+		return nil, errors.New("unexpected DNS response")
+	}
+	return resp, nil
+}
+
+// readTCPMessage reads a DNS message, prefixed with its length, from conn.
+func readTCPMessage(conn net.Conn) ([]byte, error) {
+	var l [2]byte
+	if _, err := io.ReadFull(conn, l[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(l[:]))
+	if _, err := io.ReadFull(conn, msg); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return msg, nil
+}
+
+// writeTCPMessage writes the DNS message msg, prefixed with its length, to
+// conn.
+func writeTCPMessage(conn net.Conn, msg []byte) error {
+	buf := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	copy(buf[2:], msg)
+	_, err := conn.Write(buf)
+	return err
+}
+
+// question is the single question of a standard DNS query.
+type question struct {
+	name  string
+	qtype uint16
+	class uint16
+	// end is the offset of the end of the question in the query.
+	end int
+}
+
+func parseQuestion(msg []byte) (*question, error) {
+	if len(msg) < headerLen {
+		return nil, errMalformedQuery
+	}
+	// Only standard queries with exactly one question are supported.
+	if msg[2]&0x80 != 0 || (msg[2]>>3)&0x0f != 0 || binary.BigEndian.Uint16(msg[4:]) != 1 {
+		return nil, errMalformedQuery
+	}
+
+	var labels []string
+	off := headerLen
+	for {
+		if off >= len(msg) {
+			return nil, errMalformedQuery
+		}
+		l := int(msg[off])
+		off++
+		if l == 0 {
+			break
+		}
+		// Compression pointers are not expected in questions.
+		if l&0xc0 != 0 || off+l > len(msg) {
+			return nil, errMalformedQuery
+		}
+		labels = append(labels, string(msg[off:off+l]))
+		off += l
+	}
+	if off+4 > len(msg) {
+		return nil, errMalformedQuery
+	}
+
+	return &question{
+		name:  strings.ToLower(strings.Join(labels, ".")),
+		qtype: binary.BigEndian.Uint16(msg[off:]),
+		class: binary.BigEndian.Uint16(msg[off+2:]),
+		end:   off + 4,
+	}, nil
+}
+
+// buildResponse answers the question with the addresses in ips matching the
+// type of the question. A known name without any address of the right type
+// gets an empty answer rather than being forwarded.
+func buildResponse(query []byte, q *question, ips []net.IP) []byte {
+	var answers [][]byte
+	for _, ip := range ips {
+		var data []byte
+		if ip4 := ip.To4(); ip4 != nil {
+			if q.qtype != typeA {
+				continue
+			}
+			data = ip4
+		} else {
+			if q.qtype != typeAAAA || ip.To16() == nil {
+				continue
+			}
+			data = ip.To16()
+		}
+		rr := make([]byte, 12+len(data))
+		// The name is a pointer to the one of the question.
+		binary.BigEndian.PutUint16(rr[0:], 0xc000|headerLen)
+		binary.BigEndian.PutUint16(rr[2:], q.qtype)
+		binary.BigEndian.PutUint16(rr[4:], classIN)
+		binary.BigEndian.PutUint32(rr[6:], ttl)
+		binary.BigEndian.PutUint16(rr[10:], uint16(len(data)))
+		copy(rr[12:], data)
+		answers = append(answers, rr)
+	}
+
+	resp := responseHeader(query, q, 0, len(answers))
+	for _, rr := range answers {
+		resp = append(resp, rr...)
+	}
+	return resp
+}
+
+func buildError(query []byte, q *question, rcode byte) []byte {
+	return responseHeader(query, q, rcode, 0)
+}
+
+// truncate returns the response to query without answers and with the TC
+// bit set.
+func truncate(query []byte, q *question) []byte {
+	resp := responseHeader(query, q, 0, 0)
+	resp[2] |= 0x02
+	return resp
+}
+
+// responseHeader returns the header and the question of the response to
+// query.
+func responseHeader(query []byte, q *question, rcode byte, answers int) []byte {
+	resp := make([]byte, headerLen, q.end+answers*28)
+	copy(resp[0:2], query[0:2])
+	// QR and AA set, RD copied from the query.
+	resp[2] = 0x84 | query[2]&0x01
+	// RA set.
+	resp[3] = 0x80 | rcode&0x0f
+	binary.BigEndian.PutUint16(resp[4:], 1)
+	binary.BigEndian.PutUint16(resp[6:], uint16(answers))
+	return append(resp, query[headerLen:q.end]...)
+}
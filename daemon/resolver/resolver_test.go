@@ -0,0 +1,216 @@
+package resolver
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func buildQuery(id uint16, name string, qtype uint16) []byte {
+	msg := make([]byte, headerLen)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	// RD set.
+	msg[2] = 0x01
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0, byte(qtype>>8), byte(qtype), 0, classIN)
+	return msg
+}
+
+// parseAnswers returns the rcode and the addresses in the answers of resp.
+func parseAnswers(t *testing.T, query, resp []byte) (byte, []net.IP) {
+	if len(resp) < len(query) {
+		t.Fatalf("Response too short: %v", resp)
+	}
+	if resp[0] != query[0] || resp[1] != query[1] {
+		t.Fatalf("Expected the ID of the query in the response, got %v", resp[0:2])
+	}
+	if resp[2]&0x80 == 0 || resp[2]&0x01 == 0 {
+		t.Fatalf("Expected QR and RD set in the response, got flags %x", resp[2:4])
+	}
+	if string(resp[headerLen:len(query)]) != string(query[headerLen:]) {
+		t.Fatal("Expected the question of the query in the response")
+	}
+
+	var ips []net.IP
+	off := len(query)
+	for i := 0; i < int(binary.BigEndian.Uint16(resp[6:])); i++ {
+		l := int(binary.BigEndian.Uint16(resp[off+10:]))
+		ips = append(ips, net.IP(resp[off+12:off+12+l]))
+		off += 12 + l
+	}
+	if off != len(resp) {
+		t.Fatalf("Unexpected trailing data in the response: %v", resp[off:])
+	}
+	return resp[3] & 0x0f, ips
+}
+
+// listen opens a UDP socket and a TCP listener on the same port.
+func listen(t *testing.T) (net.PacketConn, net.Listener) {
+	for {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := net.Listen("tcp", conn.LocalAddr().String())
+		if err == nil {
+			return conn, l
+		}
+		conn.Close()
+	}
+}
+
+func startResolver(t *testing.T, lookup LookupFunc, forwarders []string) (*Resolver, string) {
+	conn, l := listen(t)
+	r := New(conn, l, lookup, forwarders)
+	r.Start()
+	return r, conn.LocalAddr().String()
+}
+
+func dial(t *testing.T, network, addr string) net.Conn {
+	client, err := net.Dial(network, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func exchangeQuery(t *testing.T, client net.Conn, query []byte) []byte {
+	var (
+		resp []byte
+		err  error
+	)
+	if _, ok := client.(*net.TCPConn); ok {
+		resp, err = exchangeTCP(client, query)
+	} else {
+		resp, err = exchange(client, query)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestResolveKnownNames(t *testing.T) {
+	lookup := func(name string) []net.IP {
+		if name == "web" {
+			return []net.IP{net.ParseIP("172.20.0.2"), net.ParseIP("172.20.0.3"), net.ParseIP("fd00::2")}
+		}
+		return nil
+	}
+	r, addr := startResolver(t, lookup, nil)
+	defer r.Stop()
+
+	for _, network := range []string{"udp", "tcp"} {
+		client := dial(t, network, addr)
+		defer client.Close()
+
+		query := buildQuery(1, "WEB", typeA)
+		rcode, ips := parseAnswers(t, query, exchangeQuery(t, client, query))
+		if rcode != 0 || len(ips) != 2 || ips[0].String() != "172.20.0.2" || ips[1].String() != "172.20.0.3" {
+			t.Fatalf("Expected the IPv4 addresses of web over %s, got rcode %d and %v", network, rcode, ips)
+		}
+
+		query = buildQuery(2, "web", typeAAAA)
+		rcode, ips = parseAnswers(t, query, exchangeQuery(t, client, query))
+		if rcode != 0 || len(ips) != 1 || ips[0].String() != "fd00::2" {
+			t.Fatalf("Expected the IPv6 address of web over %s, got rcode %d and %v", network, rcode, ips)
+		}
+
+		// Unknown names fail without forwarders.
+		query = buildQuery(3, "example.com", typeA)
+		rcode, ips = parseAnswers(t, query, exchangeQuery(t, client, query))
+		if rcode != rcodeServFail || len(ips) != 0 {
+			t.Fatalf("Expected SERVFAIL for an unknown name over %s, got rcode %d and %v", network, rcode, ips)
+		}
+	}
+}
+
+func TestTruncateLargeUDPResponses(t *testing.T) {
+	var addrs []net.IP
+	for i := 0; i < 40; i++ {
+		addrs = append(addrs, net.IPv4(172, 20, 0, byte(i+2)))
+	}
+	r, addr := startResolver(t, func(name string) []net.IP { return addrs }, nil)
+	defer r.Stop()
+
+	udp := dial(t, "udp", addr)
+	defer udp.Close()
+	query := buildQuery(8, "web", typeA)
+	resp := exchangeQuery(t, udp, query)
+	if resp[2]&0x02 == 0 {
+		t.Fatal("Expected the TC bit in a response too large for UDP")
+	}
+	if rcode, ips := parseAnswers(t, query, resp); rcode != 0 || len(ips) != 0 {
+		t.Fatalf("Expected a truncated response without answers, got rcode %d and %v", rcode, ips)
+	}
+
+	tcp := dial(t, "tcp", addr)
+	defer tcp.Close()
+	resp = exchangeQuery(t, tcp, query)
+	if resp[2]&0x02 != 0 {
+		t.Fatal("Expected no TC bit over TCP")
+	}
+	if rcode, ips := parseAnswers(t, query, resp); rcode != 0 || len(ips) != len(addrs) {
+		t.Fatalf("Expected %d addresses over TCP, got rcode %d and %v", len(addrs), rcode, ips)
+	}
+}
+
+func TestForwardUnknownNames(t *testing.T) {
+	external, upstream := startResolver(t, func(name string) []net.IP {
+		if name == "example.com" {
+			return []net.IP{net.ParseIP("93.184.216.34")}
+		}
+		return nil
+	}, nil)
+	defer external.Stop()
+
+	lookup := func(name string) []net.IP { return nil }
+	r, addr := startResolver(t, lookup, []string{"127.0.0.1:1", upstream})
+	defer r.Stop()
+
+	for _, network := range []string{"udp", "tcp"} {
+		client := dial(t, network, addr)
+		defer client.Close()
+
+		query := buildQuery(4, "example.com.", typeA)
+		rcode, ips := parseAnswers(t, query, exchangeQuery(t, client, query))
+		if rcode != 0 || len(ips) != 1 || ips[0].String() != "93.184.216.34" {
+			t.Fatalf("Expected the answer of the forwarder over %s, got rcode %d and %v", network, rcode, ips)
+		}
+	}
+}
+
+func TestDropMalformedQueries(t *testing.T) {
+	for _, query := range [][]byte{
+		{0, 1, 0},
+		buildQuery(5, "web", typeA)[:15],
+		append([]byte{0, 6, 0x80, 0, 0, 1, 0, 0, 0, 0, 0, 0}, buildQuery(6, "web", typeA)[headerLen:]...),
+	} {
+		if _, err := parseQuestion(query); err != errMalformedQuery {
+			t.Fatalf("Expected %v parsing %v, got %v", errMalformedQuery, query, err)
+		}
+	}
+}
+
+func TestStop(t *testing.T) {
+	r, addr := startResolver(t, func(string) []net.IP { return nil }, nil)
+	client := dial(t, "udp", addr)
+	defer client.Close()
+	if err := r.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	client.SetDeadline(time.Now().Add(100 * time.Millisecond))
+	client.Write(buildQuery(7, "web", typeA))
+	if _, err := client.Read(make([]byte, maxMessageSize)); err == nil {
+		t.Fatal("Expected no response from a stopped resolver")
+	}
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Fatal("Expected the TCP listener of a stopped resolver to be closed")
+	}
+}
@@ -0,0 +1,180 @@
+// +build linux
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// sandboxInterface is an interface of the network namespace of a container,
+// with the addresses libnetwork configured on it.
+type sandboxInterface struct {
+	name  string
+	mac   string
+	addrs []netlink.Addr
+}
+
+// sandboxState is what libnetwork tears down in the network namespace of a
+// container when the container leaves one of its endpoints: the interfaces
+// of all its endpoints, and the default routes going through them.
+type sandboxState struct {
+	interfaces []sandboxInterface
+	gateways   []net.IP
+}
+
+// inNetNS runs fn in the network namespace mounted at path.
+func inNetNS(path string, fn func() error) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	origns, err := netns.Get()
+	if err != nil {
+		return err
+	}
+	defer origns.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to get network namespace %q: %v", path, err)
+	}
+	defer f.Close()
+
+	if err := netns.Set(netns.NsHandle(f.Fd())); err != nil {
+		return err
+	}
+	defer netns.Set(origns)
+
+	return fn()
+}
+
+// linkByMAC returns the interface of the current network namespace having
+// the MAC address mac, or nil.
+func linkByMAC(mac string) (netlink.Link, error) {
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range links {
+		if l.Attrs().HardwareAddr.String() == mac {
+			return l, nil
+		}
+	}
+	return nil, nil
+}
+
+// saveSandbox returns the state of the interfaces having the MAC addresses
+// macs in the network namespace mounted at path.
+func saveSandbox(path string, macs []string) (*sandboxState, error) {
+	state := &sandboxState{}
+	err := inNetNS(path, func() error {
+		for _, mac := range macs {
+			link, err := linkByMAC(mac)
+			if err != nil {
+				return err
+			}
+			if link == nil {
+				continue
+			}
+			addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+			if err != nil {
+				return err
+			}
+			iface := sandboxInterface{name: link.Attrs().Name, mac: mac}
+			for _, a := range addrs {
+				// The kernel configures the link-local addresses itself.
+				if !a.IP.IsLinkLocalUnicast() {
+					iface.addrs = append(iface.addrs, a)
+				}
+			}
+			state.interfaces = append(state.interfaces, iface)
+		}
+
+		for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+			routes, err := netlink.RouteList(nil, family)
+			if err != nil {
+				return err
+			}
+			for _, r := range routes {
+				if r.Dst == nil && r.Gw != nil {
+					state.gateways = append(state.gateways, r.Gw)
+				}
+			}
+		}
+		return nil
+	})
+	return state, err
+}
+
+// restoreSandbox moves the saved interfaces back from the network namespace
+// of the daemon to the one mounted at path, and configures them and the
+// default routes again. The interfaces which weren't moved out of the
+// container are left alone, as are the gateways which can't be reached
+// anymore.
+func restoreSandbox(path string, state *sandboxState) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to get network namespace %q: %v", path, err)
+	}
+	defer f.Close()
+
+	var moved []sandboxInterface
+	for _, iface := range state.interfaces {
+		link, err := linkByMAC(iface.mac)
+		if err != nil {
+			return err
+		}
+		if link == nil {
+			continue
+		}
+		if err := netlink.LinkSetNsFd(link, int(f.Fd())); err != nil {
+			return fmt.Errorf("failed to move interface %s back to network namespace %q: %v", iface.name, path, err)
+		}
+		moved = append(moved, iface)
+	}
+
+	return inNetNS(path, func() error {
+		for _, iface := range moved {
+			link, err := linkByMAC(iface.mac)
+			if err != nil {
+				return err
+			}
+			if link == nil {
+				return fmt.Errorf("interface %s not found in network namespace %q", iface.name, path)
+			}
+			if err := netlink.LinkSetName(link, iface.name); err != nil {
+				return err
+			}
+			for _, a := range iface.addrs {
+				addr := &netlink.Addr{IPNet: a.IPNet}
+				if err := netlink.AddrAdd(link, addr); err != nil {
+					return err
+				}
+			}
+			if err := netlink.LinkSetUp(link); err != nil {
+				return err
+			}
+		}
+
+		for _, gw := range state.gateways {
+			routes, err := netlink.RouteGet(gw)
+			if err != nil || len(routes) == 0 {
+				// The gateway was on the network the container left.
+				continue
+			}
+			if err := netlink.RouteAdd(&netlink.Route{
+				Scope:     netlink.SCOPE_UNIVERSE,
+				LinkIndex: routes[0].LinkIndex,
+				Gw:        gw,
+			}); err != nil && !os.IsExist(err) {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,146 @@
+// +build linux
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/docker/libnetwork/netutils"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// moveOut moves the interface name of the network namespace at path to the
+// namespace host, as libnetwork does when a container leaves an endpoint.
+func moveOut(t *testing.T, path, name string, host netns.NsHandle) {
+	err := inNetNS(path, func() error {
+		link, err := netlink.LinkByName(name)
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetDown(link); err != nil {
+			return err
+		}
+		if err := netlink.LinkSetName(link, "veth-sandbox"); err != nil {
+			return err
+		}
+		return netlink.LinkSetNsFd(link, int(host))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveRestoreSandbox(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("Test requires root")
+	}
+	defer netutils.SetupTestNetNS(t)()
+
+	host, err := netns.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer host.Close()
+	sandbox, err := netns.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sandbox.Close()
+	if err := netns.Set(host); err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/proc/self/fd/%d", int(sandbox))
+
+	mac, _ := net.ParseMAC("02:42:ac:11:00:02")
+	veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "veth-host"}, PeerName: "veth-sandbox"}
+	if err := netlink.LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	peer, err := netlink.LinkByName("veth-sandbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := netlink.LinkSetHardwareAddr(peer, mac); err != nil {
+		t.Fatal(err)
+	}
+	if err := netlink.LinkSetNsFd(peer, int(sandbox)); err != nil {
+		t.Fatal(err)
+	}
+
+	ip, ipNet, _ := net.ParseCIDR("192.168.42.2/24")
+	ipNet.IP = ip
+	gw := net.ParseIP("192.168.42.1")
+	err = inNetNS(path, func() error {
+		link, err := netlink.LinkByName("veth-sandbox")
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetName(link, "eth1"); err != nil {
+			return err
+		}
+		if err := netlink.AddrAdd(link, &netlink.Addr{IPNet: ipNet}); err != nil {
+			return err
+		}
+		if err := netlink.LinkSetUp(link); err != nil {
+			return err
+		}
+		return netlink.RouteAdd(&netlink.Route{LinkIndex: link.Attrs().Index, Gw: gw})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := saveSandbox(path, []string{mac.String(), "02:42:ac:11:00:03"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.interfaces) != 1 || state.interfaces[0].name != "eth1" {
+		t.Fatalf("Expected interface eth1 to be saved, got %v", state.interfaces)
+	}
+	if len(state.gateways) != 1 || !state.gateways[0].Equal(gw) {
+		t.Fatalf("Expected gateway %s to be saved, got %v", gw, state.gateways)
+	}
+
+	moveOut(t, path, "eth1", host)
+	if err := restoreSandbox(path, state); err != nil {
+		t.Fatal(err)
+	}
+	// Restoring again leaves the interfaces in the sandbox alone.
+	if err := restoreSandbox(path, state); err != nil {
+		t.Fatal(err)
+	}
+
+	err = inNetNS(path, func() error {
+		link, err := netlink.LinkByName("eth1")
+		if err != nil {
+			return err
+		}
+		if link.Attrs().Flags&net.FlagUp == 0 {
+			return fmt.Errorf("Expected eth1 to be up")
+		}
+		addrs, err := netlink.AddrList(link, netlink.FAMILY_V4)
+		if err != nil {
+			return err
+		}
+		if len(addrs) != 1 || addrs[0].IPNet.String() != ipNet.String() {
+			return fmt.Errorf("Expected address %s on eth1, got %v", ipNet, addrs)
+		}
+		routes, err := netlink.RouteList(nil, netlink.FAMILY_V4)
+		if err != nil {
+			return err
+		}
+		for _, r := range routes {
+			if r.Dst == nil && r.Gw.Equal(gw) {
+				return nil
+			}
+		}
+		return fmt.Errorf("Expected the default route through %s, got %v", gw, routes)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
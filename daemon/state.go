@@ -17,11 +17,14 @@ type State struct {
 	OOMKilled         bool
 	removalInProgress bool // Not need for this to be persistent on disk.
 	Dead              bool
+	Checkpointed      bool // stopped by a checkpoint, until it is restored or started
 	Pid               int
 	ExitCode          int
 	Error             string // contains last known error when starting the container
 	StartedAt         time.Time
 	FinishedAt        time.Time
+	CheckpointedAt    time.Time // time of the last checkpoint, zero if there is none
+	Health            *Health
 	waitChan          chan struct{}
 }
 
@@ -41,6 +44,10 @@ func (s *State) String() string {
 			return fmt.Sprintf("Restarting (%d) %s ago", s.ExitCode, units.HumanDuration(time.Now().UTC().Sub(s.FinishedAt)))
 		}
 
+		if h := s.Health; h != nil {
+			return fmt.Sprintf("Up %s (%s)", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)), h.String())
+		}
+
 		return fmt.Sprintf("Up %s", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)))
 	}
 
@@ -52,6 +59,10 @@ func (s *State) String() string {
 		return "Dead"
 	}
 
+	if s.Checkpointed {
+		return fmt.Sprintf("Checkpointed %s ago", units.HumanDuration(time.Now().UTC().Sub(s.CheckpointedAt)))
+	}
+
 	if s.StartedAt.IsZero() {
 		return "Created"
 	}
@@ -79,6 +90,10 @@ func (s *State) StateString() string {
 		return "dead"
 	}
 
+	if s.Checkpointed {
+		return "checkpointed"
+	}
+
 	if s.StartedAt.IsZero() {
 		return "created"
 	}
@@ -167,6 +182,7 @@ func (s *State) setRunning(pid int) {
 	s.Running = true
 	s.Paused = false
 	s.Restarting = false
+	s.Checkpointed = false
 	s.ExitCode = 0
 	s.Pid = pid
 	s.StartedAt = time.Now().UTC()
@@ -257,6 +273,13 @@ func (s *State) ResetRemovalInProgress() {
 	s.Unlock()
 }
 
+// setCheckpointed records a checkpoint of the container. Unless it was left
+// running, the container is stopped once its processes were dumped.
+func (s *State) setCheckpointed(leaveRunning bool) {
+	s.CheckpointedAt = time.Now().UTC()
+	s.Checkpointed = !leaveRunning
+}
+
 func (s *State) SetDead() {
 	s.Lock()
 	s.Dead = true
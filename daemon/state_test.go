@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -102,3 +103,33 @@ func TestStateTimeoutWait(t *testing.T) {
 	}
 
 }
+
+func TestStateCheckpointed(t *testing.T) {
+	s := NewState()
+	s.SetRunning(100)
+
+	// A checkpoint leaving the container running doesn't change its state.
+	s.setCheckpointed(true)
+	if s.CheckpointedAt.IsZero() || s.Checkpointed {
+		t.Fatalf("Unexpected checkpoint state %+v", s)
+	}
+	if st := s.StateString(); st != "running" {
+		t.Fatalf("Unexpected state %q", st)
+	}
+
+	s.setCheckpointed(false)
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 137})
+	if st := s.StateString(); st != "checkpointed" {
+		t.Fatalf("Unexpected state %q", st)
+	}
+	if st := s.String(); !strings.HasPrefix(st, "Checkpointed ") {
+		t.Fatalf("Unexpected state %q", st)
+	}
+
+	// The container isn't checkpointed anymore once restored, but its
+	// checkpoint is kept.
+	s.SetRunning(101)
+	if s.CheckpointedAt.IsZero() || s.Checkpointed {
+		t.Fatalf("Unexpected checkpoint state %+v", s)
+	}
+}
@@ -28,6 +28,8 @@ func (daemon *Daemon) ContainerStats(name string, stream bool, out io.Writer) er
 		ss.MemoryStats.Limit = uint64(update.MemoryLimit)
 		ss.Read = update.Read
 		ss.CpuStats.SystemUsage = update.SystemUsage
+		ss.PidsStats.Current = update.PidsCurrent
+		ss.PidsStats.Limit = update.PidsLimit
 		pre_cpu_stats = ss.CpuStats
 		if err := enc.Encode(ss); err != nil {
 			// TODO: handle the specific broken pipe
@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/runconfig"
+)
+
+// ContainerUpdate updates the resource limits of a container. The new limits
+// are applied right away to a running container, and persisted to be used
+// the next times the container starts.
+func (daemon *Daemon) ContainerUpdate(name string, updateConfig *runconfig.UpdateConfig) ([]string, error) {
+	container, err := daemon.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return container.Update(updateConfig)
+}
+
+// Update validates the given update against the capabilities of the system
+// and applies it to the container.
+func (container *Container) Update(updateConfig *runconfig.UpdateConfig) ([]string, error) {
+	container.Lock()
+	defer container.Unlock()
+
+	if container.removalInProgress || container.Dead {
+		return nil, fmt.Errorf("Container %s is marked for removal and cannot be updated.", container.ID)
+	}
+
+	// The update is validated on a copy of the host config, so that the
+	// container is left unchanged on error.
+	hostConfig := *container.hostConfig
+	updateConfig.Apply(&hostConfig)
+
+	warnings, err := container.daemon.verifyResources(&hostConfig)
+	if err != nil {
+		return warnings, err
+	}
+
+	if container.Running {
+		resources := *container.command.Resources
+		resources.Memory = hostConfig.Memory
+		resources.MemorySwap = hostConfig.MemorySwap
+		if resources.MemorySwap == 0 && resources.Memory > 0 {
+			// The memory+swap limit of the container follows its memory
+			// limit when it wasn't set, the driver must raise it first.
+			resources.MemorySwap = 2 * resources.Memory
+		}
+		resources.CpuShares = hostConfig.CpuShares
+		resources.CpuQuota = hostConfig.CpuQuota
+		resources.CpusetCpus = hostConfig.CpusetCpus
+		resources.BlkioWeight = hostConfig.BlkioWeight
+
+		oldResources := container.command.Resources
+		container.command.Resources = &resources
+		if err := container.daemon.execDriver.Update(container.command); err != nil {
+			container.command.Resources = oldResources
+			return warnings, fmt.Errorf("Cannot update container %s: %v", container.ID, err)
+		}
+	}
+
+	container.hostConfig = &hostConfig
+	if err := container.WriteHostConfig(); err != nil {
+		return warnings, err
+	}
+
+	container.LogEvent("update")
+
+	return warnings, nil
+}
@@ -5,9 +5,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/pkg/chrootarchive"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/volume"
@@ -195,7 +197,7 @@ func (daemon *Daemon) registerMountPoints(container *Container, hostConfig *runc
 			cp.RW = m.RW && mode != "ro"
 
 			if len(m.Source) == 0 {
-				v, err := createVolume(m.Name, m.Driver)
+				v, err := daemon.createVolume(m.Name, m.Driver, container.ID)
 				if err != nil {
 					return err
 				}
@@ -220,7 +222,7 @@ func (daemon *Daemon) registerMountPoints(container *Container, hostConfig *runc
 
 		if len(bind.Name) > 0 && len(bind.Driver) > 0 {
 			// create the volume
-			v, err := createVolume(bind.Name, bind.Driver)
+			v, err := daemon.createVolume(bind.Name, bind.Driver, container.ID)
 			if err != nil {
 				return err
 			}
@@ -334,18 +336,21 @@ func (daemon *Daemon) verifyVolumesInfo(container *Container) error {
 	return nil
 }
 
-func createVolume(name, driverName string) (volume.Volume, error) {
-	vd, err := getVolumeDriver(driverName)
-	if err != nil {
-		return nil, err
+var validVolumeNamePattern = regexp.MustCompile(`^` + validContainerNameChars + `+$`)
+
+// volumeToAPIType converts a volume into the type returned by the remote API.
+func (daemon *Daemon) volumeToAPIType(v volume.Volume) *types.Volume {
+	return &types.Volume{
+		Name:       v.Name(),
+		Driver:     v.DriverName(),
+		Mountpoint: v.Path(),
+		Labels:     daemon.volumes.Labels(v),
+		Options:    daemon.volumes.Options(v),
 	}
-	return vd.Create(name)
 }
 
-func removeVolume(v volume.Volume) error {
-	vd, err := getVolumeDriver(v.DriverName())
-	if err != nil {
-		return nil
-	}
-	return vd.Remove(v)
+// createVolume returns the named volume, creating it with the given driver
+// if needed, and records the container as one of its users.
+func (daemon *Daemon) createVolume(name, driverName, containerID string) (volume.Volume, error) {
+	return daemon.volumes.CreateWithRef(name, driverName, containerID)
 }
@@ -20,7 +20,7 @@ func TestGetVolumeDefaultDriver(t *testing.T) {
 	}
 	defer os.RemoveAll(tmp)
 
-	l, err := local.New(tmp)
+	l, err := local.New(tmp, os.Getuid(), os.Getgid())
 	if err != nil {
 		t.Fatal(err)
 	}
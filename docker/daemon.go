@@ -14,6 +14,7 @@ import (
 	apiserver "github.com/docker/docker/api/server"
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/daemon"
+	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/homedir"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/pidfile"
@@ -26,9 +27,12 @@ import (
 
 const CanDaemon = true
 
+const defaultDaemonConfigFile = "daemon.json"
+
 var (
-	daemonCfg   = &daemon.Config{}
-	registryCfg = &registry.Options{}
+	daemonCfg    = &daemon.Config{}
+	registryCfg  = &registry.Options{}
+	flConfigFile *string
 )
 
 func init() {
@@ -37,6 +41,82 @@ func init() {
 	}
 	daemonCfg.InstallFlags()
 	registryCfg.InstallFlags()
+	flConfigFile = flag.String([]string{"-config-file"}, filepath.Join(getDaemonConfDir(), defaultDaemonConfigFile), "Daemon configuration file")
+}
+
+// mergeDaemonConfigFile sets the options which weren't set by a flag from
+// the daemon configuration file. The default file doesn't have to exist.
+func mergeDaemonConfigFile() error {
+	err := daemon.MergeConfigFile(flag.CommandLine, *flConfigFile)
+	if os.IsNotExist(err) && !flag.IsSet("-config-file") {
+		return nil
+	}
+	return err
+}
+
+// reloadDaemonConfig reloads the options of the daemon configuration file
+// which can be changed without a restart: the labels, the debug mode, the
+// registry mirrors and the insecure registries. The options set by a flag
+// are left as they are.
+func reloadDaemonConfig(d *daemon.Daemon, registryService *registry.Service) {
+	config, err := daemon.ReadConfigFile(*flConfigFile)
+	if os.IsNotExist(err) {
+		config, err = daemon.ConfigFile{}, nil
+	}
+	if err == nil {
+		err = config.Validate(flag.CommandLine)
+	}
+	if err != nil {
+		logrus.Errorf("Error reloading the daemon configuration: %v", err)
+		return
+	}
+
+	flags := flag.NewFlagSet("reload", flag.ContinueOnError)
+	labels := opts.NewListOpts(opts.ValidateLabel)
+	flags.Var(&labels, []string{"-label"}, "")
+	var debug bool
+	flags.BoolVar(&debug, []string{"D", "-debug"}, false, "")
+	registryOptions := &registry.Options{
+		Mirrors:            opts.NewListOpts(registry.ValidateMirror),
+		InsecureRegistries: opts.NewListOpts(registry.ValidateIndexName),
+	}
+	flags.Var(&registryOptions.Mirrors, []string{"-registry-mirror"}, "")
+	flags.Var(&registryOptions.InsecureRegistries, []string{"-insecure-registry"}, "")
+	if err := config.Apply(flags); err != nil {
+		logrus.Errorf("Error reloading the daemon configuration: %v", err)
+		return
+	}
+
+	if !flag.IsSet("-label") {
+		d.Reload(&daemon.Config{CommonConfig: daemon.CommonConfig{Labels: labels.GetAll()}})
+	}
+	if !flag.IsSet("D") && !flag.IsSet("-debug") {
+		setDebug(debug)
+	}
+	if flag.IsSet("-registry-mirror") {
+		registryOptions.Mirrors = registryCfg.Mirrors
+	}
+	if flag.IsSet("-insecure-registry") {
+		registryOptions.InsecureRegistries = registryCfg.InsecureRegistries
+	}
+	registryService.Reload(registryOptions)
+
+	logrus.Infof("Reloaded the daemon configuration from %s", *flConfigFile)
+}
+
+// setDebug enables the debug mode, or goes back to the --log-level.
+func setDebug(debug bool) {
+	if debug {
+		os.Setenv("DEBUG", "1")
+		setLogLevel(logrus.DebugLevel)
+		return
+	}
+	os.Unsetenv("DEBUG")
+	lvl, err := logrus.ParseLevel(*flLogLevel)
+	if err != nil {
+		lvl = logrus.InfoLevel
+	}
+	setLogLevel(lvl)
 }
 
 func migrateKey() (err error) {
@@ -164,6 +244,10 @@ func mainDaemon() {
 		"graphdriver": d.GraphDriver().String(),
 	}).Info("Docker daemon")
 
+	setupConfigReloadTrap(func() {
+		reloadDaemonConfig(d, registryService)
+	})
+
 	signal.Trap(func() {
 		api.Close()
 		<-serveAPIWait
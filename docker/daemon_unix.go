@@ -4,6 +4,8 @@ package main
 
 import (
 	"os"
+	"os/signal"
+	"syscall"
 
 	apiserver "github.com/docker/docker/api/server"
 	"github.com/docker/docker/daemon"
@@ -13,6 +15,17 @@ import (
 	_ "github.com/docker/docker/daemon/execdriver/native"
 )
 
+// setupConfigReloadTrap calls reload each time the daemon receives a SIGHUP.
+func setupConfigReloadTrap(reload func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	go func() {
+		for range c {
+			reload()
+		}
+	}()
+}
+
 func setPlatformServerConfig(serverConfig *apiserver.ServerConfig, daemonCfg *daemon.Config) *apiserver.ServerConfig {
 	serverConfig.SocketGroup = daemonCfg.SocketGroup
 	return serverConfig
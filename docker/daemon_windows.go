@@ -7,6 +7,10 @@ import (
 	"github.com/docker/docker/daemon"
 )
 
+// setupConfigReloadTrap does nothing, there is no SIGHUP on Windows.
+func setupConfigReloadTrap(reload func()) {
+}
+
 func setPlatformServerConfig(serverConfig *apiserver.ServerConfig, daemonCfg *daemon.Config) *apiserver.ServerConfig {
 	return serverConfig
 }
@@ -43,6 +43,13 @@ func main() {
 		return
 	}
 
+	if *flDaemon {
+		if err := mergeDaemonConfigFile(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
 	if *flLogLevel != "" {
 		lvl, err := logrus.ParseLevel(*flLogLevel)
 		if err != nil {
@@ -31,8 +31,9 @@ var (
 	dockerCommands = []command{
 		{"attach", "Attach to a running container"},
 		{"build", "Build an image from a Dockerfile"},
+		{"checkpoint", "Checkpoint one or more running containers"},
 		{"commit", "Create a new image from a container's changes"},
-		{"cp", "Copy files/folders from a container's filesystem to the host path"},
+		{"cp", "Copy files/folders between a container and the local filesystem"},
 		{"create", "Create a new container"},
 		{"diff", "Inspect changes on a container's filesystem"},
 		{"events", "Get real time events from the server"},
@@ -48,6 +49,7 @@ var (
 		{"login", "Register or log in to a Docker registry server"},
 		{"logout", "Log out from a Docker registry server"},
 		{"logs", "Fetch the logs of a container"},
+		{"network", "Manage Docker networks"},
 		{"port", "Lookup the public-facing port that is NAT-ed to PRIVATE_PORT"},
 		{"pause", "Pause all processes within a container"},
 		{"ps", "List containers"},
@@ -55,6 +57,7 @@ var (
 		{"push", "Push an image or a repository to a Docker registry server"},
 		{"rename", "Rename an existing container"},
 		{"restart", "Restart a running container"},
+		{"restore", "Restore one or more checkpointed containers"},
 		{"rm", "Remove one or more containers"},
 		{"rmi", "Remove one or more images"},
 		{"run", "Run a command in a new container"},
@@ -66,7 +69,9 @@ var (
 		{"tag", "Tag an image into a repository"},
 		{"top", "Lookup the running processes of a container"},
 		{"unpause", "Unpause a paused container"},
+		{"update", "Update the resource limits of one or more containers"},
 		{"version", "Show the Docker version information"},
+		{"volume", "Manage Docker volumes"},
 		{"wait", "Block until a container stops, then print its exit code"},
 	}
 )
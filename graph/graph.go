@@ -19,6 +19,7 @@ import (
 	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/progressreader"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/stringid"
@@ -32,11 +33,16 @@ type Graph struct {
 	Root    string
 	idIndex *truncindex.TruncIndex
 	driver  graphdriver.Driver
+	uidMaps []idtools.IDMap
+	gidMaps []idtools.IDMap
 }
 
 // NewGraph instantiates a new graph at the given root path in the filesystem.
-// `root` will be created if it doesn't exist.
-func NewGraph(root string, driver graphdriver.Driver) (*Graph, error) {
+// `root` will be created if it doesn't exist. When the containers run in a
+// user namespace, uidMaps and gidMaps are its mappings: the owners of the
+// files of the layers are the ids in the namespace in the layer archives,
+// and the ids on the host in the driver.
+func NewGraph(root string, driver graphdriver.Driver, uidMaps, gidMaps []idtools.IDMap) (*Graph, error) {
 	abspath, err := filepath.Abs(root)
 	if err != nil {
 		return nil, err
@@ -50,6 +56,8 @@ func NewGraph(root string, driver graphdriver.Driver) (*Graph, error) {
 		Root:    abspath,
 		idIndex: truncindex.NewTruncIndex([]string{}),
 		driver:  driver,
+		uidMaps: uidMaps,
+		gidMaps: gidMaps,
 	}
 	if err := graph.restore(); err != nil {
 		return nil, err
@@ -186,6 +194,12 @@ func (graph *Graph) Register(img *image.Image, layerData archive.ArchiveReader)
 	}
 	// Apply the diff/layer
 	img.SetGraph(graph)
+	if layerData != nil {
+		// layerData itself is closed by the caller.
+		hostLayer := archive.ToHostIDs(ioutil.NopCloser(layerData), graph.uidMaps, graph.gidMaps)
+		defer hostLayer.Close()
+		layerData = hostLayer
+	}
 	if err := image.StoreImage(img, layerData, tmp); err != nil {
 		return err
 	}
@@ -210,7 +224,7 @@ func (graph *Graph) TempLayerArchive(id string, sf *streamformatter.StreamFormat
 	if err != nil {
 		return nil, err
 	}
-	a, err := image.TarLayer()
+	a, err := graph.TarLayer(image)
 	if err != nil {
 		return nil, err
 	}
@@ -227,6 +241,16 @@ func (graph *Graph) TempLayerArchive(id string, sf *streamformatter.StreamFormat
 	return archive.NewTempArchive(progressReader, tmp)
 }
 
+// TarLayer returns a tar archive of the filesystem layer of img, in which
+// the files are owned by the ids in the user namespace of the containers.
+func (graph *Graph) TarLayer(img *image.Image) (archive.Archive, error) {
+	layer, err := img.TarLayer()
+	if err != nil {
+		return nil, err
+	}
+	return archive.ToContainerIDs(layer, graph.uidMaps, graph.gidMaps), nil
+}
+
 // Mktemp creates a temporary sub-directory inside the graph's filesystem.
 func (graph *Graph) Mktemp(id string) (string, error) {
 	dir := filepath.Join(graph.Root, "_tmp", stringid.GenerateRandomID())
@@ -266,11 +290,12 @@ func bufferToFile(f *os.File, src io.Reader) (int64, digest.Digest, error) {
 
 // setupInitLayer populates a directory with mountpoints suitable
 // for bind-mounting dockerinit into the container. The mountpoint is simply an
-// empty file at /.dockerinit
+// empty file at /.dockerinit. The entries it creates are owned by rootUID and
+// rootGID, the ids of root in the user namespace of the containers.
 //
 // This extra layer is used by all containers as the top-most ro layer. It protects
 // the container from unwanted side-effects on the rw layer.
-func SetupInitLayer(initLayer string) error {
+func SetupInitLayer(initLayer string, rootUID, rootGID int) error {
 	for pth, typ := range map[string]string{
 		"/dev/pts":         "dir",
 		"/dev/shm":         "dir",
@@ -294,12 +319,12 @@ func SetupInitLayer(initLayer string) error {
 
 		if _, err := os.Stat(filepath.Join(initLayer, pth)); err != nil {
 			if os.IsNotExist(err) {
-				if err := system.MkdirAll(filepath.Join(initLayer, filepath.Dir(pth)), 0755); err != nil {
+				if err := idtools.MkdirAllAs(filepath.Join(initLayer, filepath.Dir(pth)), 0755, rootUID, rootGID); err != nil {
 					return err
 				}
 				switch typ {
 				case "dir":
-					if err := system.MkdirAll(filepath.Join(initLayer, pth), 0755); err != nil {
+					if err := idtools.MkdirAllAs(filepath.Join(initLayer, pth), 0755, rootUID, rootGID); err != nil {
 						return err
 					}
 				case "file":
@@ -307,7 +332,11 @@ func SetupInitLayer(initLayer string) error {
 					if err != nil {
 						return err
 					}
+					err = f.Chown(rootUID, rootGID)
 					f.Close()
+					if err != nil {
+						return err
+					}
 				default:
 					if err := os.Symlink(typ, filepath.Join(initLayer, pth)); err != nil {
 						return err
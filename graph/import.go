@@ -73,6 +73,10 @@ func (s *TagStore) Import(src string, repo string, tag string, imageImportConfig
 		logID = utils.ImageReference(logID, tag)
 	}
 
-	s.eventsService.Log("import", logID, "")
+	var refName string
+	if repo != "" {
+		refName = utils.ImageReference(repo, tag)
+	}
+	s.LogImageEvent("import", logID, refName)
 	return nil
 }
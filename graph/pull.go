@@ -99,7 +99,7 @@ func (s *TagStore) Pull(image string, tag string, imagePullConfig *ImagePullConf
 
 		logrus.Debugf("pulling v2 repository with local name %q", repoInfo.LocalName)
 		if err := s.pullV2Repository(r, imagePullConfig.OutStream, repoInfo, tag, sf); err == nil {
-			s.eventsService.Log("pull", logName, "")
+			s.LogImageEvent("pull", logName, logName)
 			return nil
 		} else if err != registry.ErrDoesNotExist && err != ErrV2RegistryUnavailable {
 			logrus.Errorf("Error from V2 registry: %s", err)
@@ -117,7 +117,7 @@ func (s *TagStore) Pull(image string, tag string, imagePullConfig *ImagePullConf
 		return err
 	}
 
-	s.eventsService.Log("pull", logName, "")
+	s.LogImageEvent("pull", logName, logName)
 
 	return nil
 
@@ -202,7 +202,7 @@ func (s *TagStore) pullFromV2Mirror(mirrorEndpoint *registry.Endpoint, repoInfo
 	if err := s.pullV2Repository(mirrorSession, imagePullConfig.OutStream, repoInfo, tag, sf); err != nil {
 		return err
 	}
-	s.eventsService.Log("pull", logName, "")
+	s.LogImageEvent("pull", logName, logName)
 	return nil
 }
 
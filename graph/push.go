@@ -456,7 +456,7 @@ func (s *TagStore) pushV2Image(r *registry.Session, img *image.Image, endpoint *
 	if err != nil {
 		return "", err
 	}
-	arch, err := image.TarLayer()
+	arch, err := s.graph.TarLayer(image)
 	if err != nil {
 		return "", err
 	}
@@ -542,7 +542,7 @@ func (s *TagStore) Push(localName string, imagePushConfig *ImagePushConfig) erro
 	if repoInfo.Index.Official || endpoint.Version == registry.APIVersion2 {
 		err := s.pushV2Repository(r, localRepo, imagePushConfig.OutStream, repoInfo, imagePushConfig.Tag, sf)
 		if err == nil {
-			s.eventsService.Log("push", repoInfo.LocalName, "")
+			s.LogImageEvent("push", repoInfo.LocalName, repoInfo.LocalName)
 			return nil
 		}
 
@@ -554,7 +554,7 @@ func (s *TagStore) Push(localName string, imagePushConfig *ImagePushConfig) erro
 	if err := s.pushRepository(r, imagePushConfig.OutStream, repoInfo, localRepo, imagePushConfig.Tag, sf); err != nil {
 		return err
 	}
-	s.eventsService.Log("push", repoInfo.LocalName, "")
+	s.LogImageEvent("push", repoInfo.LocalName, repoInfo.LocalName)
 	return nil
 
 }
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"sync"
 
+	eventtypes "github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/graph/tags"
 	"github.com/docker/docker/image"
@@ -157,6 +158,25 @@ func (store *TagStore) LookupImage(name string) (*image.Image, error) {
 	return img, nil
 }
 
+// LogImageEvent logs an event about an image. The actor of the event is
+// identified by id, an image ID or reference, and has the labels of the
+// image, and refName when it is given, as attributes.
+func (store *TagStore) LogImageEvent(action, id, refName string) {
+	attributes := map[string]string{}
+	if img, err := store.LookupImage(id); err == nil && img != nil && img.Config != nil {
+		for k, v := range img.Config.Labels {
+			attributes[k] = v
+		}
+	}
+	if refName != "" {
+		attributes["name"] = refName
+	}
+	store.eventsService.Log(action, eventtypes.ImageEventType, eventtypes.Actor{
+		ID:         id,
+		Attributes: attributes,
+	})
+}
+
 // Return a reverse-lookup table of all the names which refer to each image
 // Eg. {"43b5f19b10584": {"base:latest", "base:v1"}}
 func (store *TagStore) ByID() map[string][]string {
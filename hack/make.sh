@@ -124,6 +124,14 @@ if \
        DOCKER_BUILDTAGS+=' libdm_no_deferred_remove'
 fi
 
+# test whether "systemd/sd-journal.h" exists, to build the journald log reader
+if \
+	command -v gcc &> /dev/null \
+	&& gcc -E - &> /dev/null <<<'#include <systemd/sd-journal.h>' \
+; then
+	DOCKER_BUILDTAGS+=' journald'
+fi
+
 # Use these flags when compiling the tests and final binary
 
 IAMSTATIC='true'
@@ -35,6 +35,8 @@ type DockerSuite struct {
 func (s *DockerSuite) TearDownTest(c *check.C) {
 	deleteAllContainers()
 	deleteAllImages()
+	deleteAllVolumes()
+	deleteAllNetworks()
 	s.TimerSuite.TearDownTest(c)
 }
 
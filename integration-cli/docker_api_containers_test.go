@@ -3,6 +3,7 @@ package main
 import (
 	"archive/tar"
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -1291,6 +1292,54 @@ func (s *DockerSuite) TestContainerApiCopy(c *check.C) {
 	c.Assert(found, check.Equals, true)
 }
 
+func (s *DockerSuite) TestContainerApiArchive(c *check.C) {
+	name := "test-container-api-archive"
+	dockerCmd(c, "create", "--name", name, "busybox", "true")
+
+	buffer := new(bytes.Buffer)
+	tw := tar.NewWriter(buffer)
+	content := []byte("archived")
+	if err := tw.WriteHeader(&tar.Header{Name: "test.txt", Mode: 0644, Size: int64(len(content))}); err != nil {
+		c.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		c.Fatal(err)
+	}
+	tw.Close()
+
+	res, body, err := sockRequestRaw("PUT", "/containers/"+name+"/archive?path=/tmp", buffer, "application/x-tar")
+	c.Assert(err, check.IsNil)
+	body.Close()
+	c.Assert(res.StatusCode, check.Equals, http.StatusOK)
+
+	res, body, err = sockRequestRaw("HEAD", "/containers/"+name+"/archive?path=/tmp/test.txt", nil, "")
+	c.Assert(err, check.IsNil)
+	body.Close()
+	c.Assert(res.StatusCode, check.Equals, http.StatusOK)
+
+	statJSON, err := base64.StdEncoding.DecodeString(res.Header.Get("X-Docker-Container-Path-Stat"))
+	c.Assert(err, check.IsNil)
+	var stat types.ContainerPathStat
+	c.Assert(json.Unmarshal(statJSON, &stat), check.IsNil)
+	c.Assert(stat.Name, check.Equals, "test.txt")
+	c.Assert(stat.Size, check.Equals, int64(len(content)))
+
+	res, body, err = sockRequestRaw("GET", "/containers/"+name+"/archive?path=/tmp/test.txt", nil, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(res.StatusCode, check.Equals, http.StatusOK)
+	c.Assert(res.Header.Get("Content-Type"), check.Equals, "application/x-tar")
+	tr := tar.NewReader(body)
+	h, err := tr.Next()
+	c.Assert(err, check.IsNil)
+	c.Assert(h.Name, check.Equals, "test.txt")
+	body.Close()
+
+	res, body, err = sockRequestRaw("HEAD", "/containers/"+name+"/archive?path=/tmp/missing", nil, "")
+	c.Assert(err, check.IsNil)
+	body.Close()
+	c.Assert(res.StatusCode, check.Equals, http.StatusNotFound)
+}
+
 func (s *DockerSuite) TestContainerApiCopyResourcePathEmpty(c *check.C) {
 	name := "test-container-api-copy-resource-empty"
 	runCmd := exec.Command(dockerBinary, "run", "--name", name, "busybox", "touch", "/test.txt")
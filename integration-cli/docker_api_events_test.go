@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/go-check/check"
+)
+
+// getEvents returns the events between since and until, read with the API.
+func getEvents(c *check.C, since, until int64, filters string) []events.Message {
+	endpoint := fmt.Sprintf("/events?since=%d&until=%d", since, until)
+	if filters != "" {
+		endpoint += "&filters=" + filters
+	}
+	status, body, err := sockRequest("GET", endpoint, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, http.StatusOK)
+
+	var msgs []events.Message
+	dec := json.NewDecoder(strings.NewReader(string(body)))
+	for {
+		var m events.Message
+		if err := dec.Decode(&m); err != nil {
+			if err == io.EOF {
+				break
+			}
+			c.Fatal(err)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
+func (s *DockerSuite) TestEventsApiContainerAttributes(c *check.C) {
+	since := daemonTime(c).Unix()
+
+	out, _ := dockerCmd(c, "run", "-d", "--name", "eventsattributes", "-l", "key=value", "busybox", "sh", "-c", "exit 3")
+	id := strings.TrimSpace(out)
+	dockerCmd(c, "wait", id)
+
+	var die *events.Message
+	for _, m := range getEvents(c, since, daemonTime(c).Unix()+1, `{"container":["eventsattributes"],"event":["die"]}`) {
+		m := m
+		die = &m
+	}
+	if die == nil {
+		c.Fatal("Expected a die event")
+	}
+
+	c.Assert(die.Type, check.Equals, events.ContainerEventType)
+	c.Assert(die.Action, check.Equals, "die")
+	c.Assert(die.Status, check.Equals, "die")
+	c.Assert(die.Actor.ID, check.Equals, id)
+	c.Assert(die.Actor.Attributes["name"], check.Equals, "eventsattributes")
+	c.Assert(die.Actor.Attributes["image"], check.Equals, "busybox")
+	c.Assert(die.Actor.Attributes["key"], check.Equals, "value")
+	c.Assert(die.Actor.Attributes["exitCode"], check.Equals, "3")
+	c.Assert(die.TimeNano/1e9, check.Equals, die.Time)
+}
+
+func (s *DockerSuite) TestEventsApiVolumeAndNetwork(c *check.C) {
+	since := daemonTime(c).Unix()
+
+	dockerCmd(c, "volume", "create", "--name", "eventsvolume")
+	dockerCmd(c, "volume", "rm", "eventsvolume")
+	dockerCmd(c, "network", "create", "eventsnetwork")
+	dockerCmd(c, "network", "rm", "eventsnetwork")
+
+	until := daemonTime(c).Unix() + 1
+
+	var actions []string
+	for _, m := range getEvents(c, since, until, `{"volume":["eventsvolume"]}`) {
+		c.Assert(m.Type, check.Equals, events.VolumeEventType)
+		c.Assert(m.Status, check.Equals, "")
+		c.Assert(m.Actor.Attributes["driver"], check.Equals, "local")
+		actions = append(actions, m.Action)
+	}
+	c.Assert(actions, check.DeepEquals, []string{"create", "destroy"})
+
+	actions = nil
+	for _, m := range getEvents(c, since, until, `{"network":["eventsnetwork"]}`) {
+		c.Assert(m.Type, check.Equals, events.NetworkEventType)
+		c.Assert(m.Actor.Attributes["name"], check.Equals, "eventsnetwork")
+		c.Assert(m.Actor.Attributes["type"], check.Equals, "bridge")
+		actions = append(actions, m.Action)
+	}
+	c.Assert(actions, check.DeepEquals, []string{"create", "destroy"})
+}
@@ -5358,3 +5358,222 @@ func (s *DockerSuite) TestBuildRUNErrMsg(c *check.C) {
 		c.Fatalf("RUN doesn't have the correct output:\nGot:%s\nExpected:%s", out, exp)
 	}
 }
+
+func (s *DockerSuite) TestBuildBuildTimeArg(c *check.C) {
+	name := "testbuildbuildtimearg"
+	dockerfile := `FROM busybox
+  ARG foo
+  ARG bar=default
+  RUN [ "$foo" = "fromflag" ] && [ "$bar" = "default" ]
+  ENV copy ${foo}
+  RUN [ "$copy" = "fromflag" ]`
+
+	if _, out, err := buildImageWithBuildArgs(name, dockerfile, true, []string{"foo=fromflag"}); err != nil {
+		c.Fatal(err, out)
+	}
+
+	// Build-time variables are not persisted in the image.
+	res, err := inspectFieldJSON(name, "Config.Env")
+	if err != nil {
+		c.Fatal(err)
+	}
+	if strings.Contains(res, "foo=") || strings.Contains(res, "bar=") {
+		c.Fatalf("Build-time variables should not be in the environment of the image, got %s", res)
+	}
+	if !strings.Contains(res, "copy=fromflag") {
+		c.Fatalf("Expected copy=fromflag in the environment of the image, got %s", res)
+	}
+
+	// The same values hit the cache, other values do not.
+	_, out, err := buildImageWithBuildArgs(name, dockerfile, true, []string{"foo=fromflag"})
+	if err != nil {
+		c.Fatal(err, out)
+	}
+	if strings.Count(out, "Using cache") != 5 {
+		c.Fatalf("Expected all the steps to be cached, got:\n%s", out)
+	}
+	_, out, err = buildImageWithBuildArgs(name, dockerfile, true, []string{"foo=fromflag", "bar=other"})
+	if err == nil {
+		c.Fatalf("Expected the build to fail with another value of bar, got:\n%s", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildBuildTimeArgNotInHistory(c *check.C) {
+	name := "testbuildbuildtimeargnotinhistory"
+	dockerfile := `FROM busybox
+  ARG foo
+  RUN [ "$foo" = "hiddenvalue" ]`
+
+	if _, out, err := buildImageWithBuildArgs(name, dockerfile, true, []string{"foo=hiddenvalue"}); err != nil {
+		c.Fatal(err, out)
+	}
+
+	// Only a digest of the value is recorded with the command.
+	out, _ := dockerCmd(c, "history", "--no-trunc", name)
+	if strings.Contains(out, "hiddenvalue") || !strings.Contains(out, "foo=sha256:") {
+		c.Fatalf("Expected a digest of the build-time variable in the history, got:\n%s", out)
+	}
+	out, _ = dockerCmd(c, "inspect", name)
+	if strings.Contains(out, "hiddenvalue") {
+		c.Fatalf("Expected the value of the build-time variable not to be in the image, got:\n%s", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildBuildTimeArgOverriddenByEnv(c *check.C) {
+	name := "testbuildbuildtimeargoverriddenbyenv"
+	dockerfile := `FROM busybox
+  ARG foo
+  ENV foo fromenv
+  RUN [ "$foo" = "fromenv" ]`
+
+	if _, out, err := buildImageWithBuildArgs(name, dockerfile, false, []string{"foo=fromflag"}); err != nil {
+		c.Fatal(err, out)
+	}
+}
+
+func (s *DockerSuite) TestBuildBuildTimeArgNotDeclared(c *check.C) {
+	name := "testbuildbuildtimeargnotdeclared"
+	dockerfile := `FROM busybox
+  RUN [ -z "$foo" ]
+  ARG foo`
+
+	// Undeclared variables are not visible, and the build fails if they
+	// are never declared.
+	if _, out, err := buildImageWithBuildArgs(name, dockerfile, false, []string{"foo=bar"}); err != nil {
+		c.Fatal(err, out)
+	}
+	_, out, err := buildImageWithBuildArgs(name, "FROM busybox", false, []string{"foo=bar"})
+	if err == nil || !strings.Contains(out, "One or more build-args [foo] were not consumed") {
+		c.Fatalf("Expected the build to fail with an unconsumed build-arg, got %v:\n%s", err, out)
+	}
+}
+
+func (s *DockerSuite) TestBuildBuildTimeArgMultiStage(c *check.C) {
+	name := "testbuildbuildtimeargmultistage"
+	dockerfile := `FROM busybox AS build
+  ARG foo
+  ARG bar=default
+  RUN [ "$foo" = "fromflag" ] && [ "$bar" = "default" ]
+  FROM busybox
+  RUN [ -z "$foo" ] && [ -z "$bar" ]
+  ARG foo
+  RUN [ "$foo" = "fromflag" ] && [ -z "$bar" ]`
+
+	// The build-time variables are only visible to the stages declaring
+	// them.
+	if _, out, err := buildImageWithBuildArgs(name, dockerfile, false, []string{"foo=fromflag"}); err != nil {
+		c.Fatal(err, out)
+	}
+}
+
+func (s *DockerSuite) TestBuildStopSignal(c *check.C) {
+	name := "test_build_stop_signal"
+	_, err := buildImage(name,
+		`FROM busybox
+		 STOPSIGNAL SIGKILL`,
+		true)
+	c.Assert(err, check.IsNil)
+	res, err := inspectFieldJSON(name, "Config.StopSignal")
+	c.Assert(err, check.IsNil)
+	if res != `"SIGKILL"` {
+		c.Fatalf("Signal %s, expected SIGKILL", res)
+	}
+
+	_, out, err := buildImageWithOut(name+"invalid", "FROM busybox\nSTOPSIGNAL SIGFOO", true)
+	if err == nil || !strings.Contains(out, "Invalid signal: SIGFOO") {
+		c.Fatalf("Expected the build to fail with an invalid signal, got %v:\n%s", err, out)
+	}
+}
+
+func (s *DockerSuite) TestBuildMultiStage(c *check.C) {
+	name := "testbuildmultistage"
+	dockerfile := `FROM busybox AS build
+  RUN mkdir /out && echo -n built > /out/file
+  FROM busybox
+  RUN echo -n other > /file
+  FROM scratch
+  COPY --from=build /out/file /from-name
+  COPY --from=1 /file /from-index
+  COPY --from=build /out/ /dir/`
+
+	if _, err := buildImage(name, dockerfile, true); err != nil {
+		c.Fatal(err)
+	}
+
+	// Only the last stage makes the image.
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "create", "--name", name, name, "true"))
+	c.Assert(err, check.IsNil, check.Commentf(out))
+
+	tmpdir, err := ioutil.TempDir("", "multistage")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(tmpdir)
+	for file, expected := range map[string]string{"from-name": "built", "from-index": "other", "dir/file": "built"} {
+		dockerCmd(c, "cp", name+":/"+file, tmpdir)
+		content, err := ioutil.ReadFile(filepath.Join(tmpdir, filepath.Base(file)))
+		c.Assert(err, check.IsNil)
+		if string(content) != expected {
+			c.Fatalf("Expected %q in %s, got %q", expected, file, content)
+		}
+	}
+
+	_, out, err = buildImageWithOut(name+"unknown", "FROM busybox\nCOPY --from=build /bin/sh /sh", true)
+	if err == nil || !strings.Contains(out, "Unknown build stage: build") {
+		c.Fatalf("Expected the build to fail with an unknown stage, got %v:\n%s", err, out)
+	}
+}
+
+func (s *DockerSuite) TestBuildCacheFrom(c *check.C) {
+	name := "testbuildcachefrom"
+	dockerfile := `FROM busybox
+  ENV FOO bar
+  RUN echo cachefrom > /file`
+
+	id1, err := buildImage(name, dockerfile, true)
+	if err != nil {
+		c.Fatal(err)
+	}
+
+	// The image given with --cache-from is the cache.
+	id2, out, err := buildImageWithFlags(name+"2", dockerfile, true, "--cache-from", name)
+	if err != nil {
+		c.Fatal(err, out)
+	}
+	if id1 != id2 || strings.Count(out, "Using cache") != 2 {
+		c.Fatalf("Expected the build to use %s as cache, got %s:\n%s", id1, id2, out)
+	}
+
+	// Other local images are not used as cache, and a missing cache image
+	// is not an error.
+	dockerCmd(c, "rmi", name+"2")
+	id3, out, err := buildImageWithFlags(name+"3", dockerfile, true, "--cache-from", "busybox", "--cache-from", "testbuildcachefrommissing")
+	if err != nil {
+		c.Fatal(err, out)
+	}
+	if id3 == id1 || strings.Contains(out, "Using cache") {
+		c.Fatalf("Expected the build not to use any cache, got %s:\n%s", id3, out)
+	}
+	if !strings.Contains(out, "Couldn't use cache image testbuildcachefrommissing") {
+		c.Fatalf("Expected a warning about the missing cache image:\n%s", out)
+	}
+}
+
+func (s *DockerSuite) TestBuildNoCacheFromStep(c *check.C) {
+	name := "testbuildnocachefromstep"
+	dockerfile := `FROM busybox
+  ENV FOO bar
+  RUN echo first > /first
+  RUN echo second > /second`
+
+	if _, err := buildImage(name, dockerfile, true); err != nil {
+		c.Fatal(err)
+	}
+
+	// Steps 1 and 2 use the cache, step 3 does not.
+	_, out, err := buildImageWithFlags(name, dockerfile, true, "--no-cache-from-step=3")
+	if err != nil {
+		c.Fatal(err, out)
+	}
+	if strings.Count(out, "Using cache") != 2 {
+		c.Fatalf("Expected the cache to be used up to step 2:\n%s", out)
+	}
+}
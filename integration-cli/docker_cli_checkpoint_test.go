@@ -0,0 +1,75 @@
+// +build !windows
+
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestCheckpointAndRestore(c *check.C) {
+	testRequires(c, NativeExecDriver, SameHostDaemon, Criu)
+
+	name := "test-checkpoint"
+	dockerCmd(c, "run", "-d", "--name", name, "--net=none", "--security-opt", "seccomp=unconfined",
+		"busybox", "sh", "-c", "i=0; while true; do echo $i > /tmp/count; i=$((i+1)); sleep 0.1; done")
+	time.Sleep(2 * time.Second)
+	before := readCount(c, name)
+
+	dockerCmd(c, "checkpoint", name)
+	checkpointed, err := inspectField(name, "State.Checkpointed")
+	c.Assert(err, check.IsNil)
+	c.Assert(checkpointed, check.Equals, "true")
+	running, err := inspectField(name, "State.Running")
+	c.Assert(err, check.IsNil)
+	c.Assert(running, check.Equals, "false")
+
+	dockerCmd(c, "restore", name)
+	running, err = inspectField(name, "State.Running")
+	c.Assert(err, check.IsNil)
+	c.Assert(running, check.Equals, "true")
+	checkpointed, err = inspectField(name, "State.Checkpointed")
+	c.Assert(err, check.IsNil)
+	c.Assert(checkpointed, check.Equals, "false")
+
+	// The restored process goes on counting from where it was.
+	after := readCount(c, name)
+	c.Assert(after >= before, check.Equals, true, check.Commentf("count %d after restore, %d before checkpoint", after, before))
+}
+
+func readCount(c *check.C, name string) int {
+	out, _ := dockerCmd(c, "exec", name, "cat", "/tmp/count")
+	count, err := strconv.Atoi(strings.TrimSpace(out))
+	c.Assert(err, check.IsNil)
+	return count
+}
+
+func (s *DockerSuite) TestCheckpointInvalidContainers(c *check.C) {
+	testRequires(c, NativeExecDriver)
+
+	name := "test-checkpoint-tty"
+	dockerCmd(c, "run", "-dt", "--name", name, "--net=none", "busybox", "top")
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "checkpoint", name))
+	c.Assert(err, check.NotNil)
+	c.Assert(out, check.Matches, "(?s).*Containers with a TTY can't be checkpointed.*")
+
+	name = "test-checkpoint-bridge"
+	dockerCmd(c, "run", "-d", "--name", name, "busybox", "top")
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "checkpoint", name))
+	c.Assert(err, check.NotNil)
+	c.Assert(out, check.Matches, "(?s).*not using --net=host or --net=none can't be checkpointed.*")
+
+	name = "test-checkpoint-stopped"
+	dockerCmd(c, "create", "--name", name, "busybox", "true")
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "checkpoint", name))
+	c.Assert(err, check.NotNil)
+	c.Assert(out, check.Matches, "(?s).*is not running.*")
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "restore", name))
+	c.Assert(err, check.NotNil)
+	c.Assert(out, check.Matches, "(?s).*has no checkpoint.*")
+}
@@ -632,3 +632,139 @@ func (s *DockerSuite) TestCopyAndRestart(c *check.C) {
 		c.Fatalf("expected %q but got %q", expectedMsg, msg)
 	}
 }
+
+func (s *DockerSuite) TestCpToStoppedContainer(c *check.C) {
+	out, _ := dockerCmd(c, "create", "busybox", "cat", "/tmp/copied")
+	cID := strings.TrimSpace(out)
+
+	tmpDir, err := ioutil.TempDir("", "cp-test-to-container")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcPath := filepath.Join(tmpDir, "copied")
+	if err := ioutil.WriteFile(srcPath, []byte(cpHostContents), 0644); err != nil {
+		c.Fatal(err)
+	}
+
+	// The destination directory exists, the file keeps its name.
+	dockerCmd(c, "cp", srcPath, cID+":/tmp")
+
+	out, _ = dockerCmd(c, "start", "-a", cID)
+	if out != cpHostContents {
+		c.Fatalf("Expected %q in the container, got %q", cpHostContents, out)
+	}
+}
+
+func (s *DockerSuite) TestCpToRunningContainerRename(c *check.C) {
+	out, _ := dockerCmd(c, "run", "-d", "busybox", "top")
+	cID := strings.TrimSpace(out)
+
+	tmpDir, err := ioutil.TempDir("", "cp-test-to-container")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcPath := filepath.Join(tmpDir, "src")
+	if err := ioutil.WriteFile(srcPath, []byte(cpHostContents), 0644); err != nil {
+		c.Fatal(err)
+	}
+
+	// The destination doesn't exist, the file is copied with its name.
+	dockerCmd(c, "cp", srcPath, cID+":/dst")
+
+	out, _ = dockerCmd(c, "exec", cID, "cat", "/dst")
+	if out != cpHostContents {
+		c.Fatalf("Expected %q in the container, got %q", cpHostContents, out)
+	}
+
+	// Copying it back to a missing destination renames it as well.
+	dockerCmd(c, "cp", cID+":/dst", filepath.Join(tmpDir, "back"))
+
+	content, err := ioutil.ReadFile(filepath.Join(tmpDir, "back"))
+	if err != nil {
+		c.Fatal(err)
+	}
+	if string(content) != cpHostContents {
+		c.Fatalf("Expected %q, got %q", cpHostContents, content)
+	}
+}
+
+func (s *DockerSuite) TestCpToContainerVolume(c *check.C) {
+	out, _ := dockerCmd(c, "create", "-v", "/vol", "busybox", "cat", "/vol/copied")
+	cID := strings.TrimSpace(out)
+
+	tmpDir, err := ioutil.TempDir("", "cp-test-to-container")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcPath := filepath.Join(tmpDir, "copied")
+	if err := ioutil.WriteFile(srcPath, []byte(cpHostContents), 0644); err != nil {
+		c.Fatal(err)
+	}
+
+	dockerCmd(c, "cp", srcPath, cID+":/vol")
+
+	out, _ = dockerCmd(c, "start", "-a", cID)
+	if out != cpHostContents {
+		c.Fatalf("Expected %q in the volume, got %q", cpHostContents, out)
+	}
+
+	// The file must have been written in the volume, not under its mount point.
+	out, _ = dockerCmd(c, "run", "--rm", "--volumes-from", cID, "busybox", "cat", "/vol/copied")
+	if out != cpHostContents {
+		c.Fatalf("Expected %q in the volume, got %q", cpHostContents, out)
+	}
+}
+
+func (s *DockerSuite) TestCpToContainerSymlinkEscape(c *check.C) {
+	testRequires(c, SameHostDaemon)
+
+	hostDir, err := ioutil.TempDir("", "cp-test-symlink-escape")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(hostDir)
+
+	// The symlink points to a directory of the host, it must be resolved in
+	// the scope of the container's rootfs.
+	out, _ := dockerCmd(c, "create", "busybox", "sh", "-c", "ln -s "+hostDir+" /escape")
+	cID := strings.TrimSpace(out)
+	dockerCmd(c, "start", "-a", cID)
+
+	srcPath := filepath.Join(hostDir, "src")
+	if err := ioutil.WriteFile(srcPath, []byte(cpHostContents), 0644); err != nil {
+		c.Fatal(err)
+	}
+
+	runCommandWithOutput(exec.Command(dockerBinary, "cp", srcPath, cID+":/escape"))
+	runCommandWithOutput(exec.Command(dockerBinary, "cp", srcPath, cID+":/escape/copied"))
+
+	files, err := ioutil.ReadDir(hostDir)
+	if err != nil {
+		c.Fatal(err)
+	}
+	if len(files) != 1 {
+		c.Fatalf("Symlink escaped the container rootfs, found %d files in %s", len(files), hostDir)
+	}
+}
+
+func (s *DockerSuite) TestCpToContainerDirToFile(c *check.C) {
+	out, _ := dockerCmd(c, "create", "busybox", "true")
+	cID := strings.TrimSpace(out)
+
+	tmpDir, err := ioutil.TempDir("", "cp-test-to-container")
+	if err != nil {
+		c.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "cp", tmpDir, cID+":/etc/passwd"))
+	if err == nil || !strings.Contains(out, "cannot copy a directory to a file") {
+		c.Fatalf("Expected an error copying a directory to a file, got %v: %s", err, out)
+	}
+}
@@ -923,7 +923,7 @@ func (s *DockerDaemonSuite) TestDaemonLoggingDriverNoneLogsError(c *check.C) {
 	if err == nil {
 		c.Fatalf("Logs should fail with \"none\" driver")
 	}
-	if !strings.Contains(out, `"logs" command is supported only for "json-file" logging driver`) {
+	if !strings.Contains(out, `"logs" endpoint is not supported for "none" logging driver`) {
 		c.Fatalf("There should be error about non-json-file driver, got: %s", out)
 	}
 }
@@ -1264,3 +1264,59 @@ func (s *DockerDaemonSuite) TestDaemonRestartCleanupNetns(c *check.C) {
 	c.Assert(err, check.Not(check.IsNil), check.Commentf("Output: %s", out))
 	// c.Assert(out, check.Equals, "", check.Commentf("Output: %s", out))
 }
+
+func (s *DockerDaemonSuite) TestDaemonLiveRestore(c *check.C) {
+	testRequires(c, NativeExecDriver)
+	if err := s.d.StartWithBusybox("--live-restore"); err != nil {
+		c.Fatal(err)
+	}
+
+	if out, err := s.d.Cmd("run", "-d", "--name", "live", "--net", "host", "busybox", "top"); err != nil {
+		c.Fatal(out, err)
+	}
+	out, err := s.d.Cmd("run", "-d", "--name", "bridged", "busybox", "top")
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	c.Assert(out, check.Matches, "(?s).*WARNING: Containers not using --net=host or --net=none are stopped.*")
+	out, err = s.d.Cmd("run", "-d", "-t", "--name", "tty", "--net", "none", "busybox", "top")
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	c.Assert(out, check.Matches, "(?s).*WARNING: Containers with a TTY are stopped.*")
+
+	pid, err := s.d.Cmd("inspect", "-f", "{{.State.Pid}}", "live")
+	if err != nil {
+		c.Fatal(pid, err)
+	}
+
+	if err := s.d.Restart("--live-restore"); err != nil {
+		c.Fatal(err)
+	}
+
+	for name, running := range map[string]bool{"live": true, "bridged": false, "tty": false} {
+		out, err := s.d.Cmd("inspect", "-f", "{{.State.Running}}", name)
+		if err != nil {
+			c.Fatal(out, err)
+		}
+		c.Assert(strings.TrimSpace(out), check.Equals, strconv.FormatBool(running), check.Commentf("container %s", name))
+	}
+	out, err = s.d.Cmd("inspect", "-f", "{{.State.Pid}}", "live")
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	c.Assert(out, check.Equals, pid, check.Commentf("the process of the container was restarted"))
+
+	// The restored container is still managed by the daemon.
+	if out, err := s.d.Cmd("exec", "live", "true"); err != nil {
+		c.Fatal(out, err)
+	}
+	if out, err := s.d.Cmd("stop", "live"); err != nil {
+		c.Fatal(out, err)
+	}
+	out, err = s.d.Cmd("inspect", "-f", "{{.State.Running}}", "live")
+	if err != nil {
+		c.Fatal(out, err)
+	}
+	c.Assert(strings.TrimSpace(out), check.Equals, "false")
+}
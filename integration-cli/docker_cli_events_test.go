@@ -783,3 +783,70 @@ func (s *DockerSuite) TestEventsDefaultEmpty(c *check.C) {
 	out, _ := dockerCmd(c, "events", fmt.Sprintf("--until=%d", daemonTime(c).Unix()))
 	c.Assert(strings.TrimSpace(out), check.Equals, "")
 }
+
+func (s *DockerSuite) TestEventsFilterLabel(c *check.C) {
+	since := daemonTime(c).Unix()
+
+	out, _ := dockerCmd(c, "run", "-d", "-l", "key=value", "busybox", "top")
+	labeledID := strings.TrimSpace(out)
+	out, _ = dockerCmd(c, "run", "-d", "-l", "key=other", "busybox", "top")
+	otherID := strings.TrimSpace(out)
+
+	out, _ = dockerCmd(c, "events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", daemonTime(c).Unix()), "--filter", "label=key=value")
+	events := strings.Split(strings.TrimSpace(out), "\n")
+	if len(events) == 0 || !strings.Contains(out, labeledID) {
+		c.Fatalf("Expected events for %s, got %q", labeledID, out)
+	}
+	if strings.Contains(out, otherID) {
+		c.Fatalf("Unexpected events for %s: %q", otherID, out)
+	}
+}
+
+func (s *DockerSuite) TestEventsFilterType(c *check.C) {
+	since := daemonTime(c).Unix()
+
+	name := "testeventsfiltertype"
+	if _, err := buildImage(name, "FROM busybox\nLABEL key=value", true); err != nil {
+		c.Fatal(err)
+	}
+	dockerCmd(c, "tag", name, name+":tagged")
+	out, _ := dockerCmd(c, "run", "-d", name, "top")
+	containerID := strings.TrimSpace(out)
+
+	until := fmt.Sprintf("--until=%d", daemonTime(c).Unix())
+
+	out, _ = dockerCmd(c, "events", fmt.Sprintf("--since=%d", since), until, "--filter", "type=image")
+	if !strings.Contains(out, "tag") || strings.Contains(out, containerID) {
+		c.Fatalf("Expected only image events, got %q", out)
+	}
+
+	out, _ = dockerCmd(c, "events", fmt.Sprintf("--since=%d", since), until, "--filter", "type=container")
+	if !strings.Contains(out, containerID) || strings.Contains(out, " tag") {
+		c.Fatalf("Expected only container events, got %q", out)
+	}
+
+	// Image events are filtered on the labels of the image.
+	out, _ = dockerCmd(c, "events", fmt.Sprintf("--since=%d", since), until, "--filter", "type=image", "--filter", "label=key=value")
+	if !strings.Contains(out, name+":tagged") {
+		c.Fatalf("Expected the tag event of %s, got %q", name, out)
+	}
+}
+
+func (s *DockerSuite) TestEventsVolumeType(c *check.C) {
+	since := daemonTime(c).Unix()
+
+	dockerCmd(c, "volume", "create", "--name", "testeventsvolume")
+	dockerCmd(c, "volume", "rm", "testeventsvolume")
+
+	out, _ := dockerCmd(c, "events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", daemonTime(c).Unix()+1), "--filter", "type=volume")
+	events := strings.Split(strings.TrimSpace(out), "\n")
+	if len(events) != 2 {
+		c.Fatalf("Expected 2 volume events, got %q", out)
+	}
+	if !strings.HasSuffix(events[0], "testeventsvolume: (volume) create") {
+		c.Fatalf("Expected a create event, got %q", events[0])
+	}
+	if !strings.HasSuffix(events[1], "testeventsvolume: (volume) destroy") {
+		c.Fatalf("Expected a destroy event, got %q", events[1])
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestHealthRunAndPsFilter(c *check.C) {
+	testRequires(c, NativeExecDriver)
+
+	dockerCmd(c, "run", "-d", "--name=fatal_healthcheck",
+		"--health-interval=500ms", "--health-retries=2",
+		"--health-cmd=cat /status",
+		"busybox", "sh", "-c", "echo OK > /status && top")
+
+	if err := waitInspect("fatal_healthcheck", "{{.State.Health.Status}}", "healthy", 10); err != nil {
+		c.Fatal(err)
+	}
+	out, _ := dockerCmd(c, "ps", "-q", "--no-trunc", "--filter=health=healthy")
+	id, err := inspectField("fatal_healthcheck", "Id")
+	c.Assert(err, check.IsNil)
+	if strings.TrimSpace(out) != id {
+		c.Fatalf("Expected only %s to be healthy, got %q", id, out)
+	}
+
+	dockerCmd(c, "exec", "fatal_healthcheck", "rm", "/status")
+	if err := waitInspect("fatal_healthcheck", "{{.State.Health.Status}}", "unhealthy", 10); err != nil {
+		c.Fatal(err)
+	}
+	out, _ = dockerCmd(c, "ps", "-q", "--no-trunc", "--filter=health=healthy")
+	if strings.Contains(out, id) {
+		c.Fatalf("Expected %s not to be listed as healthy, got %q", id, out)
+	}
+
+	out, _ = dockerCmd(c, "events", "--since=0", fmt.Sprintf("--until=%d", daemonTime(c).Unix()), "--filter=container="+id)
+	if !strings.Contains(out, "health_status: unhealthy") {
+		c.Fatalf("Expected a health_status event, got %q", out)
+	}
+}
+
+func (s *DockerSuite) TestHealthTimeoutKillsProbe(c *check.C) {
+	testRequires(c, NativeExecDriver)
+
+	dockerCmd(c, "run", "-d", "--name=hung_healthcheck",
+		"--health-interval=500ms", "--health-timeout=500ms", "--health-retries=2",
+		"--health-cmd=sleep 100",
+		"busybox", "top")
+
+	if err := waitInspect("hung_healthcheck", "{{.State.Health.Status}}", "unhealthy", 10); err != nil {
+		c.Fatal(err)
+	}
+	// Every probe timed out, at most the one running now is left.
+	out, _ := dockerCmd(c, "top", "hung_healthcheck")
+	if n := strings.Count(out, "sleep 100"); n > 1 {
+		c.Fatalf("Expected the hung probes to be killed, %d are running:\n%s", n, out)
+	}
+}
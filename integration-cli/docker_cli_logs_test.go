@@ -466,3 +466,35 @@ func (s *DockerSuite) TestLogsFollowGoroutinesNoOutput(c *check.C) {
 		}
 	}
 }
+
+func (s *DockerSuite) TestLogsWithMaxSizeAndMaxFile(c *check.C) {
+	testLen := 2000
+	out, _ := dockerCmd(c, "run", "-d", "--log-opt", "max-size=1k", "--log-opt", "max-file=100", "busybox", "sh", "-c", fmt.Sprintf("for i in $(seq 1 %d); do echo line$i; done", testLen))
+	id := strings.TrimSpace(out)
+	dockerCmd(c, "wait", id)
+
+	out, _ = dockerCmd(c, "logs", id)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != testLen {
+		c.Fatalf("Expected log %d lines, received %d\n", testLen, len(lines))
+	}
+	for i, l := range lines {
+		if expected := fmt.Sprintf("line%d", i+1); l != expected {
+			c.Fatalf("Expected line %d to be %q, got %q", i, expected, l)
+		}
+	}
+
+	out, _ = dockerCmd(c, "logs", "--tail", "5", id)
+	lines = strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 5 || lines[4] != fmt.Sprintf("line%d", testLen) {
+		c.Fatalf("Unexpected tail output: %q", out)
+	}
+}
+
+func (s *DockerSuite) TestLogsWithInvalidMaxFile(c *check.C) {
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--log-opt", "max-size=1k", "--log-opt", "max-file=0", "busybox", "true")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err == nil || !strings.Contains(out, "max-file cannot be less than 1") {
+		c.Fatalf("Expected max-file validation error, got: %s, %v", out, err)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestNetworkCliCreateLsRm(c *check.C) {
+	out, _ := dockerCmd(c, "network", "create", "-d", "null", "test")
+	id := strings.TrimSpace(out)
+
+	out, _ = dockerCmd(c, "network", "ls")
+	c.Assert(out, check.Matches, "(?s).*"+id[:12]+"\\s+test\\s+null.*")
+	c.Assert(out, check.Matches, "(?s).*\\s+bridge\\s+bridge.*")
+
+	out, _ = dockerCmd(c, "network", "inspect", "--format={{ .Name }} {{ .Driver }}", id[:12])
+	c.Assert(strings.TrimSpace(out), check.Equals, "test null")
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "network", "create", "-d", "null", "test"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf(out))
+	c.Assert(out, check.Matches, "(?s).*already exists.*")
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "network", "rm", "bridge"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf(out))
+
+	dockerCmd(c, "network", "rm", "test")
+	out, _ = dockerCmd(c, "network", "ls", "-q", "--no-trunc")
+	c.Assert(strings.Contains(out, id), check.Equals, false)
+
+	_, _, err = runCommandWithOutput(exec.Command(dockerBinary, "network", "inspect", "test"))
+	c.Assert(err, check.Not(check.IsNil))
+}
+
+func (s *DockerSuite) TestNetworkCliConnectDisconnect(c *check.C) {
+	dockerCmd(c, "network", "create", "-d", "null", "test")
+	out, _ := dockerCmd(c, "run", "-d", "--name", "top", "busybox", "top")
+	id := strings.TrimSpace(out)
+
+	dockerCmd(c, "network", "connect", "test", "top")
+	out, _ = dockerCmd(c, "inspect", "--format={{ range $name, $ep := .NetworkSettings.Networks }}{{ $name }} {{ end }}", "top")
+	c.Assert(strings.TrimSpace(out), check.Equals, "bridge test")
+
+	out, _ = dockerCmd(c, "network", "inspect", "--format={{ range $id, $ep := .Containers }}{{ $id }}{{ end }}", "test")
+	c.Assert(strings.TrimSpace(out), check.Equals, id)
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "network", "connect", "test", "top"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf(out))
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "network", "rm", "test"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf(out))
+	c.Assert(out, check.Matches, "(?s).*used by container.*")
+
+	// the network is joined again when the container restarts
+	dockerCmd(c, "restart", "top")
+	out, _ = dockerCmd(c, "inspect", "--format={{ range $name, $ep := .NetworkSettings.Networks }}{{ $name }} {{ end }}", "top")
+	c.Assert(strings.TrimSpace(out), check.Equals, "bridge test")
+
+	dockerCmd(c, "network", "disconnect", "test", "top")
+	out, _ = dockerCmd(c, "inspect", "--format={{ range $name, $ep := .NetworkSettings.Networks }}{{ $name }} {{ end }}", "top")
+	c.Assert(strings.TrimSpace(out), check.Equals, "bridge")
+	dockerCmd(c, "network", "rm", "test")
+}
+
+func (s *DockerSuite) TestNetworkCliBridge(c *check.C) {
+	// the networks get the bridge driver by default, each its own bridge
+	dockerCmd(c, "network", "create", "front")
+	dockerCmd(c, "network", "create", "-d", "bridge", "back")
+	out, _ := dockerCmd(c, "network", "inspect", "--format={{ .Driver }}", "front")
+	c.Assert(strings.TrimSpace(out), check.Equals, "bridge")
+
+	dockerCmd(c, "run", "-d", "--name", "web", "--net=front", "busybox", "top")
+	dockerCmd(c, "run", "-d", "--name", "db", "--net=back", "busybox", "top")
+	webIP, err := inspectField("web", "NetworkSettings.IPAddress")
+	c.Assert(err, check.IsNil)
+	dbIP, err := inspectField("db", "NetworkSettings.IPAddress")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Join(strings.Split(webIP, ".")[:2], "."), check.Not(check.Equals), strings.Join(strings.Split(dbIP, ".")[:2], "."))
+
+	// containers reach each other on a network they share, the default
+	// route stays the one of their first network
+	dockerCmd(c, "run", "--rm", "--net=front", "busybox", "ping", "-c", "1", "-W", "2", webIP)
+	dockerCmd(c, "network", "connect", "back", "web")
+	out, _ = dockerCmd(c, "exec", "web", "ping", "-c", "1", "-W", "2", dbIP)
+	c.Assert(out, check.Matches, "(?s).*1 packets received.*")
+	out, _ = dockerCmd(c, "exec", "web", "ip", "route")
+	c.Assert(strings.Count(out, "default"), check.Equals, 1)
+
+	// a running container leaves a network right away, and keeps the
+	// others
+	dockerCmd(c, "network", "disconnect", "back", "web")
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "exec", "web", "ping", "-c", "1", "-W", "2", dbIP))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf(out))
+	dockerCmd(c, "run", "--rm", "--net=front", "busybox", "ping", "-c", "1", "-W", "2", webIP)
+	out, _ = dockerCmd(c, "exec", "web", "ip", "route")
+	c.Assert(out, check.Matches, "(?s).*default.*")
+
+	dockerCmd(c, "rm", "-f", "web", "db")
+	dockerCmd(c, "network", "rm", "front", "back")
+}
+
+func (s *DockerSuite) TestNetworkCliResolver(c *check.C) {
+	dockerCmd(c, "network", "create", "front")
+	dockerCmd(c, "run", "-d", "--name", "db", "--net=front", "--net-alias=cache", "busybox", "top")
+	dockerCmd(c, "run", "-d", "--name", "web", "--net=front", "busybox", "top")
+	dbIP, err := inspectField("db", "NetworkSettings.IPAddress")
+	c.Assert(err, check.IsNil)
+
+	// the containers of a network resolve each other by name and by alias
+	out, _ := dockerCmd(c, "exec", "web", "cat", "/etc/resolv.conf")
+	c.Assert(out, check.Matches, "(?s).*nameserver 127.0.0.11.*")
+	for _, name := range []string{"db", "cache"} {
+		out, _ = dockerCmd(c, "exec", "web", "nslookup", name)
+		c.Assert(out, check.Matches, "(?s).*Name:\\s+"+name+"\\s+Address 1: "+dbIP+".*")
+	}
+	out, _ = dockerCmd(c, "exec", "web", "ping", "-c", "1", "-W", "2", "cache")
+	c.Assert(out, check.Matches, "(?s).*1 packets received.*")
+
+	// other containers don't
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--rm", "busybox", "nslookup", "cache"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf(out))
+
+	dockerCmd(c, "rm", "-f", "web", "db")
+	dockerCmd(c, "network", "rm", "front")
+}
+
+func (s *DockerSuite) TestRunMultipleNetworks(c *check.C) {
+	dockerCmd(c, "network", "create", "-d", "null", "front")
+	dockerCmd(c, "network", "create", "-d", "null", "back")
+
+	dockerCmd(c, "run", "-d", "--name", "top", "--net=front", "--net=back", "busybox", "top")
+	out, _ := dockerCmd(c, "inspect", "--format={{ .HostConfig.NetworkMode }} {{ .HostConfig.Networks }}", "top")
+	c.Assert(strings.TrimSpace(out), check.Equals, "front [back]")
+
+	out, _ = dockerCmd(c, "inspect", "--format={{ range $name, $ep := .NetworkSettings.Networks }}{{ $name }} {{ end }}", "top")
+	c.Assert(strings.TrimSpace(out), check.Equals, "back front")
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--net=nosuchnetwork", "busybox", "true"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf(out))
+	c.Assert(out, check.Matches, "(?s).*No such network: nosuchnetwork.*")
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--net=host", "--net=back", "busybox", "true"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf(out))
+}
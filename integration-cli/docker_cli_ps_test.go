@@ -546,3 +546,71 @@ func (s *DockerSuite) TestPsListContainersFilterCreated(c *check.C) {
 		c.Fatalf("Expected id %s, got %s for filter, out: %s", cID, containerOut, out)
 	}
 }
+
+func (s *DockerSuite) TestPsListContainersFilterAncestor(c *check.C) {
+	imageName := "testpsancestor"
+	if _, err := buildImage(imageName, "FROM busybox\nLABEL match=me", true); err != nil {
+		c.Fatal(err)
+	}
+
+	out, _ := dockerCmd(c, "run", "-d", "busybox", "top")
+	baseID := strings.TrimSpace(out)
+	out, _ = dockerCmd(c, "run", "-d", imageName, "top")
+	childID := strings.TrimSpace(out)
+
+	// The containers of the descendants of busybox are listed too.
+	out, _ = dockerCmd(c, "ps", "-q", "--no-trunc", "--filter=ancestor=busybox")
+	if !strings.Contains(out, baseID) || !strings.Contains(out, childID) {
+		c.Fatalf("Expected ids %s and %s, got %q", baseID, childID, out)
+	}
+
+	out, _ = dockerCmd(c, "ps", "-q", "--no-trunc", "--filter=ancestor="+imageName)
+	if strings.TrimSpace(out) != childID {
+		c.Fatalf("Expected id %s, got %q", childID, out)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "ps", "--filter=ancestor=nosuchimage"))
+	if err == nil || !strings.Contains(out, "No such image: nosuchimage") {
+		c.Fatalf("Expected an error for a missing image, got %v: %s", err, out)
+	}
+}
+
+func (s *DockerSuite) TestPsListContainersFilterVolume(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name", "testpsvolume")
+
+	out, _ := dockerCmd(c, "run", "-d", "-v", "testpsvolume:/data", "busybox", "top")
+	namedID := strings.TrimSpace(out)
+	out, _ = dockerCmd(c, "run", "-d", "-v", "/other", "busybox", "top")
+	otherID := strings.TrimSpace(out)
+
+	out, _ = dockerCmd(c, "ps", "-q", "--no-trunc", "--filter=volume=testpsvolume")
+	if strings.TrimSpace(out) != namedID {
+		c.Fatalf("Expected id %s, got %q", namedID, out)
+	}
+
+	out, _ = dockerCmd(c, "ps", "-q", "--no-trunc", "--filter=volume=/other")
+	if strings.TrimSpace(out) != otherID {
+		c.Fatalf("Expected id %s, got %q", otherID, out)
+	}
+}
+
+func (s *DockerSuite) TestPsListContainersFilterNetwork(c *check.C) {
+	dockerCmd(c, "network", "create", "testpsnetwork")
+	defer dockerCmd(c, "network", "rm", "testpsnetwork")
+
+	out, _ := dockerCmd(c, "run", "-d", "--net=testpsnetwork", "busybox", "top")
+	netID := strings.TrimSpace(out)
+	defer dockerCmd(c, "rm", "-f", netID)
+	out, _ = dockerCmd(c, "create", "--net=testpsnetwork", "busybox", "top")
+	createdID := strings.TrimSpace(out)
+	defer dockerCmd(c, "rm", "-f", createdID)
+	dockerCmd(c, "run", "-d", "busybox", "top")
+
+	// Created containers are listed as connected to the networks they
+	// will join when they start.
+	out, _ = dockerCmd(c, "ps", "-a", "-q", "--no-trunc", "--filter=network=testpsnetwork")
+	ids := strings.Fields(out)
+	if len(ids) != 2 || !strings.Contains(out, netID) || !strings.Contains(out, createdID) {
+		c.Fatalf("Expected ids %s and %s, got %q", netID, createdID, out)
+	}
+}
@@ -3177,3 +3177,28 @@ func (s *DockerSuite) TestDevicePermissions(c *check.C) {
 		c.Fatalf("output should begin with %q, got %q", permissions, out)
 	}
 }
+
+func (s *DockerSuite) TestRunStopSignal(c *check.C) {
+	// The container exits cleanly when it receives the stop signal, instead
+	// of being killed.
+	out, _ := dockerCmd(c, "run", "-d", "--stop-signal=SIGUSR1", "busybox", "sh", "-c", "trap 'exit 0' USR1; while true; do sleep 1; done")
+	id := strings.TrimSpace(out)
+
+	res, err := inspectFieldJSON(id, "Config.StopSignal")
+	c.Assert(err, check.IsNil)
+	if res != `"SIGUSR1"` {
+		c.Fatalf("Signal %s, expected SIGUSR1", res)
+	}
+
+	dockerCmd(c, "stop", id)
+	res, err = inspectField(id, "State.ExitCode")
+	c.Assert(err, check.IsNil)
+	if res != "0" {
+		c.Fatalf("Expected the container to exit on SIGUSR1 with code 0, got %s", res)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "--stop-signal=SIGFOO", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "Invalid signal: SIGFOO") {
+		c.Fatalf("Expected an invalid signal error, got %v: %s", err, out)
+	}
+}
@@ -272,6 +272,49 @@ func (s *DockerSuite) TestRunEchoStdoutWithCPUQuota(c *check.C) {
 	}
 }
 
+func (s *DockerSuite) TestRunWithPidsLimit(c *check.C) {
+	testRequires(c, NativeExecDriver, PidsLimit)
+	// The shell and its first four children fill the limit.
+	runCmd := exec.Command(dockerBinary, "run", "--pids-limit", "5", "--name", "test", "busybox", "sh", "-c", "for i in 1 2 3 4 5 6; do sleep 10 & done; wait")
+	out, _, _ := runCommandWithOutput(runCmd)
+	if !strings.Contains(out, "Resource temporarily unavailable") {
+		c.Fatalf("forking more processes than the pids limit should fail: %s", out)
+	}
+
+	out, err := inspectField("test", "HostConfig.PidsLimit")
+	c.Assert(err, check.IsNil)
+	if out != "5" {
+		c.Fatalf("expected a pids limit of 5, got %s", out)
+	}
+}
+
+func (s *DockerSuite) TestRunWithBlkioThrottle(c *check.C) {
+	testRequires(c, BlkioThrottle)
+	runCmd := exec.Command(dockerBinary, "run", "--device-read-bps", "/dev/sda:1mb", "--device-write-bps", "/dev/sda:1mb",
+		"--device-read-iops", "/dev/sda:1000", "--device-write-iops", "/dev/sda:1000", "busybox", "echo", "test")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		c.Fatalf("failed to run container: %v, output: %q", err, out)
+	}
+	if strings.TrimSpace(out) != "test" {
+		c.Fatalf("container should've printed 'test', got %q", out)
+	}
+
+	runCmd = exec.Command(dockerBinary, "run", "--device-write-iops", "/dev/nonexistent:100", "busybox", "true")
+	out, _, err = runCommandWithOutput(runCmd)
+	if err == nil || !strings.Contains(out, "Cannot throttle block IO on /dev/nonexistent") {
+		c.Fatalf("expected an error for a nonexistent device, got %v: %s", err, out)
+	}
+}
+
+func (s *DockerSuite) TestRunWithInvalidBlkioThrottle(c *check.C) {
+	runCmd := exec.Command(dockerBinary, "run", "--device-read-bps", "/dev/sda:lots", "busybox", "true")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err == nil || !strings.Contains(out, "invalid rate for device") {
+		c.Fatalf("expected an invalid rate error, got %v: %s", err, out)
+	}
+}
+
 func (s *DockerSuite) TestRunWithCpuPeriod(c *check.C) {
 	testRequires(c, CpuCfsPeriod)
 	runCmd := exec.Command(dockerBinary, "run", "--cpu-period", "50000", "--name", "test", "busybox", "true")
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestUpdateRunningContainer(c *check.C) {
+	testRequires(c, NativeExecDriver)
+
+	name := "test-update-running"
+	dockerCmd(c, "run", "-d", "--name", name, "-m", "300M", "busybox", "top")
+	dockerCmd(c, "update", "-m", "500M", "--cpu-shares", "512", name)
+
+	memory, err := inspectField(name, "HostConfig.Memory")
+	c.Assert(err, check.IsNil)
+	c.Assert(memory, check.Equals, "524288000")
+	cpuShares, err := inspectField(name, "HostConfig.CpuShares")
+	c.Assert(err, check.IsNil)
+	c.Assert(cpuShares, check.Equals, "512")
+
+	out, _ := dockerCmd(c, "exec", name, "cat", "/sys/fs/cgroup/memory/memory.limit_in_bytes")
+	c.Assert(strings.TrimSpace(out), check.Equals, "524288000")
+	out, _ = dockerCmd(c, "exec", name, "cat", "/sys/fs/cgroup/cpu/cpu.shares")
+	c.Assert(strings.TrimSpace(out), check.Equals, "512")
+}
+
+func (s *DockerSuite) TestUpdateRaiseMemoryAboveSwapLimit(c *check.C) {
+	testRequires(c, NativeExecDriver)
+
+	// The memory+swap limit of the container is 600M, twice its memory.
+	name := "test-update-raise-memory"
+	dockerCmd(c, "run", "-d", "--name", name, "-m", "300M", "busybox", "top")
+	dockerCmd(c, "update", "-m", "800M", name)
+
+	out, _ := dockerCmd(c, "exec", name, "cat", "/sys/fs/cgroup/memory/memory.limit_in_bytes")
+	c.Assert(strings.TrimSpace(out), check.Equals, "838860800")
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", name, "cat", "/sys/fs/cgroup/memory/memory.memsw.limit_in_bytes")); err == nil {
+		c.Assert(strings.TrimSpace(out), check.Equals, "1677721600")
+	}
+
+	// An explicit memory+swap limit is kept, the memory can't exceed it.
+	name = "test-update-memory-swap"
+	dockerCmd(c, "run", "-d", "--name", name, "-m", "300M", "--memory-swap", "500M", "busybox", "top")
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "update", "-m", "800M", name))
+	c.Assert(err, check.NotNil)
+	c.Assert(out, check.Matches, "(?s).*Minimum memoryswap limit should be larger than memory limit.*")
+}
+
+func (s *DockerSuite) TestUpdateStoppedContainer(c *check.C) {
+	testRequires(c, NativeExecDriver)
+
+	name := "test-update-stopped"
+	dockerCmd(c, "create", "--name", name, "-m", "300M", "busybox", "cat", "/sys/fs/cgroup/memory/memory.limit_in_bytes")
+	dockerCmd(c, "update", "-m", "500M", name)
+
+	// The new limit is used when the container starts.
+	out, _ := dockerCmd(c, "start", "-a", name)
+	c.Assert(strings.TrimSpace(out), check.Equals, "524288000")
+}
+
+func (s *DockerSuite) TestUpdateInvalidLimits(c *check.C) {
+	name := "test-update-invalid"
+	dockerCmd(c, "create", "--name", name, "--blkio-weight", "300", "busybox", "true")
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "update", "--blkio-weight", "5", name))
+	c.Assert(err, check.NotNil)
+	c.Assert(out, check.Matches, "(?s).*Range of blkio weight is from 10 to 1000.*")
+
+	// The container is left unchanged.
+	weight, err := inspectField(name, "HostConfig.BlkioWeight")
+	c.Assert(err, check.IsNil)
+	c.Assert(weight, check.Equals, "300")
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "update", name))
+	c.Assert(err, check.NotNil)
+	c.Assert(out, check.Matches, "(?s).*You must provide one or more flags.*")
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/go-check/check"
+)
+
+func (s *DockerSuite) TestVolumeCliCreate(c *check.C) {
+	dockerCmd(c, "volume", "create")
+
+	out, _ := dockerCmd(c, "volume", "create", "--name=test")
+	name := strings.TrimSpace(out)
+	c.Assert(name, check.Equals, "test")
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "create", "--name=../escape"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf(out))
+}
+
+func (s *DockerSuite) TestVolumeCliInspect(c *check.C) {
+	_, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "inspect", "doesntexist"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf("volume inspect should error on non-existent volume"))
+
+	out, _ := dockerCmd(c, "volume", "create")
+	name := strings.TrimSpace(out)
+	out, _ = dockerCmd(c, "volume", "inspect", "--format={{ .Name }}", name)
+	c.Assert(strings.TrimSpace(out), check.Equals, name)
+
+	dockerCmd(c, "volume", "create", "--name", "test")
+	out, _ = dockerCmd(c, "volume", "inspect", "--format={{ .Name }} {{ .Driver }}", "test")
+	c.Assert(strings.TrimSpace(out), check.Equals, "test local")
+}
+
+func (s *DockerSuite) TestVolumeCliLs(c *check.C) {
+	out, _ := dockerCmd(c, "volume", "create")
+	id := strings.TrimSpace(out)
+
+	dockerCmd(c, "volume", "create", "--name", "test")
+	dockerCmd(c, "run", "-v", "/foo", "busybox", "ls", "/")
+
+	out, _ = dockerCmd(c, "volume", "ls")
+	outArr := strings.Split(strings.TrimSpace(out), "\n")
+	c.Assert(len(outArr), check.Equals, 4, check.Commentf("\n%s", out))
+
+	// Since there is no guaranteed ordering of volumes, we just make sure the names are in the output
+	c.Assert(strings.Contains(out, id+"\n"), check.Equals, true)
+	c.Assert(strings.Contains(out, "test\n"), check.Equals, true)
+}
+
+func (s *DockerSuite) TestVolumeCliLsFilterDangling(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name", "testnotinuse")
+	dockerCmd(c, "volume", "create", "--name", "testinuse")
+	dockerCmd(c, "run", "--name", "volume-test", "-v", "/foo", "busybox", "true")
+
+	out, _ := dockerCmd(c, "volume", "ls", "-q", "-f", "dangling=true")
+	dangling := strings.Fields(out)
+	c.Assert(dangling, check.HasLen, 2, check.Commentf("\n%s", out))
+	c.Assert(strings.Contains(out, "testnotinuse\n"), check.Equals, true)
+
+	out, _ = dockerCmd(c, "volume", "ls", "-q", "-f", "dangling=false")
+	inUse := strings.Fields(out)
+	c.Assert(inUse, check.HasLen, 1, check.Commentf("\n%s", out))
+
+	_, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "ls", "-f", "nosuchfilter=true"))
+	c.Assert(err, check.Not(check.IsNil))
+}
+
+func (s *DockerSuite) TestVolumeCliRm(c *check.C) {
+	out, _ := dockerCmd(c, "volume", "create")
+	id := strings.TrimSpace(out)
+
+	dockerCmd(c, "volume", "create", "--name", "test")
+	dockerCmd(c, "volume", "rm", id)
+	dockerCmd(c, "volume", "rm", "test")
+
+	out, _ = dockerCmd(c, "volume", "ls")
+	outArr := strings.Split(strings.TrimSpace(out), "\n")
+	c.Assert(len(outArr), check.Equals, 1, check.Commentf("%s\n", out))
+
+	dockerCmd(c, "run", "--name", "volume-test", "-v", "/foo", "busybox", "true")
+	out, _ = dockerCmd(c, "volume", "ls", "-q")
+	volumeID := strings.TrimSpace(out)
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "rm", volumeID))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf("Should not be able to remove volume that is in use by a container\n%s", out))
+	c.Assert(out, check.Matches, "(?s).*is in use.*")
+
+	// removing the container without -v keeps the volume, which can then be removed
+	dockerCmd(c, "rm", "volume-test")
+	out, _ = dockerCmd(c, "volume", "ls", "-q")
+	c.Assert(strings.TrimSpace(out), check.Equals, volumeID)
+	dockerCmd(c, "volume", "rm", volumeID)
+
+	_, _, err = runCommandWithOutput(exec.Command(dockerBinary, "volume", "rm", "doesntexist"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf("volume rm should fail with non-existent volume"))
+}
+
+func (s *DockerSuite) TestRmVolumeSharedWithVolumesFrom(c *check.C) {
+	dockerCmd(c, "run", "--name", "parent", "-v", "/foo", "busybox", "true")
+	dockerCmd(c, "run", "--name", "child", "--volumes-from", "parent", "busybox", "true")
+	out, _ := dockerCmd(c, "volume", "ls", "-q")
+	volumeID := strings.TrimSpace(out)
+
+	// the volume is still used by the child
+	dockerCmd(c, "rm", "-v", "parent")
+	out, _ = dockerCmd(c, "volume", "ls", "-q")
+	c.Assert(strings.TrimSpace(out), check.Equals, volumeID)
+
+	dockerCmd(c, "rm", "-v", "child")
+	out, _ = dockerCmd(c, "volume", "ls", "-q")
+	c.Assert(strings.TrimSpace(out), check.Equals, "")
+}
+
+func (s *DockerSuite) TestVolumeCliCreateLabelsAndOptions(c *check.C) {
+	dockerCmd(c, "volume", "create", "--name", "test", "--label", "com.example.tier=db", "--label", "owner=me")
+
+	out, _ := dockerCmd(c, "volume", "inspect", "--format={{ .Labels }}", "test")
+	c.Assert(strings.TrimSpace(out), check.Equals, "map[com.example.tier:db owner:me]")
+
+	// creating the volume again needs the same labels
+	dockerCmd(c, "volume", "create", "--name", "test", "--label", "com.example.tier=db", "--label", "owner=me")
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "create", "--name", "test", "--label", "com.example.tier=web"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf(out))
+	c.Assert(out, check.Matches, "(?s).*Conflict.*")
+
+	// the local driver does not take any option
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "volume", "create", "--name", "test2", "-o", "size=10G"))
+	c.Assert(err, check.Not(check.IsNil), check.Commentf(out))
+	c.Assert(out, check.Matches, "(?s).*does not support options.*")
+}
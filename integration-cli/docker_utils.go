@@ -413,6 +413,76 @@ func deleteAllContainers() error {
 	return nil
 }
 
+func getAllVolumes() ([]string, error) {
+	out, exitCode, err := runCommandWithOutput(exec.Command(dockerBinary, "volume", "ls", "-q"))
+	if exitCode != 0 && err == nil {
+		err = fmt.Errorf("failed to get a list of volumes: %v\n", out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+func deleteAllVolumes() error {
+	volumes, err := getAllVolumes()
+	if err != nil {
+		return err
+	}
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	args := append([]string{"volume", "rm"}, volumes...)
+	out, exitCode, err := runCommandWithOutput(exec.Command(dockerBinary, args...))
+	if exitCode != 0 && err == nil {
+		err = fmt.Errorf("failed to remove volumes: %v\n", out)
+	}
+	return err
+}
+
+// getAllNetworks returns the names of the user-defined networks.
+func getAllNetworks() ([]string, error) {
+	out, exitCode, err := runCommandWithOutput(exec.Command(dockerBinary, "network", "ls"))
+	if exitCode != 0 && err == nil {
+		err = fmt.Errorf("failed to get a list of networks: %v\n", out)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var networks []string
+	for _, l := range strings.Split(strings.TrimSpace(out), "\n")[1:] {
+		fields := strings.Fields(l)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[1] {
+		case "bridge", "host", "none":
+		default:
+			networks = append(networks, fields[1])
+		}
+	}
+	return networks, nil
+}
+
+func deleteAllNetworks() error {
+	networks, err := getAllNetworks()
+	if err != nil {
+		return err
+	}
+	if len(networks) == 0 {
+		return nil
+	}
+
+	args := append([]string{"network", "rm"}, networks...)
+	out, exitCode, err := runCommandWithOutput(exec.Command(dockerBinary, args...))
+	if exitCode != 0 && err == nil {
+		err = fmt.Errorf("failed to remove networks: %v\n", out)
+	}
+	return err
+}
+
 var protectedImages = map[string]struct{}{}
 
 func init() {
@@ -881,10 +951,26 @@ func getContainerState(c *check.C, id string) (int, bool, error) {
 }
 
 func buildImageWithOut(name, dockerfile string, useCache bool) (string, string, error) {
+	return buildImageWithBuildArgs(name, dockerfile, useCache, nil)
+}
+
+// buildImageWithBuildArgs builds dockerfile given as KEY=value strings in
+// buildArgs to --build-arg.
+func buildImageWithBuildArgs(name, dockerfile string, useCache bool, buildArgs []string) (string, string, error) {
+	var flags []string
+	for _, arg := range buildArgs {
+		flags = append(flags, "--build-arg", arg)
+	}
+	return buildImageWithFlags(name, dockerfile, useCache, flags...)
+}
+
+// buildImageWithFlags builds dockerfile with more flags given to docker build.
+func buildImageWithFlags(name, dockerfile string, useCache bool, flags ...string) (string, string, error) {
 	args := []string{"build", "-t", name}
 	if !useCache {
 		args = append(args, "--no-cache")
 	}
+	args = append(args, flags...)
 	args = append(args, "-")
 	buildCmd := exec.Command(dockerBinary, args...)
 	buildCmd.Stdin = strings.NewReader(dockerfile)
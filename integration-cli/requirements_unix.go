@@ -4,6 +4,8 @@ package main
 
 import (
 	"io/ioutil"
+	"os"
+	"os/exec"
 	"path"
 
 	"github.com/docker/libcontainer/cgroups"
@@ -36,4 +38,33 @@ var (
 		},
 		"Test requires an environment that supports cgroup cfs quota.",
 	}
+	PidsLimit = TestRequirement{
+		func() bool {
+			_, err := cgroups.FindCgroupMountpoint("pids")
+			return err == nil
+		},
+		"Test requires an environment that supports cgroup pids limit.",
+	}
+	BlkioThrottle = TestRequirement{
+		func() bool {
+			cgroupBlkioMountpoint, err := cgroups.FindCgroupMountpoint("blkio")
+			if err != nil {
+				return false
+			}
+			if _, err := ioutil.ReadFile(path.Join(cgroupBlkioMountpoint, "blkio.throttle.read_bps_device")); err != nil {
+				return false
+			}
+			// The tests throttle the block IO on /dev/sda.
+			_, err = os.Stat("/dev/sda")
+			return err == nil
+		},
+		"Test requires an environment that supports cgroup blkio throttle, with a /dev/sda device.",
+	}
+	Criu = TestRequirement{
+		func() bool {
+			_, err := exec.LookPath("criu")
+			return err == nil
+		},
+		"Test requires CRIU to be installed.",
+	}
 )
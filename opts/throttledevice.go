@@ -0,0 +1,88 @@
+package opts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/pkg/blkiodev"
+	"github.com/docker/docker/pkg/units"
+)
+
+// ValidatorThrottleFctType validates and parses the rate limit of a device.
+type ValidatorThrottleFctType func(val string) (*blkiodev.ThrottleDevice, error)
+
+// ThrottledeviceOpt holds the rate limits of devices, set in the form
+// path:rate.
+type ThrottledeviceOpt struct {
+	values    []*blkiodev.ThrottleDevice
+	validator ValidatorThrottleFctType
+}
+
+func NewThrottledeviceOpt(validator ValidatorThrottleFctType) *ThrottledeviceOpt {
+	return &ThrottledeviceOpt{validator: validator}
+}
+
+func (o *ThrottledeviceOpt) Set(val string) error {
+	d, err := o.validator(val)
+	if err != nil {
+		return err
+	}
+	o.values = append(o.values, d)
+	return nil
+}
+
+func (o *ThrottledeviceOpt) String() string {
+	var out []string
+	for _, v := range o.values {
+		out = append(out, v.String())
+	}
+
+	return fmt.Sprintf("%v", out)
+}
+
+func (o *ThrottledeviceOpt) GetList() []*blkiodev.ThrottleDevice {
+	return o.values
+}
+
+// splitThrottleDevice splits a rate limit in the form path:rate.
+func splitThrottleDevice(val string) (string, string, error) {
+	i := strings.LastIndex(val, ":")
+	if i == -1 {
+		return "", "", fmt.Errorf("bad format: %s, expected <device-path>:<rate>", val)
+	}
+	path, rate := val[:i], val[i+1:]
+	if !strings.HasPrefix(path, "/dev/") {
+		return "", "", fmt.Errorf("bad format for device path: %s", path)
+	}
+	return path, rate, nil
+}
+
+// ValidateThrottleBpsDevice validates a rate limit in bytes per second in the
+// form path:rate, where rate is a number with an optional unit (kb, mb or
+// gb).
+func ValidateThrottleBpsDevice(val string) (*blkiodev.ThrottleDevice, error) {
+	path, rate, err := splitThrottleDevice(val)
+	if err != nil {
+		return nil, err
+	}
+	bps, err := units.RAMInBytes(rate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate for device: %s. The correct format is <device-path>:<number>[<unit>]. Number must be a positive integer. Unit is optional and can be kb, mb, or gb", val)
+	}
+	return &blkiodev.ThrottleDevice{Path: path, Rate: uint64(bps)}, nil
+}
+
+// ValidateThrottleIOpsDevice validates a rate limit in IO operations per
+// second in the form path:rate.
+func ValidateThrottleIOpsDevice(val string) (*blkiodev.ThrottleDevice, error) {
+	path, rate, err := splitThrottleDevice(val)
+	if err != nil {
+		return nil, err
+	}
+	iops, err := strconv.ParseUint(rate, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate for device: %s. The correct format is <device-path>:<number>. Number must be a positive integer", val)
+	}
+	return &blkiodev.ThrottleDevice{Path: path, Rate: iops}, nil
+}
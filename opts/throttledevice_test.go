@@ -0,0 +1,73 @@
+package opts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateThrottleBpsDevice(t *testing.T) {
+	valid := map[string]uint64{
+		"/dev/sda:1024": 1024,
+		"/dev/sda:1kb":  1024,
+		"/dev/sdb:10MB": 10 * 1024 * 1024,
+		"/dev/sdc:0":    0,
+		"/dev/disk/by-path/pci-0000:00:1f.2-ata-1:1mb": 1024 * 1024,
+	}
+	invalid := map[string]string{
+		"/dev/sda":      "bad format",
+		"sda:1024":      "bad format for device path",
+		"/dev/sda:-1":   "invalid rate",
+		"/dev/sda:1ab":  "invalid rate",
+		"/dev/sda:lots": "invalid rate",
+	}
+
+	for val, rate := range valid {
+		d, err := ValidateThrottleBpsDevice(val)
+		if err != nil {
+			t.Fatalf("ValidateThrottleBpsDevice(%q) should succeed: error %v", val, err)
+		}
+		if d.Rate != rate {
+			t.Fatalf("ValidateThrottleBpsDevice(%q) rate is %d, expected %d", val, d.Rate, rate)
+		}
+	}
+	for val, expectedError := range invalid {
+		if _, err := ValidateThrottleBpsDevice(val); err == nil || !strings.Contains(err.Error(), expectedError) {
+			t.Fatalf("ValidateThrottleBpsDevice(%q) should fail with %q, got %v", val, expectedError, err)
+		}
+	}
+}
+
+func TestValidateThrottleIOpsDevice(t *testing.T) {
+	d, err := ValidateThrottleIOpsDevice("/dev/sda:1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Path != "/dev/sda" || d.Rate != 1000 {
+		t.Fatalf("Expected /dev/sda:1000, got %s", d)
+	}
+
+	for _, val := range []string{"/dev/sda:1kb", "/dev/sda:-1", "/dev/sda:"} {
+		if _, err := ValidateThrottleIOpsDevice(val); err == nil || !strings.Contains(err.Error(), "invalid rate") {
+			t.Fatalf("ValidateThrottleIOpsDevice(%q) should fail with an invalid rate, got %v", val, err)
+		}
+	}
+}
+
+func TestThrottledeviceOpt(t *testing.T) {
+	o := NewThrottledeviceOpt(ValidateThrottleIOpsDevice)
+	if err := o.Set("/dev/sda:100"); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Set("/dev/sdb:200"); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Set("/dev/sdc"); err == nil {
+		t.Fatal("Expected an error for a rate limit without rate")
+	}
+	if len(o.GetList()) != 2 {
+		t.Fatalf("Expected 2 rate limits, got %d", len(o.GetList()))
+	}
+	if o.String() != "[/dev/sda:100 /dev/sdb:200]" {
+		t.Fatalf("Unexpected string %s", o.String())
+	}
+}
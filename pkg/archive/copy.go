@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"archive/tar"
+	"io"
+	"strings"
+)
+
+// RebaseArchiveEntries renames the entries of the tar archive srcContent
+// named oldBase, or nested under oldBase, to be named newBase or nested under
+// newBase instead. The other entries are left unchanged. It is used to copy a
+// file or directory to a destination with another name.
+func RebaseArchiveEntries(srcContent ArchiveReader, oldBase, newBase string) Archive {
+	rebased, w := io.Pipe()
+
+	go func() {
+		srcTar := tar.NewReader(srcContent)
+		rebasedTar := tar.NewWriter(w)
+
+		for {
+			hdr, err := srcTar.Next()
+			if err == io.EOF {
+				// Signals end of archive.
+				rebasedTar.Close()
+				w.Close()
+				return
+			}
+			if err != nil {
+				w.CloseWithError(err)
+				return
+			}
+
+			hdr.Name = rebaseName(hdr.Name, oldBase, newBase)
+			if hdr.Typeflag == tar.TypeLink {
+				// Hard links point to entries of the same archive.
+				hdr.Linkname = rebaseName(hdr.Linkname, oldBase, newBase)
+			}
+
+			if err := rebasedTar.WriteHeader(hdr); err != nil {
+				w.CloseWithError(err)
+				return
+			}
+			if _, err := io.Copy(rebasedTar, srcTar); err != nil {
+				w.CloseWithError(err)
+				return
+			}
+		}
+	}()
+
+	return rebased
+}
+
+func rebaseName(name, oldBase, newBase string) string {
+	if name == oldBase || strings.HasPrefix(name, oldBase+"/") {
+		return newBase + name[len(oldBase):]
+	}
+	return name
+}
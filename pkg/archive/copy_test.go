@@ -0,0 +1,92 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRebaseArchiveEntries(t *testing.T) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, hdr := range []*tar.Header{
+		{Name: "src/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "src/file", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "src/link", Typeflag: tar.TypeLink, Linkname: "src/file"},
+		{Name: "srcfile", Typeflag: tar.TypeReg, Mode: 0644},
+	} {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+
+	rebased := RebaseArchiveEntries(buf, "src", "dst")
+	defer rebased.Close()
+
+	var names, links []string
+	tr := tar.NewReader(rebased)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		if hdr.Linkname != "" {
+			links = append(links, hdr.Linkname)
+		}
+	}
+
+	expected := []string{"dst/", "dst/file", "dst/link", "srcfile"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, names)
+		}
+	}
+	if len(links) != 1 || links[0] != "dst/file" {
+		t.Fatalf("Expected the hard link to point to dst/file, got %v", links)
+	}
+}
+
+func TestUntarNoOverwriteDirNonDir(t *testing.T) {
+	dest, err := ioutil.TempDir("", "docker-archive-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+	if err := os.Mkdir(filepath.Join(dest, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "dir", Typeflag: tar.TypeReg, Mode: 0644}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	content := buf.Bytes()
+
+	if err := Untar(bytes.NewReader(content), dest, &TarOptions{NoOverwriteDirNonDir: true}); err == nil {
+		t.Fatal("Expected an error replacing a directory with a file")
+	}
+	if fi, err := os.Stat(filepath.Join(dest, "dir")); err != nil || !fi.IsDir() {
+		t.Fatalf("Expected the directory to be kept, got %v, %v", fi, err)
+	}
+
+	if err := Untar(bytes.NewReader(content), dest, nil); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(filepath.Join(dest, "dir")); err != nil || fi.IsDir() {
+		t.Fatalf("Expected the directory to be replaced with a file, got %v, %v", fi, err)
+	}
+}
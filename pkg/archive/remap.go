@@ -0,0 +1,82 @@
+package archive
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/ioutils"
+)
+
+// ToHostIDs returns an uncompressed copy of the tar archive layer in which
+// the owners of the entries, ids in a user namespace with the mappings
+// uidMaps and gidMaps, are translated to the ids on the host. The archive
+// is returned as is without mappings.
+func ToHostIDs(layer ArchiveReader, uidMaps, gidMaps []idtools.IDMap) Archive {
+	return translateIDs(layer, uidMaps, gidMaps, idtools.ToHost)
+}
+
+// ToContainerIDs returns an uncompressed copy of the tar archive layer in
+// which the owners of the entries, ids on the host, are translated to the
+// ids in a user namespace with the mappings uidMaps and gidMaps. The archive
+// is returned as is without mappings.
+func ToContainerIDs(layer ArchiveReader, uidMaps, gidMaps []idtools.IDMap) Archive {
+	return translateIDs(layer, uidMaps, gidMaps, idtools.ToContainer)
+}
+
+func translateIDs(layer ArchiveReader, uidMaps, gidMaps []idtools.IDMap, translate func(int, []idtools.IDMap) (int, error)) Archive {
+	if uidMaps == nil && gidMaps == nil {
+		if a, ok := layer.(Archive); ok {
+			return a
+		}
+		return ioutil.NopCloser(layer)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(copyTranslatedIDs(pw, layer, uidMaps, gidMaps, translate))
+	}()
+	return ioutils.NewReadCloserWrapper(pr, func() error {
+		err := pr.Close()
+		if c, ok := layer.(io.Closer); ok {
+			if cerr := c.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	})
+}
+
+func copyTranslatedIDs(dest io.Writer, layer ArchiveReader, uidMaps, gidMaps []idtools.IDMap, translate func(int, []idtools.IDMap) (int, error)) error {
+	decompressed, err := DecompressStream(layer)
+	if err != nil {
+		return err
+	}
+	defer decompressed.Close()
+
+	tr := tar.NewReader(decompressed)
+	tw := tar.NewWriter(dest)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Uid, err = translate(hdr.Uid, uidMaps); err != nil {
+			return err
+		}
+		if hdr.Gid, err = translate(hdr.Gid, gidMaps); err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
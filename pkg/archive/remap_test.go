@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/docker/docker/pkg/idtools"
+)
+
+func TestTranslateIDs(t *testing.T) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, hdr := range []*tar.Header{
+		{Name: "root", Uid: 0, Gid: 0, Size: 4, Mode: 0644},
+		{Name: "user", Uid: 1000, Gid: 100, Size: 4, Mode: 0644},
+	} {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte("data")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	uidMaps := []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	gidMaps := []idtools.IDMap{{ContainerID: 0, HostID: 200000, Size: 65536}}
+	toHost := ToHostIDs(bytes.NewReader(buf.Bytes()), uidMaps, gidMaps)
+	hostLayer, err := ioutil.ReadAll(toHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toHost.Close()
+	checkOwners(t, hostLayer, [][2]int{{100000, 200000}, {101000, 200100}})
+
+	toContainer := ToContainerIDs(bytes.NewReader(hostLayer), uidMaps, gidMaps)
+	containerLayer, err := ioutil.ReadAll(toContainer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toContainer.Close()
+	checkOwners(t, containerLayer, [][2]int{{0, 0}, {1000, 100}})
+
+	// The owners of the host layer aren't mapped in the container.
+	if _, err := ioutil.ReadAll(ToHostIDs(bytes.NewReader(hostLayer), uidMaps, gidMaps)); err == nil {
+		t.Fatal("Expected an error translating unmapped ids")
+	}
+}
+
+func checkOwners(t *testing.T, layer []byte, owners [][2]int) {
+	tr := tar.NewReader(bytes.NewReader(layer))
+	for i := 0; ; i++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			if i != len(owners) {
+				t.Fatalf("Expected %d entries, got %d", len(owners), i)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Uid != owners[i][0] || hdr.Gid != owners[i][1] {
+			t.Fatalf("Expected %s to be owned by %d:%d, got %d:%d", hdr.Name, owners[i][0], owners[i][1], hdr.Uid, hdr.Gid)
+		}
+		if data, err := ioutil.ReadAll(tr); err != nil || string(data) != "data" {
+			t.Fatalf("Unexpected content %q of %s, %v", data, hdr.Name, err)
+		}
+	}
+}
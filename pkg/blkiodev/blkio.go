@@ -0,0 +1,16 @@
+// Package blkiodev defines the block IO limits of a container on a device
+// of the host.
+package blkiodev
+
+import "fmt"
+
+// ThrottleDevice is a rate limit of the block IO of a container on the
+// device at Path, in bytes or IO operations per second.
+type ThrottleDevice struct {
+	Path string
+	Rate uint64
+}
+
+func (t *ThrottleDevice) String() string {
+	return fmt.Sprintf("%s:%d", t.Path, t.Rate)
+}
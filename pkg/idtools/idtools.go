@@ -0,0 +1,144 @@
+// Package idtools maps user and group ids between a user namespace and the
+// host, and reads the subordinate id ranges allotted to the users of the host.
+package idtools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// IDMap is a range of ids of a user namespace, starting at ContainerID,
+// mapped to the ids of the host starting at HostID.
+type IDMap struct {
+	ContainerID int `json:"container_id"`
+	HostID      int `json:"host_id"`
+	Size        int `json:"size"`
+}
+
+// The files listing the subordinate uid and gid ranges of the users.
+var (
+	subuidFile = "/etc/subuid"
+	subgidFile = "/etc/subgid"
+)
+
+// ToHost translates the id of a user namespace with the mappings idMap to
+// the id on the host. Without mappings, ids are the same on both sides.
+func ToHost(containerID int, idMap []IDMap) (int, error) {
+	if idMap == nil {
+		return containerID, nil
+	}
+	for _, m := range idMap {
+		if containerID >= m.ContainerID && containerID < m.ContainerID+m.Size {
+			return m.HostID + (containerID - m.ContainerID), nil
+		}
+	}
+	return -1, fmt.Errorf("container id %d is not mapped to the host", containerID)
+}
+
+// ToContainer translates the id hostID of the host to the id in a user
+// namespace with the mappings idMap.
+func ToContainer(hostID int, idMap []IDMap) (int, error) {
+	if idMap == nil {
+		return hostID, nil
+	}
+	for _, m := range idMap {
+		if hostID >= m.HostID && hostID < m.HostID+m.Size {
+			return m.ContainerID + (hostID - m.HostID), nil
+		}
+	}
+	return -1, fmt.Errorf("host id %d is not mapped in the container", hostID)
+}
+
+// GetRootUIDGID returns the uid and gid on the host of root in a user
+// namespace with the given mappings.
+func GetRootUIDGID(uidMap, gidMap []IDMap) (int, int, error) {
+	uid, err := ToHost(0, uidMap)
+	if err != nil {
+		return -1, -1, err
+	}
+	gid, err := ToHost(0, gidMap)
+	if err != nil {
+		return -1, -1, err
+	}
+	return uid, gid, nil
+}
+
+// CreateIDMappings returns the mappings of the subordinate uids of username
+// and gids of groupname, as listed in /etc/subuid and /etc/subgid, to the
+// ids of a user namespace starting at 0.
+func CreateIDMappings(username, groupname string) ([]IDMap, []IDMap, error) {
+	uidRanges, err := parseSubids(subuidFile, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(uidRanges) == 0 {
+		return nil, nil, fmt.Errorf("no subordinate uid range found for %q in %s", username, subuidFile)
+	}
+	gidRanges, err := parseSubids(subgidFile, groupname)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(gidRanges) == 0 {
+		return nil, nil, fmt.Errorf("no subordinate gid range found for %q in %s", groupname, subgidFile)
+	}
+	return createIDMap(uidRanges), createIDMap(gidRanges), nil
+}
+
+type subIDRange struct {
+	start  int
+	length int
+}
+
+// createIDMap maps the ranges one after the other, from 0.
+func createIDMap(ranges []subIDRange) []IDMap {
+	var idMap []IDMap
+	containerID := 0
+	for _, r := range ranges {
+		idMap = append(idMap, IDMap{
+			ContainerID: containerID,
+			HostID:      r.start,
+			Size:        r.length,
+		})
+		containerID += r.length
+	}
+	return idMap
+}
+
+// parseSubids returns the ranges of the entries of name in the subordinate
+// id file path, whose lines are of the form name:start:length.
+func parseSubids(path, name string) ([]subIDRange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ranges []subIDRange
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid line %q in %s", line, path)
+		}
+		if parts[0] != name {
+			continue
+		}
+		start, err := strconv.Atoi(parts[1])
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid start of range in line %q of %s", line, path)
+		}
+		length, err := strconv.Atoi(parts[2])
+		if err != nil || length <= 0 {
+			return nil, fmt.Errorf("invalid length of range in line %q of %s", line, path)
+		}
+		ranges = append(ranges, subIDRange{start, length})
+	}
+	return ranges, s.Err()
+}
@@ -0,0 +1,103 @@
+package idtools
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func writeSubidFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "subid-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestCreateIDMappings(t *testing.T) {
+	uidPath := writeSubidFile(t, "other:100000:65536\n# comment\n\nremap:165536:65536\nremap:300000:1000\n")
+	defer os.Remove(uidPath)
+	gidPath := writeSubidFile(t, "remapgroup:200000:65536\n")
+	defer os.Remove(gidPath)
+	subuidFile, subgidFile = uidPath, gidPath
+	defer func() { subuidFile, subgidFile = "/etc/subuid", "/etc/subgid" }()
+
+	uidMap, gidMap, err := CreateIDMappings("remap", "remapgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedUIDs := []IDMap{
+		{ContainerID: 0, HostID: 165536, Size: 65536},
+		{ContainerID: 65536, HostID: 300000, Size: 1000},
+	}
+	if !reflect.DeepEqual(uidMap, expectedUIDs) {
+		t.Fatalf("Expected uid mappings %v, got %v", expectedUIDs, uidMap)
+	}
+	expectedGIDs := []IDMap{{ContainerID: 0, HostID: 200000, Size: 65536}}
+	if !reflect.DeepEqual(gidMap, expectedGIDs) {
+		t.Fatalf("Expected gid mappings %v, got %v", expectedGIDs, gidMap)
+	}
+
+	if _, _, err := CreateIDMappings("unknown", "remapgroup"); err == nil {
+		t.Fatal("Expected an error for a user without subordinate uids")
+	}
+	if _, _, err := CreateIDMappings("remap", "unknown"); err == nil {
+		t.Fatal("Expected an error for a group without subordinate gids")
+	}
+}
+
+func TestCreateIDMappingsInvalid(t *testing.T) {
+	for _, content := range []string{
+		"remap:100000\n",
+		"remap:start:65536\n",
+		"remap:100000:0\n",
+		"remap:-1:65536\n",
+	} {
+		path := writeSubidFile(t, content)
+		subuidFile, subgidFile = path, path
+		if _, _, err := CreateIDMappings("remap", "remap"); err == nil {
+			t.Fatalf("Expected an error parsing %q", content)
+		}
+		os.Remove(path)
+	}
+	subuidFile, subgidFile = "/etc/subuid", "/etc/subgid"
+}
+
+func TestTranslateIDs(t *testing.T) {
+	idMap := []IDMap{
+		{ContainerID: 0, HostID: 100000, Size: 1000},
+		{ContainerID: 1000, HostID: 300000, Size: 10},
+	}
+	for _, c := range []struct{ container, host int }{
+		{0, 100000},
+		{999, 100999},
+		{1000, 300000},
+		{1009, 300009},
+	} {
+		if host, err := ToHost(c.container, idMap); err != nil || host != c.host {
+			t.Fatalf("Expected %d to map to %d on the host, got %d, %v", c.container, c.host, host, err)
+		}
+		if container, err := ToContainer(c.host, idMap); err != nil || container != c.container {
+			t.Fatalf("Expected %d to map to %d in the container, got %d, %v", c.host, c.container, container, err)
+		}
+	}
+	if _, err := ToHost(1010, idMap); err == nil {
+		t.Fatal("Expected an error for an unmapped container id")
+	}
+	if _, err := ToContainer(0, idMap); err == nil {
+		t.Fatal("Expected an error for an unmapped host id")
+	}
+	if id, err := ToHost(42, nil); err != nil || id != 42 {
+		t.Fatalf("Ids should be the same without mappings, got %d, %v", id, err)
+	}
+
+	uid, gid, err := GetRootUIDGID(idMap, []IDMap{{ContainerID: 0, HostID: 200000, Size: 1}})
+	if err != nil || uid != 100000 || gid != 200000 {
+		t.Fatalf("Unexpected root %d:%d, %v", uid, gid, err)
+	}
+}
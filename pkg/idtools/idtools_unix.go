@@ -0,0 +1,35 @@
+// +build !windows
+
+package idtools
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/pkg/system"
+)
+
+// MkdirAllAs creates the directory path and its missing parents with mode,
+// like os.MkdirAll, and makes ownerUID and ownerGID the owners of the
+// directories it created. The owners of existing directories are kept.
+func MkdirAllAs(path string, mode os.FileMode, ownerUID, ownerGID int) error {
+	var created []string
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		if _, err := os.Stat(p); err == nil || !os.IsNotExist(err) {
+			break
+		}
+		created = append(created, p)
+		if p == filepath.Dir(p) {
+			break
+		}
+	}
+	if err := system.MkdirAll(path, mode); err != nil && !os.IsExist(err) {
+		return err
+	}
+	for _, p := range created {
+		if err := os.Chown(p, ownerUID, ownerGID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
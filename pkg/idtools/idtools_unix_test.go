@@ -0,0 +1,57 @@
+// +build !windows
+
+package idtools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestMkdirAllAs(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("Changing the owner of directories requires root")
+	}
+	root, err := ioutil.TempDir("", "mkdirallas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	existing := filepath.Join(root, "existing")
+	if err := os.Mkdir(existing, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(existing, "a", "b")
+	if err := MkdirAllAs(path, 0700, 100000, 200000); err != nil {
+		t.Fatal(err)
+	}
+	for p, owner := range map[string][2]uint32{
+		existing:                     {0, 0},
+		filepath.Join(existing, "a"): {100000, 200000},
+		path:                         {100000, 200000},
+	} {
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		st := fi.Sys().(*syscall.Stat_t)
+		if st.Uid != owner[0] || st.Gid != owner[1] {
+			t.Fatalf("Expected %s to be owned by %d:%d, got %d:%d", p, owner[0], owner[1], st.Uid, st.Gid)
+		}
+	}
+
+	// Existing directories keep their owner.
+	if err := MkdirAllAs(path, 0700, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := fi.Sys().(*syscall.Stat_t); st.Uid != 100000 {
+		t.Fatalf("The owner of %s shouldn't change, got %d", path, st.Uid)
+	}
+}
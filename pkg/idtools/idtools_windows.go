@@ -0,0 +1,18 @@
+// +build windows
+
+package idtools
+
+import (
+	"os"
+
+	"github.com/docker/docker/pkg/system"
+)
+
+// MkdirAllAs creates the directory path and its missing parents with mode.
+// Ownership isn't supported on Windows, ownerUID and ownerGID are ignored.
+func MkdirAllAs(path string, mode os.FileMode, ownerUID, ownerGID int) error {
+	if err := system.MkdirAll(path, mode); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
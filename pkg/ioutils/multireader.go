@@ -0,0 +1,92 @@
+package ioutils
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+type multiReadSeeker struct {
+	readers []io.ReadSeeker
+	idx     int
+}
+
+// MultiReadSeeker returns an io.ReadSeeker that is the logical concatenation
+// of the given readers. Readers are expected to be positioned at their start.
+// Sizes are looked up on every Seek, so the last reader is allowed to grow.
+func MultiReadSeeker(readers ...io.ReadSeeker) io.ReadSeeker {
+	return &multiReadSeeker{readers: readers}
+}
+
+func (r *multiReadSeeker) Read(b []byte) (int, error) {
+	for r.idx < len(r.readers) {
+		n, err := r.readers[r.idx].Read(b)
+		if err == io.EOF && r.idx < len(r.readers)-1 {
+			r.idx++
+			if _, err := r.readers[r.idx].Seek(0, os.SEEK_SET); err != nil {
+				return n, err
+			}
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+	return 0, io.EOF
+}
+
+func (r *multiReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	if len(r.readers) == 0 {
+		return 0, nil
+	}
+
+	var current int64
+	if whence == os.SEEK_CUR {
+		pos, err := r.readers[r.idx].Seek(0, os.SEEK_CUR)
+		if err != nil {
+			return 0, err
+		}
+		current = pos
+	}
+
+	sizes := make([]int64, len(r.readers))
+	var total int64
+	for i, rdr := range r.readers {
+		size, err := rdr.Seek(0, os.SEEK_END)
+		if err != nil {
+			return 0, err
+		}
+		if i < r.idx {
+			current += size
+		}
+		sizes[i] = size
+		total += size
+	}
+
+	var abs int64
+	switch whence {
+	case os.SEEK_SET:
+		abs = offset
+	case os.SEEK_CUR:
+		abs = current + offset
+	case os.SEEK_END:
+		abs = total + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if abs < 0 {
+		return 0, fmt.Errorf("negative position: %d", abs)
+	}
+
+	rel := abs
+	idx := 0
+	for ; idx < len(sizes)-1 && rel >= sizes[idx]; idx++ {
+		rel -= sizes[idx]
+	}
+	if _, err := r.readers[idx].Seek(rel, os.SEEK_SET); err != nil {
+		return 0, err
+	}
+	r.idx = idx
+	return abs, nil
+}
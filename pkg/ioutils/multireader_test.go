@@ -0,0 +1,79 @@
+package ioutils
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMultiReadSeekerReadAll(t *testing.T) {
+	str := "hello world"
+	s1 := strings.NewReader(str + " 1")
+	s2 := strings.NewReader(str + " 2")
+	s3 := strings.NewReader(str + " 3")
+	mr := MultiReadSeeker(s1, s2, s3)
+
+	expected := "hello world 1hello world 2hello world 3"
+	b, err := ioutil.ReadAll(mr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected {
+		t.Fatalf("ReadAll failed, got: %q, expected %q", string(b), expected)
+	}
+
+	if _, err := mr.Seek(0, os.SEEK_SET); err != nil {
+		t.Fatal(err)
+	}
+	b, err = ioutil.ReadAll(mr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected {
+		t.Fatalf("ReadAll after seek failed, got: %q, expected %q", string(b), expected)
+	}
+}
+
+func TestMultiReadSeekerSeek(t *testing.T) {
+	s1 := strings.NewReader("abc")
+	s2 := strings.NewReader("defg")
+	s3 := strings.NewReader("hi")
+	mr := MultiReadSeeker(s1, s2, s3)
+
+	pos, err := mr.Seek(-4, os.SEEK_END)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 5 {
+		t.Fatalf("expected position 5, got %d", pos)
+	}
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(mr, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "fg" {
+		t.Fatalf("expected %q, got %q", "fg", buf)
+	}
+
+	pos, err = mr.Seek(-4, os.SEEK_CUR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 3 {
+		t.Fatalf("expected position 3, got %d", pos)
+	}
+	rest, err := ioutil.ReadAll(mr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rest, []byte("defghi")) {
+		t.Fatalf("expected %q, got %q", "defghi", rest)
+	}
+
+	if _, err := mr.Seek(-1, os.SEEK_SET); err == nil {
+		t.Fatal("expected error seeking to a negative position")
+	}
+}
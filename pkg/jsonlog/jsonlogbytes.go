@@ -2,6 +2,7 @@ package jsonlog
 
 import (
 	"bytes"
+	"sort"
 	"unicode/utf8"
 )
 
@@ -9,9 +10,10 @@ import (
 // It allows marshalling JSONLog from Log as []byte
 // and an already marshalled Created timestamp.
 type JSONLogBytes struct {
-	Log     []byte `json:"log,omitempty"`
-	Stream  string `json:"stream,omitempty"`
-	Created string `json:"time"`
+	Log     []byte            `json:"log,omitempty"`
+	Stream  string            `json:"stream,omitempty"`
+	Created string            `json:"time"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
 }
 
 // MarshalJSONBuf is based on the same method from JSONLog
@@ -45,6 +47,23 @@ func (mj *JSONLogBytes) MarshalJSONBuf(buf *bytes.Buffer) error {
 	}
 	buf.WriteString(`"time":`)
 	buf.WriteString(mj.Created)
+	if len(mj.Attrs) > 0 {
+		buf.WriteString(`,"attrs":{`)
+		keys := make([]string, 0, len(mj.Attrs))
+		for k := range mj.Attrs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteString(`,`)
+			}
+			ffjson_WriteJsonString(buf, k)
+			buf.WriteString(`:`)
+			ffjson_WriteJsonString(buf, mj.Attrs[k])
+		}
+		buf.WriteString(`}`)
+	}
 	buf.WriteString(`}`)
 	return nil
 }
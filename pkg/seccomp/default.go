@@ -0,0 +1,42 @@
+package seccomp
+
+// DefaultProfile allows all the system calls but those which administer the
+// host, load code into the kernel, or inspect and change other processes.
+// Most of them already need a capability which containers don't have by
+// default; the profile keeps them out of reach of the kernel for processes
+// which have the capability, and closes the ones which don't need any.
+var DefaultProfile = &Profile{
+	DefaultAction: ActAllow,
+	Syscalls: deny(
+		// Kernel keyring, not namespaced.
+		"add_key", "keyctl", "request_key",
+		// Tracing and inspection of other processes.
+		"kcmp", "process_vm_readv", "process_vm_writev", "ptrace",
+		"perf_event_open", "lookup_dcookie",
+		// Mount table and namespaces, set up by Docker.
+		"mount", "umount2", "pivot_root", "setns", "unshare",
+		"name_to_handle_at", "open_by_handle_at",
+		// Kernel modules and code loaded into the kernel.
+		"create_module", "delete_module", "finit_module", "init_module",
+		"query_module", "get_kernel_syms", "kexec_file_load", "kexec_load",
+		"bpf", "uselib",
+		// Host administration.
+		"acct", "quotactl", "reboot", "swapoff", "swapon", "_sysctl",
+		"sysfs", "ustat", "nfsservctl", "iopl", "ioperm", "vhangup",
+		// Clocks, not namespaced.
+		"clock_adjtime", "clock_settime", "settimeofday",
+		// NUMA memory policies of the host.
+		"get_mempolicy", "mbind", "migrate_pages", "move_pages",
+		"set_mempolicy",
+		// Recent system calls with a history of vulnerabilities.
+		"userfaultfd",
+	),
+}
+
+func deny(names ...string) []*Syscall {
+	syscalls := make([]*Syscall, 0, len(names))
+	for _, name := range names {
+		syscalls = append(syscalls, &Syscall{Name: name, Action: ActErrno})
+	}
+	return syscalls
+}
@@ -0,0 +1,110 @@
+// Package seccomp loads and installs the seccomp profiles of containers,
+// which filter the system calls made by their processes.
+package seccomp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Action is what happens when a process makes a system call.
+type Action string
+
+const (
+	// ActAllow lets the system call run.
+	ActAllow Action = "allow"
+	// ActErrno fails the system call with EPERM.
+	ActErrno Action = "errno"
+	// ActKill kills the process.
+	ActKill Action = "kill"
+)
+
+// Syscall is the action taken for the system call Name.
+type Syscall struct {
+	Name   string `json:"name"`
+	Action Action `json:"action"`
+}
+
+// Profile lists the system calls for which an action other than
+// DefaultAction is taken.
+type Profile struct {
+	DefaultAction Action     `json:"defaultAction"`
+	Syscalls      []*Syscall `json:"syscalls"`
+}
+
+// ErrNotSupported is returned for profiles on the architectures for which
+// the system calls aren't known.
+var ErrNotSupported = errors.New("seccomp profiles are not supported on this architecture")
+
+// requiredSyscalls are made to start the process of a container once its
+// profile is installed, and must be allowed by all profiles.
+var requiredSyscalls = []string{"capset", "execve"}
+
+// Supported returns whether profiles can be installed on this architecture.
+func Supported() bool {
+	return syscalls != nil
+}
+
+// LoadProfile decodes the JSON profile data, and checks it.
+func LoadProfile(data []byte) (*Profile, error) {
+	var p Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("Invalid seccomp profile: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks that the actions and system calls of the profile are known,
+// and that it allows the system calls needed to start a container.
+func (p *Profile) Validate() error {
+	if !Supported() {
+		return ErrNotSupported
+	}
+	if !p.DefaultAction.valid() {
+		return fmt.Errorf("Invalid seccomp profile: unknown default action %q", p.DefaultAction)
+	}
+	seen := make(map[string]bool)
+	for _, s := range p.Syscalls {
+		if s == nil {
+			return fmt.Errorf("Invalid seccomp profile: empty system call")
+		}
+		if _, ok := syscalls[s.Name]; !ok {
+			return fmt.Errorf("Invalid seccomp profile: unknown system call %q", s.Name)
+		}
+		if !s.Action.valid() {
+			return fmt.Errorf("Invalid seccomp profile: unknown action %q for %s", s.Action, s.Name)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("Invalid seccomp profile: %s is listed more than once", s.Name)
+		}
+		seen[s.Name] = true
+	}
+	for _, name := range requiredSyscalls {
+		if p.action(name) != ActAllow {
+			return fmt.Errorf("Invalid seccomp profile: %s must be allowed to start the container", name)
+		}
+	}
+	return nil
+}
+
+// action returns the action of the profile for the system call name.
+func (p *Profile) action(name string) Action {
+	for _, s := range p.Syscalls {
+		if s.Name == name {
+			return s.Action
+		}
+	}
+	return p.DefaultAction
+}
+
+func (a Action) valid() bool {
+	switch a {
+	case ActAllow, ActErrno, ActKill:
+		return true
+	}
+	return false
+}
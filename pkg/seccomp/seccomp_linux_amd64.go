@@ -0,0 +1,114 @@
+package seccomp
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const (
+	seccompModeFilter = 2
+
+	seccompRetKill  = 0x00000000
+	seccompRetErrno = 0x00050000
+	seccompRetAllow = 0x7fff0000
+
+	// x32SyscallBit is set in the numbers of the system calls of the x32
+	// ABI, which are also available to amd64 processes.
+	x32SyscallBit = 0x40000000
+
+	// Offsets of the fields of struct seccomp_data.
+	dataNrOffset   = 0
+	dataArchOffset = 4
+)
+
+// Install filters the system calls of the calling thread, and of the
+// processes it executes, with the profile. The filter can't be removed. The
+// thread must either have CAP_SYS_ADMIN, or have set no_new_privs.
+func (p *Profile) Install() error {
+	filter, err := p.compile()
+	if err != nil {
+		return err
+	}
+	prog := syscall.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_SECCOMP, seccompModeFilter, uintptr(unsafe.Pointer(&prog))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// compile returns the BPF program of the profile. The amd64 and i386 system
+// calls are checked by separate rules, since their numbers differ. It fails
+// the calls of the x32 ABI, which would otherwise bypass the rules, and kills
+// the processes making calls for another architecture.
+func (p *Profile) compile() ([]syscall.SockFilter, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	amd64 := []syscall.SockFilter{
+		load(dataNrOffset),
+		{Code: syscall.BPF_JMP | syscall.BPF_JGE | syscall.BPF_K, Jt: 0, Jf: 1, K: x32SyscallBit},
+		ret(ActErrno),
+	}
+	i386 := []syscall.SockFilter{
+		load(dataNrOffset),
+	}
+	for _, s := range p.Syscalls {
+		if s.Action == p.DefaultAction {
+			continue
+		}
+		amd64 = append(amd64, rule(syscalls[s.Name], s.Action)...)
+		if nr, ok := syscallsI386[s.Name]; ok {
+			i386 = append(i386, rule(nr, s.Action)...)
+		}
+		for _, alias := range aliasesI386[s.Name] {
+			i386 = append(i386, rule(syscallsI386[alias], s.Action)...)
+		}
+	}
+	amd64 = append(amd64, ret(p.DefaultAction))
+	i386 = append(i386, ret(p.DefaultAction))
+
+	// The conditional jumps have 8-bit offsets, the amd64 rules are skipped
+	// with an unconditional one.
+	filter := []syscall.SockFilter{
+		load(dataArchOffset),
+		jumpIfEqual(auditArch, 1, 0),
+		{Code: syscall.BPF_JMP | syscall.BPF_JA, K: uint32(len(amd64))},
+	}
+	filter = append(filter, amd64...)
+	filter = append(filter,
+		jumpIfEqual(auditArchI386, 1, 0),
+		ret(ActKill),
+	)
+	return append(filter, i386...), nil
+}
+
+// rule returns the instructions taking action for the system call nr.
+func rule(nr uint32, a Action) []syscall.SockFilter {
+	return []syscall.SockFilter{
+		jumpIfEqual(nr, 0, 1),
+		ret(a),
+	}
+}
+
+func load(offset uint32) syscall.SockFilter {
+	return syscall.SockFilter{Code: syscall.BPF_LD | syscall.BPF_W | syscall.BPF_ABS, K: offset}
+}
+
+func jumpIfEqual(value uint32, jt, jf uint8) syscall.SockFilter {
+	return syscall.SockFilter{Code: syscall.BPF_JMP | syscall.BPF_JEQ | syscall.BPF_K, Jt: jt, Jf: jf, K: value}
+}
+
+func ret(a Action) syscall.SockFilter {
+	k := uint32(seccompRetKill)
+	switch a {
+	case ActAllow:
+		k = seccompRetAllow
+	case ActErrno:
+		k = seccompRetErrno | uint32(syscall.EPERM)
+	}
+	return syscall.SockFilter{Code: syscall.BPF_RET | syscall.BPF_K, K: k}
+}
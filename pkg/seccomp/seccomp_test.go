@@ -0,0 +1,138 @@
+// +build linux,amd64
+
+package seccomp
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	p, err := LoadProfile([]byte(`{"defaultAction": "errno", "syscalls": [{"name": "execve", "action": "allow"}, {"name": "capset", "action": "allow"}, {"name": "keyctl", "action": "kill"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.DefaultAction != ActErrno || len(p.Syscalls) != 3 {
+		t.Fatalf("Unexpected profile %+v", p)
+	}
+	if p.action("keyctl") != ActKill || p.action("read") != ActErrno {
+		t.Fatalf("Unexpected actions %s and %s", p.action("keyctl"), p.action("read"))
+	}
+}
+
+func TestLoadProfileInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{"defaultAction": "allow"`,
+		`{"defaultAction": "deny"}`,
+		`{"defaultAction": "allow", "syscalls": [{"name": "nosuchcall", "action": "errno"}]}`,
+		`{"defaultAction": "allow", "syscalls": [{"name": "keyctl", "action": "trap"}]}`,
+		`{"defaultAction": "allow", "syscalls": [{"name": "keyctl", "action": "errno"}, {"name": "keyctl", "action": "allow"}]}`,
+		`{"defaultAction": "allow", "syscalls": [{"name": "execve", "action": "errno"}]}`,
+		`{"defaultAction": "errno", "syscalls": [{"name": "execve", "action": "allow"}]}`,
+		`{"defaultAction": "allow", "syscalls": [null]}`,
+	} {
+		if _, err := LoadProfile([]byte(data)); err == nil {
+			t.Fatalf("Expected an error loading %s", data)
+		}
+	}
+}
+
+func TestDefaultProfile(t *testing.T) {
+	if err := DefaultProfile.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"keyctl", "ptrace", "mount"} {
+		if a := DefaultProfile.action(name); a != ActErrno {
+			t.Fatalf("Expected %s to be denied, got %s", name, a)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	p := &Profile{
+		DefaultAction: ActAllow,
+		Syscalls: []*Syscall{
+			{Name: "keyctl", Action: ActErrno},
+			{Name: "read", Action: ActAllow},
+			{Name: "ptrace", Action: ActKill},
+		},
+	}
+	filter, err := p.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The amd64 check and the jump over the amd64 rules, the load of the
+	// system call and the x32 check, 2 instructions per rule but for read,
+	// which is allowed by default, and the default action.
+	if f := filter[2]; f.Code != syscall.BPF_JMP|syscall.BPF_JA || f.K != 3+4+1 {
+		t.Fatalf("Unexpected jump over the amd64 rules %+v", f)
+	}
+	if f := filter[6]; f.K != syscalls["keyctl"] || f.Jt != 0 || f.Jf != 1 {
+		t.Fatalf("Unexpected check of keyctl %+v", f)
+	}
+	if f := filter[7]; f.K != seccompRetErrno|uint32(syscall.EPERM) {
+		t.Fatalf("Unexpected action for keyctl %+v", f)
+	}
+	if f := filter[9]; f.K != seccompRetKill {
+		t.Fatalf("Unexpected action for ptrace %+v", f)
+	}
+	if f := filter[10]; f.K != seccompRetAllow {
+		t.Fatalf("Unexpected default action %+v", f)
+	}
+	// The i386 check, the other architectures are killed, then the load of
+	// the system call and the same rules with the i386 numbers.
+	if f := filter[11]; f.K != auditArchI386 || f.Jt != 1 || f.Jf != 0 {
+		t.Fatalf("Unexpected check of i386 %+v", f)
+	}
+	if f := filter[12]; f.K != seccompRetKill {
+		t.Fatalf("Unexpected action for other architectures %+v", f)
+	}
+	if len(filter) != 14+4+1 {
+		t.Fatalf("Unexpected filter length %d", len(filter))
+	}
+	if f := filter[14]; f.K != syscallsI386["keyctl"] {
+		t.Fatalf("Unexpected check of keyctl on i386 %+v", f)
+	}
+	if f := filter[16]; f.K != syscallsI386["ptrace"] {
+		t.Fatalf("Unexpected check of ptrace on i386 %+v", f)
+	}
+	if f := filter[18]; f.K != seccompRetAllow {
+		t.Fatalf("Unexpected default action on i386 %+v", f)
+	}
+}
+
+func TestCompileI386Aliases(t *testing.T) {
+	p := &Profile{
+		DefaultAction: ActAllow,
+		Syscalls:      []*Syscall{{Name: "umount2", Action: ActErrno}},
+	}
+	filter, err := p.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The i386 rules follow the amd64 ones, the i386 check and the load of
+	// the system call.
+	i386 := filter[3+int(filter[2].K)+2+1:]
+	if len(i386) != 2+2+1 {
+		t.Fatalf("Unexpected number of i386 instructions %d", len(i386))
+	}
+	if i386[0].K != syscallsI386["umount2"] || i386[2].K != syscallsI386["umount"] {
+		t.Fatalf("Unexpected i386 rules %+v", i386)
+	}
+}
+
+func TestAliasesI386(t *testing.T) {
+	for name, aliases := range aliasesI386 {
+		if _, ok := syscalls[name]; !ok {
+			t.Fatalf("Unknown system call %s", name)
+		}
+		for _, alias := range aliases {
+			if _, ok := syscallsI386[alias]; !ok {
+				t.Fatalf("Unknown i386 system call %s", alias)
+			}
+			if _, ok := syscalls[alias]; ok {
+				t.Fatalf("%s is an amd64 system call, it can't be an alias of %s", alias, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// +build !linux !amd64
+
+package seccomp
+
+// syscalls isn't known on this architecture.
+var syscalls map[string]uint32
+
+// Install returns ErrNotSupported.
+func (p *Profile) Install() error {
+	return ErrNotSupported
+}
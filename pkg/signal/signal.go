@@ -1,8 +1,12 @@
 package signal
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 )
 
 func CatchAll(sigc chan os.Signal) {
@@ -17,3 +21,19 @@ func StopCatch(sigc chan os.Signal) {
 	signal.Stop(sigc)
 	close(sigc)
 }
+
+// ParseSignal translates a signal given by number, or by name with or
+// without the SIG prefix, to a syscall signal.
+func ParseSignal(rawSignal string) (syscall.Signal, error) {
+	if s, err := strconv.Atoi(rawSignal); err == nil {
+		if s <= 0 {
+			return -1, fmt.Errorf("Invalid signal: %s", rawSignal)
+		}
+		return syscall.Signal(s), nil
+	}
+	s, ok := SignalMap[strings.TrimPrefix(strings.ToUpper(rawSignal), "SIG")]
+	if !ok {
+		return -1, fmt.Errorf("Invalid signal: %s", rawSignal)
+	}
+	return s, nil
+}
@@ -0,0 +1,33 @@
+package signal
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	for raw, expected := range map[string]syscall.Signal{
+		"SIGQUIT": syscall.SIGQUIT,
+		"QUIT":    syscall.SIGQUIT,
+		"sigint":  syscall.SIGINT,
+		"15":      syscall.SIGTERM,
+	} {
+		s, err := ParseSignal(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != expected {
+			t.Fatalf("Expected %v for %s, got %v", expected, raw, s)
+		}
+	}
+
+	for _, raw := range []string{"", "0", "-1", "SIGFOO"} {
+		if _, err := ParseSignal(raw); err == nil {
+			t.Fatalf("Expected an error parsing %q", raw)
+		}
+	}
+
+	if _, err := ParseSignal(DefaultStopSignal); err != nil {
+		t.Fatal(err)
+	}
+}
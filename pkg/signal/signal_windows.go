@@ -10,3 +10,7 @@ import (
 // invalid signals so they don't get handled)
 const SIGCHLD = syscall.Signal(0xff)
 const SIGWINCH = syscall.Signal(0xff)
+
+// DefaultStopSignal is the signal sent to stop a container, unless it was
+// given another one.
+const DefaultStopSignal = "15"
@@ -9,5 +9,11 @@ type SysInfo struct {
 	CpuCfsQuota            bool
 	IPv4ForwardingDisabled bool
 	AppArmor               bool
+	Seccomp                bool
 	OomKillDisable         bool
+	PidsLimit              bool
+	BlkioReadBpsDevice     bool
+	BlkioWriteBpsDevice    bool
+	BlkioReadIOpsDevice    bool
+	BlkioWriteIOpsDevice   bool
 }
@@ -6,11 +6,15 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/libcontainer/cgroups"
 )
 
+// seccompModeFilter is SECCOMP_MODE_FILTER in <linux/seccomp.h>.
+const seccompModeFilter = 2
+
 // New returns a new SysInfo, using the filesystem to detect which features the kernel supports.
 func New(quiet bool) *SysInfo {
 	sysInfo := &SysInfo{}
@@ -52,6 +56,42 @@ func New(quiet bool) *SysInfo {
 		}
 	}
 
+	if cgroupBlkioMountpoint, err := cgroups.FindCgroupMountpoint("blkio"); err != nil {
+		if !quiet {
+			logrus.Warnf("%v", err)
+		}
+	} else {
+		_, err := ioutil.ReadFile(path.Join(cgroupBlkioMountpoint, "blkio.throttle.read_bps_device"))
+		sysInfo.BlkioReadBpsDevice = err == nil
+		if !sysInfo.BlkioReadBpsDevice && !quiet {
+			logrus.Warn("Your kernel does not support cgroup blkio throttle.read_bps_device")
+		}
+		_, err = ioutil.ReadFile(path.Join(cgroupBlkioMountpoint, "blkio.throttle.write_bps_device"))
+		sysInfo.BlkioWriteBpsDevice = err == nil
+		if !sysInfo.BlkioWriteBpsDevice && !quiet {
+			logrus.Warn("Your kernel does not support cgroup blkio throttle.write_bps_device")
+		}
+		_, err = ioutil.ReadFile(path.Join(cgroupBlkioMountpoint, "blkio.throttle.read_iops_device"))
+		sysInfo.BlkioReadIOpsDevice = err == nil
+		if !sysInfo.BlkioReadIOpsDevice && !quiet {
+			logrus.Warn("Your kernel does not support cgroup blkio throttle.read_iops_device")
+		}
+		_, err = ioutil.ReadFile(path.Join(cgroupBlkioMountpoint, "blkio.throttle.write_iops_device"))
+		sysInfo.BlkioWriteIOpsDevice = err == nil
+		if !sysInfo.BlkioWriteIOpsDevice && !quiet {
+			logrus.Warn("Your kernel does not support cgroup blkio throttle.write_iops_device")
+		}
+	}
+
+	// Check if the pids cgroup, limiting the number of processes, is mounted.
+	if _, err := cgroups.FindCgroupMountpoint("pids"); err != nil {
+		if !quiet {
+			logrus.Warn("Your kernel does not support cgroup pids limit")
+		}
+	} else {
+		sysInfo.PidsLimit = true
+	}
+
 	// Checek if ipv4_forward is disabled.
 	if data, err := ioutil.ReadFile("/proc/sys/net/ipv4/ip_forward"); os.IsNotExist(err) {
 		sysInfo.IPv4ForwardingDisabled = true
@@ -70,6 +110,14 @@ func New(quiet bool) *SysInfo {
 		sysInfo.AppArmor = true
 	}
 
+	// Check if seccomp filters are supported, setting one with an invalid
+	// pointer fails with EFAULT rather than EINVAL.
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_GET_SECCOMP, 0, 0); errno != syscall.EINVAL {
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_SECCOMP, seccompModeFilter, 0); errno != syscall.EINVAL {
+			sysInfo.Seccomp = true
+		}
+	}
+
 	// Check if Devices cgroup is mounted, it is hard requirement for container security.
 	if _, err := cgroups.FindCgroupMountpoint("devices"); err != nil {
 		logrus.Fatalf("Error mounting devices cgroup: %v", err)
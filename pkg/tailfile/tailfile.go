@@ -3,6 +3,7 @@ package tailfile
 import (
 	"bytes"
 	"errors"
+	"io"
 	"os"
 )
 
@@ -11,8 +12,8 @@ const blockSize = 1024
 var eol = []byte("\n")
 var ErrNonPositiveLinesNumber = errors.New("Lines number must be positive")
 
-//TailFile returns last n lines of file f
-func TailFile(f *os.File, n int) ([][]byte, error) {
+//TailFile returns last n lines of reader f (could be a file).
+func TailFile(f io.ReadSeeker, n int) ([][]byte, error) {
 	if n <= 0 {
 		return nil, ErrNonPositiveLinesNumber
 	}
@@ -32,7 +33,7 @@ func TailFile(f *os.File, n int) ([][]byte, error) {
 				return nil, err
 			}
 			b = make([]byte, blockSize+left)
-			if _, err := f.Read(b); err != nil {
+			if _, err := io.ReadFull(f, b); err != nil {
 				return nil, err
 			}
 			data = append(b, data...)
@@ -42,7 +43,7 @@ func TailFile(f *os.File, n int) ([][]byte, error) {
 			if _, err := f.Seek(step, os.SEEK_END); err != nil {
 				return nil, err
 			}
-			if _, err := f.Read(b); err != nil {
+			if _, err := io.ReadFull(f, b); err != nil {
 				return nil, err
 			}
 			data = append(b, data...)
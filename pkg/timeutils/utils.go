@@ -34,3 +34,34 @@ func GetTimestamp(value string, reference time.Time) string {
 	}
 	return strconv.FormatInt(t.Unix(), 10)
 }
+
+// ParseTimestamps parses a Unix timestamp with an optional fractional part,
+// like "1136073600" or "1136073600.000000001", and returns its seconds and
+// nanoseconds, to be given to time.Unix. A fractional part with less or more
+// than 9 digits is converted to nanoseconds. It returns def as the seconds
+// if value is empty.
+func ParseTimestamps(value string, def int64) (int64, int64, error) {
+	if value == "" {
+		return def, 0, nil
+	}
+	sa := strings.SplitN(value, ".", 2)
+	s, err := strconv.ParseInt(sa[0], 10, 64)
+	if err != nil {
+		return s, 0, err
+	}
+	if len(sa) != 2 {
+		return s, 0, nil
+	}
+	digits := sa[1]
+	if len(digits) > 9 {
+		digits = digits[:9]
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return s, 0, err
+	}
+	for i := len(digits); i < 9; i++ {
+		n *= 10
+	}
+	return s, n, nil
+}
@@ -90,7 +90,9 @@ func NewServiceConfig(options *Options) *ServiceConfig {
 	//
 	// TODO: should we deprecate this once it is easier for people to set up a TLS registry or change
 	// daemon flags on boot2docker?
-	options.InsecureRegistries.Set("127.0.0.0/8")
+	if !options.InsecureRegistries.Get("127.0.0.0/8") {
+		options.InsecureRegistries.Set("127.0.0.0/8")
+	}
 
 	config := &ServiceConfig{
 		InsecureRegistryCIDRs: make([]*netIPNet, 0),
@@ -2,22 +2,40 @@ package registry
 
 import (
 	"net/http"
+	"sync"
 
 	"github.com/docker/docker/cliconfig"
 )
 
 type Service struct {
-	Config *ServiceConfig
+	mu     sync.RWMutex
+	config *ServiceConfig
 }
 
 // NewService returns a new instance of Service ready to be
 // installed no an engine.
 func NewService(options *Options) *Service {
 	return &Service{
-		Config: NewServiceConfig(options),
+		config: NewServiceConfig(options),
 	}
 }
 
+// Config returns the configuration of the registries.
+func (s *Service) Config() *ServiceConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// Reload replaces the configuration of the registries, the mirrors and the
+// insecure registries, with the one of options.
+func (s *Service) Reload(options *Options) {
+	config := NewServiceConfig(options)
+	s.mu.Lock()
+	s.config = config
+	s.mu.Unlock()
+}
+
 // Auth contacts the public registry with the provided credentials,
 // and returns OK if authentication was sucessful.
 // It can be used to verify the validity of a client's credentials.
@@ -62,10 +80,10 @@ func (s *Service) Search(term string, authConfig *cliconfig.AuthConfig, headers
 // ResolveRepository splits a repository name into its components
 // and configuration of the associated registry.
 func (s *Service) ResolveRepository(name string) (*RepositoryInfo, error) {
-	return s.Config.NewRepositoryInfo(name)
+	return s.Config().NewRepositoryInfo(name)
 }
 
 // ResolveIndex takes indexName and returns index info
 func (s *Service) ResolveIndex(name string) (*IndexInfo, error) {
-	return s.Config.NewIndexInfo(name)
+	return s.Config().NewIndexInfo(name)
 }
@@ -0,0 +1,37 @@
+package runconfig
+
+import (
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+// CheckpointConfig holds the options of the checkpoint of a container. The
+// options allowing resources to be dumped are also needed to restore them,
+// they are saved with the container.
+type CheckpointConfig struct {
+	LeaveRunning            bool // Leave the container running once checkpointed
+	TcpEstablished          bool // Checkpoint established TCP connections
+	ExternalUnixConnections bool // Checkpoint connections to external unix sockets
+	ShellJob                bool // Checkpoint processes of a shell job
+}
+
+// ParseCheckpoint parses the flags of `docker checkpoint`. It returns the
+// options of the checkpoint of the containers given as arguments.
+func ParseCheckpoint(cmd *flag.FlagSet, args []string) (*CheckpointConfig, error) {
+	var (
+		flLeaveRunning = cmd.Bool([]string{"-leave-running"}, false, "Leave the container running after checkpoint")
+		flTcp          = cmd.Bool([]string{"-allow-tcp"}, false, "Allow checkpointing established TCP connections")
+		flExtUnix      = cmd.Bool([]string{"-allow-ext-unix"}, false, "Allow checkpointing external unix connections")
+		flShell        = cmd.Bool([]string{"-allow-shell"}, false, "Allow checkpointing shell jobs")
+	)
+	cmd.Require(flag.Min, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return nil, err
+	}
+
+	return &CheckpointConfig{
+		LeaveRunning:            *flLeaveRunning,
+		TcpEstablished:          *flTcp,
+		ExternalUnixConnections: *flExtUnix,
+		ShellJob:                *flShell,
+	}, nil
+}
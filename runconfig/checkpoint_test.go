@@ -0,0 +1,37 @@
+package runconfig
+
+import (
+	"io/ioutil"
+	"testing"
+
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+func parseCheckpoint(args []string) (*CheckpointConfig, error) {
+	cmd := flag.NewFlagSet("checkpoint", flag.ContinueOnError)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.Usage = nil
+	return ParseCheckpoint(cmd, args)
+}
+
+func TestParseCheckpoint(t *testing.T) {
+	config, err := parseCheckpoint([]string{"container"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *config != (CheckpointConfig{}) {
+		t.Fatalf("Unexpected default options: %#v", config)
+	}
+
+	config, err = parseCheckpoint([]string{"--leave-running", "--allow-tcp", "container"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.LeaveRunning || !config.TcpEstablished || config.ExternalUnixConnections || config.ShellJob {
+		t.Fatalf("Unexpected options: %#v", config)
+	}
+
+	if _, err := parseCheckpoint([]string{"--allow-udp", "container"}); err == nil {
+		t.Fatal("Expected an error for an unknown option")
+	}
+}
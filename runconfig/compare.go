@@ -10,6 +10,7 @@ func Compare(a, b *Config) bool {
 	if a.AttachStdout != b.AttachStdout ||
 		a.AttachStderr != b.AttachStderr ||
 		a.User != b.User ||
+		a.StopSignal != b.StopSignal ||
 		a.OpenStdin != b.OpenStdin ||
 		a.Tty != b.Tty {
 		return false
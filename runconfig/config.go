@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/nat"
 )
@@ -103,6 +104,22 @@ func NewCommand(parts ...string) *Command {
 	return &Command{parts}
 }
 
+// HealthConfig holds the configuration of the periodic health check of a
+// container.
+type HealthConfig struct {
+	// Test is the check to run inside the container. An empty slice
+	// inherits the check of the image.
+	//   {"NONE"}: disables the health check
+	//   {"CMD", args...}: executes the arguments directly
+	//   {"CMD-SHELL", command}: runs command with /bin/sh -c
+	Test []string `json:",omitempty"`
+
+	// Zero means to inherit, or use the daemon default.
+	Interval time.Duration `json:",omitempty"` // Time to wait between checks
+	Timeout  time.Duration `json:",omitempty"` // Time to wait before considering a check as hung
+	Retries  int           `json:",omitempty"` // Consecutive failures needed to report unhealthy
+}
+
 // Note: the Config structure should hold only portable information about the container.
 // Here, "portable" means "independent from the host we are running on".
 // Non-portable information *should* appear in HostConfig.
@@ -128,6 +145,8 @@ type Config struct {
 	MacAddress      string
 	OnBuild         []string
 	Labels          map[string]string
+	Healthcheck     *HealthConfig
+	StopSignal      string // Signal to stop the container, SIGTERM if empty
 }
 
 type ContainerConfigWrapper struct {
@@ -211,6 +211,7 @@ func TestMerge(t *testing.T) {
 		ExposedPorts: portsImage,
 		Env:          []string{"VAR1=1", "VAR2=2"},
 		Volumes:      volumesImage,
+		StopSignal:   "SIGQUIT",
 	}
 
 	portsUser := make(nat.PortSet)
@@ -254,6 +255,10 @@ func TestMerge(t *testing.T) {
 		}
 	}
 
+	if configUser.StopSignal != "SIGQUIT" {
+		t.Fatalf("Expected the stop signal of the image, found %s", configUser.StopSignal)
+	}
+
 	ports, _, err := nat.ParsePortSpecs([]string{"0000"})
 	if err != nil {
 		t.Error(err)
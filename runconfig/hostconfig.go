@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/docker/docker/nat"
+	"github.com/docker/docker/pkg/blkiodev"
 	"github.com/docker/docker/pkg/ulimit"
 )
 
@@ -38,6 +39,12 @@ func (n NetworkMode) IsNone() bool {
 	return n == "none"
 }
 
+// IsUserDefined indicates whether the container uses a network created
+// with `docker network create` instead of one of the builtin modes
+func (n NetworkMode) IsUserDefined() bool {
+	return n != "" && !n.IsBridge() && !n.IsHost() && !n.IsNone() && !n.IsContainer()
+}
+
 type IpcMode string
 
 // IsPrivate indicates whether container use it's private ipc stack
@@ -118,6 +125,30 @@ func (n PidMode) Valid() bool {
 	return true
 }
 
+// UsernsMode represents the user namespace of a container.
+type UsernsMode string
+
+// IsHost indicates whether the container uses the user namespace of the
+// host, rather than the remapped one of the daemon.
+func (n UsernsMode) IsHost() bool {
+	return n == "host"
+}
+
+// IsPrivate indicates whether the container uses the remapped user
+// namespace of the daemon, when the daemon remaps the ids of the containers.
+func (n UsernsMode) IsPrivate() bool {
+	return !(n.IsHost())
+}
+
+func (n UsernsMode) Valid() bool {
+	switch n {
+	case "", "host":
+	default:
+		return false
+	}
+	return true
+}
+
 type DeviceMapping struct {
 	PathOnHost        string
 	PathInContainer   string
@@ -196,39 +227,47 @@ func NewLxcConfig(values []KeyValuePair) *LxcConfig {
 }
 
 type HostConfig struct {
-	Binds           []string
-	ContainerIDFile string
-	LxcConf         *LxcConfig
-	Memory          int64 // Memory limit (in bytes)
-	MemorySwap      int64 // Total memory usage (memory + swap); set `-1` to disable swap
-	CpuShares       int64 // CPU shares (relative weight vs. other containers)
-	CpuPeriod       int64
-	CpusetCpus      string // CpusetCpus 0-2, 0,1
-	CpusetMems      string // CpusetMems 0-2, 0,1
-	CpuQuota        int64
-	BlkioWeight     int64 // Block IO weight (relative weight vs. other containers)
-	OomKillDisable  bool  // Whether to disable OOM Killer or not
-	Privileged      bool
-	PortBindings    nat.PortMap
-	Links           []string
-	PublishAllPorts bool
-	Dns             []string
-	DnsSearch       []string
-	ExtraHosts      []string
-	VolumesFrom     []string
-	Devices         []DeviceMapping
-	NetworkMode     NetworkMode
-	IpcMode         IpcMode
-	PidMode         PidMode
-	UTSMode         UTSMode
-	CapAdd          []string
-	CapDrop         []string
-	RestartPolicy   RestartPolicy
-	SecurityOpt     []string
-	ReadonlyRootfs  bool
-	Ulimits         []*ulimit.Ulimit
-	LogConfig       LogConfig
-	CgroupParent    string // Parent cgroup.
+	Binds                []string
+	ContainerIDFile      string
+	LxcConf              *LxcConfig
+	Memory               int64 // Memory limit (in bytes)
+	MemorySwap           int64 // Total memory usage (memory + swap); set `-1` to disable swap
+	CpuShares            int64 // CPU shares (relative weight vs. other containers)
+	CpuPeriod            int64
+	CpusetCpus           string // CpusetCpus 0-2, 0,1
+	CpusetMems           string // CpusetMems 0-2, 0,1
+	CpuQuota             int64
+	BlkioWeight          int64                      // Block IO weight (relative weight vs. other containers)
+	BlkioDeviceReadBps   []*blkiodev.ThrottleDevice // Limit read rate (bytes per second) from a device
+	BlkioDeviceWriteBps  []*blkiodev.ThrottleDevice // Limit write rate (bytes per second) to a device
+	BlkioDeviceReadIOps  []*blkiodev.ThrottleDevice // Limit read rate (IO per second) from a device
+	BlkioDeviceWriteIOps []*blkiodev.ThrottleDevice // Limit write rate (IO per second) to a device
+	OomKillDisable       bool                       // Whether to disable OOM Killer or not
+	PidsLimit            int64                      // Maximum number of processes, 0 for no limit
+	Privileged           bool
+	PortBindings         nat.PortMap
+	Links                []string
+	PublishAllPorts      bool
+	Dns                  []string
+	DnsSearch            []string
+	ExtraHosts           []string
+	VolumesFrom          []string
+	Devices              []DeviceMapping
+	NetworkMode          NetworkMode
+	Networks             []string // User-defined networks to connect to, on top of NetworkMode
+	NetworkAliases       []string // Names resolving to the container on its user-defined networks
+	IpcMode              IpcMode
+	PidMode              PidMode
+	UTSMode              UTSMode
+	UsernsMode           UsernsMode
+	CapAdd               []string
+	CapDrop              []string
+	RestartPolicy        RestartPolicy
+	SecurityOpt          []string
+	ReadonlyRootfs       bool
+	Ulimits              []*ulimit.Ulimit
+	LogConfig            LogConfig
+	CgroupParent         string // Parent cgroup.
 }
 
 func MergeConfigs(config *Config, hostConfig *HostConfig) *ContainerConfigWrapper {
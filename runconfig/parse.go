@@ -2,13 +2,16 @@ package runconfig
 
 import (
 	"fmt"
+	"io/ioutil"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/pkg/units"
 )
@@ -20,6 +23,8 @@ var (
 	ErrConflictHostNetworkAndLinks      = fmt.Errorf("Conflicting options: --net=host can't be used with links. This would result in undefined behavior")
 	ErrConflictContainerNetworkAndMac   = fmt.Errorf("Conflicting options: --mac-address and the network mode (--net)")
 	ErrConflictNetworkHosts             = fmt.Errorf("Conflicting options: --add-host and the network mode (--net)")
+	ErrConflictMultipleNetworks         = fmt.Errorf("Conflicting options: only user-defined networks can be given more than once with --net, after the network mode")
+	ErrConflictNetworkAliases           = fmt.Errorf("Conflicting options: --net-alias and the network mode (--net)")
 )
 
 func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSet, error) {
@@ -35,6 +40,11 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		ulimits   = make(map[string]*ulimit.Ulimit)
 		flUlimits = opts.NewUlimitOpt(ulimits)
 
+		flDeviceReadBps   = opts.NewThrottledeviceOpt(opts.ValidateThrottleBpsDevice)
+		flDeviceWriteBps  = opts.NewThrottledeviceOpt(opts.ValidateThrottleBpsDevice)
+		flDeviceReadIOps  = opts.NewThrottledeviceOpt(opts.ValidateThrottleIOpsDevice)
+		flDeviceWriteIOps = opts.NewThrottledeviceOpt(opts.ValidateThrottleIOpsDevice)
+
 		flPublish     = opts.NewListOpts(nil)
 		flExpose      = opts.NewListOpts(nil)
 		flDns         = opts.NewListOpts(opts.ValidateIPAddress)
@@ -48,11 +58,14 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flSecurityOpt = opts.NewListOpts(nil)
 		flLabelsFile  = opts.NewListOpts(nil)
 		flLoggingOpts = opts.NewListOpts(nil)
+		flNetModes    = opts.NewListOpts(nil)
+		flNetAliases  = opts.NewListOpts(nil)
 
 		flNetwork         = cmd.Bool([]string{"#n", "#-networking"}, true, "Enable networking for this container")
 		flPrivileged      = cmd.Bool([]string{"#privileged", "-privileged"}, false, "Give extended privileges to this container")
 		flPidMode         = cmd.String([]string{"-pid"}, "", "PID namespace to use")
 		flUTSMode         = cmd.String([]string{"-uts"}, "", "UTS namespace to use")
+		flUsernsMode      = cmd.String([]string{"-userns"}, "", "User namespace to use")
 		flPublishAll      = cmd.Bool([]string{"P", "-publish-all"}, false, "Publish all exposed ports to random ports")
 		flStdin           = cmd.Bool([]string{"i", "-interactive"}, false, "Keep STDIN open even if not attached")
 		flTty             = cmd.Bool([]string{"t", "-tty"}, false, "Allocate a pseudo-TTY")
@@ -70,13 +83,19 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flCpusetMems      = cmd.String([]string{"-cpuset-mems"}, "", "MEMs in which to allow execution (0-3, 0,1)")
 		flCpuQuota        = cmd.Int64([]string{"-cpu-quota"}, 0, "Limit the CPU CFS quota")
 		flBlkioWeight     = cmd.Int64([]string{"-blkio-weight"}, 0, "Block IO (relative weight), between 10 and 1000")
-		flNetMode         = cmd.String([]string{"-net"}, "bridge", "Set the Network mode for the container")
+		flPidsLimit       = cmd.Int64([]string{"-pids-limit"}, 0, "Tune container pids limit (0 for unlimited)")
 		flMacAddress      = cmd.String([]string{"-mac-address"}, "", "Container MAC address (e.g. 92:d0:c6:0a:29:33)")
 		flIpcMode         = cmd.String([]string{"-ipc"}, "", "IPC namespace to use")
 		flRestartPolicy   = cmd.String([]string{"-restart"}, "no", "Restart policy to apply when a container exits")
 		flReadonlyRootfs  = cmd.Bool([]string{"-read-only"}, false, "Mount the container's root filesystem as read only")
 		flLoggingDriver   = cmd.String([]string{"-log-driver"}, "", "Logging driver for container")
 		flCgroupParent    = cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
+		flHealthCmd       = cmd.String([]string{"-health-cmd"}, "", "Command to run to check health")
+		flHealthInterval  = cmd.Duration([]string{"-health-interval"}, 0, "Time between running the check")
+		flHealthTimeout   = cmd.Duration([]string{"-health-timeout"}, 0, "Maximum time to allow one check to run")
+		flHealthRetries   = cmd.Int([]string{"-health-retries"}, 0, "Consecutive failures needed to report unhealthy")
+		flNoHealthcheck   = cmd.Bool([]string{"-no-healthcheck"}, false, "Disable any container-specified HEALTHCHECK")
+		flStopSignal      = cmd.String([]string{"-stop-signal"}, "", fmt.Sprintf("Signal to stop a container, %s by default", signal.DefaultStopSignal))
 	)
 
 	cmd.Var(&flAttach, []string{"a", "-attach"}, "Attach to STDIN, STDOUT or STDERR")
@@ -98,7 +117,13 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	cmd.Var(&flCapDrop, []string{"-cap-drop"}, "Drop Linux capabilities")
 	cmd.Var(&flSecurityOpt, []string{"-security-opt"}, "Security Options")
 	cmd.Var(flUlimits, []string{"-ulimit"}, "Ulimit options")
+	cmd.Var(flDeviceReadBps, []string{"-device-read-bps"}, "Limit read rate (bytes per second) from a device")
+	cmd.Var(flDeviceWriteBps, []string{"-device-write-bps"}, "Limit write rate (bytes per second) to a device")
+	cmd.Var(flDeviceReadIOps, []string{"-device-read-iops"}, "Limit read rate (IO per second) from a device")
+	cmd.Var(flDeviceWriteIOps, []string{"-device-write-iops"}, "Limit write rate (IO per second) to a device")
 	cmd.Var(&flLoggingOpts, []string{"-log-opt"}, "Log driver options")
+	cmd.Var(&flNetModes, []string{"-net"}, "Set the Network mode for the container, repeat to connect to more user-defined networks")
+	cmd.Var(&flNetAliases, []string{"-net-alias"}, "Add a name resolving to the container on its user-defined networks")
 
 	expFlags := attachExperimentalFlags(cmd)
 
@@ -114,9 +139,9 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		attachStderr = flAttach.Get("stderr")
 	)
 
-	netMode, err := parseNetMode(*flNetMode)
+	netMode, networks, err := parseNetModes(flNetModes.GetAll())
 	if err != nil {
-		return nil, nil, cmd, fmt.Errorf("--net: invalid net mode: %v", err)
+		return nil, nil, cmd, err
 	}
 
 	if (netMode.IsHost() || netMode.IsContainer()) && *flHostname != "" {
@@ -139,6 +164,10 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		return nil, nil, cmd, ErrConflictNetworkHosts
 	}
 
+	if (netMode.IsContainer() || netMode.IsHost()) && flNetAliases.Len() > 0 {
+		return nil, nil, cmd, ErrConflictNetworkAliases
+	}
+
 	if (netMode.IsContainer() || netMode.IsHost()) && *flMacAddress != "" {
 		return nil, nil, cmd, ErrConflictContainerNetworkAndMac
 	}
@@ -289,6 +318,11 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		return nil, nil, cmd, fmt.Errorf("--uts: invalid UTS mode")
 	}
 
+	usernsMode := UsernsMode(*flUsernsMode)
+	if !usernsMode.Valid() {
+		return nil, nil, cmd, fmt.Errorf("--userns: invalid USER mode")
+	}
+
 	restartPolicy, err := ParseRestartPolicy(*flRestartPolicy)
 	if err != nil {
 		return nil, nil, cmd, err
@@ -299,6 +333,22 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		return nil, nil, cmd, err
 	}
 
+	securityOpts, err := parseSecurityOpts(flSecurityOpt.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
+	healthConfig, err := parseHealthConfig(*flHealthCmd, *flHealthInterval, *flHealthTimeout, *flHealthRetries, *flNoHealthcheck)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
+	if *flStopSignal != "" {
+		if _, err := signal.ParseSignal(*flStopSignal); err != nil {
+			return nil, nil, cmd, err
+		}
+	}
+
 	config := &Config{
 		Hostname:        hostname,
 		Domainname:      domainname,
@@ -317,43 +367,53 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		MacAddress:      *flMacAddress,
 		Entrypoint:      entrypoint,
 		WorkingDir:      *flWorkingDir,
-		Labels:          convertKVStringsToMap(labels),
+		Labels:          ConvertKVStringsToMap(labels),
+		Healthcheck:     healthConfig,
+		StopSignal:      *flStopSignal,
 	}
 
 	hostConfig := &HostConfig{
-		Binds:           binds,
-		ContainerIDFile: *flContainerIDFile,
-		LxcConf:         lxcConf,
-		Memory:          flMemory,
-		MemorySwap:      MemorySwap,
-		CpuShares:       *flCpuShares,
-		CpuPeriod:       *flCpuPeriod,
-		CpusetCpus:      *flCpusetCpus,
-		CpusetMems:      *flCpusetMems,
-		CpuQuota:        *flCpuQuota,
-		BlkioWeight:     *flBlkioWeight,
-		OomKillDisable:  *flOomKillDisable,
-		Privileged:      *flPrivileged,
-		PortBindings:    portBindings,
-		Links:           flLinks.GetAll(),
-		PublishAllPorts: *flPublishAll,
-		Dns:             flDns.GetAll(),
-		DnsSearch:       flDnsSearch.GetAll(),
-		ExtraHosts:      flExtraHosts.GetAll(),
-		VolumesFrom:     flVolumesFrom.GetAll(),
-		NetworkMode:     netMode,
-		IpcMode:         ipcMode,
-		PidMode:         pidMode,
-		UTSMode:         utsMode,
-		Devices:         deviceMappings,
-		CapAdd:          flCapAdd.GetAll(),
-		CapDrop:         flCapDrop.GetAll(),
-		RestartPolicy:   restartPolicy,
-		SecurityOpt:     flSecurityOpt.GetAll(),
-		ReadonlyRootfs:  *flReadonlyRootfs,
-		Ulimits:         flUlimits.GetList(),
-		LogConfig:       LogConfig{Type: *flLoggingDriver, Config: loggingOpts},
-		CgroupParent:    *flCgroupParent,
+		Binds:                binds,
+		ContainerIDFile:      *flContainerIDFile,
+		LxcConf:              lxcConf,
+		Memory:               flMemory,
+		MemorySwap:           MemorySwap,
+		CpuShares:            *flCpuShares,
+		CpuPeriod:            *flCpuPeriod,
+		CpusetCpus:           *flCpusetCpus,
+		CpusetMems:           *flCpusetMems,
+		CpuQuota:             *flCpuQuota,
+		BlkioWeight:          *flBlkioWeight,
+		BlkioDeviceReadBps:   flDeviceReadBps.GetList(),
+		BlkioDeviceWriteBps:  flDeviceWriteBps.GetList(),
+		BlkioDeviceReadIOps:  flDeviceReadIOps.GetList(),
+		BlkioDeviceWriteIOps: flDeviceWriteIOps.GetList(),
+		OomKillDisable:       *flOomKillDisable,
+		PidsLimit:            *flPidsLimit,
+		Privileged:           *flPrivileged,
+		PortBindings:         portBindings,
+		Links:                flLinks.GetAll(),
+		PublishAllPorts:      *flPublishAll,
+		Dns:                  flDns.GetAll(),
+		DnsSearch:            flDnsSearch.GetAll(),
+		ExtraHosts:           flExtraHosts.GetAll(),
+		VolumesFrom:          flVolumesFrom.GetAll(),
+		NetworkMode:          netMode,
+		Networks:             networks,
+		NetworkAliases:       flNetAliases.GetAll(),
+		IpcMode:              ipcMode,
+		PidMode:              pidMode,
+		UTSMode:              utsMode,
+		UsernsMode:           usernsMode,
+		Devices:              deviceMappings,
+		CapAdd:               flCapAdd.GetAll(),
+		CapDrop:              flCapDrop.GetAll(),
+		RestartPolicy:        restartPolicy,
+		SecurityOpt:          securityOpts,
+		ReadonlyRootfs:       *flReadonlyRootfs,
+		Ulimits:              flUlimits.GetList(),
+		LogConfig:            LogConfig{Type: *flLoggingDriver, Config: loggingOpts},
+		CgroupParent:         *flCgroupParent,
 	}
 
 	applyExperimentalFlags(expFlags, config, hostConfig)
@@ -381,8 +441,8 @@ func readKVStrings(files []string, override []string) ([]string, error) {
 	return envVariables, nil
 }
 
-// converts ["key=value"] to {"key":"value"}
-func convertKVStringsToMap(values []string) map[string]string {
+// ConvertKVStringsToMap converts ["key=value"] to {"key":"value"}
+func ConvertKVStringsToMap(values []string) map[string]string {
 	result := make(map[string]string, len(values))
 	for _, value := range values {
 		kv := strings.SplitN(value, "=", 2)
@@ -397,7 +457,7 @@ func convertKVStringsToMap(values []string) map[string]string {
 }
 
 func parseLoggingOpts(loggingDriver string, loggingOpts []string) (map[string]string, error) {
-	loggingOptsMap := convertKVStringsToMap(loggingOpts)
+	loggingOptsMap := ConvertKVStringsToMap(loggingOpts)
 	if loggingDriver == "none" && len(loggingOpts) > 0 {
 		return map[string]string{}, fmt.Errorf("Invalid logging opts for driver %s", loggingDriver)
 	}
@@ -405,6 +465,60 @@ func parseLoggingOpts(loggingDriver string, loggingOpts []string) (map[string]st
 	return loggingOptsMap, nil
 }
 
+// parseSecurityOpts replaces the path of the seccomp profile, if any, with
+// the content of the file, which the daemon may not be able to read.
+func parseSecurityOpts(securityOpts []string) ([]string, error) {
+	for i, opt := range securityOpts {
+		if !strings.HasPrefix(opt, "seccomp=") && !strings.HasPrefix(opt, "seccomp:") {
+			continue
+		}
+		path := opt[len("seccomp="):]
+		if path == "unconfined" {
+			continue
+		}
+		profile, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Opening seccomp profile (%s) failed: %v", path, err)
+		}
+		securityOpts[i] = opt[:len("seccomp=")] + string(profile)
+	}
+	return securityOpts, nil
+}
+
+// parseHealthConfig builds the health check configuration from the
+// --health-* and --no-healthcheck flags. It returns nil if none were set.
+func parseHealthConfig(healthCmd string, interval, timeout time.Duration, retries int, disable bool) (*HealthConfig, error) {
+	haveOpts := healthCmd != "" || interval != 0 || timeout != 0 || retries != 0
+	if disable {
+		if haveOpts {
+			return nil, fmt.Errorf("--no-healthcheck conflicts with --health-* options")
+		}
+		return &HealthConfig{Test: []string{"NONE"}}, nil
+	}
+	if !haveOpts {
+		return nil, nil
+	}
+	if interval < 0 {
+		return nil, fmt.Errorf("--health-interval cannot be negative")
+	}
+	if timeout < 0 {
+		return nil, fmt.Errorf("--health-timeout cannot be negative")
+	}
+	if retries < 0 {
+		return nil, fmt.Errorf("--health-retries cannot be negative")
+	}
+	var test []string
+	if healthCmd != "" {
+		test = []string{"CMD-SHELL", healthCmd}
+	}
+	return &HealthConfig{
+		Test:     test,
+		Interval: interval,
+		Timeout:  timeout,
+		Retries:  retries,
+	}, nil
+}
+
 // ParseRestartPolicy returns the parsed policy or an error indicating what is incorrect
 func ParseRestartPolicy(policy string) (RestartPolicy, error) {
 	p := RestartPolicy{}
@@ -473,6 +587,33 @@ func parseKeyValueOpts(opts opts.ListOpts) ([]KeyValuePair, error) {
 	return out, nil
 }
 
+// parseNetModes splits the values given to --net into the network mode of
+// the container, which is the first one, and the additional user-defined
+// networks to connect the container to.
+func parseNetModes(values []string) (NetworkMode, []string, error) {
+	if len(values) == 0 {
+		return NetworkMode("bridge"), nil, nil
+	}
+
+	netMode, err := parseNetMode(values[0])
+	if err != nil {
+		return "", nil, fmt.Errorf("--net: invalid net mode: %v", err)
+	}
+
+	var networks []string
+	for _, v := range values[1:] {
+		n, err := parseNetMode(v)
+		if err != nil {
+			return "", nil, fmt.Errorf("--net: invalid net mode: %v", err)
+		}
+		if !n.IsUserDefined() || netMode.IsHost() || netMode.IsContainer() || n == netMode {
+			return "", nil, ErrConflictMultipleNetworks
+		}
+		networks = append(networks, v)
+	}
+	return netMode, networks, nil
+}
+
 func parseNetMode(netMode string) (NetworkMode, error) {
 	parts := strings.Split(netMode, ":")
 	switch mode := parts[0]; mode {
@@ -481,8 +622,14 @@ func parseNetMode(netMode string) (NetworkMode, error) {
 		if len(parts) < 2 || parts[1] == "" {
 			return "", fmt.Errorf("invalid container format container:<name|id>")
 		}
-	default:
+	case "":
 		return "", fmt.Errorf("invalid --net: %s", netMode)
+	default:
+		// Anything else is the name of a user-defined network, the daemon
+		// checks that it exists.
+		if len(parts) > 1 {
+			return "", fmt.Errorf("invalid --net: %s", netMode)
+		}
 	}
 	return NetworkMode(netMode), nil
 }
@@ -2,7 +2,9 @@ package runconfig
 
 import (
 	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
@@ -63,3 +65,213 @@ func TestConflictContainerNetworkAndLinks(t *testing.T) {
 		t.Fatalf("Expected error ErrConflictContainerNetworkAndLinks, got: %s", err)
 	}
 }
+
+func TestParseNetworks(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.NetworkMode != "bridge" || len(hostConfig.Networks) != 0 {
+		t.Fatalf("Expected the bridge network mode only, got %s %v", hostConfig.NetworkMode, hostConfig.Networks)
+	}
+
+	_, hostConfig, _, err = parseRun([]string{"--net=front", "--net=back", "--net=db", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.NetworkMode != "front" || !hostConfig.NetworkMode.IsUserDefined() {
+		t.Fatalf("Expected the front user-defined network mode, got %s", hostConfig.NetworkMode)
+	}
+	if len(hostConfig.Networks) != 2 || hostConfig.Networks[0] != "back" || hostConfig.Networks[1] != "db" {
+		t.Fatalf("Expected the back and db networks, got %v", hostConfig.Networks)
+	}
+
+	if _, hostConfig, _, err = parseRun([]string{"--net=bridge", "--net=back", "img", "cmd"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"--net=host", "--net=back", "img", "cmd"},
+		{"--net=container:other", "--net=back", "img", "cmd"},
+		{"--net=back", "--net=none", "img", "cmd"},
+		{"--net=back", "--net=back", "img", "cmd"},
+	} {
+		if _, _, _, err := parseRun(args); err != ErrConflictMultipleNetworks {
+			t.Fatalf("Expected error ErrConflictMultipleNetworks for %v, got: %v", args, err)
+		}
+	}
+
+	if _, _, _, err := parseRun([]string{"--net=back:front", "img", "cmd"}); err == nil {
+		t.Fatal("Expected error for an invalid network name")
+	}
+
+	_, hostConfig, _, err = parseRun([]string{"--net=back", "--net-alias=db", "--net-alias=cache", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.NetworkAliases) != 2 || hostConfig.NetworkAliases[0] != "db" || hostConfig.NetworkAliases[1] != "cache" {
+		t.Fatalf("Expected the db and cache aliases, got %v", hostConfig.NetworkAliases)
+	}
+	if _, _, _, err := parseRun([]string{"--net=host", "--net-alias=db", "img", "cmd"}); err != ErrConflictNetworkAliases {
+		t.Fatalf("Expected error ErrConflictNetworkAliases, got: %v", err)
+	}
+}
+
+func TestParseHealth(t *testing.T) {
+	checkOk := func(args ...string) *HealthConfig {
+		config, _, _, err := parseRun(args)
+		if err != nil {
+			t.Fatalf("%#v: %v", args, err)
+		}
+		return config.Healthcheck
+	}
+	checkError := func(expected string, args ...string) {
+		config, _, _, err := parseRun(args)
+		if err == nil {
+			t.Fatalf("Expected error, but got %#v", config)
+		}
+		if err.Error() != expected {
+			t.Fatalf("Expected %#v, got %#v", expected, err)
+		}
+	}
+
+	if health := checkOk("img", "cmd"); health != nil {
+		t.Fatalf("Unexpected health check config: %#v", health)
+	}
+	health := checkOk("--no-healthcheck", "img", "cmd")
+	if health == nil || len(health.Test) != 1 || health.Test[0] != "NONE" {
+		t.Fatalf("--no-healthcheck failed: %#v", health)
+	}
+
+	health = checkOk("--health-cmd=/check.sh -q", "img", "cmd")
+	if len(health.Test) != 2 || health.Test[0] != "CMD-SHELL" || health.Test[1] != "/check.sh -q" {
+		t.Fatalf("--health-cmd: got %#v", health.Test)
+	}
+	if health.Timeout != 0 {
+		t.Fatalf("--health-cmd: timeout = %s", health.Timeout)
+	}
+
+	checkError("--no-healthcheck conflicts with --health-* options",
+		"--no-healthcheck", "--health-cmd=/check.sh -q", "img", "cmd")
+
+	health = checkOk("--health-timeout=2s", "--health-retries=3", "--health-interval=4.5s", "img", "cmd")
+	if health.Timeout != 2*time.Second || health.Retries != 3 || health.Interval != 4500*time.Millisecond {
+		t.Fatalf("--health-*: got %#v", health)
+	}
+
+	checkError("--health-retries cannot be negative", "--health-retries=-1", "img", "cmd")
+}
+
+func TestParseStopSignal(t *testing.T) {
+	config, _, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.StopSignal != "" {
+		t.Fatalf("Expected no stop signal by default, got %s", config.StopSignal)
+	}
+
+	config, _, _, err = parseRun([]string{"--stop-signal=SIGQUIT", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.StopSignal != "SIGQUIT" {
+		t.Fatalf("Expected SIGQUIT, got %s", config.StopSignal)
+	}
+
+	if _, _, _, err := parseRun([]string{"--stop-signal=SIGFOO", "img", "cmd"}); err == nil || err.Error() != "Invalid signal: SIGFOO" {
+		t.Fatalf("Expected an invalid signal error, got %v", err)
+	}
+}
+
+func TestParseUsernsMode(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--userns=host", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hostConfig.UsernsMode.IsHost() {
+		t.Fatalf("Expected the user namespace of the host, got %q", hostConfig.UsernsMode)
+	}
+
+	if _, _, _, err := parseRun([]string{"--userns=container:other", "img", "cmd"}); err == nil || err.Error() != "--userns: invalid USER mode" {
+		t.Fatalf("Expected an invalid user namespace error, got %v", err)
+	}
+}
+
+func TestParseSeccompProfile(t *testing.T) {
+	f, err := ioutil.TempFile("", "seccomp-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	profile := `{"defaultAction": "allow"}`
+	if _, err := f.WriteString(profile); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	_, hostConfig, _, err := parseRun([]string{"--security-opt", "seccomp=" + f.Name(), "--security-opt", "label:disable", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.SecurityOpt) != 2 || hostConfig.SecurityOpt[0] != "seccomp="+profile || hostConfig.SecurityOpt[1] != "label:disable" {
+		t.Fatalf("Expected the content of the seccomp profile, got %v", hostConfig.SecurityOpt)
+	}
+
+	if _, hostConfig, _, err = parseRun([]string{"--security-opt", "seccomp=unconfined", "img", "cmd"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.SecurityOpt) != 1 || hostConfig.SecurityOpt[0] != "seccomp=unconfined" {
+		t.Fatalf("Expected seccomp=unconfined, got %v", hostConfig.SecurityOpt)
+	}
+
+	if _, _, _, err := parseRun([]string{"--security-opt", "seccomp=/nonexistent/profile.json", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a missing seccomp profile")
+	}
+}
+
+func TestParsePidsLimit(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.PidsLimit != 0 {
+		t.Fatalf("Expected no pids limit by default, got %d", hostConfig.PidsLimit)
+	}
+
+	if _, hostConfig, _, err = parseRun([]string{"--pids-limit=100", "img", "cmd"}); err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.PidsLimit != 100 {
+		t.Fatalf("Expected a pids limit of 100, got %d", hostConfig.PidsLimit)
+	}
+
+	if _, _, _, err := parseRun([]string{"--pids-limit=many", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for an invalid pids limit")
+	}
+}
+
+func TestParseBlkioThrottleDevices(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--device-read-bps=/dev/sda:1mb", "--device-read-bps=/dev/sdb:1024", "--device-write-bps=/dev/sda:2kb", "--device-read-iops=/dev/sda:100", "--device-write-iops=/dev/sda:200", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.BlkioDeviceReadBps) != 2 || hostConfig.BlkioDeviceReadBps[0].String() != "/dev/sda:1048576" || hostConfig.BlkioDeviceReadBps[1].String() != "/dev/sdb:1024" {
+		t.Fatalf("Unexpected read bps limits %v", hostConfig.BlkioDeviceReadBps)
+	}
+	if len(hostConfig.BlkioDeviceWriteBps) != 1 || hostConfig.BlkioDeviceWriteBps[0].String() != "/dev/sda:2048" {
+		t.Fatalf("Unexpected write bps limits %v", hostConfig.BlkioDeviceWriteBps)
+	}
+	if len(hostConfig.BlkioDeviceReadIOps) != 1 || hostConfig.BlkioDeviceReadIOps[0].String() != "/dev/sda:100" {
+		t.Fatalf("Unexpected read iops limits %v", hostConfig.BlkioDeviceReadIOps)
+	}
+	if len(hostConfig.BlkioDeviceWriteIOps) != 1 || hostConfig.BlkioDeviceWriteIOps[0].String() != "/dev/sda:200" {
+		t.Fatalf("Unexpected write iops limits %v", hostConfig.BlkioDeviceWriteIOps)
+	}
+
+	for _, arg := range []string{"--device-read-bps=/dev/sda", "--device-write-bps=sda:1mb", "--device-read-iops=/dev/sda:1kb", "--device-write-iops=/dev/sda:-1"} {
+		if _, _, _, err := parseRun([]string{arg, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for %s", arg)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package runconfig
+
+import (
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/units"
+)
+
+// UpdateConfig holds the resource limits of a container which can be changed
+// while it is running. Zero values leave the current limits unchanged.
+type UpdateConfig struct {
+	Memory      int64  // Memory limit (in bytes)
+	CpuShares   int64  // CPU shares (relative weight vs. other containers)
+	CpuQuota    int64  // CPU CFS quota
+	CpusetCpus  string // CpusetCpus 0-2, 0,1
+	BlkioWeight int64  // Block IO weight (relative weight vs. other containers)
+}
+
+// Apply sets the limits of the update on the given host config.
+func (u *UpdateConfig) Apply(hostConfig *HostConfig) {
+	if u.Memory != 0 {
+		hostConfig.Memory = u.Memory
+	}
+	if u.CpuShares != 0 {
+		hostConfig.CpuShares = u.CpuShares
+	}
+	if u.CpuQuota != 0 {
+		hostConfig.CpuQuota = u.CpuQuota
+	}
+	if u.CpusetCpus != "" {
+		hostConfig.CpusetCpus = u.CpusetCpus
+	}
+	if u.BlkioWeight != 0 {
+		hostConfig.BlkioWeight = u.BlkioWeight
+	}
+}
+
+// ParseUpdate parses the flags of `docker update`. It returns the update to
+// apply to the containers given as arguments.
+func ParseUpdate(cmd *flag.FlagSet, args []string) (*UpdateConfig, error) {
+	var (
+		flMemoryString = cmd.String([]string{"m", "-memory"}, "", "Memory limit")
+		flCpuShares    = cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
+		flCpusetCpus   = cmd.String([]string{"-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
+		flCpuQuota     = cmd.Int64([]string{"-cpu-quota"}, 0, "Limit the CPU CFS quota")
+		flBlkioWeight  = cmd.Int64([]string{"-blkio-weight"}, 0, "Block IO (relative weight), between 10 and 1000")
+	)
+	cmd.Require(flag.Min, 1)
+	if err := cmd.ParseFlags(args, true); err != nil {
+		return nil, err
+	}
+
+	var flMemory int64
+	if *flMemoryString != "" {
+		parsedMemory, err := units.RAMInBytes(*flMemoryString)
+		if err != nil {
+			return nil, err
+		}
+		flMemory = parsedMemory
+	}
+
+	return &UpdateConfig{
+		Memory:      flMemory,
+		CpuShares:   *flCpuShares,
+		CpuQuota:    *flCpuQuota,
+		CpusetCpus:  *flCpusetCpus,
+		BlkioWeight: *flBlkioWeight,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package runconfig
+
+import (
+	"io/ioutil"
+	"testing"
+
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+func parseUpdate(args []string) (*UpdateConfig, error) {
+	cmd := flag.NewFlagSet("update", flag.ContinueOnError)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.Usage = nil
+	return ParseUpdate(cmd, args)
+}
+
+func TestParseUpdate(t *testing.T) {
+	update, err := parseUpdate([]string{"-m", "512m", "--cpu-shares=512", "--cpuset-cpus=0,1", "container"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.Memory != 512*1024*1024 || update.CpuShares != 512 || update.CpusetCpus != "0,1" {
+		t.Fatalf("Unexpected update: %#v", update)
+	}
+	if update.CpuQuota != 0 || update.BlkioWeight != 0 {
+		t.Fatalf("Expected the other limits to be left unchanged, got %#v", update)
+	}
+
+	if _, err := parseUpdate([]string{"-m", "lots", "container"}); err == nil {
+		t.Fatal("Expected an error for an invalid memory limit")
+	}
+}
+
+func TestUpdateApply(t *testing.T) {
+	hostConfig := &HostConfig{
+		Memory:      64 * 1024 * 1024,
+		CpuShares:   1024,
+		CpusetCpus:  "0",
+		BlkioWeight: 500,
+	}
+	update := &UpdateConfig{
+		CpuShares:  512,
+		CpusetCpus: "1",
+	}
+	update.Apply(hostConfig)
+
+	if hostConfig.CpuShares != 512 || hostConfig.CpusetCpus != "1" {
+		t.Fatalf("Expected the limits to be updated, got %#v", hostConfig)
+	}
+	if hostConfig.Memory != 64*1024*1024 || hostConfig.BlkioWeight != 500 {
+		t.Fatalf("Expected the unset limits to be kept, got %#v", hostConfig)
+	}
+}
@@ -9,6 +9,11 @@ type volumeDriverRequest struct {
 	Name string
 }
 
+type volumeDriverCreateRequest struct {
+	Name string
+	Opts map[string]string `json:",omitempty"`
+}
+
 type volumeDriverResponse struct {
 	Mountpoint string `json:",omitempty"`
 	Err        string `json:",omitempty"`
@@ -18,8 +23,8 @@ type volumeDriverProxy struct {
 	c client
 }
 
-func (pp *volumeDriverProxy) Create(name string) error {
-	args := volumeDriverRequest{name}
+func (pp *volumeDriverProxy) Create(name string, opts map[string]string) error {
+	args := volumeDriverCreateRequest{name, opts}
 	var ret volumeDriverResponse
 	err := pp.c.Call("VolumeDriver.Create", args, &ret)
 	if err != nil {
@@ -1,6 +1,7 @@
 package volumedrivers
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -45,7 +46,7 @@ func TestVolumeRequestError(t *testing.T) {
 	client := plugins.NewClient("tcp://" + u.Host)
 	driver := volumeDriverProxy{client}
 
-	err := driver.Create("volume")
+	err := driver.Create("volume", nil)
 	if err == nil {
 		t.Fatal("Expected error, was nil")
 	}
@@ -90,3 +91,29 @@ func TestVolumeRequestError(t *testing.T) {
 		t.Fatalf("Unexpected error: %v\n", err)
 	}
 }
+
+func TestVolumeCreateOptions(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var req volumeDriverCreateRequest
+	mux.HandleFunc("/VolumeDriver.Create", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		fmt.Fprintln(w, `{}`)
+	})
+
+	u, _ := url.Parse(server.URL)
+	client := plugins.NewClient("tcp://" + u.Host)
+	driver := volumeDriverProxy{client}
+
+	if err := driver.Create("volume", map[string]string{"size": "10G"}); err != nil {
+		t.Fatal(err)
+	}
+	if req.Name != "volume" || req.Opts["size"] != "10G" {
+		t.Fatalf("Unexpected request: %+v", req)
+	}
+}
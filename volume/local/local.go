@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/volume"
 )
 
@@ -22,10 +23,13 @@ const (
 
 var oldVfsDir = filepath.Join("vfs", "dir")
 
-func New(scope string) (*Root, error) {
+// New creates the local volume driver storing its volumes under scope. The
+// volumes are created owned by rootUID and rootGID, the ids of root in the
+// user namespace of the containers.
+func New(scope string, rootUID, rootGID int) (*Root, error) {
 	rootDirectory := filepath.Join(scope, volumesPathName)
 
-	if err := os.MkdirAll(rootDirectory, 0700); err != nil {
+	if err := idtools.MkdirAllAs(rootDirectory, 0700, rootUID, rootGID); err != nil {
 		return nil, err
 	}
 
@@ -33,6 +37,8 @@ func New(scope string) (*Root, error) {
 		scope:   scope,
 		path:    rootDirectory,
 		volumes: make(map[string]*Volume),
+		rootUID: rootUID,
+		rootGID: rootGID,
 	}
 
 	dirs, err := ioutil.ReadDir(rootDirectory)
@@ -56,6 +62,8 @@ type Root struct {
 	scope   string
 	path    string
 	volumes map[string]*Volume
+	rootUID int
+	rootGID int
 }
 
 func (r *Root) DataPath(volumeName string) string {
@@ -66,14 +74,20 @@ func (r *Root) Name() string {
 	return "local"
 }
 
-func (r *Root) Create(name string) (volume.Volume, error) {
+// Create creates a volume named name in the docker root, or returns the
+// existing one. The local driver does not take any option.
+func (r *Root) Create(name string, opts map[string]string) (volume.Volume, error) {
+	if len(opts) != 0 {
+		return nil, fmt.Errorf("The local volume driver does not support options")
+	}
+
 	r.m.Lock()
 	defer r.m.Unlock()
 
 	v, exists := r.volumes[name]
 	if !exists {
 		path := r.DataPath(name)
-		if err := os.MkdirAll(path, 0755); err != nil {
+		if err := idtools.MkdirAllAs(path, 0755, r.rootUID, r.rootGID); err != nil {
 			if os.IsExist(err) {
 				return nil, fmt.Errorf("volume already exists under %s", filepath.Dir(path))
 			}
@@ -86,10 +100,20 @@ func (r *Root) Create(name string) (volume.Volume, error) {
 		}
 		r.volumes[name] = v
 	}
-	v.use()
 	return v, nil
 }
 
+// List returns all the volumes created by the local driver.
+func (r *Root) List() []volume.Volume {
+	r.m.Lock()
+	defer r.m.Unlock()
+	var ls []volume.Volume
+	for _, v := range r.volumes {
+		ls = append(ls, v)
+	}
+	return ls
+}
+
 func (r *Root) Remove(v volume.Volume) error {
 	r.m.Lock()
 	defer r.m.Unlock()
@@ -97,24 +121,20 @@ func (r *Root) Remove(v volume.Volume) error {
 	if !ok {
 		return errors.New("unknown volume type")
 	}
-	lv.release()
-	if lv.usedCount == 0 {
-		realPath, err := filepath.EvalSymlinks(lv.path)
-		if err != nil {
-			return err
-		}
-		if !r.scopedPath(realPath) {
-			return fmt.Errorf("Unable to remove a directory of out the Docker root: %s", realPath)
-		}
-
-		if err := os.RemoveAll(realPath); err != nil {
-			return err
-		}
+	realPath, err := filepath.EvalSymlinks(lv.path)
+	if err != nil {
+		return err
+	}
+	if !r.scopedPath(realPath) {
+		return fmt.Errorf("Unable to remove a directory of out the Docker root: %s", realPath)
+	}
 
-		delete(r.volumes, lv.name)
-		return os.RemoveAll(filepath.Dir(lv.path))
+	if err := os.RemoveAll(realPath); err != nil {
+		return err
 	}
-	return nil
+
+	delete(r.volumes, lv.name)
+	return os.RemoveAll(filepath.Dir(lv.path))
 }
 
 // scopedPath verifies that the path where the volume is located
@@ -134,8 +154,6 @@ func (r *Root) scopedPath(realPath string) bool {
 }
 
 type Volume struct {
-	m         sync.Mutex
-	usedCount int
 	// unique name of the volume
 	name string
 	// path is the path on the host where the data lives
@@ -163,15 +181,3 @@ func (v *Volume) Mount() (string, error) {
 func (v *Volume) Unmount() error {
 	return nil
 }
-
-func (v *Volume) use() {
-	v.m.Lock()
-	v.usedCount++
-	v.m.Unlock()
-}
-
-func (v *Volume) release() {
-	v.m.Lock()
-	v.usedCount--
-	v.m.Unlock()
-}
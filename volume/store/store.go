@@ -0,0 +1,334 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/volume"
+)
+
+var (
+	// ErrVolumeInUse is returned when trying to remove a volume that is
+	// still referenced by a container.
+	ErrVolumeInUse = errors.New("volume is in use")
+	// ErrNoSuchVolume is returned when the requested volume is not in the store.
+	ErrNoSuchVolume = errors.New("no such volume")
+)
+
+// DriverLookup resolves a volume driver name into a volume.Driver.
+type DriverLookup func(name string) (volume.Driver, error)
+
+type volumeRefs struct {
+	volume.Volume
+	refs map[string]struct{}
+}
+
+// volumeMetadata is what the store persists about a volume, on top of what
+// its driver keeps.
+type volumeMetadata struct {
+	Name    string
+	Driver  string
+	Labels  map[string]string `json:",omitempty"`
+	Options map[string]string `json:",omitempty"`
+}
+
+// VolumeStore keeps track of all the volumes known by the daemon, and of
+// which containers reference them, regardless of the driver that created them.
+type VolumeStore struct {
+	mu      sync.Mutex
+	vols    map[string]*volumeRefs
+	meta    map[string]*volumeMetadata
+	root    string
+	drivers DriverLookup
+}
+
+// New initializes a VolumeStore. Drivers are resolved through lookup when
+// volumes are created or removed. The driver, labels and driver options of
+// the volumes created through the store are saved in root, and loaded back
+// from it. If root is empty,
+// nothing is persisted.
+func New(root string, lookup DriverLookup) (*VolumeStore, error) {
+	s := &VolumeStore{
+		vols:    make(map[string]*volumeRefs),
+		meta:    make(map[string]*volumeMetadata),
+		root:    root,
+		drivers: lookup,
+	}
+	if root == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(root, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var m volumeMetadata
+		if err := json.Unmarshal(data, &m); err != nil {
+			logrus.Errorf("Ignoring invalid volume metadata %s: %v", f.Name(), err)
+			continue
+		}
+		s.meta[m.Name] = &m
+	}
+	return s, nil
+}
+
+// Add registers an existing volume in the store without referencing it.
+// It is used to load the volumes that already exist when the daemon starts.
+func (s *VolumeStore) Add(v volume.Volume) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.vols[v.Name()]; !exists {
+		s.vols[v.Name()] = &volumeRefs{Volume: v, refs: make(map[string]struct{})}
+	}
+}
+
+// Create returns the volume with the given name, creating it with the given
+// driver, driver options and labels if it does not exist yet. Creating an
+// existing volume with other driver options or labels fails.
+func (s *VolumeStore) Create(name, driverName string, opts, labels map[string]string) (volume.Volume, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vc, err := s.create(name, driverName, opts, labels)
+	if err != nil {
+		return nil, err
+	}
+	return vc.Volume, nil
+}
+
+// CreateWithRef is like Create, and also records ref, usually a container
+// ID, as a user of the volume. Adding the same ref twice has no effect.
+func (s *VolumeStore) CreateWithRef(name, driverName, ref string) (volume.Volume, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vc, err := s.create(name, driverName, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	vc.refs[ref] = struct{}{}
+	return vc.Volume, nil
+}
+
+func (s *VolumeStore) create(name, driverName string, opts, labels map[string]string) (*volumeRefs, error) {
+	if driverName == "" {
+		driverName = volume.DefaultDriverName
+	}
+	if vc, exists := s.vols[name]; exists {
+		if vc.DriverName() != driverName {
+			return nil, fmt.Errorf("Conflict: volume name %s is already used by driver %s", name, vc.DriverName())
+		}
+		if err := checkOptions(name, s.meta[name], opts, labels); err != nil {
+			return nil, err
+		}
+		return vc, nil
+	}
+
+	// Volumes created in a previous run of the daemon are created again
+	// with the options they were first created with.
+	m, exists := s.meta[name]
+	if !exists {
+		m = &volumeMetadata{Name: name, Driver: driverName, Labels: labels, Options: opts}
+	} else if m.Driver != driverName {
+		return nil, fmt.Errorf("Conflict: volume name %s is already used by driver %s", name, m.Driver)
+	} else if err := checkOptions(name, m, opts, labels); err != nil {
+		return nil, err
+	}
+
+	vd, err := s.drivers(driverName)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Debugf("Creating volume %s with driver %s", name, vd.Name())
+	v, err := vd.Create(name, m.Options)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := s.saveMetadata(m); err != nil {
+			if err := vd.Remove(v); err != nil {
+				logrus.Errorf("Error removing volume %s after failing to save its metadata: %v", name, err)
+			}
+			return nil, err
+		}
+		s.meta[name] = m
+	}
+
+	vc := &volumeRefs{Volume: v, refs: make(map[string]struct{})}
+	s.vols[name] = vc
+	return vc, nil
+}
+
+// checkOptions returns an error if the driver options or labels given to
+// create the existing volume name differ from the ones it was created with,
+// in m. Giving none uses the volume as it is.
+func checkOptions(name string, m *volumeMetadata, opts, labels map[string]string) error {
+	if len(opts) == 0 && len(labels) == 0 {
+		return nil
+	}
+	var curOpts, curLabels map[string]string
+	if m != nil {
+		curOpts, curLabels = m.Options, m.Labels
+	}
+	if !equalMaps(opts, curOpts) || !equalMaps(labels, curLabels) {
+		return fmt.Errorf("Conflict: volume %s already exists with other options or labels", name)
+	}
+	return nil
+}
+
+func equalMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *VolumeStore) saveMetadata(m *volumeMetadata) error {
+	if s.root == "" {
+		return nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.metadataPath(m.Name), data, 0600)
+}
+
+func (s *VolumeStore) metadataPath(name string) string {
+	return filepath.Join(s.root, name+".json")
+}
+
+// Get returns the volume with the given name.
+func (s *VolumeStore) Get(name string) (volume.Volume, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vc, exists := s.vols[name]
+	if !exists {
+		return nil, ErrNoSuchVolume
+	}
+	return vc.Volume, nil
+}
+
+// Remove deletes the volume through its driver and drops it from the store.
+// It fails with ErrVolumeInUse if the volume is still referenced.
+func (s *VolumeStore) Remove(v volume.Volume) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := v.Name()
+	vc, exists := s.vols[name]
+	if !exists {
+		return ErrNoSuchVolume
+	}
+	if len(vc.refs) > 0 {
+		return ErrVolumeInUse
+	}
+
+	vd, err := s.drivers(vc.DriverName())
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("Removing volume %s with driver %s", name, vd.Name())
+	if err := vd.Remove(vc.Volume); err != nil {
+		return err
+	}
+	delete(s.vols, name)
+
+	if _, exists := s.meta[name]; exists {
+		delete(s.meta, name)
+		if s.root != "" {
+			if err := os.Remove(s.metadataPath(name)); err != nil && !os.IsNotExist(err) {
+				logrus.Errorf("Error removing metadata of volume %s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Dereference removes ref from the users of the volume. The volume itself is
+// kept in the store.
+func (s *VolumeStore) Dereference(v volume.Volume, ref string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if vc, exists := s.vols[v.Name()]; exists {
+		delete(vc.refs, ref)
+	}
+}
+
+// Labels returns the labels the volume was created with.
+func (s *VolumeStore) Labels(v volume.Volume) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, exists := s.meta[v.Name()]; exists {
+		return m.Labels
+	}
+	return nil
+}
+
+// Options returns the driver options the volume was created with.
+func (s *VolumeStore) Options(v volume.Volume) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, exists := s.meta[v.Name()]; exists {
+		return m.Options
+	}
+	return nil
+}
+
+// Count returns the number of references to the volume.
+func (s *VolumeStore) Count(v volume.Volume) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if vc, exists := s.vols[v.Name()]; exists {
+		return len(vc.refs)
+	}
+	return 0
+}
+
+// List returns all the volumes in the store.
+func (s *VolumeStore) List() []volume.Volume {
+	return s.filter(func(*volumeRefs) bool { return true })
+}
+
+// FilterByDriver returns the volumes created by the given driver.
+func (s *VolumeStore) FilterByDriver(name string) []volume.Volume {
+	return s.filter(func(vc *volumeRefs) bool { return vc.DriverName() == name })
+}
+
+// FilterByUsed returns the volumes that are referenced by at least one
+// container if used is true, or the ones that are not referenced otherwise.
+func (s *VolumeStore) FilterByUsed(used bool) []volume.Volume {
+	return s.filter(func(vc *volumeRefs) bool { return (len(vc.refs) > 0) == used })
+}
+
+func (s *VolumeStore) filter(f func(*volumeRefs) bool) []volume.Volume {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ls []volume.Volume
+	for _, vc := range s.vols {
+		if f(vc) {
+			ls = append(ls, vc.Volume)
+		}
+	}
+	return ls
+}
@@ -0,0 +1,286 @@
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/volume"
+)
+
+type fakeVolume struct {
+	name   string
+	driver string
+}
+
+func (v fakeVolume) Name() string           { return v.name }
+func (v fakeVolume) DriverName() string     { return v.driver }
+func (v fakeVolume) Path() string           { return "/fake/" + v.name }
+func (v fakeVolume) Mount() (string, error) { return v.Path(), nil }
+func (v fakeVolume) Unmount() error         { return nil }
+
+type fakeDriver struct {
+	name    string
+	opts    map[string]map[string]string
+	removed []string
+}
+
+func (d *fakeDriver) Name() string { return d.name }
+func (d *fakeDriver) Create(name string, opts map[string]string) (volume.Volume, error) {
+	if d.opts == nil {
+		d.opts = make(map[string]map[string]string)
+	}
+	d.opts[name] = opts
+	return fakeVolume{name: name, driver: d.name}, nil
+}
+func (d *fakeDriver) Remove(v volume.Volume) error {
+	d.removed = append(d.removed, v.Name())
+	return nil
+}
+
+func newTestStore(t *testing.T, root string) (*VolumeStore, map[string]*fakeDriver) {
+	drivers := map[string]*fakeDriver{
+		"local": {name: "local"},
+		"fake":  {name: "fake"},
+	}
+	s, err := New(root, func(name string) (volume.Driver, error) {
+		d, ok := drivers[name]
+		if !ok {
+			return nil, fmt.Errorf("no such driver %s", name)
+		}
+		return d, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, drivers
+}
+
+func TestCreate(t *testing.T) {
+	s, _ := newTestStore(t, "")
+
+	v, err := s.Create("fake1", "fake", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name() != "fake1" || v.DriverName() != "fake" {
+		t.Fatalf("Expected fake1 created by fake, got %s by %s", v.Name(), v.DriverName())
+	}
+	if l := s.List(); len(l) != 1 {
+		t.Fatalf("Expected 1 volume in the store, got %v", l)
+	}
+
+	v, err = s.Create("fake1", "fake", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := s.List(); len(l) != 1 {
+		t.Fatalf("Expected 1 volume in the store after creating it twice, got %v", l)
+	}
+
+	if _, err := s.Create("fake1", "local", nil, nil); err == nil {
+		t.Fatal("Expected error creating a volume with a name used by another driver")
+	}
+	if _, err := s.Create("none", "none", nil, nil); err == nil {
+		t.Fatal("Expected error creating a volume with an unknown driver")
+	}
+
+	v, err = s.Create("default", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.DriverName() != volume.DefaultDriverName {
+		t.Fatalf("Expected the default driver, got %s", v.DriverName())
+	}
+}
+
+func TestReferences(t *testing.T) {
+	s, drivers := newTestStore(t, "")
+
+	v, err := s.CreateWithRef("fake1", "fake", "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateWithRef("fake1", "fake", "c1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateWithRef("fake1", "fake", "c2"); err != nil {
+		t.Fatal(err)
+	}
+	if c := s.Count(v); c != 2 {
+		t.Fatalf("Expected 2 references, got %d", c)
+	}
+
+	if err := s.Remove(v); err != ErrVolumeInUse {
+		t.Fatalf("Expected ErrVolumeInUse, got %v", err)
+	}
+
+	s.Dereference(v, "c1")
+	s.Dereference(v, "c1")
+	if c := s.Count(v); c != 1 {
+		t.Fatalf("Expected 1 reference, got %d", c)
+	}
+	s.Dereference(v, "c2")
+
+	if err := s.Remove(v); err != nil {
+		t.Fatal(err)
+	}
+	if len(drivers["fake"].removed) != 1 {
+		t.Fatalf("Expected the driver to remove the volume, got %v", drivers["fake"].removed)
+	}
+	if _, err := s.Get("fake1"); err != ErrNoSuchVolume {
+		t.Fatalf("Expected ErrNoSuchVolume, got %v", err)
+	}
+	if err := s.Remove(v); err != ErrNoSuchVolume {
+		t.Fatalf("Expected ErrNoSuchVolume, got %v", err)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	s, _ := newTestStore(t, "")
+
+	s.Add(fakeVolume{name: "existing", driver: "local"})
+	if _, err := s.CreateWithRef("fake1", "fake", "c1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("fake2", "fake", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if l := s.FilterByDriver("fake"); len(l) != 2 {
+		t.Fatalf("Expected 2 volumes created by fake, got %v", l)
+	}
+	if l := s.FilterByDriver("local"); len(l) != 1 || l[0].Name() != "existing" {
+		t.Fatalf("Expected the existing local volume, got %v", l)
+	}
+	if l := s.FilterByUsed(true); len(l) != 1 || l[0].Name() != "fake1" {
+		t.Fatalf("Expected fake1 to be the only volume in use, got %v", l)
+	}
+	if l := s.FilterByUsed(false); len(l) != 2 {
+		t.Fatalf("Expected 2 unused volumes, got %v", l)
+	}
+}
+
+func TestCreateWithOptions(t *testing.T) {
+	root, err := ioutil.TempDir("", "volume-store-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	s, drivers := newTestStore(t, root)
+	opts := map[string]string{"size": "10G"}
+	labels := map[string]string{"com.example.tier": "db"}
+	v, err := s.Create("fake1", "fake", opts, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(drivers["fake"].opts["fake1"], opts) {
+		t.Fatalf("Expected the driver to get %v, got %v", opts, drivers["fake"].opts["fake1"])
+	}
+	if !reflect.DeepEqual(s.Options(v), opts) || !reflect.DeepEqual(s.Labels(v), labels) {
+		t.Fatalf("Unexpected options %v and labels %v", s.Options(v), s.Labels(v))
+	}
+
+	// A new store, like after a daemon restart, recreates the volume with
+	// the options it was created with.
+	s, drivers = newTestStore(t, root)
+	v, err = s.CreateWithRef("fake1", "fake", "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(drivers["fake"].opts["fake1"], opts) {
+		t.Fatalf("Expected the driver to get %v, got %v", opts, drivers["fake"].opts["fake1"])
+	}
+	if !reflect.DeepEqual(s.Labels(v), labels) {
+		t.Fatalf("Expected labels %v, got %v", labels, s.Labels(v))
+	}
+	if _, err := s.Create("fake1", "local", nil, nil); err == nil {
+		t.Fatal("Expected error creating a volume with a name used by another driver")
+	}
+
+	s.Dereference(v, "c1")
+	if err := s.Remove(v); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "fake1.json")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the metadata of the volume to be removed, got %v", err)
+	}
+	if s.Labels(v) != nil || s.Options(v) != nil {
+		t.Fatal("Expected no labels or options for a removed volume")
+	}
+}
+
+func TestCreateConflict(t *testing.T) {
+	root, err := ioutil.TempDir("", "volume-store-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	s, _ := newTestStore(t, root)
+	opts := map[string]string{"size": "10G"}
+	labels := map[string]string{"com.example.tier": "db"}
+	if _, err := s.Create("fake1", "fake", opts, labels); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("fake1", "fake", opts, labels); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("fake1", "fake", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateWithRef("fake1", "fake", "c1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("fake1", "fake", map[string]string{"size": "20G"}, labels); err == nil {
+		t.Fatal("Expected error creating a volume with other options")
+	}
+	if _, err := s.Create("fake1", "fake", opts, nil); err == nil {
+		t.Fatal("Expected error creating a volume with other labels")
+	}
+
+	// The options saved by a previous run of the daemon are checked too.
+	s, _ = newTestStore(t, root)
+	if _, err := s.Create("fake1", "fake", nil, map[string]string{"com.example.tier": "web"}); err == nil {
+		t.Fatal("Expected error creating a volume with other labels after a restart")
+	}
+	if _, err := s.Create("fake1", "fake", opts, labels); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateSavesMetadata(t *testing.T) {
+	root, err := ioutil.TempDir("", "volume-store-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	s, _ := newTestStore(t, root)
+	if _, err := s.CreateWithRef("fake1", "fake", "c1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "fake1.json")); err != nil {
+		t.Fatalf("Expected the metadata of a volume without options to be saved, got %v", err)
+	}
+
+	// After a restart, the volume is still known to be created by fake.
+	s, _ = newTestStore(t, root)
+	if _, err := s.CreateWithRef("fake1", "local", "c1"); err == nil {
+		t.Fatal("Expected error creating a volume with a name used by another driver after a restart")
+	}
+	if _, err := s.CreateWithRef("fake1", "", "c1"); err == nil {
+		t.Fatal("Expected error creating a volume with the default driver after a restart")
+	}
+	v, err := s.CreateWithRef("fake1", "fake", "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.DriverName() != "fake" {
+		t.Fatalf("Expected fake1 created by fake, got %s", v.DriverName())
+	}
+}
@@ -5,8 +5,8 @@ const DefaultDriverName = "local"
 type Driver interface {
 	// Name returns the name of the volume driver.
 	Name() string
-	// Create makes a new volume with the given id.
-	Create(string) (Volume, error)
+	// Create makes a new volume with the given id and driver specific options.
+	Create(name string, opts map[string]string) (Volume, error)
 	// Remove deletes the volume.
 	Remove(Volume) error
 }